@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
-	"sync/atomic"
 
 	"github.com/0xsoniclabs/substate/db"
 	"github.com/0xsoniclabs/substate/substate"
@@ -19,85 +19,47 @@ type comparePair struct {
 
 // Compare function compares substates from two databases
 func Compare(ctx *cli.Context, src db.SubstateDB, target db.SubstateDB, workers int, first uint64, last uint64) error {
-	errChan := make(chan error, 3+workers)
-	wg := &sync.WaitGroup{}
+	workers = max(workers, 1)
+	compareCtx, cancelCtx := context.WithCancel(ctx.Context)
+	defer cancelCtx()
 
 	srcSubstateChan := make(chan *substate.Substate, workers*10)
 	targetSubstateChan := make(chan *substate.Substate, workers*10)
+	readErrChan := make(chan error, 2)
 
-	compareCtx, cancelCtx := context.WithCancel(ctx.Context)
-
-	wg.Add(1)
-	go comparator(compareCtx, srcSubstateChan, targetSubstateChan, errChan, workers, wg)
-
-	// using only src database for substates counter
-	var counter uint64
-
-	// start taskpools to retrieve substates
-	wg.Add(1)
-	go startCompareTaskPool(compareCtx, "compare-source", src, srcSubstateChan, first, last, errChan, ctx, &counter, wg)
-	wg.Add(1)
-	go startCompareTaskPool(compareCtx, "compare-target", target, targetSubstateChan, first, last, errChan, ctx, nil, wg)
-
+	// both databases are read in parallel, substates arrive ordered by block and transaction
+	go func() {
+		readErrChan <- readOrdered(compareCtx, readSubstates(src), first, last, workers, srcSubstateChan)
+	}()
 	go func() {
-		wg.Wait()
-		close(errChan)
+		readErrChan <- readOrdered(compareCtx, readSubstates(target), first, last, workers, targetSubstateChan)
 	}()
 
-	err, ok := <-errChan
-	if ok {
-		cancelCtx()
-		return err
-	}
+	total, err := comparator(compareCtx, srcSubstateChan, targetSubstateChan, workers)
 
-	total := atomic.LoadUint64(&counter)
-	fmt.Printf("%v identical substates were found\n", total)
+	// stop readers and wait for them to release the databases
 	cancelCtx()
-	return nil
-}
-
-// startCompareTaskPool is wrapper around the SubstateTask pool to retrieve the substates in order
-func startCompareTaskPool(compareCtx context.Context, name string, dbInstance db.SubstateDB, substateChan chan *substate.Substate, first uint64, last uint64, errChan chan error, ctx *cli.Context, counter *uint64, wg *sync.WaitGroup) {
-	defer wg.Done()
-	defer close(substateChan)
-
-	taskPool := &db.SubstateTaskPool{
-		Name:     name,
-		TaskFunc: compareFeeder(compareCtx, counter, substateChan),
-
-		First: first,
-		Last:  last,
-
-		// has to be 1 to keep substates order
-		Workers: 1,
-		Ctx:     ctx,
-		DB:      dbInstance,
+	for range 2 {
+		if readErr := <-readErrChan; readErr != nil && !errors.Is(readErr, context.Canceled) && err == nil {
+			err = readErr
+		}
 	}
-	err := taskPool.Execute()
 	if err != nil {
-		errChan <- err
+		return err
 	}
-}
-
-// compareFeeder is a task function that feeds the substate channel with the substates from SubstateTaskPool
-func compareFeeder(compareCtx context.Context, counter *uint64, substateChan chan *substate.Substate) db.SubstateTaskFunc {
-	return func(block uint64, tx int, substate *substate.Substate, taskPool *db.SubstateTaskPool) error {
-		if counter != nil {
-			atomic.AddUint64(counter, 1)
-		}
 
-		select {
-		case <-compareCtx.Done():
-		case substateChan <- substate:
-		}
-		return nil
-	}
+	fmt.Printf("%v identical substates were found\n", total)
+	return nil
 }
 
-// comparator reads from both channels from the databases the substates and compares them
-func comparator(ctx context.Context, srcChan chan *substate.Substate, targetChan chan *substate.Substate, errChan chan error, workers int, wg *sync.WaitGroup) {
-	defer wg.Done()
+// comparator reads from both channels from the databases the substates and compares them,
+// it returns the number of identical substates or the first difference found
+func comparator(ctx context.Context, srcChan chan *substate.Substate, targetChan chan *substate.Substate, workers int) (uint64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	toCompareChan := make(chan comparePair, workers*10)
+	errChan := make(chan error, workers+1)
 
 	// internal WaitGroup to wait for all workers to finish
 	workersWg := &sync.WaitGroup{}
@@ -107,62 +69,51 @@ func comparator(ctx context.Context, srcChan chan *substate.Substate, targetChan
 		workersWg.Add(1)
 		go func() {
 			defer workersWg.Done()
-			for {
-				select {
-				case <-ctx.Done():
+			for p := range toCompareChan {
+				if err := p.srcSubstate.Equal(p.targetSubstate); err != nil {
+					errChan <- err
+					cancel()
 					return
-				case p, ok := <-toCompareChan:
-					if !ok {
-						return
-					}
-					err := p.srcSubstate.Equal(p.targetSubstate)
-					if err != nil {
-						errChan <- err
-						return
-					}
 				}
 			}
 		}()
 	}
 
 	// read from both database channels one value, pair them together and send to workers for comparison
-	for {
-		// substates are ordered by block and transaction, therefore have to be read by pair
-		srcSubstate, ok := <-srcChan
-		targetSubstate, ok2 := <-targetChan
-		if !ok || !ok2 {
-			if ok == ok2 {
-				// both channels are closed as same time
-				close(toCompareChan)
-				break
+	var counter uint64
+	func() {
+		defer close(toCompareChan)
+		for {
+			// substates are ordered by block and transaction, therefore have to be read by pair
+			srcSubstate, ok := <-srcChan
+			targetSubstate, ok2 := <-targetChan
+			if !ok || !ok2 {
+				// one channel contained additional data
+				if ok {
+					errChan <- fmt.Errorf("target db doesn't contain substates from %v-%v onwards", srcSubstate.Block, srcSubstate.Transaction)
+				} else if ok2 {
+					errChan <- fmt.Errorf("source db doesn't contain substate from %v-%v onwards", targetSubstate.Block, targetSubstate.Transaction)
+				}
+				return
 			}
 
-			// one channel contained additional data
-			if ok {
-				errChan <- fmt.Errorf("target db doesn't contain substates from %v-%v onwards", srcSubstate.Block, srcSubstate.Transaction)
-			} else {
-				errChan <- fmt.Errorf("source db doesn't contain substate from %v-%v onwards", targetSubstate.Block, targetSubstate.Transaction)
+			// pairing substates together
+			select {
+			case <-ctx.Done():
+				return
+			case toCompareChan <- comparePair{srcSubstate: srcSubstate, targetSubstate: targetSubstate}:
+				counter++
 			}
-			close(toCompareChan)
-			break
 		}
-
-		// pairing substates together
-		toCompareChan <- comparePair{srcSubstate: srcSubstate.Clone(), targetSubstate: targetSubstate.Clone()}
-	}
-
-	// wait for all workers to finish
-	doneChan := make(chan struct{}, 1)
-	go func() {
-		workersWg.Wait()
-		doneChan <- struct{}{}
 	}()
 
-	select {
-	case <-ctx.Done():
-		{
-			workersWg.Wait()
-		}
-	case <-doneChan:
+	workersWg.Wait()
+	close(errChan)
+	if err, found := <-errChan; found {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
+	return counter, nil
 }
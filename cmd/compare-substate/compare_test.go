@@ -146,16 +146,17 @@ func TestCompare_MissingDst(t *testing.T) {
 
 	input0 := getGenericSubstate()
 
+	// reading the source stops once the comparison failed
 	gomock.InOrder(
 		src.EXPECT().GetBlockSubstates(uint64(0)).Return(map[int]*substate.Substate{
 			0: input0,
 		}, nil),
 		src.EXPECT().GetBlockSubstates(uint64(1)).Return(map[int]*substate.Substate{
 			0: input0,
-		}, nil),
+		}, nil).MaxTimes(1),
 		src.EXPECT().GetBlockSubstates(uint64(2)).Return(map[int]*substate.Substate{
 			0: input0,
-		}, nil),
+		}, nil).MaxTimes(1),
 	)
 
 	gomock.InOrder(
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/diff"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// AllFamilies selects every record family.
+const AllFamilies = "all"
+
+// record is a record of a family, it is compared with the record of the same key in the other database.
+// The transaction of records stored per block is 0.
+type record struct {
+	key   Key
+	value any
+}
+
+// family is a kind of records stored per block or per block and transaction.
+type family struct {
+	name string
+	// newReader returns a function returning the records of a block of sdb ordered by transaction.
+	newReader func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error)
+	// compare returns the differences of got from want, ignore drops differences.
+	compare func(want, got any, ignore *diff.Ignore) diff.Differences
+}
+
+var families = []family{
+	{
+		name: "substates",
+		newReader: func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error) {
+			read := readSubstates(sdb)
+			return func(block uint64) ([]record, error) {
+				substates, err := read(block)
+				if err != nil {
+					return nil, err
+				}
+				records := make([]record, len(substates))
+				for i, ss := range substates {
+					records[i] = record{key: Key{Block: ss.Block, Transaction: ss.Transaction}, value: ss}
+				}
+				return records, nil
+			}, nil
+		},
+		compare: func(want, got any, ignore *diff.Ignore) diff.Differences {
+			ss := want.(*substate.Substate)
+			return ignore.Filter(diff.Substate(ss, got.(*substate.Substate)), coinbase(ss))
+		},
+	},
+	{
+		name: "update-sets",
+		newReader: func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error) {
+			udb, err := db.MakeDefaultUpdateDBFromBaseDB(sdb)
+			if err != nil {
+				return nil, err
+			}
+			return func(block uint64) ([]record, error) {
+				if found, err := sdb.Has(db.UpdateDBKey(block)); err != nil || !found {
+					return nil, err
+				}
+				us, err := udb.GetUpdateSet(block)
+				if err != nil || us == nil {
+					return nil, err
+				}
+				return []record{{key: Key{Block: block}, value: us}}, nil
+			}, nil
+		},
+		compare: func(want, got any, ignore *diff.Ignore) diff.Differences {
+			return ignore.Filter(diff.UpdateSet(want.(*updateset.UpdateSet), got.(*updateset.UpdateSet)), types.Address{})
+		},
+	},
+	{
+		name: "destroyed-accounts",
+		newReader: func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error) {
+			ddb, err := db.MakeDefaultDestroyedAccountDBFromBaseDB(sdb)
+			if err != nil {
+				return nil, err
+			}
+			return func(block uint64) ([]record, error) {
+				iter := sdb.NewIterator(db.EncodeDestroyedAccountKey(block, 0)[:len(db.DestroyedAccountPrefix)+8], nil)
+				defer iter.Release()
+				var records []record
+				for iter.Next() {
+					b, tx, err := db.DecodeDestroyedAccountKey(iter.Key())
+					if err != nil {
+						return nil, err
+					}
+					lists, err := ddb.Decode(iter.Value())
+					if err != nil {
+						return nil, fmt.Errorf("cannot decode destroyed accounts block: %v, tx: %v; %w", b, tx, err)
+					}
+					records = append(records, record{key: Key{Block: b, Transaction: tx}, value: lists})
+				}
+				return records, iter.Error()
+			}, nil
+		},
+		compare: func(want, got any, ignore *diff.Ignore) diff.Differences {
+			w, g := want.(db.SuicidedAccountLists), got.(db.SuicidedAccountLists)
+			differences := compareAddresses("DestroyedAccounts", w.DestroyedAccounts, g.DestroyedAccounts)
+			differences = append(differences, compareAddresses("ResurrectedAccounts", w.ResurrectedAccounts, g.ResurrectedAccounts)...)
+			return ignore.Filter(differences, types.Address{})
+		},
+	},
+	{
+		name: "exceptions",
+		newReader: func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error) {
+			edb := db.MakeDefaultExceptionDBFromBaseDB(sdb)
+			return func(block uint64) ([]record, error) {
+				ex, err := edb.GetException(block)
+				if err != nil || ex == nil {
+					return nil, err
+				}
+				return []record{{key: Key{Block: block}, value: ex}}, nil
+			}, nil
+		},
+		compare: func(want, got any, ignore *diff.Ignore) diff.Differences {
+			return ignore.Filter(diff.Exception(want.(*substate.Exception), got.(*substate.Exception)), types.Address{})
+		},
+	},
+	{
+		name:      "block-hashes",
+		newReader: newRawReader("block hash", db.BlockHashDBKey),
+		compare:   compareRaw("BlockHash"),
+	},
+	{
+		name:      "state-roots",
+		newReader: newRawReader("state root", db.StateRootHashDBKey),
+		compare:   compareRaw("StateRoot"),
+	},
+}
+
+// getFamilies returns the families of names, all families are returned for AllFamilies.
+func getFamilies(names []string) ([]family, error) {
+	var selected []family
+	for _, name := range names {
+		if name == AllFamilies {
+			return families, nil
+		}
+		found := false
+		for _, f := range families {
+			if f.name == name {
+				selected = append(selected, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown record family %v, available families: %v, %v", name, strings.Join(familyNames(), ", "), AllFamilies)
+		}
+	}
+	return selected, nil
+}
+
+func familyNames() []string {
+	names := make([]string, len(families))
+	for i, f := range families {
+		names[i] = f.name
+	}
+	return names
+}
+
+// readSubstates returns a function returning the substates of a block of sdb ordered by transaction.
+func readSubstates(sdb db.SubstateDB) func(block uint64) ([]*substate.Substate, error) {
+	return func(block uint64) ([]*substate.Substate, error) {
+		substates, err := sdb.GetBlockSubstates(block)
+		if err != nil {
+			return nil, err
+		}
+		txs := make([]int, 0, len(substates))
+		for tx := range substates {
+			txs = append(txs, tx)
+		}
+		sort.Ints(txs)
+		sorted := make([]*substate.Substate, len(txs))
+		for i, tx := range txs {
+			sorted[i] = substates[tx]
+		}
+		return sorted, nil
+	}
+}
+
+// newRawReader returns a reader of values stored per block under keys of key.
+func newRawReader(name string, key func(block uint64) []byte) func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error) {
+	return func(sdb db.SubstateDB) (func(block uint64) ([]record, error), error) {
+		return func(block uint64) ([]record, error) {
+			value, err := sdb.Get(key(block))
+			if errors.Is(err, leveldb.ErrNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("cannot get %v of block %v; %w", name, block, err)
+			}
+			return []record{{key: Key{Block: block}, value: value}}, nil
+		}, nil
+	}
+}
+
+func compareRaw(path string) func(want, got any, ignore *diff.Ignore) diff.Differences {
+	return func(want, got any, ignore *diff.Ignore) diff.Differences {
+		w, g := want.([]byte), got.([]byte)
+		if bytes.Equal(w, g) {
+			return nil
+		}
+		return ignore.Filter(diff.Differences{{Path: path, Got: fmt.Sprintf("0x%x", g), Want: fmt.Sprintf("0x%x", w)}}, types.Address{})
+	}
+}
+
+func compareAddresses(path string, want, got []types.Address) diff.Differences {
+	if len(got) != len(want) {
+		return diff.Differences{{Path: path + ".Length", Got: fmt.Sprint(len(got)), Want: fmt.Sprint(len(want))}}
+	}
+	var differences diff.Differences
+	for i := range want {
+		if got[i] != want[i] {
+			differences = append(differences, diff.Difference{Path: fmt.Sprintf("%v[%v]", path, i), Got: got[i].String(), Want: want[i].String()})
+		}
+	}
+	return differences
+}
+
+func coinbase(ss *substate.Substate) types.Address {
+	if ss.Env == nil {
+		return types.Address{}
+	}
+	return ss.Env.Coinbase
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// createFamilyTestDB creates a database holding records of every family in blocks 1 to 3,
+// target databases differ in block 2 of every family and miss block 3.
+func createFamilyTestDB(t *testing.T, path string, target bool) db.SubstateDB {
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { sdb.Close() })
+	udb, err := db.MakeDefaultUpdateDBFromBaseDB(sdb)
+	require.NoError(t, err)
+	ddb, err := db.MakeDefaultDestroyedAccountDBFromBaseDB(sdb)
+	require.NoError(t, err)
+	edb := db.MakeDefaultExceptionDBFromBaseDB(sdb)
+
+	for block := uint64(1); block <= 3; block++ {
+		if target && block == 3 {
+			break
+		}
+		value := byte(block)
+		if target && block == 2 {
+			value = 0xff
+		}
+		ws := substate.NewWorldState().Add(types.Address{1}, uint64(value), uint256.NewInt(1), nil)
+		require.NoError(t, udb.PutUpdateSet(&updateset.UpdateSet{WorldState: ws, Block: block}, nil))
+		require.NoError(t, ddb.SetDestroyedAccounts(block, 0, []types.Address{{value}}, nil))
+		require.NoError(t, ddb.SetDestroyedAccounts(block, 1, nil, []types.Address{{1}}))
+		require.NoError(t, edb.PutException(&substate.Exception{Block: block, Data: substate.ExceptionBlock{
+			Transactions: map[int]substate.ExceptionTx{0: {PreTransaction: &ws}},
+		}}))
+		require.NoError(t, sdb.Put(db.BlockHashDBKey(block), types.Hash{value}.Bytes()))
+		require.NoError(t, sdb.Put(db.StateRootHashDBKey(block), types.Hash{value, 1}.Bytes()))
+	}
+	return sdb
+}
+
+func TestCompareAll_ComparesRecordFamilies(t *testing.T) {
+	src := createFamilyTestDB(t, t.TempDir(), false)
+	target := createFamilyTestDB(t, t.TempDir(), true)
+	addr := types.Address{1}.String()
+
+	tests := map[string]struct {
+		mismatch  []string
+		missing   []Key
+		compared  int
+		identical int
+	}{
+		"update-sets": {
+			mismatch: []string{"UpdateSet.WorldState[" + addr + "].Nonce"},
+			missing:  []Key{{3, 0}},
+			compared: 2, identical: 1,
+		},
+		"destroyed-accounts": {
+			mismatch: []string{"DestroyedAccounts[0]"},
+			missing:  []Key{{3, 0}, {3, 1}},
+			compared: 4, identical: 3,
+		},
+		"exceptions": {
+			mismatch: []string{"Exception.Transactions[0].PreTransaction[" + addr + "].Nonce"},
+			missing:  []Key{{3, 0}},
+			compared: 2, identical: 1,
+		},
+		"block-hashes": {
+			mismatch: []string{"BlockHash"},
+			missing:  []Key{{3, 0}},
+			compared: 2, identical: 1,
+		},
+		"state-roots": {
+			mismatch: []string{"StateRoot"},
+			missing:  []Key{{3, 0}},
+			compared: 2, identical: 1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			report, err := CompareAll(context.Background(), src, target, 0, 5, ReportOptions{Workers: 2, Family: name})
+			require.NoError(t, err)
+			assert.Equal(t, name, report.Family)
+			assert.Equal(t, test.compared, report.Compared)
+			assert.Equal(t, test.identical, report.Identical)
+			require.Len(t, report.Mismatches, 1)
+			assert.Equal(t, uint64(2), report.Mismatches[0].Block)
+			assert.Equal(t, test.mismatch, report.Mismatches[0].Differences.Paths())
+			assert.Equal(t, test.missing, report.MissingInTarget)
+			assert.Empty(t, report.ExtraInTarget)
+
+			report, err = CompareAll(context.Background(), src, src, 0, 5, ReportOptions{Workers: 2, Family: name})
+			require.NoError(t, err)
+			assert.Zero(t, report.Problems())
+			assert.Equal(t, report.Compared, report.Identical)
+		})
+	}
+
+	_, err := CompareAll(context.Background(), src, target, 0, 5, ReportOptions{Family: "unknown"})
+	assert.ErrorContains(t, err, "unknown record family unknown")
+}
+
+func TestGetFamilies_SelectsFamilies(t *testing.T) {
+	selected, err := getFamilies([]string{"state-roots", "substates"})
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "state-roots", selected[0].name)
+
+	selected, err = getFamilies([]string{AllFamilies})
+	require.NoError(t, err)
+	assert.Len(t, selected, len(families))
+}
+
+func TestCompareSubstate_ComparesAllFamilies(t *testing.T) {
+	dir := t.TempDir()
+	createFamilyTestDB(t, dir+"/src", false).Close()
+	createFamilyTestDB(t, dir+"/target", true).Close()
+
+	run := func(args ...string) error {
+		app := &cli.App{Name: "test", Action: compare, Flags: flags}
+		return app.Run(append([]string{"dummy", "--src", dir + "/src", "--target", dir + "/target",
+			"--block-segment", "1-3"}, args...))
+	}
+
+	err := run("--keep-going", "--families", AllFamilies, "--report", dir+"/report.json",
+		"--ignore", "BlockHash", "--ignore", "StateRoot")
+	assert.ErrorContains(t, err, "update-sets: 1 differ, 1 are missing in target and 0 are extra in target; destroyed-accounts")
+	assert.NotContains(t, err.Error(), "substates:")
+
+	data, err := os.ReadFile(dir + "/report.json")
+	require.NoError(t, err)
+	var reports []*Report
+	require.NoError(t, json.Unmarshal(data, &reports))
+	require.Len(t, reports, len(families))
+	for i, report := range reports {
+		assert.Equal(t, families[i].name, report.Family)
+	}
+	assert.Empty(t, reports[4].Mismatches, "block hash differences are ignored")
+	assert.Len(t, reports[4].MissingInTarget, 1)
+
+	err = run("--families", "exceptions")
+	assert.ErrorContains(t, err, "--families requires --keep-going")
+
+	err = run("--keep-going", "--families", "blocks")
+	assert.ErrorContains(t, err, "unknown record family blocks")
+}
@@ -1,27 +1,63 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/diff"
 	"github.com/0xsoniclabs/substate/utils"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/urfave/cli/v2"
 )
 
+var (
+	keepGoingFlag = cli.BoolFlag{
+		Name:  "keep-going",
+		Usage: "Compare all substates and write a JSON report of differences, missing and extra substates instead of failing at the first one",
+	}
+	maxProblemsFlag = cli.IntFlag{
+		Name:  "max-problems",
+		Usage: "Number of differing, missing and extra substates after which a comparison with --keep-going stops, 0 for no limit",
+		Value: 1000,
+	}
+	familiesFlag = cli.StringSliceFlag{
+		Name: "families",
+		Usage: "Record families compared with --keep-going: " + strings.Join(familyNames(), ", ") +
+			" or " + AllFamilies,
+		Value: cli.NewStringSlice(families[0].name),
+	}
+	ignoreFlag = cli.StringSliceFlag{
+		Name: "ignore",
+		Usage: "Fields whose differences are ignored by a comparison with --keep-going, e.g. Result.Bloom, Env.BlockHashes " +
+			"or OutputSubstate[coinbase].Balance; [*] matches all keys",
+	}
+)
+
+var flags = []cli.Flag{
+	&utils.WorkersFlag,
+	&utils.SrcDbFlag,
+	&utils.TargetDbFlag,
+	&utils.BlockSegmentFlag,
+	&utils.DbBackendFlag,
+	&utils.LayerPrecedenceFlag,
+	&keepGoingFlag,
+	&maxProblemsFlag,
+	&familiesFlag,
+	&ignoreFlag,
+	&utils.ReportFlag,
+}
+
 func main() {
 	app := &cli.App{
 		Name: "compare-substate",
 		Usage: "Compare two substate databases for equality. " +
-			"The tool iterates trough both databases, pairs up the corresponding substates and compares them for equality.",
+			"The tool iterates trough both databases, pairs up the corresponding substates and compares them for equality. " +
+			"Comma separated paths are compared as a union of the databases.",
 		Action: compare,
-		Flags: []cli.Flag{
-			&utils.WorkersFlag,
-			&utils.SrcDbFlag,
-			&utils.TargetDbFlag,
-			&utils.BlockSegmentFlag,
-		},
+		Flags:  flags,
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -32,12 +68,7 @@ func main() {
 // compare is the main function that compares two substate databases
 func compare(ctx *cli.Context) error {
 	// Open src DB
-	src, err := db.NewSubstateDB(ctx.String(utils.SrcDbFlag.Name), &opt.Options{
-		OpenFilesCacheCapacity: 1024,
-		BlockCacheCapacity:     50 * opt.MiB,
-		WriteBuffer:            25 * opt.MiB,
-		ReadOnly:               true,
-	}, nil, nil)
+	src, err := openSubstateDB(ctx, ctx.String(utils.SrcDbFlag.Name))
 	if err != nil {
 		return err
 	}
@@ -48,12 +79,7 @@ func compare(ctx *cli.Context) error {
 	}()
 
 	// Open target DB
-	target, err := db.NewSubstateDB(ctx.String(utils.TargetDbFlag.Name), &opt.Options{
-		OpenFilesCacheCapacity: 1024,
-		BlockCacheCapacity:     50 * opt.MiB,
-		WriteBuffer:            25 * opt.MiB,
-		ReadOnly:               true,
-	}, nil, nil)
+	target, err := openSubstateDB(ctx, ctx.String(utils.TargetDbFlag.Name))
 	if err != nil {
 		return err
 	}
@@ -68,5 +94,75 @@ func compare(ctx *cli.Context) error {
 		return err
 	}
 
-	return Compare(ctx, src, target, ctx.Int(utils.WorkersFlag.Name), segment.First, segment.Last)
+	if !ctx.Bool(keepGoingFlag.Name) {
+		if ctx.IsSet(familiesFlag.Name) {
+			return fmt.Errorf("--%v requires --%v", familiesFlag.Name, keepGoingFlag.Name)
+		}
+		return Compare(ctx, src, target, ctx.Int(utils.WorkersFlag.Name), segment.First, segment.Last)
+	}
+	return compareAll(ctx, src, target, segment.First, segment.Last)
+}
+
+// compareAll compares all records of the selected families, prints a summary per family and
+// writes the reports, it fails if the databases differ
+func compareAll(ctx *cli.Context, src db.SubstateDB, target db.SubstateDB, first uint64, last uint64) error {
+	selected, err := getFamilies(ctx.StringSlice(familiesFlag.Name))
+	if err != nil {
+		return err
+	}
+	ignore, err := diff.NewIgnore(ctx.StringSlice(ignoreFlag.Name)...)
+	if err != nil {
+		return err
+	}
+
+	var reports []*Report
+	var problems []string
+	for _, f := range selected {
+		report, err := CompareAll(ctx.Context, src, target, first, last, ReportOptions{
+			Workers:     ctx.Int(utils.WorkersFlag.Name),
+			MaxProblems: ctx.Int(maxProblemsFlag.Name),
+			Ignore:      ignore,
+			Family:      f.name,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%v: %v compared, %v identical, %v differ, %v missing in target, %v extra in target\n",
+			f.name, report.Compared, report.Identical, len(report.Mismatches), len(report.MissingInTarget), len(report.ExtraInTarget))
+		reports = append(reports, report)
+		if report.Problems() > 0 {
+			problems = append(problems, fmt.Sprintf("%v: %v differ, %v are missing in target and %v are extra in target",
+				f.name, len(report.Mismatches), len(report.MissingInTarget), len(report.ExtraInTarget)))
+		}
+	}
+	if err = utils.WriteJSONReport(ctx.Path(utils.ReportFlag.Name), reports); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("databases differ; %v", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// openSubstateDB opens a read-only substate database. Comma separated paths
+// are opened as a union of all the databases.
+func openSubstateDB(ctx *cli.Context, path string) (db.SubstateDB, error) {
+	backend := db.Backend(ctx.String(utils.DbBackendFlag.Name))
+	options := &opt.Options{
+		OpenFilesCacheCapacity: 1024,
+		BlockCacheCapacity:     50 * opt.MiB,
+		WriteBuffer:            25 * opt.MiB,
+		ReadOnly:               true,
+	}
+
+	paths := strings.Split(path, ",")
+	if len(paths) == 1 {
+		return db.NewSubstateDBWithBackend(path, backend, options, nil, nil)
+	}
+
+	precedence, err := db.ParseLayerPrecedence(ctx.String(utils.LayerPrecedenceFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	return db.NewUnionSubstateDB(paths, backend, options, precedence)
 }
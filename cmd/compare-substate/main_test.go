@@ -6,6 +6,7 @@ import (
 	"github.com/0xsoniclabs/substate/db"
 	"github.com/0xsoniclabs/substate/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
 )
 
@@ -135,3 +136,51 @@ func TestCompareSubstate_TargetError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cannot open leveldb")
 }
+
+func TestCompareSubstate_UnionSource(t *testing.T) {
+	dir := t.TempDir()
+	putSubstates := func(path string, blocks ...uint64) {
+		sdb, err := db.NewDefaultSubstateDB(path)
+		require.NoError(t, err)
+		for _, block := range blocks {
+			ss := getGenericSubstate()
+			ss.Block = block
+			require.NoError(t, sdb.PutSubstate(ss))
+		}
+		require.NoError(t, sdb.Close())
+	}
+	putSubstates(dir+"/src-a", 1, 2)
+	putSubstates(dir+"/src-b", 2, 3)
+	putSubstates(dir+"/target", 1, 2, 3)
+
+	run := func(src, target, precedence string) error {
+		app := &cli.App{
+			Name:   "test",
+			Action: compare,
+			Flags: []cli.Flag{
+				&utils.WorkersFlag,
+				&utils.SrcDbFlag,
+				&utils.TargetDbFlag,
+				&utils.BlockSegmentFlag,
+				&utils.LayerPrecedenceFlag,
+			},
+		}
+		return app.Run([]string{
+			"dummy",
+			"--workers", "1",
+			"--src", src,
+			"--target", target,
+			"--block-segment", "0-10",
+			"--layer-precedence", precedence,
+		})
+	}
+
+	assert.NoError(t, run(dir+"/src-a,"+dir+"/src-b", dir+"/target", "last"))
+
+	// union of target has substates missing in source
+	err := run(dir+"/src-a", dir+"/src-a,"+dir+"/target", "first")
+	assert.ErrorContains(t, err, "source db doesn't contain substate from 3-1 onwards")
+
+	err = run(dir+"/src-a,"+dir+"/src-b", dir+"/target", "middle")
+	assert.ErrorContains(t, err, "unknown layer precedence")
+}
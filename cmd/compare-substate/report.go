@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/diff"
+)
+
+// ReportOptions configures CompareAll.
+type ReportOptions struct {
+	// Workers is the number of blocks read in parallel from each database.
+	Workers int
+	// MaxProblems stops the comparison once as many differing, missing and extra substates
+	// are found, there is no limit if it is 0.
+	MaxProblems int
+	// Ignore drops differences of fields, all fields are compared if it is nil.
+	Ignore *diff.Ignore
+	// Family is the name of the compared record family, substates are compared if it is empty.
+	Family string
+}
+
+// Key identifies a record, the transaction of records stored per block is 0.
+type Key struct {
+	Block       uint64 `json:"block"`
+	Transaction int    `json:"transaction"`
+}
+
+func (k Key) less(o Key) bool {
+	if k.Block != o.Block {
+		return k.Block < o.Block
+	}
+	return k.Transaction < o.Transaction
+}
+
+// Mismatch is a pair of records with differences, the source record is the expected one.
+type Mismatch struct {
+	Key
+	Differences diff.Differences `json:"differences"`
+}
+
+// Report describes the outcome of CompareAll, problems are ordered by block and transaction.
+type Report struct {
+	Family    string `json:"family"`
+	First     uint64 `json:"first"`
+	Last      uint64 `json:"last"`
+	Compared  int    `json:"compared"`
+	Identical int    `json:"identical"`
+	// Truncated is set if the comparison stopped before the last block as MaxProblems was reached.
+	Truncated bool `json:"truncated"`
+
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+	// MissingInTarget are records of the source without a record in the target,
+	// ExtraInTarget are records of the target without a record in the source.
+	MissingInTarget []Key `json:"missingInTarget,omitempty"`
+	ExtraInTarget   []Key `json:"extraInTarget,omitempty"`
+}
+
+// Problems returns the number of mismatches, missing and extra records.
+func (r *Report) Problems() int {
+	return len(r.Mismatches) + len(r.MissingInTarget) + len(r.ExtraInTarget)
+}
+
+// CompareAll compares all records of the family of options in blocks first to last of src and target
+// and reports every difference instead of failing at the first one. Ignored differences count as identical.
+func CompareAll(parent context.Context, src db.SubstateDB, target db.SubstateDB, first uint64, last uint64, options ReportOptions) (*Report, error) {
+	name := options.Family
+	if name == "" {
+		name = families[0].name
+	}
+	selected, err := getFamilies([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	f := selected[0]
+	readSrc, err := f.newReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %v of source; %w", f.name, err)
+	}
+	readTarget, err := f.newReader(target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %v of target; %w", f.name, err)
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	workers := max(options.Workers, 1)
+	srcChan := make(chan record, workers*10)
+	targetChan := make(chan record, workers*10)
+	errChan := make(chan error, 2)
+	go func() { errChan <- readOrdered(ctx, readSrc, first, last, workers, srcChan) }()
+	go func() { errChan <- readOrdered(ctx, readTarget, first, last, workers, targetChan) }()
+
+	report := &Report{Family: f.name, First: first, Last: last}
+	srcRecord, srcOk := <-srcChan
+	targetRecord, targetOk := <-targetChan
+	for srcOk || targetOk {
+		if options.MaxProblems > 0 && report.Problems() >= options.MaxProblems {
+			report.Truncated = true
+			break
+		}
+		switch {
+		case !targetOk || (srcOk && srcRecord.key.less(targetRecord.key)):
+			report.MissingInTarget = append(report.MissingInTarget, srcRecord.key)
+			srcRecord, srcOk = <-srcChan
+		case !srcOk || targetRecord.key.less(srcRecord.key):
+			report.ExtraInTarget = append(report.ExtraInTarget, targetRecord.key)
+			targetRecord, targetOk = <-targetChan
+		default:
+			report.Compared++
+			differences := f.compare(srcRecord.value, targetRecord.value, options.Ignore)
+			if len(differences) == 0 {
+				report.Identical++
+			} else {
+				report.Mismatches = append(report.Mismatches, Mismatch{Key: srcRecord.key, Differences: differences})
+			}
+			srcRecord, srcOk = <-srcChan
+			targetRecord, targetOk = <-targetChan
+		}
+	}
+
+	// stop readers of truncated comparisons and wait for both of them
+	cancel()
+	for range 2 {
+		if readErr := <-errChan; readErr != nil && !errors.Is(readErr, context.Canceled) && err == nil {
+			err = readErr
+		}
+	}
+	if err == nil {
+		err = parent.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// blockJob is a block read by a worker of readOrdered.
+type blockJob[T any] struct {
+	block  uint64
+	result chan blockResult[T]
+}
+
+type blockResult[T any] struct {
+	values []T
+	err    error
+}
+
+// readOrdered calls read for blocks first to last with workers in parallel and sends the returned
+// values ordered by block to out which is closed once all values are sent. It returns after all
+// workers stopped reading, hence the database read from may be closed afterwards.
+func readOrdered[T any](ctx context.Context, read func(block uint64) ([]T, error), first uint64, last uint64, workers int, out chan<- T) error {
+	defer close(out)
+	ctx, cancel := context.WithCancel(ctx)
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	defer cancel()
+
+	// blocks are queued in order while workers read them in any order
+	jobs := make(chan blockJob[T], workers)
+	queue := make(chan blockJob[T], workers*10)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(queue)
+		for block := first; block <= last; block++ {
+			job := blockJob[T]{block: block, result: make(chan blockResult[T], 1)}
+			select {
+			case <-ctx.Done():
+				return
+			case queue <- job:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- job:
+			}
+			if block == math.MaxUint64 {
+				return
+			}
+		}
+	}()
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job, ok := <-jobs:
+					if !ok {
+						return
+					}
+					values, err := read(job.block)
+					job.result <- blockResult[T]{values: values, err: err}
+				}
+			}
+		}()
+	}
+
+	for job := range queue {
+		var result blockResult[T]
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result = <-job.result:
+		}
+		if result.err != nil {
+			return fmt.Errorf("cannot read block %v; %w", job.block, result.err)
+		}
+		for _, value := range result.values {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- value:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/diff"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/mock/gomock"
+)
+
+// createReportTestDB creates a database holding transactions 0 and 1 of blocks, change modifies substates before they are put.
+func createReportTestDB(t *testing.T, path string, blocks []uint64, change func(ss *substate.Substate)) db.SubstateDB {
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { sdb.Close() })
+	for _, block := range blocks {
+		for tx := 0; tx < 2; tx++ {
+			ss := getGenericSubstate()
+			ss.Block, ss.Transaction = block, tx
+			ss.OutputSubstate = substate.NewWorldState().
+				Add(types.Address{1}, 1, uint256.NewInt(block), nil).
+				Add(types.Address{2}, 1, uint256.NewInt(block), nil)
+			if change != nil {
+				change(ss)
+			}
+			require.NoError(t, sdb.PutSubstate(ss))
+		}
+	}
+	return sdb
+}
+
+func TestCompareAll_ReportsIdenticalDatabases(t *testing.T) {
+	blocks := []uint64{1, 2, 3, 5, 8}
+	src := createReportTestDB(t, t.TempDir(), blocks, nil)
+	target := createReportTestDB(t, t.TempDir(), blocks, nil)
+
+	report, err := CompareAll(context.Background(), src, target, 0, 10, ReportOptions{Workers: 3})
+	require.NoError(t, err)
+	assert.Equal(t, &Report{Family: "substates", First: 0, Last: 10, Compared: 10, Identical: 10}, report)
+}
+
+func TestCompareAll_ReportsAllProblemsInOrder(t *testing.T) {
+	src := createReportTestDB(t, t.TempDir(), []uint64{1, 2, 3, 4, 5}, nil)
+	target := createReportTestDB(t, t.TempDir(), []uint64{2, 3, 5, 6}, func(ss *substate.Substate) {
+		if ss.Block == 3 || (ss.Block == 5 && ss.Transaction == 1) {
+			ss.Result.Status = 0
+		}
+	})
+
+	report, err := CompareAll(context.Background(), src, target, 0, 10, ReportOptions{Workers: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Compared)
+	assert.Equal(t, 3, report.Identical)
+	assert.False(t, report.Truncated)
+	status := diff.Differences{{Path: "Result.Status", Got: "0", Want: "1"}}
+	assert.Equal(t, []Mismatch{
+		{Key: Key{Block: 3, Transaction: 0}, Differences: status},
+		{Key: Key{Block: 3, Transaction: 1}, Differences: status},
+		{Key: Key{Block: 5, Transaction: 1}, Differences: status},
+	}, report.Mismatches)
+	assert.Equal(t, []Key{{1, 0}, {1, 1}, {4, 0}, {4, 1}}, report.MissingInTarget)
+	assert.Equal(t, []Key{{6, 0}, {6, 1}}, report.ExtraInTarget)
+	assert.Equal(t, 9, report.Problems())
+}
+
+func TestCompareAll_StopsAtLimit(t *testing.T) {
+	blocks := []uint64{1, 2, 3, 4, 5, 6, 7, 8}
+	src := createReportTestDB(t, t.TempDir(), blocks, nil)
+	target := createReportTestDB(t, t.TempDir(), blocks, func(ss *substate.Substate) {
+		ss.Result.GasUsed = 2
+	})
+
+	report, err := CompareAll(context.Background(), src, target, 1, 8, ReportOptions{Workers: 2, MaxProblems: 3})
+	require.NoError(t, err)
+	assert.True(t, report.Truncated)
+	require.Len(t, report.Mismatches, 3)
+	assert.Equal(t, Key{Block: 2, Transaction: 0}, report.Mismatches[2].Key)
+}
+
+func TestCompareAll_IgnoresFields(t *testing.T) {
+	blocks := []uint64{1, 2}
+	src := createReportTestDB(t, t.TempDir(), blocks, nil)
+	target := createReportTestDB(t, t.TempDir(), blocks, func(ss *substate.Substate) {
+		ss.Result.Bloom = types.Bloom{1}
+		ss.Env.BlockHashes = map[uint64]types.Hash{ss.Block: {1}}
+		// the coinbase is account 1
+		ss.OutputSubstate[types.Address{1}].Balance = uint256.NewInt(100)
+		if ss.Block == 2 {
+			ss.OutputSubstate[types.Address{2}].Balance = uint256.NewInt(100)
+		}
+	})
+
+	ignore, err := diff.NewIgnore("Result.Bloom", "Env.BlockHashes", "OutputSubstate[coinbase].Balance")
+	require.NoError(t, err)
+	report, err := CompareAll(context.Background(), src, target, 1, 2, ReportOptions{Workers: 2, Ignore: ignore})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Identical)
+	require.Len(t, report.Mismatches, 2)
+	assert.Equal(t, []string{"OutputSubstate[" + types.Address{2}.String() + "].Balance"}, report.Mismatches[0].Differences.Paths())
+}
+
+func TestCompareAll_FailsIfDatabaseCannotBeRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	src := db.NewMockSubstateDB(ctrl)
+	target := createReportTestDB(t, t.TempDir(), []uint64{1}, nil)
+	injected := errors.New("injected")
+	src.EXPECT().GetBlockSubstates(uint64(1)).Return(map[int]*substate.Substate{}, nil).AnyTimes()
+	src.EXPECT().GetBlockSubstates(uint64(2)).Return(nil, injected).AnyTimes()
+	// blocks are read ahead of the failing one
+	src.EXPECT().GetBlockSubstates(uint64(3)).Return(map[int]*substate.Substate{}, nil).AnyTimes()
+
+	_, err := CompareAll(context.Background(), src, target, 1, 3, ReportOptions{Workers: 2})
+	assert.ErrorIs(t, err, injected)
+	assert.ErrorContains(t, err, "cannot read block 2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CompareAll(ctx, target, target, 1, 3, ReportOptions{Workers: 2})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCompareSubstate_KeepGoingWritesReport(t *testing.T) {
+	dir := t.TempDir()
+	createReportTestDB(t, dir+"/src", []uint64{1, 2}, nil).Close()
+	createReportTestDB(t, dir+"/target", []uint64{2, 3}, func(ss *substate.Substate) {
+		ss.Result.Bloom = types.Bloom{1}
+	}).Close()
+
+	run := func(args ...string) error {
+		app := &cli.App{Name: "test", Action: compare, Flags: flags}
+		return app.Run(append([]string{"dummy", "--src", dir + "/src", "--target", dir + "/target",
+			"--block-segment", "1-3", "--keep-going", "--report", dir + "/report.json"}, args...))
+	}
+
+	err := run("--ignore", "Result.Bloom")
+	assert.ErrorContains(t, err, "substates: 0 differ, 2 are missing in target and 2 are extra in target")
+	data, err := os.ReadFile(dir + "/report.json")
+	require.NoError(t, err)
+	var reports []*Report
+	require.NoError(t, json.Unmarshal(data, &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, 2, reports[0].Identical)
+	assert.Equal(t, []Key{{1, 0}, {1, 1}}, reports[0].MissingInTarget)
+
+	err = run("--max-problems", "1")
+	assert.ErrorContains(t, err, "substates: 0 differ, 1 are missing in target and 0 are extra in target")
+
+	err = run("--ignore", "Result[")
+	assert.ErrorContains(t, err, "invalid ignore rule")
+}
@@ -21,6 +21,7 @@ func main() {
 			&utils.SkipCallTxsFlag,
 			&utils.SkipCreateTxsFlag,
 			&utils.BlockSegmentFlag,
+			&utils.DbBackendFlag,
 		},
 	}
 
@@ -62,7 +62,7 @@ func (c *rlpToProtobufCommand) performSubstateUpgrade(
 
 func RunRlpToProtobuf(ctx *cli.Context) (outErr error) {
 	// Open old DB
-	src, err := db.NewSubstateDB(ctx.String(utils.SrcDbFlag.Name), &opt.Options{
+	src, err := db.NewSubstateDBWithBackend(ctx.String(utils.SrcDbFlag.Name), db.Backend(ctx.String(utils.DbBackendFlag.Name)), &opt.Options{
 		OpenFilesCacheCapacity: 1024,
 		BlockCacheCapacity:     50 * opt.MiB,
 		WriteBuffer:            25 * opt.MiB,
@@ -79,7 +79,7 @@ func RunRlpToProtobuf(ctx *cli.Context) (outErr error) {
 	}()
 
 	// Open new DB
-	dst, err := db.NewSubstateDB(ctx.String(utils.DstDbFlag.Name), &opt.Options{
+	dst, err := db.NewSubstateDBWithBackend(ctx.String(utils.DstDbFlag.Name), db.Backend(ctx.String(utils.DbBackendFlag.Name)), &opt.Options{
 		OpenFilesCacheCapacity: 1024,
 		BlockCacheCapacity:     50 * opt.MiB,
 		WriteBuffer:            25 * opt.MiB,
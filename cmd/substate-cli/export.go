@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+// export writes substates of the block segment as one JSON document per line
+func export(ctx *cli.Context) error {
+	segment, err := utils.ParseBlockSegment(ctx.String(utils.BlockSegmentFlag.Name))
+	if err != nil {
+		return err
+	}
+	sdb, err := db.NewSubstateDBWithBackend(
+		ctx.Path(utils.DbFlag.Name),
+		db.Backend(ctx.String(utils.DbBackendFlag.Name)),
+		&opt.Options{ReadOnly: true, ErrorIfMissing: true},
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	out := ctx.App.Writer
+	if path := ctx.Path(outputFlag.Name); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("cannot create output; %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	count, err := exportSubstates(sdb, segment.First, segment.Last, out)
+	if err != nil {
+		return err
+	}
+	log.Printf("Exported substates: %v", count)
+	return nil
+}
+
+// exportSubstates writes substates of blocks first to last into out and returns their number
+func exportSubstates(sdb db.SubstateDB, first, last uint64, out io.Writer) (int, error) {
+	iter := sdb.NewSubstateIterator(int(first), 1)
+	defer iter.Release()
+
+	encoder := json.NewEncoder(out)
+	count := 0
+	for iter.Next() {
+		ss := iter.Value()
+		if ss.Block > last {
+			break
+		}
+		if err := encoder.Encode(ss); err != nil {
+			return count, fmt.Errorf("cannot export substate block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+		}
+		count++
+	}
+	return count, iter.Error()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// importSubstates reads substates as JSON documents and writes them into the database
+func importSubstates(ctx *cli.Context) error {
+	in := ctx.App.Reader
+	if path := ctx.Path(inputFlag.Name); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("cannot open input; %w", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	sdb, err := db.NewSubstateDBWithBackend(ctx.Path(utils.DbFlag.Name), db.Backend(ctx.String(utils.DbBackendFlag.Name)), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+	if ctx.IsSet(encodingFlag.Name) {
+		if err = sdb.SetSubstateEncoding(db.SubstateEncodingSchema(ctx.String(encodingFlag.Name))); err != nil {
+			return err
+		}
+	}
+
+	count, err := readSubstates(in, sdb.PutSubstate)
+	if err != nil {
+		return err
+	}
+	log.Printf("Imported substates: %v", count)
+	return nil
+}
+
+// readSubstates calls put for every substate read from in and returns their number
+func readSubstates(in io.Reader, put func(*substate.Substate) error) (int, error) {
+	decoder := json.NewDecoder(in)
+	count := 0
+	for {
+		ss := new(substate.Substate)
+		err := decoder.Decode(ss)
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("cannot decode substate %v; %w", count+1, err)
+		}
+		if ss.Env == nil || ss.Message == nil || ss.Result == nil {
+			return count, fmt.Errorf("substate block: %v, tx: %v is incomplete", ss.Block, ss.Transaction)
+		}
+		if err = put(ss); err != nil {
+			return count, fmt.Errorf("cannot put substate block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+		}
+		count++
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// outputFlag is optional, the standard output is used if it is not set
+	outputFlag = cli.PathFlag{
+		Name:  "output",
+		Usage: "File the substates are written to as newline-delimited JSON, standard output is used if not set",
+	}
+	// inputFlag is optional, the standard input is used if it is not set
+	inputFlag = cli.PathFlag{
+		Name:  "input",
+		Usage: "File the substates are read from as newline-delimited JSON, standard input is used if not set",
+	}
+	// txFlag is optional, all transactions of the block segment are exported if it is not set
+	txFlag = cli.IntFlag{
+		Name:  "tx",
+		Usage: "Only export the transaction of this index within blocks of the block segment",
+		Value: -1,
+	}
+	outputDirFlag = cli.PathFlag{
+		Name:     "output-dir",
+		Usage:    "Directory the fixtures are written to",
+		Required: true,
+	}
+	// chainIDFlag is optional, the chain id recorded by the DB is used if it is not set
+	chainIDFlag = cli.Uint64Flag{
+		Name:  "chain-id",
+		Usage: "Chain id of the recorded transactions, the chain id recorded by the DB is used if not set",
+	}
+	// forkFlag is optional, the fork is derived from the chain and the block if it is not set
+	forkFlag = cli.StringFlag{
+		Name: "fork",
+		Usage: "Fork of the exported tests (e.g. Osaka), derived from the block if not set; " +
+			"chains other than mainnet get the latest fork whose features are recorded",
+	}
+	// encodingFlag is optional, the encoding of the database is kept if it is not set
+	encodingFlag = cli.StringFlag{
+		Name:  utils.EncodingFlag.Name,
+		Usage: "Encoding imported substates are written with (rlp, protobuf), the encoding of the DB is used if not set",
+	}
+	blocksFlag = cli.PathFlag{
+		Name:     "blocks",
+		Usage:    "File holding blocks with full transactions as returned by eth_getBlockByNumber",
+		Required: true,
+	}
+	receiptsFlag = cli.PathFlag{
+		Name:     "receipts",
+		Usage:    "File holding receipts of the blocks as returned by eth_getBlockReceipts",
+		Required: true,
+	}
+	tracesFlag = cli.PathFlag{
+		Name:     "traces",
+		Usage:    "File holding prestateTracer results of the blocks as returned by debug_traceBlockByNumber",
+		Required: true,
+	}
+	allocFlag = cli.PathFlag{
+		Name:     "alloc",
+		Usage:    "Input alloc of evm t8n",
+		Required: true,
+	}
+	envFlag = cli.PathFlag{
+		Name:     "env",
+		Usage:    "Input env of evm t8n",
+		Required: true,
+	}
+	txsFlag = cli.PathFlag{
+		Name:     "txs",
+		Usage:    "Input txs of evm t8n, transactions must carry their sender",
+		Required: true,
+	}
+	resultFlag = cli.PathFlag{
+		Name:     "result",
+		Usage:    "Output result of evm t8n",
+		Required: true,
+	}
+	outputAllocFlag = cli.PathFlag{
+		Name:     "output-alloc",
+		Usage:    "Output alloc of evm t8n",
+		Required: true,
+	}
+	// blobBaseFeeUpdateFractionFlag is optional, the fraction of the mainnet blob schedule is used if it is not set
+	blobBaseFeeUpdateFractionFlag = cli.Uint64Flag{
+		Name:  "blob-base-fee-update-fraction",
+		Usage: "Fraction blob base fees are derived from the excess blob gas with if receipts carry no blob gas price",
+	}
+	writeIncompleteFlag = cli.BoolFlag{
+		Name:  "write-incomplete",
+		Usage: "Also write substates of incomplete records, they are reported either way",
+	}
+)
+
+var exportCommand = cli.Command{
+	Name:   "export",
+	Usage:  "Write substates of a block range as newline-delimited JSON",
+	Action: export,
+	Flags: []cli.Flag{
+		&utils.DbFlag,
+		&utils.DbBackendFlag,
+		&utils.BlockSegmentFlag,
+		&outputFlag,
+	},
+}
+
+var importCommand = cli.Command{
+	Name:   "import",
+	Usage:  "Write substates read as newline-delimited JSON into a database",
+	Action: importSubstates,
+	Flags: []cli.Flag{
+		&utils.DbFlag,
+		&utils.DbBackendFlag,
+		&encodingFlag,
+		&inputFlag,
+	},
+}
+
+var stateTestCommand = cli.Command{
+	Name: "statetest",
+	Usage: "Write substates of a block range as GeneralStateTests fixtures, one file per transaction. " +
+		"Post states carry the changed accounts and the receipt instead of the state root hash",
+	Action: exportStateTests,
+	Flags: []cli.Flag{
+		&utils.DbFlag,
+		&utils.DbBackendFlag,
+		&utils.BlockSegmentFlag,
+		&txFlag,
+		&chainIDFlag,
+		&forkFlag,
+		&outputDirFlag,
+	},
+}
+
+var importTraceCommand = cli.Command{
+	Name:   "import-trace",
+	Usage:  "Write substates built from prestateTracer results in diff mode, blocks and receipts into a database",
+	Action: importTrace,
+	Flags: []cli.Flag{
+		&utils.DbFlag,
+		&utils.DbBackendFlag,
+		&encodingFlag,
+		&blocksFlag,
+		&receiptsFlag,
+		&tracesFlag,
+		&chainIDFlag,
+		&blobBaseFeeUpdateFractionFlag,
+		&writeIncompleteFlag,
+		&utils.ReportFlag,
+	},
+}
+
+var importT8nCommand = cli.Command{
+	Name:   "import-t8n",
+	Usage:  "Write substates built from inputs and outputs of evm t8n into a database",
+	Action: importT8n,
+	Flags: []cli.Flag{
+		&utils.DbFlag,
+		&utils.DbBackendFlag,
+		&encodingFlag,
+		&allocFlag,
+		&envFlag,
+		&txsFlag,
+		&resultFlag,
+		&outputAllocFlag,
+		&chainIDFlag,
+		&blobBaseFeeUpdateFractionFlag,
+		&writeIncompleteFlag,
+		&utils.ReportFlag,
+	},
+}
+
+func main() {
+	app := &cli.App{
+		Name:  "substate-cli",
+		Usage: "Inspect and edit substate databases",
+		Commands: []*cli.Command{
+			&exportCommand,
+			&importCommand,
+			&stateTestCommand,
+			&importTraceCommand,
+			&importT8nCommand,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
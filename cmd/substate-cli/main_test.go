@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/statetest"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runCli(in io.Reader, out io.Writer, args ...string) error {
+	app := &cli.App{
+		Name:     "test",
+		Commands: []*cli.Command{&exportCommand, &importCommand, &stateTestCommand, &importTraceCommand, &importT8nCommand},
+		Reader:   in,
+		Writer:   out,
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func getTestSubstate(block uint64) *substate.Substate {
+	input := substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60, byte(block)})
+	input[types.Address{1}].Storage[types.Hash{1}] = types.Hash{byte(block)}
+	return &substate.Substate{
+		InputSubstate:  input,
+		OutputSubstate: substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(0), []byte{0x60, byte(block)}),
+		Env: &substate.Env{Number: block, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1),
+			BlockHashes: map[uint64]types.Hash{block - 1: {1}}},
+		Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{2}, big.NewInt(1),
+			[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+		Result: substate.NewResult(1, types.Bloom{},
+			[]*types.Log{{Address: types.Address{1}, Topics: []types.Hash{{2}}, Data: []byte{3}}}, types.Address{}, 1),
+		Block: block,
+	}
+}
+
+func createTestDB(t *testing.T) string {
+	path := t.TempDir() + "/substate-db"
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 5; block++ {
+		require.NoError(t, sdb.PutSubstate(getTestSubstate(block)))
+	}
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func TestSubstateCli_ExportImportRoundTrip(t *testing.T) {
+	src := createTestDB(t)
+	file := t.TempDir() + "/substates.ndjson"
+	require.NoError(t, runCli(nil, nil, "export", "--db", src, "--block-segment", "2-3", "--output", file))
+
+	dst := t.TempDir() + "/import-db"
+	require.NoError(t, runCli(nil, nil, "import", "--db", dst, "--encoding", "protobuf", "--input", file))
+
+	sdb, err := db.NewReadOnlySubstateDB(dst)
+	require.NoError(t, err)
+	defer sdb.Close()
+	assert.Equal(t, db.ProtobufEncodingSchema, sdb.GetSubstateEncoding())
+	for block, want := range map[uint64]bool{1: false, 2: true, 3: true, 4: false} {
+		has, err := sdb.HasSubstate(block, 0)
+		require.NoError(t, err)
+		require.Equal(t, want, has, "substate of block %v", block)
+		if want {
+			got, err := sdb.GetSubstate(block, 0)
+			require.NoError(t, err)
+			assert.NoError(t, getTestSubstate(block).Equal(got))
+		}
+	}
+}
+
+func TestSubstateCli_StreamsThroughStandardIO(t *testing.T) {
+	src := createTestDB(t)
+	out := new(bytes.Buffer)
+	require.NoError(t, runCli(nil, out, "export", "--db", src, "--block-segment", "4-10"))
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"))
+
+	dst := t.TempDir() + "/import-db"
+	require.NoError(t, runCli(strings.NewReader(out.String()), nil, "import", "--db", dst))
+	sdb, err := db.NewReadOnlySubstateDB(dst)
+	require.NoError(t, err)
+	defer sdb.Close()
+	got, err := sdb.GetSubstate(5, 0)
+	require.NoError(t, err)
+	assert.NoError(t, getTestSubstate(5).Equal(got))
+}
+
+func TestSubstateCli_ImportRejectsInvalidInput(t *testing.T) {
+	dst := t.TempDir() + "/import-db"
+	assert.ErrorContains(t, runCli(strings.NewReader(`{"block":"0x1","transaction":"0x0"}`), nil, "import", "--db", dst), "incomplete")
+	assert.ErrorContains(t, runCli(strings.NewReader(`{"block":1}`), nil, "import", "--db", dst), "cannot decode substate 1")
+	assert.Error(t, runCli(nil, nil, "import", "--db", dst, "--input", t.TempDir()+"/missing"))
+}
+
+func TestSubstateCli_ExportErrors(t *testing.T) {
+	src := createTestDB(t)
+	assert.Error(t, runCli(nil, nil, "export", "--db", src, "--block-segment", "3-2"))
+	assert.Error(t, runCli(nil, nil, "export", "--db", t.TempDir()+"/missing", "--block-segment", "1-2"))
+}
+
+func TestSubstateCli_ExportsStateTests(t *testing.T) {
+	src := createTestDB(t)
+	dir := t.TempDir() + "/fixtures"
+	require.NoError(t, runCli(nil, nil, "statetest", "--db", src, "--block-segment", "2-3", "--chain-id", "146", "--output-dir", dir))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	data, err := os.ReadFile(dir + "/substate_3_0.json")
+	require.NoError(t, err)
+	var fixture statetest.Fixture
+	require.NoError(t, json.Unmarshal(data, &fixture))
+	require.Contains(t, fixture, "substate_3_0")
+	assert.Equal(t, uint64(146), fixture["substate_3_0"].Info.ChainID)
+	got, err := fixture["substate_3_0"].ToSubstate()
+	require.NoError(t, err)
+	assert.NoError(t, getTestSubstate(3).Equal(got))
+
+	dir = t.TempDir() + "/osaka"
+	require.NoError(t, runCli(nil, nil, "statetest", "--db", src, "--block-segment", "3-3", "--chain-id", "146", "--fork", "Osaka", "--output-dir", dir))
+	data, err = os.ReadFile(dir + "/substate_3_0.json")
+	require.NoError(t, err)
+	fixture = statetest.Fixture{}
+	require.NoError(t, json.Unmarshal(data, &fixture))
+	assert.Contains(t, fixture["substate_3_0"].Post, "Osaka")
+	err = runCli(nil, nil, "statetest", "--db", src, "--block-segment", "3-3", "--chain-id", "146", "--fork", "osaka", "--output-dir", dir)
+	assert.ErrorContains(t, err, "unknown fork osaka")
+
+	dir = t.TempDir() + "/single"
+	require.NoError(t, runCli(nil, nil, "statetest", "--db", src, "--block-segment", "1-5", "--tx", "1", "--chain-id", "1", "--output-dir", dir))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSubstateCli_StateTestRequiresChainID(t *testing.T) {
+	src := createTestDB(t)
+	err := runCli(nil, nil, "statetest", "--db", src, "--block-segment", "1-2", "--output-dir", t.TempDir())
+	assert.ErrorContains(t, err, "chain id is not recorded")
+}
+
+func writeT8nFiles(t *testing.T, txs string) []string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"alloc":        `{"0x0100000000000000000000000000000000000000": {"balance": "0x100", "nonce": "0x1"}}`,
+		"env":          `{"currentCoinbase": "0x0300000000000000000000000000000000000000", "currentGasLimit": "0x1000000", "currentNumber": "0x7", "currentTimestamp": "0x10", "currentBaseFee": "0x1"}`,
+		"txs":          txs,
+		"result":       `{"receipts": [{"status": "0x1", "gasUsed": "0x5208", "logsBloom": "0x` + strings.Repeat("00", types.BloomByteLength) + `"}]}`,
+		"output-alloc": `{"0x0100000000000000000000000000000000000000": {"balance": "0xf0", "nonce": "0x2"}, "0x0200000000000000000000000000000000000000": {"balance": "0x10"}}`,
+	}
+	var args []string
+	for name, content := range files {
+		path := dir + "/" + name + ".json"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		args = append(args, "--"+name, path)
+	}
+	return args
+}
+
+func TestSubstateCli_ImportsT8n(t *testing.T) {
+	tx := `{"sender": "0x0100000000000000000000000000000000000000", "to": "0x0200000000000000000000000000000000000000", "nonce": "0x1", "gas": "0x5208", "gasPrice": "0x1", "value": "0x10", "input": "0x"}`
+	dst := t.TempDir() + "/import-db"
+	report := t.TempDir() + "/report.json"
+	args := append([]string{"import-t8n", "--db", dst, "--report", report}, writeT8nFiles(t, "["+tx+"]")...)
+	require.NoError(t, runCli(nil, nil, args...))
+
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"imported":0,"incomplete":[{"block":7,"transaction":0,"txHash":"0x0000000000000000000000000000000000000000000000000000000000000000","problems":["recipient 0x0200000000000000000000000000000000000000 is missing from input substate"],"written":false}]}`, string(data))
+
+	args = append(args, "--write-incomplete", "--chain-id", "146")
+	require.NoError(t, runCli(nil, nil, args...))
+	sdb, err := db.NewReadOnlySubstateDB(dst)
+	require.NoError(t, err)
+	defer sdb.Close()
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(146), md.ChainID)
+	got, err := sdb.GetSubstate(7, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.OutputSubstate[types.Address{1}].Nonce)
+	assert.Equal(t, uint256.NewInt(16), got.OutputSubstate[types.Address{2}].Balance)
+}
+
+func TestSubstateCli_ImportTraceRequiresFiles(t *testing.T) {
+	dir := t.TempDir()
+	err := runCli(nil, nil, "import-trace", "--db", dir+"/db", "--blocks", dir+"/blocks.json", "--receipts", dir+"/receipts.json", "--traces", dir+"/traces.json")
+	assert.ErrorContains(t, err, "cannot open blocks")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/statetest"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+// exportStateTests writes a GeneralStateTests fixture for every substate of the block segment
+func exportStateTests(ctx *cli.Context) error {
+	segment, err := utils.ParseBlockSegment(ctx.String(utils.BlockSegmentFlag.Name))
+	if err != nil {
+		return err
+	}
+	sdb, err := db.NewSubstateDBWithBackend(
+		ctx.Path(utils.DbFlag.Name),
+		db.Backend(ctx.String(utils.DbBackendFlag.Name)),
+		&opt.Options{ReadOnly: true, ErrorIfMissing: true},
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	chainID := ctx.Uint64(chainIDFlag.Name)
+	if !ctx.IsSet(chainIDFlag.Name) {
+		md, err := sdb.GetMetadata()
+		if err != nil {
+			return err
+		}
+		if md == nil || md.ChainID == 0 {
+			return errors.New("chain id is not recorded by the DB, set it with --chain-id")
+		}
+		chainID = md.ChainID
+	}
+
+	fork := ctx.String(forkFlag.Name)
+	if fork != "" && !statetest.IsFork(fork) {
+		return fmt.Errorf("unknown fork %v", fork)
+	}
+
+	dir := ctx.Path(outputDirFlag.Name)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create output directory; %w", err)
+	}
+
+	tx := ctx.Int(txFlag.Name)
+	iter := sdb.NewSubstateIterator(int(segment.First), 1)
+	defer iter.Release()
+	count := 0
+	for iter.Next() {
+		ss := iter.Value()
+		if ss.Block > segment.Last {
+			break
+		}
+		if tx >= 0 && ss.Transaction != tx {
+			continue
+		}
+		test, err := statetest.NewStateTest(ss, chainID, fork)
+		if err != nil {
+			return err
+		}
+		name := statetest.Name(ss.Block, ss.Transaction)
+		data, err := json.MarshalIndent(statetest.Fixture{name: test}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("cannot encode fixture %v; %w", name, err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, name+".json"), data, 0o644); err != nil {
+			return fmt.Errorf("cannot write fixture %v; %w", name, err)
+		}
+		count++
+	}
+	if err = iter.Error(); err != nil {
+		return err
+	}
+	log.Printf("Exported fixtures: %v", count)
+	return nil
+}
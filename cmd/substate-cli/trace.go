@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/importer"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// importTrace builds substates from results of the prestateTracer, blocks and receipts
+func importTrace(ctx *cli.Context) error {
+	files, err := openFiles(ctx, &blocksFlag, &receiptsFlag, &tracesFlag)
+	if err != nil {
+		return err
+	}
+	defer closeFiles(files)
+
+	records, err := importer.ImportTracer(files[0], files[1], files[2], importOptions(ctx))
+	if err != nil {
+		return err
+	}
+	return writeRecords(ctx, records)
+}
+
+// importT8n builds substates from inputs and outputs of evm t8n
+func importT8n(ctx *cli.Context) error {
+	files, err := openFiles(ctx, &allocFlag, &envFlag, &txsFlag, &resultFlag, &outputAllocFlag)
+	if err != nil {
+		return err
+	}
+	defer closeFiles(files)
+
+	records, err := importer.ImportT8n(files[0], files[1], files[2], files[3], files[4], importOptions(ctx))
+	if err != nil {
+		return err
+	}
+	return writeRecords(ctx, records)
+}
+
+func importOptions(ctx *cli.Context) importer.Options {
+	return importer.Options{
+		ChainID:                   ctx.Uint64(chainIDFlag.Name),
+		BlobBaseFeeUpdateFraction: ctx.Uint64(blobBaseFeeUpdateFractionFlag.Name),
+	}
+}
+
+// openFiles opens the files given by flags in their order
+func openFiles(ctx *cli.Context, flags ...*cli.PathFlag) ([]*os.File, error) {
+	var files []*os.File
+	for _, flag := range flags {
+		file, err := os.Open(ctx.Path(flag.Name))
+		if err != nil {
+			closeFiles(files)
+			return nil, fmt.Errorf("cannot open %v; %w", flag.Name, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+// writeRecords writes substates of records into the database and reports incomplete records
+func writeRecords(ctx *cli.Context, records []*importer.Record) error {
+	sdb, err := db.NewSubstateDBWithBackend(ctx.Path(utils.DbFlag.Name), db.Backend(ctx.String(utils.DbBackendFlag.Name)), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+	if ctx.IsSet(encodingFlag.Name) {
+		if err = sdb.SetSubstateEncoding(db.SubstateEncodingSchema(ctx.String(encodingFlag.Name))); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet(chainIDFlag.Name) {
+		if err = db.RecordChainID(sdb, ctx.Uint64(chainIDFlag.Name)); err != nil {
+			return err
+		}
+	}
+
+	report, err := importer.Write(sdb, records, ctx.Bool(writeIncompleteFlag.Name))
+	if err != nil {
+		return err
+	}
+	log.Printf("Imported substates: %v, incomplete records: %v", report.Imported, len(report.Incomplete))
+	return utils.WriteJSONReport(ctx.Path(utils.ReportFlag.Name), report)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/replay"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	referenceFlag = cli.StringFlag{
+		Name:  "reference",
+		Usage: "Name of the executor whose outcomes are expected",
+		Value: "recorded",
+	}
+	candidateFlag = cli.StringFlag{
+		Name:     "candidate",
+		Usage:    "Name of the executor compared with the reference",
+		Required: true,
+	}
+	// exceptionDbFlag is optional, divergences are only reported if it is not set
+	exceptionDbFlag = cli.PathFlag{
+		Name:  "exception-db",
+		Usage: "Database exceptions holding reference outcomes of divergent transactions are written to",
+	}
+)
+
+var flags = []cli.Flag{
+	&utils.DbFlag,
+	&utils.DbBackendFlag,
+	&utils.BlockSegmentFlag,
+	&utils.WorkersFlag,
+	&referenceFlag,
+	&candidateFlag,
+	&exceptionDbFlag,
+	&utils.ReportFlag,
+}
+
+// Executors are made available by packages registering them with replay.Register,
+// link them by importing them for their side effects.
+func main() {
+	app := &cli.App{
+		Name: "substate-diff",
+		Usage: "Execute substates with two executors and report transactions with different outcomes. " +
+			"Available executors: " + strings.Join(replay.Executors(), ", "),
+		Action: diff,
+		Flags:  flags,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// diff runs both executors on the block segment and writes the report of divergences
+func diff(ctx *cli.Context) error {
+	segment, err := utils.ParseBlockSegment(ctx.String(utils.BlockSegmentFlag.Name))
+	if err != nil {
+		return err
+	}
+	reference, err := replay.GetExecutor(ctx.String(referenceFlag.Name))
+	if err != nil {
+		return err
+	}
+	candidate, err := replay.GetExecutor(ctx.String(candidateFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	backend := db.Backend(ctx.String(utils.DbBackendFlag.Name))
+	sdb, err := db.NewSubstateDBWithBackend(ctx.Path(utils.DbFlag.Name), backend, &opt.Options{ReadOnly: true, ErrorIfMissing: true}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	options := replay.DiffOptions{Workers: ctx.Int(utils.WorkersFlag.Name)}
+	if path := ctx.Path(exceptionDbFlag.Name); path != "" {
+		edb, err := db.NewExceptionDBWithBackend(path, backend, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err = edb.Close(); err != nil {
+				log.Printf("Error closing DB: %v", err)
+			}
+		}()
+		options.Exceptions = edb
+	}
+
+	report, err := replay.Diff(sdb, segment.First, segment.Last, reference, candidate, options)
+	if err != nil {
+		return err
+	}
+	if err = utils.WriteJSONReport(ctx.Path(utils.ReportFlag.Name), report); err != nil {
+		return err
+	}
+	if len(report.Divergences) > 0 {
+		return fmt.Errorf("%v of %v transactions diverge", len(report.Divergences), report.Transactions)
+	}
+	return nil
+}
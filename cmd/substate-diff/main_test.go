@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/replay"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	// fail-on-block-2 returns recorded outcomes except for block 2 whose output substate is lost
+	replay.Register("fail-on-block-2", replay.ExecutorFunc(func(ss *substate.Substate) (*replay.Outcome, error) {
+		outcome, _ := replay.Recorded.Execute(ss)
+		if ss.Block == 2 {
+			outcome = &replay.Outcome{OutputSubstate: substate.NewWorldState(), Result: ss.Result}
+		}
+		return outcome, nil
+	}))
+}
+
+func runDiff(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: diff,
+		Flags:  flags,
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func createTestDB(t *testing.T) string {
+	path := t.TempDir() + "/substate-db"
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 3; block++ {
+		require.NoError(t, sdb.PutSubstate(&substate.Substate{
+			InputSubstate:  substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), nil),
+			OutputSubstate: substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(block), nil),
+			Env:            &substate.Env{Number: block, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1)},
+			Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{1}, big.NewInt(1),
+				[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+			Result: substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 1),
+			Block:  block,
+		}))
+	}
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func TestSubstateDiff_ReportsDivergences(t *testing.T) {
+	path := createTestDB(t)
+	dir := t.TempDir()
+	report := dir + "/report.json"
+
+	require.NoError(t, runDiff("--db", path, "--block-segment", "1-3", "--candidate", "recorded", "--report", report))
+
+	err := runDiff("--db", path, "--block-segment", "1-3", "--candidate", "fail-on-block-2",
+		"--report", report, "--exception-db", dir+"/exceptions")
+	assert.ErrorContains(t, err, "1 of 3 transactions diverge")
+
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	got := new(replay.DiffReport)
+	require.NoError(t, json.Unmarshal(data, got))
+	require.Len(t, got.Divergences, 1)
+	assert.Equal(t, uint64(2), got.Divergences[0].Block)
+	assert.Equal(t, "OutputSubstate["+types.Address{1}.String()+"]: got missing, want present", got.Divergences[0].Differences.String())
+
+	edb, err := db.NewReadOnlyExceptionDB(dir + "/exceptions")
+	require.NoError(t, err)
+	defer edb.Close()
+	ex, err := edb.GetException(2)
+	require.NoError(t, err)
+	require.NotNil(t, ex)
+	assert.Contains(t, ex.Data.Transactions, 0)
+}
+
+func TestSubstateDiff_RequiresRegisteredExecutors(t *testing.T) {
+	path := createTestDB(t)
+	err := runDiff("--db", path, "--block-segment", "1-3", "--candidate", "unknown")
+	assert.ErrorContains(t, err, "executor unknown is not registered")
+}
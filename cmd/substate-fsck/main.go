@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+// encodingFlag is optional, the recorded encoding is used if it is not set
+var encodingFlag = cli.StringFlag{
+	Name:  utils.EncodingFlag.Name,
+	Usage: "Encoding of records not tagged with their encoding (rlp, protobuf), the recorded encoding is used if not set",
+}
+
+func main() {
+	app := &cli.App{
+		Name: "substate-fsck",
+		Usage: "Check that all entries of a substate database can be decoded, that referenced code exists " +
+			"and that code matches its hash. The database must not be written to while the tool runs.",
+		Action: fsck,
+		Flags: []cli.Flag{
+			&utils.DbFlag,
+			&utils.DbBackendFlag,
+			&encodingFlag,
+			&utils.RepairFlag,
+			&utils.BatchSizeFlag,
+			&utils.ReportFlag,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fsck checks the database and writes the JSON report
+func fsck(ctx *cli.Context) error {
+	repair := ctx.Bool(utils.RepairFlag.Name)
+	codeDB, err := db.NewCodeDBWithBackend(
+		ctx.Path(utils.DbFlag.Name),
+		db.Backend(ctx.String(utils.DbBackendFlag.Name)),
+		&opt.Options{ReadOnly: !repair, ErrorIfMissing: true},
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = codeDB.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	report, err := db.CheckIntegrity(codeDB, db.FsckOptions{
+		Encoding:  db.SubstateEncodingSchema(ctx.String(encodingFlag.Name)),
+		Repair:    repair,
+		BatchSize: ctx.Int(utils.BatchSizeFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+	if err = utils.WriteJSONReport(ctx.Path(utils.ReportFlag.Name), report); err != nil {
+		return err
+	}
+
+	log.Printf("Checked entries: %v, unknown entries: %v, corrupted entries: %v, missing code: %v, tx gaps: %v, repaired: %v",
+		report.Entries, report.Unknown, len(report.Issues), len(report.MissingCode), len(report.TxGaps), report.Repaired)
+	if !report.Healthy() {
+		return errors.New("database is corrupted")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runFsck(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: fsck,
+		Flags: []cli.Flag{
+			&utils.DbFlag,
+			&utils.DbBackendFlag,
+			&encodingFlag,
+			&utils.RepairFlag,
+			&utils.BatchSizeFlag,
+			&utils.ReportFlag,
+		},
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func readReport(t *testing.T, path string) *db.FsckReport {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := new(db.FsckReport)
+	require.NoError(t, json.Unmarshal(data, report))
+	return report
+}
+
+func TestSubstateFsck_CheckAndRepair(t *testing.T) {
+	path := t.TempDir() + "/substate-db"
+	codeDB, err := db.NewDefaultCodeDB(path)
+	require.NoError(t, err)
+	require.NoError(t, codeDB.PutCode([]byte{1, 2, 3}))
+	require.NoError(t, codeDB.Put(db.BlockHashDBKey(1), []byte{1}))
+	require.NoError(t, codeDB.Close())
+
+	reportPath := t.TempDir() + "/report.json"
+	err = runFsck("--db", path, "--report", reportPath)
+	assert.ErrorContains(t, err, "database is corrupted")
+	report := readReport(t, reportPath)
+	assert.Equal(t, 1, report.Entries[db.CodeDBPrefix])
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, db.BlockHashPrefix, report.Issues[0].Prefix)
+	assert.False(t, report.Issues[0].Repaired)
+
+	require.NoError(t, runFsck("--db", path, "--repair", "--report", reportPath))
+	report = readReport(t, reportPath)
+	assert.Equal(t, 1, report.Repaired)
+
+	require.NoError(t, runFsck("--db", path, "--report", reportPath))
+	assert.Empty(t, readReport(t, reportPath).Issues)
+}
+
+func TestSubstateFsck_Errors(t *testing.T) {
+	assert.Error(t, runFsck("--db", t.TempDir()+"/missing"))
+
+	path := t.TempDir() + "/substate-db"
+	codeDB, err := db.NewDefaultCodeDB(path)
+	require.NoError(t, err)
+	require.NoError(t, codeDB.Close())
+	assert.ErrorContains(t, runFsck("--db", path, "--encoding", "xml"), "encoding not supported")
+}
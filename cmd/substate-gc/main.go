@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	app := &cli.App{
+		Name: "substate-gc",
+		Usage: "Remove bytecode which is not referenced by any substate, update set or exception. " +
+			"The database must not be written to while the tool runs.",
+		Action: collect,
+		Flags: []cli.Flag{
+			&utils.DbFlag,
+			&utils.DbBackendFlag,
+			&utils.DryRunFlag,
+			&utils.BatchSizeFlag,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// collect opens the database and removes its orphaned code
+func collect(ctx *cli.Context) error {
+	dryRun := ctx.Bool(utils.DryRunFlag.Name)
+	codeDB, err := db.NewCodeDBWithBackend(
+		ctx.Path(utils.DbFlag.Name),
+		db.Backend(ctx.String(utils.DbBackendFlag.Name)),
+		&opt.Options{ReadOnly: dryRun},
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = codeDB.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	report, err := db.CollectOrphanedCode(codeDB, db.CodeGCOptions{
+		DryRun:    dryRun,
+		BatchSize: ctx.Int(utils.BatchSizeFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range report.Orphans {
+		log.Printf("Orphaned code %v", codeHash)
+	}
+	log.Printf("Referenced code: %v, scanned code: %v, orphaned code: %v (%v bytes), deleted: %v",
+		report.Referenced, report.Scanned, len(report.Orphans), report.OrphanedBytes, report.Deleted)
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runCollect(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: collect,
+		Flags: []cli.Flag{
+			&utils.DbFlag,
+			&utils.DbBackendFlag,
+			&utils.DryRunFlag,
+			&utils.BatchSizeFlag,
+		},
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func hasCode(t *testing.T, path string, code []byte) bool {
+	codeDB, err := db.NewDefaultCodeDB(path)
+	require.NoError(t, err)
+	defer codeDB.Close()
+	has, err := codeDB.HasCode(hash.Keccak256Hash(code))
+	require.NoError(t, err)
+	return has
+}
+
+func TestSubstateGC_DryRunAndCollect(t *testing.T) {
+	path := t.TempDir() + "/substate-db"
+	codeDB, err := db.NewDefaultCodeDB(path)
+	require.NoError(t, err)
+	require.NoError(t, codeDB.PutCode([]byte{1, 2, 3}))
+	require.NoError(t, codeDB.Close())
+
+	require.NoError(t, runCollect("--db", path, "--dry-run"))
+	assert.True(t, hasCode(t, path, []byte{1, 2, 3}))
+
+	require.NoError(t, runCollect("--db", path, "--batch-size", "1"))
+	assert.False(t, hasCode(t, path, []byte{1, 2, 3}))
+}
+
+func TestSubstateGC_MissingDB(t *testing.T) {
+	err := runCollect("--db", t.TempDir()+"/missing", "--dry-run")
+	assert.Error(t, err)
+}
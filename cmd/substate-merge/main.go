@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+// encodingFlag is optional, the recorded encoding of the target or the encoding of the first source is used if it is not set
+var encodingFlag = cli.StringFlag{
+	Name:  utils.EncodingFlag.Name,
+	Usage: "Encoding of records written into the target (rlp, protobuf)",
+}
+
+func main() {
+	app := &cli.App{
+		Name: "substate-merge",
+		Usage: "Merge substates, code, update sets, destroyed accounts, exceptions and hashes " +
+			"of source databases into the target database",
+		ArgsUsage: "<source db> [<source db> ...]",
+		Action:    merge,
+		Flags: []cli.Flag{
+			&utils.TargetDbFlag,
+			&utils.DbBackendFlag,
+			&encodingFlag,
+			&utils.ConflictPolicyFlag,
+			&utils.ReportFlag,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// merge opens the databases, merges the sources into the target and writes the JSON report
+func merge(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("no source database given")
+	}
+	backend := db.Backend(ctx.String(utils.DbBackendFlag.Name))
+
+	var sources []db.BaseDB
+	defer func() {
+		for _, src := range sources {
+			if err := src.Close(); err != nil {
+				log.Printf("Error closing source DB: %v", err)
+			}
+		}
+	}()
+	for _, path := range ctx.Args().Slice() {
+		src, err := db.NewCodeDBWithBackend(path, backend, &opt.Options{ReadOnly: true, ErrorIfMissing: true}, nil, nil)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, src)
+	}
+
+	target, err := db.NewCodeDBWithBackend(ctx.Path(utils.TargetDbFlag.Name), backend, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = target.Close(); err != nil {
+			log.Printf("Error closing target DB: %v", err)
+		}
+	}()
+
+	report, err := db.MergeDatabases(target, sources, db.MergeOptions{
+		Policy:   db.MergeConflictPolicy(ctx.String(utils.ConflictPolicyFlag.Name)),
+		Encoding: db.SubstateEncodingSchema(ctx.String(encodingFlag.Name)),
+	})
+	if report != nil {
+		if reportErr := utils.WriteJSONReport(ctx.Path(utils.ReportFlag.Name), report); reportErr != nil {
+			return reportErr
+		}
+		log.Printf("Merged records: %v, duplicates: %v, conflicts: %v",
+			report.Merged, report.Duplicates, len(report.Conflicts))
+	}
+	return err
+}
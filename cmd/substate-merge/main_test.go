@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runMerge(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: merge,
+		Flags: []cli.Flag{
+			&utils.TargetDbFlag,
+			&utils.DbBackendFlag,
+			&encodingFlag,
+			&utils.ConflictPolicyFlag,
+			&utils.ReportFlag,
+		},
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+// createTestDB creates a db with substates of blocks first to last, their gas limit is set to gasLimit
+func createTestDB(t *testing.T, first, last uint64, gasLimit uint64) string {
+	path := t.TempDir() + "/substate-db"
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	for block := first; block <= last; block++ {
+		require.NoError(t, sdb.PutSubstate(&substate.Substate{
+			InputSubstate:  substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60}),
+			OutputSubstate: substate.NewWorldState(),
+			Env:            &substate.Env{Number: block, GasLimit: gasLimit, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1)},
+			Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{2}, big.NewInt(1),
+				[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+			Result: substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 1),
+			Block:  block,
+		}))
+	}
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func readReport(t *testing.T, path string) *db.MergeReport {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := new(db.MergeReport)
+	require.NoError(t, json.Unmarshal(data, report))
+	return report
+}
+
+func TestSubstateMerge_MergesSources(t *testing.T) {
+	first := createTestDB(t, 1, 2, 1)
+	second := createTestDB(t, 3, 4, 1)
+	target := t.TempDir() + "/target-db"
+	reportPath := t.TempDir() + "/report.json"
+
+	require.NoError(t, runMerge("--target", target, "--report", reportPath, first, second))
+	assert.Equal(t, 4, readReport(t, reportPath).Merged[db.SubstateDBPrefix])
+
+	sdb, err := db.NewReadOnlySubstateDB(target)
+	require.NoError(t, err)
+	defer sdb.Close()
+	for block := uint64(1); block <= 4; block++ {
+		has, err := sdb.HasSubstate(block, 0)
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+}
+
+func TestSubstateMerge_Conflicts(t *testing.T) {
+	first := createTestDB(t, 1, 2, 1)
+	second := createTestDB(t, 2, 3, 2)
+	reportPath := t.TempDir() + "/report.json"
+
+	err := runMerge("--target", t.TempDir()+"/target-db", "--report", reportPath, first, second)
+	assert.ErrorContains(t, err, "conflicts with merged record")
+
+	err = runMerge("--target", t.TempDir()+"/target-db", "--report", reportPath, "--conflict-policy", "keep-last", first, second)
+	require.NoError(t, err)
+	report := readReport(t, reportPath)
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, 1, report.Conflicts[0].Source)
+}
+
+func TestSubstateMerge_Errors(t *testing.T) {
+	target := t.TempDir() + "/target-db"
+	assert.ErrorContains(t, runMerge("--target", target), "no source database given")
+	assert.Error(t, runMerge("--target", target, t.TempDir()+"/missing"))
+	assert.ErrorContains(t, runMerge("--target", target, "--conflict-policy", "random", createTestDB(t, 1, 1, 1)), "unknown conflict policy")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+// dstDbFlag is optional, the source database is migrated in place if it is not set
+var dstDbFlag = cli.PathFlag{
+	Name:  utils.DstDbFlag.Name,
+	Usage: "Destination Aida DB, the source DB is migrated in place if not set",
+}
+
+func main() {
+	app := &cli.App{
+		Name: "substate-migrate",
+		Usage: "Convert substates, update sets and destroyed accounts to another encoding. " +
+			"An interrupted migration continues once it is run again with the same encoding.",
+		Action: migrate,
+		Flags: []cli.Flag{
+			&utils.SrcDbFlag,
+			&dstDbFlag,
+			&utils.EncodingFlag,
+			&utils.EncodingTagFlag,
+			&utils.BatchSizeFlag,
+			&utils.DbBackendFlag,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// migrate opens the databases and converts records of the source into the destination
+func migrate(ctx *cli.Context) error {
+	backend := db.Backend(ctx.String(utils.DbBackendFlag.Name))
+	src, err := db.NewCodeDBWithBackend(ctx.Path(utils.SrcDbFlag.Name), backend, &opt.Options{ErrorIfMissing: true}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = src.Close(); err != nil {
+			log.Printf("Error closing src DB: %v", err)
+		}
+	}()
+
+	dst := src
+	if ctx.IsSet(dstDbFlag.Name) {
+		dst, err = db.NewCodeDBWithBackend(ctx.Path(dstDbFlag.Name), backend, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err = dst.Close(); err != nil {
+				log.Printf("Error closing dst DB: %v", err)
+			}
+		}()
+	}
+
+	report, err := db.MigrateEncoding(src, dst, db.MigrationOptions{
+		Encoding:  db.SubstateEncodingSchema(ctx.String(utils.EncodingFlag.Name)),
+		Tag:       ctx.Bool(utils.EncodingTagFlag.Name),
+		BatchSize: ctx.Int(utils.BatchSizeFlag.Name),
+	})
+	if report != nil {
+		if report.Resumed {
+			log.Printf("Migration resumed from checkpoint")
+		}
+		log.Printf("Substates: %v, update sets: %v, destroyed accounts: %v, exceptions: %v, copied entries: %v",
+			report.Substates, report.UpdateSets, report.DestroyedAccounts, report.Exceptions, report.Copied)
+	}
+	return err
+}
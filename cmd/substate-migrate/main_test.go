@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runMigrate(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: migrate,
+		Flags: []cli.Flag{
+			&utils.SrcDbFlag,
+			&dstDbFlag,
+			&utils.EncodingFlag,
+			&utils.EncodingTagFlag,
+			&utils.BatchSizeFlag,
+			&utils.DbBackendFlag,
+		},
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func createTestDB(t *testing.T, path string, schema db.SubstateEncodingSchema) {
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(schema))
+	for block := uint64(1); block <= 3; block++ {
+		require.NoError(t, sdb.PutSubstate(&substate.Substate{
+			InputSubstate:  substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60}),
+			OutputSubstate: substate.NewWorldState(),
+			Env:            &substate.Env{Number: block, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1)},
+			Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{2}, big.NewInt(1),
+				[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+			Result: substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 1),
+			Block:  block,
+		}))
+	}
+	require.NoError(t, sdb.Close())
+}
+
+func checkEncoding(t *testing.T, path string, schema db.SubstateEncodingSchema) {
+	sdb, err := db.NewReadOnlySubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+	assert.Equal(t, schema, sdb.GetSubstateEncoding())
+	for block := uint64(1); block <= 3; block++ {
+		ss, err := sdb.GetSubstate(block, 0)
+		require.NoError(t, err)
+		assert.Equal(t, block, ss.Env.Number)
+	}
+}
+
+func TestSubstateMigrate_InPlace(t *testing.T) {
+	path := t.TempDir() + "/substate-db"
+	createTestDB(t, path, db.RLPEncodingSchema)
+
+	require.NoError(t, runMigrate("--src", path, "--encoding", "protobuf", "--batch-size", "1"))
+	checkEncoding(t, path, db.ProtobufEncodingSchema)
+}
+
+func TestSubstateMigrate_IntoNewDB(t *testing.T) {
+	src := t.TempDir() + "/src-db"
+	dst := t.TempDir() + "/dst-db"
+	createTestDB(t, src, db.ProtobufEncodingSchema)
+
+	require.NoError(t, runMigrate("--src", src, "--dst", dst, "--encoding", "rlp", "--encoding-tag"))
+	checkEncoding(t, src, db.ProtobufEncodingSchema)
+	checkEncoding(t, dst, db.RLPEncodingSchema)
+}
+
+func TestSubstateMigrate_Errors(t *testing.T) {
+	path := t.TempDir() + "/substate-db"
+	createTestDB(t, path, db.RLPEncodingSchema)
+
+	assert.ErrorContains(t, runMigrate("--src", path, "--encoding", "xml"), "encoding not supported")
+	assert.Error(t, runMigrate("--src", t.TempDir()+"/missing", "--encoding", "rlp"))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/replay"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	blockFlag = cli.Uint64Flag{
+		Name:     "block",
+		Usage:    "Block of the minimised transaction",
+		Required: true,
+	}
+	txFlag = cli.IntFlag{
+		Name:  "tx",
+		Usage: "Index of the minimised transaction within its block",
+	}
+	referenceFlag = cli.StringFlag{
+		Name:  "reference",
+		Usage: "Name of the executor whose outcomes are expected",
+		Value: "recorded",
+	}
+	candidateFlag = cli.StringFlag{
+		Name:     "candidate",
+		Usage:    "Name of the executor which must keep diverging from the reference as it does for the original substate",
+		Required: true,
+	}
+	// outputFlag is optional, the standard output is used if neither it nor outputDbFlag is set
+	outputFlag = cli.PathFlag{
+		Name:  "output",
+		Usage: "File the minimised substate is written to as JSON",
+	}
+	// outputDbFlag is optional, the minimised substate is written as JSON if it is not set
+	outputDbFlag = cli.PathFlag{
+		Name:  "output-db",
+		Usage: "Database the minimised substate is written to",
+	}
+)
+
+var flags = []cli.Flag{
+	&utils.DbFlag,
+	&utils.DbBackendFlag,
+	&blockFlag,
+	&txFlag,
+	&referenceFlag,
+	&candidateFlag,
+	&outputFlag,
+	&outputDbFlag,
+}
+
+// Executors are made available by packages registering them with replay.Register,
+// link them by importing them for their side effects.
+func main() {
+	app := &cli.App{
+		Name: "substate-minimize",
+		Usage: "Remove accounts, storage slots and block hashes from a substate as long as the candidate " +
+			"executor still diverges from the reference at the same paths. Available executors: " + strings.Join(replay.Executors(), ", "),
+		Action: minimize,
+		Flags:  flags,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// minimize reduces the selected substate and writes the result
+func minimize(ctx *cli.Context) error {
+	reference, err := replay.GetExecutor(ctx.String(referenceFlag.Name))
+	if err != nil {
+		return err
+	}
+	candidate, err := replay.GetExecutor(ctx.String(candidateFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	backend := db.Backend(ctx.String(utils.DbBackendFlag.Name))
+	sdb, err := db.NewSubstateDBWithBackend(ctx.Path(utils.DbFlag.Name), backend, &opt.Options{ReadOnly: true, ErrorIfMissing: true}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	block, tx := ctx.Uint64(blockFlag.Name), ctx.Int(txFlag.Name)
+	ss, err := sdb.GetSubstate(block, tx)
+	if err != nil {
+		return err
+	}
+	if ss == nil {
+		return fmt.Errorf("substate block: %v, tx: %v does not exist", block, tx)
+	}
+
+	minimal, err := replay.Minimize(ss, replay.Diverges(reference, candidate, ss))
+	if err != nil {
+		return err
+	}
+	log.Printf("Accounts: %v -> %v, storage slots: %v -> %v, block hashes: %v -> %v",
+		len(ss.InputSubstate), len(minimal.InputSubstate),
+		countSlots(ss.InputSubstate), countSlots(minimal.InputSubstate),
+		len(ss.Env.BlockHashes), len(minimal.Env.BlockHashes))
+
+	if path := ctx.Path(outputDbFlag.Name); path != "" {
+		if err = putSubstate(path, backend, minimal); err != nil {
+			return err
+		}
+		if !ctx.IsSet(outputFlag.Name) {
+			return nil
+		}
+	}
+	return writeSubstate(ctx, minimal)
+}
+
+// putSubstate writes ss into the database at path which is created if it does not exist
+func putSubstate(path string, backend db.Backend, ss *substate.Substate) error {
+	out, err := db.NewSubstateDBWithBackend(path, backend, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = out.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+	if err = out.PutSubstate(ss); err != nil {
+		return fmt.Errorf("cannot put substate block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+	}
+	return nil
+}
+
+// writeSubstate writes ss as JSON into the output file or the standard output
+func writeSubstate(ctx *cli.Context, ss *substate.Substate) error {
+	out := ctx.App.Writer
+	if path := ctx.Path(outputFlag.Name); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("cannot create output; %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(ss); err != nil {
+		return fmt.Errorf("cannot write substate; %w", err)
+	}
+	return nil
+}
+
+func countSlots(ws substate.WorldState) int {
+	count := 0
+	for _, acc := range ws {
+		count += len(acc.Storage)
+	}
+	return count
+}
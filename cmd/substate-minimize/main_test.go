@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/replay"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	// needs-account-3 loses the output substate as long as account 3 holds slot 1
+	replay.Register("needs-account-3", replay.ExecutorFunc(func(ss *substate.Substate) (*replay.Outcome, error) {
+		outcome, _ := replay.Recorded.Execute(ss)
+		if acc, found := ss.InputSubstate[types.Address{3}]; found && acc.Storage[types.Hash{1}] != (types.Hash{}) {
+			outcome = &replay.Outcome{OutputSubstate: substate.NewWorldState(), Result: ss.Result}
+		}
+		return outcome, nil
+	}))
+}
+
+func runMinimize(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: minimize,
+		Flags:  flags,
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func createTestDB(t *testing.T) string {
+	path := t.TempDir() + "/substate-db"
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	input := substate.NewWorldState()
+	for i := byte(1); i <= 5; i++ {
+		input.Add(types.Address{i}, 1, uint256.NewInt(uint64(i)), nil)
+		input[types.Address{i}].Storage[types.Hash{1}] = types.Hash{i}
+		input[types.Address{i}].Storage[types.Hash{2}] = types.Hash{i}
+	}
+	require.NoError(t, sdb.PutSubstate(&substate.Substate{
+		InputSubstate:  input,
+		OutputSubstate: substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(1), nil),
+		Env: &substate.Env{Number: 7, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1),
+			BlockHashes: map[uint64]types.Hash{5: {5}, 6: {6}}},
+		Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{1}, big.NewInt(1),
+			[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+		Result:      substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 1),
+		Block:       7,
+		Transaction: 1,
+	}))
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func TestSubstateMinimize_WritesMinimalSubstate(t *testing.T) {
+	path := createTestDB(t)
+	dir := t.TempDir()
+
+	require.NoError(t, runMinimize("--db", path, "--block", "7", "--tx", "1", "--candidate", "needs-account-3",
+		"--output", dir+"/minimal.json", "--output-db", dir+"/minimal"))
+
+	data, err := os.ReadFile(dir + "/minimal.json")
+	require.NoError(t, err)
+	got := new(substate.Substate)
+	require.NoError(t, json.Unmarshal(data, got))
+	want := substate.NewWorldState().Add(types.Address{3}, 1, uint256.NewInt(3), nil)
+	want[types.Address{3}].Storage[types.Hash{1}] = types.Hash{3}
+	assert.True(t, want.Equal(got.InputSubstate), "got %v", got.InputSubstate)
+	assert.Empty(t, got.Env.BlockHashes)
+
+	sdb, err := db.NewReadOnlySubstateDB(dir + "/minimal")
+	require.NoError(t, err)
+	defer sdb.Close()
+	stored, err := sdb.GetSubstate(7, 1)
+	require.NoError(t, err)
+	assert.True(t, want.Equal(stored.InputSubstate))
+}
+
+func TestSubstateMinimize_FailsWithoutDivergence(t *testing.T) {
+	path := createTestDB(t)
+	err := runMinimize("--db", path, "--block", "7", "--tx", "1", "--candidate", "recorded")
+	assert.ErrorContains(t, err, "predicate does not hold for substate block: 7, tx: 1")
+
+	err = runMinimize("--db", path, "--block", "7", "--candidate", "unknown")
+	assert.ErrorContains(t, err, "executor unknown is not registered")
+
+	err = runMinimize("--db", path, "--block", "8", "--candidate", "needs-account-3")
+	assert.ErrorContains(t, err, "cannot get substate block: 8, tx: 0")
+}
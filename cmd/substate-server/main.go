@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	app := &cli.App{
+		Name: "substate-server",
+		Usage: "Serve a substate database read-only over gRPC. " +
+			"Clients connect using db.NewRemoteSubstateDB.",
+		Action: serve,
+		Flags: []cli.Flag{
+			&utils.DbFlag,
+			&utils.ListenAddressFlag,
+			&utils.DbBackendFlag,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// serve opens the substate database and serves it until interrupted
+func serve(ctx *cli.Context) error {
+	sdb, err := db.NewSubstateDBWithBackend(
+		ctx.Path(utils.DbFlag.Name),
+		db.Backend(ctx.String(utils.DbBackendFlag.Name)),
+		&opt.Options{ReadOnly: true},
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = sdb.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	lis, err := net.Listen("tcp", ctx.String(utils.ListenAddressFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return Serve(sigCtx, lis, sdb)
+}
+
+// Serve serves sdb on lis until ctx is done, pending requests are finished before returning.
+func Serve(ctx context.Context, lis net.Listener, sdb db.SubstateDB) error {
+	server := grpc.NewServer()
+	if err := db.RegisterSubstateServer(server, sdb); err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, server.GracefulStop)
+	defer stop()
+
+	log.Printf("Serving %v substate database on %v", sdb.GetSubstateEncoding(), lis.Addr())
+	return server.Serve(lis)
+}
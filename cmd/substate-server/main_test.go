@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstateServer_ServeUntilCancelled(t *testing.T) {
+	sdb, err := db.NewDefaultSubstateDB(t.TempDir() + "/substate-db")
+	require.NoError(t, err)
+	require.NoError(t, sdb.PutCode([]byte{1, 2, 3}))
+	defer sdb.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, lis, sdb)
+	}()
+
+	remote, err := db.NewRemoteSubstateDB(lis.Addr().String())
+	require.NoError(t, err)
+	code, err := remote.GetCode(hash.Keccak256Hash([]byte{1, 2, 3}))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, code)
+	require.NoError(t, remote.Close())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
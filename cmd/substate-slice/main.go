@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	app := &cli.App{
+		Name: "substate-slice",
+		Usage: "Copy a block range with all records needed to replay it into a new database. " +
+			"The source database must not be written to while the tool runs.",
+		Action: slice,
+		Flags: []cli.Flag{
+			&utils.SrcDbFlag,
+			&utils.DstDbFlag,
+			&utils.BlockSegmentFlag,
+			&utils.BatchSizeFlag,
+			&utils.DbBackendFlag,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// slice opens the databases and copies the block range of the source into the destination
+func slice(ctx *cli.Context) error {
+	segment, err := utils.ParseBlockSegment(ctx.String(utils.BlockSegmentFlag.Name))
+	if err != nil {
+		return err
+	}
+	backend := db.Backend(ctx.String(utils.DbBackendFlag.Name))
+
+	src, err := db.NewCodeDBWithBackend(ctx.Path(utils.SrcDbFlag.Name), backend, &opt.Options{ReadOnly: true, ErrorIfMissing: true}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = src.Close(); err != nil {
+			log.Printf("Error closing src DB: %v", err)
+		}
+	}()
+
+	dst, err := db.NewCodeDBWithBackend(ctx.Path(utils.DstDbFlag.Name), backend, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = dst.Close(); err != nil {
+			log.Printf("Error closing dst DB: %v", err)
+		}
+	}()
+
+	report, err := db.SliceDatabase(src, dst, db.SliceOptions{
+		First:     segment.First,
+		Last:      segment.Last,
+		BatchSize: ctx.Int(utils.BatchSizeFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Blocks %v-%v: substates: %v, code: %v, update sets: %v, destroyed accounts: %v, exceptions: %v, block hashes: %v, state roots: %v",
+		segment.First, segment.Last, report.Substates, report.Code, report.UpdateSets, report.DestroyedAccounts,
+		report.Exceptions, report.BlockHashes, report.StateRoots)
+	return nil
+}
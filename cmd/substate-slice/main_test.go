@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runSlice(args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: slice,
+		Flags: []cli.Flag{
+			&utils.SrcDbFlag,
+			&utils.DstDbFlag,
+			&utils.BlockSegmentFlag,
+			&utils.BatchSizeFlag,
+			&utils.DbBackendFlag,
+		},
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func createTestDB(t *testing.T) string {
+	path := t.TempDir() + "/substate-db"
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 5; block++ {
+		require.NoError(t, sdb.PutSubstate(&substate.Substate{
+			InputSubstate:  substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60, byte(block)}),
+			OutputSubstate: substate.NewWorldState(),
+			Env:            &substate.Env{Number: block, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1)},
+			Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{2}, big.NewInt(1),
+				[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+			Result: substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 1),
+			Block:  block,
+		}))
+	}
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func TestSubstateSlice_CopiesRange(t *testing.T) {
+	src := createTestDB(t)
+	dst := t.TempDir() + "/slice-db"
+
+	require.NoError(t, runSlice("--src", src, "--dst", dst, "--block-segment", "2-3"))
+
+	sdb, err := db.NewReadOnlySubstateDB(dst)
+	require.NoError(t, err)
+	defer sdb.Close()
+	for block, want := range map[uint64]bool{1: false, 2: true, 3: true, 4: false} {
+		has, err := sdb.HasSubstate(block, 0)
+		require.NoError(t, err)
+		assert.Equal(t, want, has, "substate of block %v", block)
+	}
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, &db.BlockRange{First: 2, Last: 3}, md.Blocks)
+}
+
+func TestSubstateSlice_Errors(t *testing.T) {
+	src := createTestDB(t)
+	assert.Error(t, runSlice("--src", src, "--dst", t.TempDir()+"/slice-db", "--block-segment", "3-2"))
+	assert.Error(t, runSlice("--src", t.TempDir()+"/missing", "--dst", t.TempDir()+"/slice-db", "--block-segment", "1-2"))
+	assert.ErrorContains(t, runSlice("--src", src, "--dst", src, "--block-segment", "1-2"), "resource temporarily unavailable")
+}
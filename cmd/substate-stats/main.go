@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/utils"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+)
+
+// blockSegmentFlag is optional, all blocks are analysed if it is not set
+var blockSegmentFlag = cli.StringFlag{
+	Name:  utils.BlockSegmentFlag.Name,
+	Usage: "Block segment analysed (e.g. 1001, 1_001-2_000, 1-2M), all blocks are analysed if not set",
+}
+
+// reportFlag is optional, only the table is printed if it is not set
+var reportFlag = cli.PathFlag{
+	Name:  utils.ReportFlag.Name,
+	Usage: "File the JSON report is written to, the report is not written if not set",
+}
+
+var flags = []cli.Flag{
+	&utils.DbFlag,
+	&utils.DbBackendFlag,
+	&utils.WorkersFlag,
+	&blockSegmentFlag,
+	&reportFlag,
+}
+
+func main() {
+	app := &cli.App{
+		Name: "substate-stats",
+		Usage: "Print an inventory of a substate database and optionally write it as JSON report. " +
+			"The database must not be written to while the tool runs.",
+		Action: stats,
+		Flags:  flags,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// stats collects statistics of the database, prints them as table and writes the JSON report
+func stats(ctx *cli.Context) error {
+	options := db.StatsOptions{Workers: ctx.Int(utils.WorkersFlag.Name)}
+	if s := ctx.String(blockSegmentFlag.Name); s != "" {
+		segment, err := utils.ParseBlockSegment(s)
+		if err != nil {
+			return err
+		}
+		options.Blocks = &db.BlockRange{First: segment.First, Last: segment.Last}
+	}
+
+	baseDB, err := db.NewCodeDBWithBackend(
+		ctx.Path(utils.DbFlag.Name),
+		db.Backend(ctx.String(utils.DbBackendFlag.Name)),
+		&opt.Options{ReadOnly: true, ErrorIfMissing: true},
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = baseDB.Close(); err != nil {
+			log.Printf("Error closing DB: %v", err)
+		}
+	}()
+
+	report, err := db.CollectStats(baseDB, options)
+	if err != nil {
+		return err
+	}
+	if err = printStats(ctx.App.Writer, report); err != nil {
+		return err
+	}
+	if path := ctx.Path(reportFlag.Name); path != "" {
+		return utils.WriteJSONReport(path, report)
+	}
+	return nil
+}
+
+// printStats writes stats as human-readable tables into w
+func printStats(w io.Writer, stats *db.DatabaseStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "prefix\tkeys\tkey bytes\tvalue bytes\tfirst block\tlast block\t")
+	prefixes := make([]string, 0, len(stats.Prefixes))
+	for prefix := range stats.Prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		s := stats.Prefixes[prefix]
+		first, last := "-", "-"
+		if s.Blocks != nil {
+			first, last = fmt.Sprint(s.Blocks.First), fmt.Sprint(s.Blocks.Last)
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t\n", prefix, s.Keys, s.KeyBytes, s.ValueBytes, first, last)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "blocks\t%v\t\n", stats.Blocks)
+	fmt.Fprintf(tw, "transactions\t%v\t\n", stats.Transactions)
+	fmt.Fprintf(tw, "txs per block (min/avg/max)\t%v / %.2f / %v\t\n", stats.TxsPerBlock.Min, stats.TxsPerBlock.Average, stats.TxsPerBlock.Max)
+	for _, kind := range []struct {
+		name  string
+		count int
+	}{{"creations", stats.Creations}, {"calls", stats.Calls}, {"transfers", stats.Transfers}} {
+		fmt.Fprintf(tw, "%v\t%v (%.1f%%)\t\n", kind.name, kind.count, percentage(kind.count, stats.Transactions))
+	}
+	fmt.Fprintf(tw, "input accounts / storage slots (avg)\t%.2f / %.2f\t\n", stats.InputSubstate.Accounts, stats.InputSubstate.StorageSlots)
+	fmt.Fprintf(tw, "output accounts / storage slots (avg)\t%.2f / %.2f\t\n", stats.OutputSubstate.Accounts, stats.OutputSubstate.StorageSlots)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "tx type\ttransactions\tshare\t")
+	txTypes := make([]string, 0, len(stats.TxTypes))
+	for name := range stats.TxTypes {
+		txTypes = append(txTypes, name)
+	}
+	sort.Strings(txTypes)
+	for _, name := range txTypes {
+		count := stats.TxTypes[name]
+		fmt.Fprintf(tw, "%v\t%v\t%.1f%%\t\n", name, count, percentage(count, stats.Transactions))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "code\t%v\t\n", stats.Code.Count)
+	fmt.Fprintf(tw, "code bytes\t%v\t\n", stats.Code.Bytes)
+	fmt.Fprintf(tw, "code size (min/avg/max)\t%v / %.2f / %v\t\n", stats.Code.MinSize, stats.Code.AverageSize, stats.Code.MaxSize)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "code size\tcount\tshare\t")
+	for _, bucket := range stats.Code.Distribution {
+		size := fmt.Sprintf("<= %v", bucket.Max)
+		if bucket.Max == 0 {
+			size = "larger"
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%.1f%%\t\n", size, bucket.Count, percentage(bucket.Count, stats.Code.Count))
+	}
+	return tw.Flush()
+}
+
+// percentage returns the share of part in total in percent
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(total)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func runStats(out *bytes.Buffer, args ...string) error {
+	app := &cli.App{
+		Name:   "test",
+		Action: stats,
+		Flags:  flags,
+		Writer: out,
+	}
+	return app.Run(append([]string{"dummy"}, args...))
+}
+
+func createTestDB(t *testing.T) string {
+	path := t.TempDir() + "/substate-db"
+	sdb, err := db.NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 3; block++ {
+		require.NoError(t, sdb.PutSubstate(&substate.Substate{
+			InputSubstate:  substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60, byte(block)}),
+			OutputSubstate: substate.NewWorldState(),
+			Env:            &substate.Env{Number: block, Difficulty: big.NewInt(1), BaseFee: big.NewInt(1)},
+			Message: substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, &types.Address{1}, big.NewInt(1),
+				[]byte{1}, nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil, nil),
+			Result: substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 1),
+			Block:  block,
+		}))
+	}
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func TestSubstateStats_PrintsTableAndWritesReport(t *testing.T) {
+	path := createTestDB(t)
+	reportPath := t.TempDir() + "/report.json"
+
+	out := new(bytes.Buffer)
+	require.NoError(t, runStats(out, "--db", path, "--block-segment", "2-3", "--report", reportPath))
+	assert.Contains(t, out.String(), "transactions")
+	assert.Contains(t, out.String(), "code size")
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	report := new(db.DatabaseStats)
+	require.NoError(t, json.Unmarshal(data, report))
+	assert.Equal(t, 2, report.Transactions)
+	assert.Equal(t, 2, report.Calls)
+	assert.Equal(t, &db.BlockRange{First: 2, Last: 3}, report.Prefixes[db.SubstateDBPrefix].Blocks)
+	assert.Equal(t, 3, report.Code.Count)
+}
+
+func TestSubstateStats_Errors(t *testing.T) {
+	path := createTestDB(t)
+	out := new(bytes.Buffer)
+	assert.Error(t, runStats(out, "--db", t.TempDir()+"/missing"))
+	assert.Error(t, runStats(out, "--db", path, "--block-segment", "3-2"))
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Backend identifies the key-value store implementation behind a DbAdapter.
+type Backend string
+
+const (
+	LevelDBBackend Backend = "leveldb"
+	PebbleBackend  Backend = "pebble"
+	DefaultBackend         = LevelDBBackend
+)
+
+// AllBackends lists every backend which can be passed to OpenBackend.
+var AllBackends = []Backend{
+	LevelDBBackend,
+	PebbleBackend,
+}
+
+// OpenBackend opens (or creates) a key-value store of the given backend at path.
+// Note: o is nillable. LevelDB specific options are translated to the closest
+// equivalent of the chosen backend.
+func OpenBackend(backend Backend, path string, o *opt.Options) (DbAdapter, error) {
+	switch backend {
+	case "", LevelDBBackend:
+		db, err := leveldb.OpenFile(path, o)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open leveldb; %w", err)
+		}
+		return db, nil
+
+	case PebbleBackend:
+		db, err := openPebbleDB(path, o)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open pebble; %w", err)
+		}
+		return db, nil
+
+	default:
+		return nil, fmt.Errorf("backend not supported: %s", backend)
+	}
+}
+
+// deleteRangeBatchSize is the amount of data after which DeleteRange flushes its batch
+// on backends without native range deletions.
+const deleteRangeBatchSize = 1 * opt.MiB
+
+// rangeDeleter is implemented by backends supporting native range deletions.
+type rangeDeleter interface {
+	DeleteRange(start []byte, limit []byte, wo *opt.WriteOptions) error
+}
+
+// DeleteRange removes all keys within range [start, limit) from db. Backends with native
+// range deletions (pebble) drop the whole range in a single operation, for others the keys
+// are iterated and deleted in batches.
+func DeleteRange(db BaseDB, start []byte, limit []byte) error {
+	if deleter, ok := db.GetBackend().(rangeDeleter); ok {
+		return deleter.DeleteRange(start, limit, nil)
+	}
+
+	iter := db.newIterator(&util.Range{Start: start, Limit: limit})
+	defer iter.Release()
+
+	batch := db.NewBatch()
+	for iter.Next() {
+		if err := batch.Delete(iter.Key()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= deleteRangeBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
@@ -0,0 +1,286 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+func TestOpenBackend_UnknownBackend(t *testing.T) {
+	db, err := OpenBackend("unknown", t.TempDir(), nil)
+	assert.Nil(t, db)
+	assert.ErrorContains(t, err, "backend not supported: unknown")
+}
+
+func TestOpenBackend_ErrorMessageContainsBackend(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			path := t.TempDir()
+			db, err := OpenBackend(backend, path, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			// database is locked
+			_, err = OpenBackend(backend, path, nil)
+			assert.ErrorContains(t, err, fmt.Sprintf("cannot open %v", backend))
+		})
+	}
+}
+
+func TestBackend_BaseDB_PutGetHasDelete(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewCodeDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			key, value := []byte("key"), []byte("value")
+			require.NoError(t, db.Put(key, value))
+
+			has, err := db.Has(key)
+			require.NoError(t, err)
+			assert.True(t, has)
+
+			got, err := db.Get(key)
+			require.NoError(t, err)
+			assert.Equal(t, value, got)
+
+			require.NoError(t, db.Delete(key))
+
+			has, err = db.Has(key)
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			_, err = db.Get(key)
+			assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+		})
+	}
+}
+
+func TestBackend_BaseDB_Batch(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewCodeDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			require.NoError(t, db.Put([]byte("b"), []byte{2}))
+
+			batch := db.NewBatch()
+			require.NoError(t, batch.Put([]byte("a"), []byte{1}))
+			require.NoError(t, batch.Delete([]byte("b")))
+			require.NoError(t, batch.Write())
+
+			got, err := db.Get([]byte("a"))
+			require.NoError(t, err)
+			assert.Equal(t, []byte{1}, got)
+
+			has, err := db.Has([]byte("b"))
+			require.NoError(t, err)
+			assert.False(t, has)
+		})
+	}
+}
+
+func TestBackend_BaseDB_Iterator(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewCodeDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			for _, key := range []string{"aa1", "ab1", "ab2", "ab3", "ac1"} {
+				require.NoError(t, db.Put([]byte(key), []byte(key)))
+			}
+
+			iter := db.NewIterator([]byte("ab"), []byte("2"))
+			var got []string
+			for iter.Next() {
+				got = append(got, string(iter.Key()))
+				assert.Equal(t, iter.Key(), iter.Value())
+			}
+			iter.Release()
+			require.NoError(t, iter.Error())
+			assert.Equal(t, []string{"ab2", "ab3"}, got)
+
+			iter = db.NewIterator([]byte("ab"), nil)
+			defer iter.Release()
+			require.True(t, iter.Last())
+			assert.Equal(t, "ab3", string(iter.Key()))
+			require.True(t, iter.First())
+			assert.Equal(t, "ab1", string(iter.Key()))
+			require.True(t, iter.Seek([]byte("ab2")))
+			assert.Equal(t, "ab2", string(iter.Key()))
+			require.True(t, iter.Prev())
+			assert.Equal(t, "ab1", string(iter.Key()))
+			assert.False(t, iter.Prev())
+		})
+	}
+}
+
+func TestBackend_BaseDB_CompactAndStat(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewCodeDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			require.NoError(t, db.PutCode([]byte{1, 2, 3}))
+			require.NoError(t, db.Compact(nil, nil))
+			require.NoError(t, db.Compact([]byte(CodeDBPrefix), nil))
+
+			stats, err := db.Stat("leveldb.stats")
+			require.NoError(t, err)
+			assert.NotEmpty(t, stats)
+
+			s := new(leveldb.DBStats)
+			require.NoError(t, db.stats(s))
+			assert.NotZero(t, s.IOWrite)
+		})
+	}
+}
+
+func TestBackend_BaseDB_ReadOnly(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			path := t.TempDir()
+			db, err := NewCodeDBWithBackend(path, backend, nil, nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, db.PutCode([]byte{1}))
+			require.NoError(t, db.Close())
+
+			db, err = NewCodeDBWithBackend(path, backend, &opt.Options{ReadOnly: true}, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			has, err := db.HasCode(hash.Keccak256Hash([]byte{1}))
+			require.NoError(t, err)
+			assert.True(t, has)
+
+			err = db.PutCode([]byte{2})
+			assert.True(t, errors.Is(err, leveldb.ErrReadOnly))
+		})
+	}
+}
+
+func TestBackend_BaseDB_Reopen(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			path := t.TempDir()
+			db, err := NewCodeDBWithBackend(path, backend, nil, nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, db.Put([]byte("key"), []byte("value")))
+			require.NoError(t, db.Close())
+			assert.Equal(t, leveldb.ErrClosed, db.Close())
+
+			db, err = NewCodeDBWithBackend(path, backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			got, err := db.Get([]byte("key"))
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value"), got)
+		})
+	}
+}
+
+func TestBackend_SubstateDB(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewSubstateDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			for _, block := range []uint64{10, 11, 300, 70_000} {
+				for tx := 0; tx < 3; tx++ {
+					ss := getTestSubstate(ProtobufEncodingSchema)
+					ss.Block = block
+					ss.Transaction = tx
+					require.NoError(t, db.PutSubstate(ss))
+				}
+			}
+
+			got, err := db.GetSubstate(300, 1)
+			require.NoError(t, err)
+			want := getTestSubstate(ProtobufEncodingSchema)
+			want.Block, want.Transaction = 300, 1
+			assert.NoError(t, want.Equal(got))
+
+			block, err := db.GetBlockSubstates(11)
+			require.NoError(t, err)
+			assert.Len(t, block, 3)
+
+			first := db.GetFirstSubstate()
+			require.NotNil(t, first)
+			assert.Equal(t, uint64(10), first.Block)
+			assert.Equal(t, 0, first.Transaction)
+
+			last, err := db.GetLastSubstate()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(70_000), last.Block)
+			assert.Equal(t, 2, last.Transaction)
+
+			iter := db.NewSubstateIterator(300, 2)
+			count := 0
+			for iter.Next() {
+				assert.GreaterOrEqual(t, iter.Value().Block, uint64(300))
+				count++
+			}
+			iter.Release()
+			require.NoError(t, iter.Error())
+			assert.Equal(t, 6, count)
+
+			require.NoError(t, db.DeleteSubstate(300, 1))
+			has, err := db.HasSubstate(300, 1)
+			require.NoError(t, err)
+			assert.False(t, has)
+		})
+	}
+}
+
+func TestBackend_OtherDBs(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			path := t.TempDir()
+			updateDB, err := NewUpdateDBWithBackend(path+"/update", backend, nil, nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, updateDB.Close())
+
+			exceptionDB, err := NewExceptionDBWithBackend(path+"/exception", backend, nil, nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, exceptionDB.Close())
+
+			destroyedAccountDB, err := NewDestroyedAccountDBWithBackend(path+"/destroyed", backend, nil, nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, destroyedAccountDB.Close())
+		})
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewCodeDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			for _, key := range []string{"a", "b1", "b2", "b3", "c"} {
+				require.NoError(t, db.Put([]byte(key), []byte(key)))
+			}
+
+			require.NoError(t, DeleteRange(db, []byte("b"), []byte("c")))
+
+			for key, want := range map[string]bool{"a": true, "b1": false, "b2": false, "b3": false, "c": true} {
+				has, err := db.Has([]byte(key))
+				require.NoError(t, err)
+				assert.Equal(t, want, has, key)
+			}
+		})
+	}
+}
@@ -99,6 +99,16 @@ type BaseDB interface {
 	// will compact entire data store.
 	Compact(start []byte, limit []byte) error
 
+	// Snapshot returns a read-only view of the database pinned to its current content,
+	// the view keeps the encoding schema of the database. Snapshot must be closed once unused.
+	Snapshot() (DBSnapshot, error)
+
+	// GetMetadata returns the metadata record of the database, nil if it has none.
+	GetMetadata() (*Metadata, error)
+
+	// SetMetadata replaces the metadata record of the database.
+	SetMetadata(md *Metadata) error
+
 	// Close closes the DB. This will also release any outstanding snapshot,
 	// abort any in-flight compaction and discard open transaction.
 	//
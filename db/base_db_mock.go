@@ -333,6 +333,21 @@ func (mr *MockBaseDBMockRecorder) GetBackend() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBackend", reflect.TypeOf((*MockBaseDB)(nil).GetBackend))
 }
 
+// GetMetadata mocks base method.
+func (m *MockBaseDB) GetMetadata() (*Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(*Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockBaseDBMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockBaseDB)(nil).GetMetadata))
+}
+
 // GetSubstateEncoding mocks base method.
 func (m *MockBaseDB) GetSubstateEncoding() SubstateEncodingSchema {
 	m.ctrl.T.Helper()
@@ -404,6 +419,35 @@ func (mr *MockBaseDBMockRecorder) Put(key, value any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBaseDB)(nil).Put), key, value)
 }
 
+// SetMetadata mocks base method.
+func (m *MockBaseDB) SetMetadata(md *Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", md)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockBaseDBMockRecorder) SetMetadata(md any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockBaseDB)(nil).SetMetadata), md)
+}
+
+// Snapshot mocks base method.
+func (m *MockBaseDB) Snapshot() (DBSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(DBSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockBaseDBMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockBaseDB)(nil).Snapshot))
+}
+
 // Stat mocks base method.
 func (m *MockBaseDB) Stat(property string) (string, error) {
 	m.ctrl.T.Helper()
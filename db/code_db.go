@@ -1,6 +1,7 @@
 package db
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -44,8 +45,14 @@ func NewCodeDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOp
 	return newCodeDB(path, o, wo, ro)
 }
 
+// NewCodeDBWithBackend creates new instance of CodeDB stored in given backend.
+// Note: Any of three options is nillable. If that's the case a default value for the option is set.
+func NewCodeDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (CodeDB, error) {
+	return newCodeDBWithBackend(path, backend, o, wo, ro)
+}
+
 func MakeDefaultCodeDBFromBaseDB(db BaseDB) CodeDB {
-	return &codeDB{db.GetBackend(), nil, nil}
+	return &codeDB{backend: db.GetBackend(), metadata: metadataCacheOf(db)}
 }
 
 // NewReadOnlyCodeDB creates a new instance of read-only CodeDB.
@@ -54,14 +61,23 @@ func NewReadOnlyCodeDB(path string) (CodeDB, error) {
 }
 
 func newCodeDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*codeDB, error) {
-	b, err := leveldb.OpenFile(path, o)
+	return newCodeDBWithBackend(path, DefaultBackend, o, wo, ro)
+}
+
+func newCodeDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*codeDB, error) {
+	b, err := OpenBackend(backend, path, o)
 	if err != nil {
-		return nil, fmt.Errorf("cannot open leveldb; %w", err)
+		return nil, err
+	}
+	if err = initMetadata(b, o); err != nil {
+		b.Close()
+		return nil, err
 	}
 	return &codeDB{
-		backend: b,
-		wo:      wo,
-		ro:      ro,
+		backend:  b,
+		wo:       wo,
+		ro:       ro,
+		metadata: newMetadataCache(b, o, wo, ro),
 	}, nil
 }
 
@@ -69,6 +85,8 @@ type codeDB struct {
 	backend DbAdapter
 	wo      *opt.WriteOptions
 	ro      *opt.ReadOptions
+	// metadata is nil for databases writing metadata changes at once
+	metadata *metadataCache
 }
 
 func (db *codeDB) GetSubstateEncoding() SubstateEncodingSchema {
@@ -142,15 +160,59 @@ func (db *codeDB) GetBackend() DbAdapter {
 	return db.backend
 }
 
+func (db *codeDB) Snapshot() (DBSnapshot, error) {
+	if err := db.metadata.flush(); err != nil {
+		return nil, err
+	}
+	return newDBSnapshot(db.backend, db.GetSubstateEncoding())
+}
+
+func (db *codeDB) GetMetadata() (*Metadata, error) {
+	if db.metadata == nil {
+		return getMetadata(db.backend, db.ro)
+	}
+	return db.metadata.get()
+}
+
+func (db *codeDB) SetMetadata(md *Metadata) error {
+	if db.metadata == nil {
+		return putMetadata(db.backend, db.wo, md)
+	}
+	return db.metadata.set(md)
+}
+
+func (db *codeDB) metadataCache() *metadataCache {
+	return db.metadata
+}
+
 func (db *codeDB) Put(key []byte, value []byte) error {
+	// metadata records written around the cache are read again
+	if db.metadata != nil && bytes.HasPrefix(key, []byte(MetadataPrefix)) {
+		if err := db.metadata.flush(); err != nil {
+			return err
+		}
+		defer db.metadata.drop()
+	}
 	return db.backend.Put(key, value, db.wo)
 }
 
 func (db *codeDB) Delete(key []byte) error {
+	// metadata records written around the cache are read again
+	if db.metadata != nil && bytes.HasPrefix(key, []byte(MetadataPrefix)) {
+		if err := db.metadata.flush(); err != nil {
+			return err
+		}
+		defer db.metadata.drop()
+	}
 	return db.backend.Delete(key, db.wo)
 }
 
+// Close writes pending metadata changes and closes the backend.
 func (db *codeDB) Close() error {
+	if err := db.metadata.flush(); err != nil {
+		db.backend.Close()
+		return err
+	}
 	return db.backend.Close()
 }
 
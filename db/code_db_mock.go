@@ -143,6 +143,21 @@ func (mr *MockCodeDBMockRecorder) GetCode(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCode", reflect.TypeOf((*MockCodeDB)(nil).GetCode), arg0)
 }
 
+// GetMetadata mocks base method.
+func (m *MockCodeDB) GetMetadata() (*Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(*Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockCodeDBMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockCodeDB)(nil).GetMetadata))
+}
+
 // GetSubstateEncoding mocks base method.
 func (m *MockCodeDB) GetSubstateEncoding() SubstateEncodingSchema {
 	m.ctrl.T.Helper()
@@ -243,6 +258,35 @@ func (mr *MockCodeDBMockRecorder) PutCode(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutCode", reflect.TypeOf((*MockCodeDB)(nil).PutCode), arg0)
 }
 
+// SetMetadata mocks base method.
+func (m *MockCodeDB) SetMetadata(md *Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", md)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockCodeDBMockRecorder) SetMetadata(md any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockCodeDB)(nil).SetMetadata), md)
+}
+
+// Snapshot mocks base method.
+func (m *MockCodeDB) Snapshot() (DBSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(DBSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockCodeDBMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockCodeDB)(nil).Snapshot))
+}
+
 // Stat mocks base method.
 func (m *MockCodeDB) Stat(property string) (string, error) {
 	m.ctrl.T.Helper()
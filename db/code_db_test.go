@@ -16,92 +16,108 @@ import (
 var testCode = []byte{1}
 
 func TestCodeDB_PutCode(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-
-	db, err := createDbAndPutCode(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	s := new(leveldb.DBStats)
-	err = db.stats(s)
-	if err != nil {
-		t.Fatalf("cannot get db stats; %v", err)
-	}
-
-	// 54 is the base write when creating levelDB
-	if s.IOWrite <= 54 {
-		t.Fatal("db file should have something inside")
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+
+			db, err := createDbAndPutCode(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := new(leveldb.DBStats)
+			err = db.stats(s)
+			if err != nil {
+				t.Fatalf("cannot get db stats; %v", err)
+			}
+
+			// 54 is the base write when creating levelDB
+			if s.IOWrite <= 54 {
+				t.Fatal("db file should have something inside")
+			}
+
+		})
 	}
-
 }
 
 func TestCodeDB_HasCode(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutCode(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	hash := hash.Keccak256Hash(testCode)
-	has, err := db.HasCode(hash)
-	if err != nil {
-		t.Fatalf("get code returned error; %v", err)
-	}
-
-	if !has {
-		t.Fatal("code is not within db")
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutCode(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hash := hash.Keccak256Hash(testCode)
+			has, err := db.HasCode(hash)
+			if err != nil {
+				t.Fatalf("get code returned error; %v", err)
+			}
+
+			if !has {
+				t.Fatal("code is not within db")
+			}
+		})
 	}
 }
 
 func TestCodeDB_GetCode(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutCode(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	hash := hash.Keccak256Hash(testCode)
-	code, err := db.GetCode(hash)
-	if err != nil {
-		t.Fatalf("get code returned error; %v", err)
-	}
-
-	if !bytes.Equal(code, testCode) {
-		t.Fatal("code returned by the db is different")
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutCode(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hash := hash.Keccak256Hash(testCode)
+			code, err := db.GetCode(hash)
+			if err != nil {
+				t.Fatalf("get code returned error; %v", err)
+			}
+
+			if !bytes.Equal(code, testCode) {
+				t.Fatal("code returned by the db is different")
+			}
+		})
 	}
 }
 
 func TestCodeDB_DeleteCode(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutCode(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	h := hash.Keccak256Hash(testCode)
-
-	err = db.DeleteCode(h)
-	if err != nil {
-		t.Fatalf("delete code returned error; %v", err)
-	}
-
-	code, err := db.GetCode(h)
-	if err == nil {
-		t.Fatal("get code must fail")
-	}
-
-	if got, want := err, leveldb.ErrNotFound; !errors.Is(got, want) {
-		t.Fatalf("unexpected err, got: %v, want: %v", got, want)
-	}
-
-	if code != nil {
-		t.Fatal("code was not deleted")
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutCode(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := hash.Keccak256Hash(testCode)
+
+			err = db.DeleteCode(h)
+			if err != nil {
+				t.Fatalf("delete code returned error; %v", err)
+			}
+
+			code, err := db.GetCode(h)
+			if err == nil {
+				t.Fatal("get code must fail")
+			}
+
+			if got, want := err, leveldb.ErrNotFound; !errors.Is(got, want) {
+				t.Fatalf("unexpected err, got: %v, want: %v", got, want)
+			}
+
+			if code != nil {
+				t.Fatal("code was not deleted")
+			}
+		})
 	}
 }
 
-func createDbAndPutCode(dbPath string) (*codeDB, error) {
-	db, err := newCodeDB(dbPath, nil, nil, nil)
+func createDbAndPutCode(dbPath string, backend Backend) (*codeDB, error) {
+	db, err := newCodeDBWithBackend(dbPath, backend, nil, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open db; %v", err)
 	}
@@ -120,7 +136,7 @@ func TestCodeDB_GetSubstateEncoding(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	encoding := db.GetSubstateEncoding()
@@ -134,7 +150,7 @@ func TestCodeDB_HashCodeSuccess(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{1})
@@ -150,7 +166,7 @@ func TestCodeDB_HashCodeEmptyFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{})
@@ -165,7 +181,7 @@ func TestCodeDB_HashCodeReadFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{1})
@@ -181,7 +197,7 @@ func TestCodeDB_GetCodeSuccess(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{1})
@@ -198,7 +214,7 @@ func TestCodeDB_GetCodeEmptyFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{})
@@ -213,7 +229,7 @@ func TestCodeDB_GetCodeReadFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{1})
@@ -229,7 +245,7 @@ func TestCodeDB_PutCodeSuccess(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	inputCode := []byte{1, 2, 3}
@@ -245,7 +261,7 @@ func TestCodeDB_PutCodeWriteFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	inputCode := []byte{1, 2, 3}
@@ -261,7 +277,7 @@ func TestCodeDB_DeleteCodeSuccess(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{1})
@@ -276,7 +292,7 @@ func TestCodeDB_DeleteCodeEmptyFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{})
@@ -290,7 +306,7 @@ func TestCodeDB_DeleteCodeWriteFail(t *testing.T) {
 
 	baseDb := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		baseDb, nil, nil,
+		baseDb, nil, nil, nil,
 	}
 
 	input := types.BytesToHash([]byte{1})
@@ -333,7 +349,7 @@ func TestDecodeCodeDB_BasicOperations(t *testing.T) {
 
 	mockBackend := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		mockBackend, nil, nil,
+		mockBackend, nil, nil, nil,
 	}
 
 	// Test stats
@@ -384,7 +400,7 @@ func TestDecodeCodeDB_Compact(t *testing.T) {
 
 	mockBackend := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		mockBackend, nil, nil,
+		mockBackend, nil, nil, nil,
 	}
 
 	mockBackend.EXPECT().CompactRange(gomock.Any()).Return(nil)
@@ -398,7 +414,7 @@ func TestDecodeCodeDB_Stat(t *testing.T) {
 
 	mockBackend := NewMockDbAdapter(ctrl)
 	db := &codeDB{
-		mockBackend, nil, nil,
+		mockBackend, nil, nil, nil,
 	}
 
 	mockBackend.EXPECT().GetProperty("property").Return("value", nil)
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultCodeGCBatchSize is the number of code entries deleted by a single batch.
+const DefaultCodeGCBatchSize = 1000
+
+// CodeGCOptions configures CollectOrphanedCode.
+type CodeGCOptions struct {
+	// DryRun only reports orphaned code, nothing is deleted.
+	DryRun bool
+
+	// BatchSize is the number of code entries deleted by a single batch,
+	// DefaultCodeGCBatchSize is used if not set.
+	BatchSize int
+}
+
+// CodeGCReport summarizes a garbage collection of orphaned code.
+type CodeGCReport struct {
+	// Referenced is the number of distinct code hashes referenced by
+	// substates, update sets and exceptions.
+	Referenced int
+
+	// Scanned is the number of code entries found in the database.
+	Scanned int
+
+	// Orphans are hashes of code entries not referenced by any record.
+	Orphans []types.Hash
+
+	// OrphanedBytes is the total size of orphaned code.
+	OrphanedBytes uint64
+
+	// Deleted is the number of removed code entries, it is zero in dry-run mode.
+	Deleted int
+}
+
+// CollectOrphanedCode removes code which is not referenced by any substate, update set
+// or exception of db. Referenced code hashes are marked first by scanning all records,
+// unreferenced code entries are then swept in batches unless options.DryRun is set.
+//
+// Note: Records written while the collection runs are not taken into account,
+// hence db must not be written to concurrently.
+func CollectOrphanedCode(db BaseDB, options CodeGCOptions) (*CodeGCReport, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultCodeGCBatchSize
+	}
+
+	referenced, err := markReferencedCode(db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CodeGCReport{Referenced: len(referenced)}
+	if err = sweepOrphanedCode(db, referenced, options, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// markReferencedCode returns hashes of code referenced by any substate, update set or exception.
+func markReferencedCode(db BaseDB) (map[types.Hash]struct{}, error) {
+	referenced := make(map[types.Hash]struct{})
+	mark := func(codeHash types.Hash) ([]byte, error) {
+		referenced[codeHash] = struct{}{}
+		return nil, nil
+	}
+	compression := newValueCompression(db)
+
+	err := scanPrefix(db, SubstateDBPrefix, func(key, value []byte) error {
+		return markSubstateCode(compression, mark, key, value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot mark code of substates; %w", err)
+	}
+
+	err = scanPrefix(db, UpdateDBPrefix, func(key, value []byte) error {
+		return markUpdateSetCode(compression, mark, key, value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot mark code of update sets; %w", err)
+	}
+
+	err = scanPrefix(db, ExceptionDBPrefix, func(key, value []byte) error {
+		return markExceptionCode(mark, key, value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot mark code of exceptions; %w", err)
+	}
+	return referenced, nil
+}
+
+// markSubstateCode decodes a substate record calling mark for every code hash it references.
+func markSubstateCode(compression *valueCompression, mark codeLookupFunc, key, value []byte) error {
+	block, tx, err := DecodeSubstateDBKey(key)
+	if err != nil {
+		return err
+	}
+	if value, err = compression.decompress(value); err != nil {
+		return fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	schema, payload, err := recordEncoding(value)
+	if err != nil {
+		return fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	encoding, err := newSubstateEncoding(schema, mark)
+	if err != nil {
+		return err
+	}
+	_, err = encoding.decode(payload, block, tx)
+	return err
+}
+
+// markUpdateSetCode decodes an update-set record calling mark for every code hash it references.
+func markUpdateSetCode(compression *valueCompression, mark codeLookupFunc, key, value []byte) error {
+	block, err := DecodeUpdateSetKey(key)
+	if err != nil {
+		return err
+	}
+	if value, err = compression.decompress(value); err != nil {
+		return fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	schema, payload, err := recordEncoding(value)
+	if err != nil {
+		return fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	encoding, err := newUpdateSetEncoding(schema)
+	if err != nil {
+		return err
+	}
+	if _, err = encoding.decode(block, mark, payload); err != nil {
+		return fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	return nil
+}
+
+// markExceptionCode decodes an exception record calling mark for every code hash it references.
+func markExceptionCode(mark codeLookupFunc, key, value []byte) error {
+	block, err := DecodeExceptionDBKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = decodeException(mark, block, value)
+	return err
+}
+
+// sweepOrphanedCode finds code entries missing in referenced and deletes them unless in dry-run mode.
+func sweepOrphanedCode(db BaseDB, referenced map[types.Hash]struct{}, options CodeGCOptions, report *CodeGCReport) error {
+	batch := db.NewBatch()
+	pending := 0
+	flush := func() error {
+		if err := batch.Write(); err != nil {
+			return fmt.Errorf("cannot delete orphaned code; %w", err)
+		}
+		report.Deleted += pending
+		batch.Reset()
+		pending = 0
+		return nil
+	}
+
+	err := scanPrefix(db, CodeDBPrefix, func(key, value []byte) error {
+		codeHash, err := DecodeCodeDBKey(key)
+		if err != nil {
+			return err
+		}
+		report.Scanned++
+		if _, found := referenced[codeHash]; found {
+			return nil
+		}
+		report.Orphans = append(report.Orphans, codeHash)
+		report.OrphanedBytes += uint64(len(value))
+		if options.DryRun {
+			return nil
+		}
+
+		// the iterator is not affected by deletions of visited keys
+		if err = batch.Delete(key); err != nil {
+			return err
+		}
+		if pending++; pending >= options.BatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// scanPrefix calls visit for every key-value pair with given prefix.
+func scanPrefix(db BaseDB, prefix string, visit func(key, value []byte) error) error {
+	return scanRange(db, util.BytesPrefix([]byte(prefix)), visit)
+}
+
+// scanRange calls visit for every entry of db within r.
+func scanRange(db BaseDB, r *util.Range, visit func(key, value []byte) error) error {
+	iter := db.newIterator(r)
+	defer iter.Release()
+	for iter.Next() {
+		if err := visit(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
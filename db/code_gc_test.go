@@ -0,0 +1,137 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	gcSubstateCode  = []byte{0x60, 0x10}
+	gcUpdateSetCode = []byte{0x60, 0x11}
+	gcExceptionCode = []byte{0x60, 0x12}
+	gcDeletedCode   = []byte{0x60, 0x13}
+	gcOrphanCode    = []byte{0x60, 0x14}
+)
+
+// createCodeGCTestDB creates a db referencing some of its code from records of all kinds.
+func createCodeGCTestDB(t *testing.T, schema SubstateEncodingSchema) MemoryDB {
+	db := newTestDB(t, schema)
+	sdb := db.substates
+	ss := getTestSubstate(schema)
+	ss.Block = 10
+	ss.InputSubstate = substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), gcSubstateCode)
+	require.NoError(t, sdb.PutSubstate(ss))
+
+	// code of a deleted substate is left behind
+	deleted := getTestSubstate(schema)
+	deleted.Block = 11
+	deleted.InputSubstate = substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), gcDeletedCode)
+	require.NoError(t, sdb.PutSubstate(deleted))
+	require.NoError(t, sdb.DeleteSubstate(11, deleted.Transaction))
+
+	require.NoError(t, db.updateSets.PutUpdateSet(&updateset.UpdateSet{
+		WorldState: substate.NewWorldState().Add(types.Address{2}, 1, uint256.NewInt(1), gcUpdateSetCode),
+		Block:      10,
+	}, nil))
+
+	preBlock := substate.NewWorldState().Add(types.Address{3}, 1, uint256.NewInt(1), gcExceptionCode)
+	require.NoError(t, MakeDefaultExceptionDBFromBaseDB(db).PutException(&substate.Exception{
+		Block: 10,
+		Data:  substate.ExceptionBlock{PreBlock: &preBlock},
+	}))
+	// exceptions do not store their code
+	require.NoError(t, sdb.PutCode(gcExceptionCode))
+
+	require.NoError(t, sdb.PutCode(gcOrphanCode))
+	return db.MemoryDB
+}
+
+func TestCollectOrphanedCode_DryRunKeepsCode(t *testing.T) {
+	db := createCodeGCTestDB(t, ProtobufEncodingSchema)
+
+	report, err := CollectOrphanedCode(db, CodeGCOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.Hash{hash.Keccak256Hash(gcDeletedCode), hash.Keccak256Hash(gcOrphanCode)}, report.Orphans)
+	assert.Equal(t, uint64(len(gcDeletedCode)+len(gcOrphanCode)), report.OrphanedBytes)
+	assert.Equal(t, 0, report.Deleted)
+
+	has, err := MakeDefaultCodeDBFromBaseDB(db).HasCode(hash.Keccak256Hash(gcOrphanCode))
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestCollectOrphanedCode_DeletesOnlyOrphans(t *testing.T) {
+	for _, schema := range []SubstateEncodingSchema{ProtobufEncodingSchema, RLPEncodingSchema} {
+		t.Run(string(schema), func(t *testing.T) {
+			db := createCodeGCTestDB(t, schema)
+
+			report, err := CollectOrphanedCode(db, CodeGCOptions{BatchSize: 1})
+			require.NoError(t, err)
+			assert.Len(t, report.Orphans, 2)
+			assert.Equal(t, 2, report.Deleted)
+			assert.Equal(t, report.Scanned-2, countCode(t, db))
+
+			codeDB := MakeDefaultCodeDBFromBaseDB(db)
+			for _, code := range [][]byte{gcSubstateCode, gcUpdateSetCode, gcExceptionCode} {
+				has, err := codeDB.HasCode(hash.Keccak256Hash(code))
+				require.NoError(t, err)
+				assert.True(t, has)
+			}
+			for _, code := range [][]byte{gcDeletedCode, gcOrphanCode} {
+				has, err := codeDB.HasCode(hash.Keccak256Hash(code))
+				require.NoError(t, err)
+				assert.False(t, has)
+			}
+
+			// a second run finds nothing
+			report, err = CollectOrphanedCode(db, CodeGCOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, report.Orphans)
+			assert.Equal(t, 0, report.Deleted)
+		})
+	}
+}
+
+func TestCollectOrphanedCode_CompressedRecords(t *testing.T) {
+	db := createCompressionTestDB(t, ProtobufEncodingSchema)
+	dict, err := TrainCompressionDictionary(db, DefaultCompressionSamples)
+	require.NoError(t, err)
+	require.NoError(t, PutCompressionDictionary(db, dict))
+
+	sdb, err := MakeDefaultSubstateDBFromBaseDB(db)
+	require.NoError(t, err)
+	ss := getTestSubstate(ProtobufEncodingSchema)
+	ss.Block = 100
+	ss.InputSubstate = substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), gcSubstateCode)
+	require.NoError(t, sdb.PutSubstate(ss))
+
+	report, err := CollectOrphanedCode(db, CodeGCOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, report.Orphans, hash.Keccak256Hash(gcSubstateCode))
+}
+
+func TestCollectOrphanedCode_InvalidRecord(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Put(SubstateDBKey(1, 0), []byte{0x0a, 0xff}))
+
+	_, err = CollectOrphanedCode(db, CodeGCOptions{})
+	assert.ErrorContains(t, err, "cannot mark code of substates")
+}
+
+func countCode(t *testing.T, db BaseDB) int {
+	count := 0
+	require.NoError(t, scanPrefix(db, CodeDBPrefix, func([]byte, []byte) error {
+		count++
+		return nil
+	}))
+	return count
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// CompressionDictionaryKey is the metadata key of the zstd dictionary used to compress
+// substates and update sets.
+const CompressionDictionaryKey = MetadataPrefix + "zd"
+
+const (
+	// DefaultCompressionSamples is the number of records sampled when training a dictionary.
+	DefaultCompressionSamples = 1000
+
+	// maxCompressionDictionarySize caps the raw content of a trained dictionary,
+	// which is the size recommended by zstd.
+	maxCompressionDictionarySize = 112 * 1024
+)
+
+// zstdMagic starts every zstd frame. Values are told apart by their first byte, which
+// is 0x28 for compressed values, 0x0a for protobuf messages and at least 0xc0 for RLP lists.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// isCompressed returns true if value is a zstd frame.
+func isCompressed(value []byte) bool {
+	return bytes.HasPrefix(value, zstdMagic)
+}
+
+// TrainCompressionDictionary trains a zstd dictionary from substates and update sets sampled
+// at random blocks of db. At most samples records of each kind are used.
+func TrainCompressionDictionary(db BaseDB, samples int) (dict []byte, err error) {
+	var values [][]byte
+	for _, prefix := range []string{SubstateDBPrefix, UpdateDBPrefix} {
+		v, err := sampleValues(db, []byte(prefix), samples)
+		if err != nil {
+			return nil, fmt.Errorf("cannot sample %v records; %w", prefix, err)
+		}
+		values = append(values, v...)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("cannot train dictionary; no records found")
+	}
+
+	// every other sample forms the raw content of the dictionary, the rest is used to
+	// tune its entropy tables - which cannot be done on content the dictionary contains
+	var history []byte
+	contents := values
+	if len(values) > 1 {
+		contents = nil
+		for i, value := range values {
+			if i%2 == 0 && len(history)+len(value) <= maxCompressionDictionarySize {
+				history = append(history, value...)
+			} else {
+				contents = append(contents, value)
+			}
+		}
+	} else {
+		history = values[0]
+	}
+
+	// BuildDict panics on degenerated input, e.g. if all contents are found in history
+	defer func() {
+		if r := recover(); r != nil {
+			dict, err = nil, fmt.Errorf("cannot train dictionary; %v", r)
+		}
+	}()
+	dict, err = zstd.BuildDict(zstd.BuildDictOptions{
+		// ids below 32768 are reserved by zstd, so are ids above 2^31
+		ID:       32768 + crc32.ChecksumIEEE(history)%(1<<31-32768),
+		Contents: contents,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot train dictionary; %w", err)
+	}
+	return dict, nil
+}
+
+// sampleValues returns uncompressed values of up to samples keys with given prefix. Keys
+// are found by seeking to random block numbers between the first and the last block.
+func sampleValues(db BaseDB, prefix []byte, samples int) ([][]byte, error) {
+	iter := db.newIterator(util.BytesPrefix(prefix))
+	defer iter.Release()
+
+	if !iter.First() {
+		return nil, iter.Error()
+	}
+	first := binary.BigEndian.Uint64(iter.Key()[len(prefix):])
+	if !iter.Last() {
+		return nil, iter.Error()
+	}
+	last := binary.BigEndian.Uint64(iter.Key()[len(prefix):])
+
+	seen := make(map[string]struct{})
+	var values [][]byte
+	for i := 0; i < samples; i++ {
+		block := first + uint64(rand.Int63n(int64(last-first+1)))
+		key := binary.BigEndian.AppendUint64(append([]byte{}, prefix...), block)
+		if !iter.Seek(key) {
+			break
+		}
+		if _, found := seen[string(iter.Key())]; found || isCompressed(iter.Value()) {
+			continue
+		}
+		seen[string(iter.Key())] = struct{}{}
+		values = append(values, bytes.Clone(iter.Value()))
+	}
+	return values, iter.Error()
+}
+
+// PutCompressionDictionary stores dict in db. Substates and update sets are compressed
+// with it by any SubstateDB or UpdateDB whose encoding is set afterwards. A dictionary
+// cannot be replaced, as records compressed with it would become unreadable.
+func PutCompressionDictionary(db BaseDB, dict []byte) error {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict))
+	if err != nil {
+		return fmt.Errorf("invalid compression dictionary; %w", err)
+	}
+	decoder.Close()
+
+	has, err := db.Has([]byte(CompressionDictionaryKey))
+	if err != nil {
+		return fmt.Errorf("cannot check compression dictionary; %w", err)
+	}
+	if has {
+		return errors.New("compression dictionary is already set")
+	}
+	return db.Put([]byte(CompressionDictionaryKey), dict)
+}
+
+// GetCompressionDictionary returns the dictionary stored in db, or nil if there is none.
+func GetCompressionDictionary(db BaseDB) ([]byte, error) {
+	dict, err := db.Get([]byte(CompressionDictionaryKey))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get compression dictionary; %w", err)
+	}
+	return dict, nil
+}
+
+// valueCompression compresses values with the dictionary of a database. The dictionary is
+// loaded once it is first needed, databases without a dictionary store values uncompressed.
+// Values are only compressed by the stateless EncodeAll and DecodeAll which start no
+// goroutines, hence the encoder and decoder need not be closed and are left to the GC.
+type valueCompression struct {
+	db      BaseDB
+	once    sync.Once
+	encoder *zstd.Encoder // nil if db has no dictionary
+	decoder *zstd.Decoder
+	err     error
+}
+
+func newValueCompression(db BaseDB) *valueCompression {
+	return &valueCompression{db: db}
+}
+
+func (c *valueCompression) load() error {
+	c.once.Do(func() {
+		var dict []byte
+		dict, c.err = GetCompressionDictionary(c.db)
+		if c.err != nil {
+			return
+		}
+		// a decoder of every CPU allows as many concurrent DecodeAll calls
+		if dict == nil {
+			c.decoder, c.err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+			return
+		}
+		if c.encoder, c.err = zstd.NewWriter(nil, zstd.WithEncoderDict(dict)); c.err != nil {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderDicts(dict))
+	})
+	return c.err
+}
+
+// compress returns value compressed if db has a dictionary, otherwise value itself.
+func (c *valueCompression) compress(value []byte) ([]byte, error) {
+	if c == nil {
+		return value, nil
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	if c.encoder == nil {
+		return value, nil
+	}
+	return c.encoder.EncodeAll(value, nil), nil
+}
+
+// decompress returns value decompressed if it is compressed, otherwise value itself.
+func (c *valueCompression) decompress(value []byte) ([]byte, error) {
+	if c == nil || !isCompressed(value) {
+		return value, nil
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	value, err := c.decoder.DecodeAll(value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress value; %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/holiman/uint256"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createCompressionTestDB creates a db with uncompressed substates of blocks 1 to 50
+// and update sets of blocks 1 to 10.
+func createCompressionTestDB(t *testing.T, schema SubstateEncodingSchema) MemoryDB {
+	db := newTestDB(t, schema)
+	for block := uint64(1); block <= 50; block++ {
+		ss := getTestSubstate(schema)
+		ss.Block = block
+		ss.Env.Number = block
+		require.NoError(t, db.substates.PutSubstate(ss))
+	}
+	for block := uint64(1); block <= 10; block++ {
+		require.NoError(t, db.updateSets.PutUpdateSet(getCompressionTestUpdateSet(block), nil))
+	}
+	return db.MemoryDB
+}
+
+func getCompressionTestUpdateSet(block uint64) *updateset.UpdateSet {
+	return &updateset.UpdateSet{
+		WorldState: substate.NewWorldState().
+			Add(types.Address{1}, block, uint256.NewInt(block), nil).
+			Add(types.Address{2}, block, uint256.NewInt(block), nil),
+		Block: block,
+	}
+}
+
+func TestCompression_CompressedAndUncompressedRecordsCoexist(t *testing.T) {
+	for _, schema := range []SubstateEncodingSchema{ProtobufEncodingSchema, RLPEncodingSchema} {
+		t.Run(string(schema), func(t *testing.T) {
+			db := createCompressionTestDB(t, schema)
+
+			dict, err := TrainCompressionDictionary(db, DefaultCompressionSamples)
+			require.NoError(t, err)
+			require.NoError(t, PutCompressionDictionary(db, dict))
+
+			sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, schema)
+			require.NoError(t, err)
+			ss := getTestSubstate(schema)
+			ss.Block = 60
+			require.NoError(t, sdb.PutSubstate(ss))
+
+			compressed, err := db.Get(SubstateDBKey(60, ss.Transaction))
+			require.NoError(t, err)
+			assert.True(t, isCompressed(compressed))
+			plain, err := db.Get(SubstateDBKey(1, ss.Transaction))
+			require.NoError(t, err)
+			assert.False(t, isCompressed(plain))
+			assert.Less(t, len(compressed), len(plain))
+
+			got, err := sdb.GetSubstate(60, ss.Transaction)
+			require.NoError(t, err)
+			assert.NoError(t, ss.Equal(got))
+
+			count := 0
+			iter := sdb.NewSubstateIterator(0, 2)
+			for iter.Next() {
+				count++
+			}
+			iter.Release()
+			require.NoError(t, iter.Error())
+			assert.Equal(t, 51, count)
+
+			udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, schema)
+			require.NoError(t, err)
+			require.NoError(t, udb.PutUpdateSet(getCompressionTestUpdateSet(20), nil))
+			value, err := db.Get(UpdateDBKey(20))
+			require.NoError(t, err)
+			assert.True(t, isCompressed(value))
+
+			blocks := 0
+			usIter := udb.NewUpdateSetIterator(0, 20)
+			for usIter.Next() {
+				want := getCompressionTestUpdateSet(usIter.Value().Block)
+				assert.True(t, want.WorldState.Equal(usIter.Value().WorldState))
+				blocks++
+			}
+			usIter.Release()
+			require.NoError(t, usIter.Error())
+			assert.Equal(t, 11, blocks)
+		})
+	}
+}
+
+func TestCompression_EncodingIsDetected(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := newSubstateDB(path, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(RLPEncodingSchema))
+	for block := uint64(1); block <= 5; block++ {
+		ss := getTestSubstate(RLPEncodingSchema)
+		ss.Block = block
+		ss.Env.Number = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+	dict, err := TrainCompressionDictionary(sdb, DefaultCompressionSamples)
+	require.NoError(t, err)
+	require.NoError(t, PutCompressionDictionary(sdb, dict))
+
+	// handles pick up the dictionary once their encoding is set, the first substate is compressed
+	require.NoError(t, sdb.SetSubstateEncoding(RLPEncodingSchema))
+	ss := getTestSubstate(RLPEncodingSchema)
+	ss.Block = 0
+	require.NoError(t, sdb.PutSubstate(ss))
+	require.NoError(t, sdb.Close())
+
+	db, err := NewSubstateDB(path, nil, nil, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, RLPEncodingSchema, db.GetSubstateEncoding())
+	first := db.GetFirstSubstate()
+	require.NotNil(t, first)
+	assert.Equal(t, uint64(0), first.Block)
+}
+
+func TestCompression_DictionaryCannotBeReplaced(t *testing.T) {
+	db := createCompressionTestDB(t, ProtobufEncodingSchema)
+
+	dict, err := TrainCompressionDictionary(db, DefaultCompressionSamples)
+	require.NoError(t, err)
+	require.NoError(t, PutCompressionDictionary(db, dict))
+
+	got, err := GetCompressionDictionary(db)
+	require.NoError(t, err)
+	assert.Equal(t, dict, got)
+
+	assert.ErrorContains(t, PutCompressionDictionary(db, dict), "already set")
+}
+
+func TestCompression_InvalidDictionary(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.ErrorContains(t, PutCompressionDictionary(db, []byte{1, 2, 3}), "invalid compression dictionary")
+
+	dict, err := GetCompressionDictionary(db)
+	require.NoError(t, err)
+	assert.Nil(t, dict)
+}
+
+func TestCompression_TrainingRequiresRecords(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = TrainCompressionDictionary(db, DefaultCompressionSamples)
+	assert.ErrorContains(t, err, "no records found")
+}
+
+func TestCompression_DecompressWithoutDictionaryFails(t *testing.T) {
+	db := createCompressionTestDB(t, ProtobufEncodingSchema)
+	dict, err := TrainCompressionDictionary(db, DefaultCompressionSamples)
+	require.NoError(t, err)
+
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderDict(dict))
+	require.NoError(t, err)
+	compressed := encoder.EncodeAll([]byte("value"), nil)
+
+	// db has no dictionary stored
+	_, err = newValueCompression(db).decompress(compressed)
+	assert.ErrorContains(t, err, "cannot decompress value")
+
+	value, err := newValueCompression(db).decompress([]byte("value"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	// nil compression leaves values as they are
+	var none *valueCompression
+	value, err = none.compress([]byte("value"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
@@ -51,6 +51,12 @@ func NewDefaultDestroyedAccountDB(destroyedAccountDir string) (DestroyedAccountD
 	return newDestroyedAccountDB(destroyedAccountDir, nil, nil, nil)
 }
 
+// NewDestroyedAccountDBWithBackend creates new instance of DestroyedAccountDB stored in given backend.
+// Note: Any of three options is nillable. If that's the case a default value for the option is set.
+func NewDestroyedAccountDBWithBackend(destroyedAccountDir string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (DestroyedAccountDB, error) {
+	return newDestroyedAccountDBWithBackend(destroyedAccountDir, backend, o, wo, ro)
+}
+
 func MakeDefaultDestroyedAccountDBFromBaseDB(db BaseDB) (DestroyedAccountDB, error) {
 	value, err := MakeDefaultDestroyedAccountDBFromBaseDBWithEncoding(db, db.GetSubstateEncoding())
 	if err != nil {
@@ -77,10 +83,18 @@ func NewReadOnlyDestroyedAccountDB(destroyedAccountDir string) (DestroyedAccount
 }
 
 func newDestroyedAccountDB(destroyedAccountDir string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (DestroyedAccountDB, error) {
-	backend, err := leveldb.OpenFile(destroyedAccountDir, o)
+	return newDestroyedAccountDBWithBackend(destroyedAccountDir, DefaultBackend, o, wo, ro)
+}
+
+func newDestroyedAccountDBWithBackend(destroyedAccountDir string, backendType Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (DestroyedAccountDB, error) {
+	backend, err := OpenBackend(backendType, destroyedAccountDir, o)
 	if err != nil {
 		return nil, fmt.Errorf("error opening deletion-db %s: %w", destroyedAccountDir, err)
 	}
+	if err = initMetadata(backend, o); err != nil {
+		backend.Close()
+		return nil, err
+	}
 	encoding, err := newDestroyedAccountEncoding(DefaultEncodingSchema)
 	if err != nil {
 		return nil, err
@@ -211,6 +225,18 @@ func (db *destroyedAccountDB) GetBackend() DbAdapter {
 	return db.backend
 }
 
+func (db *destroyedAccountDB) Snapshot() (DBSnapshot, error) {
+	return newDBSnapshot(db.backend, db.GetSubstateEncoding())
+}
+
+func (db *destroyedAccountDB) GetMetadata() (*Metadata, error) {
+	return getMetadata(db.backend, db.ro)
+}
+
+func (db *destroyedAccountDB) SetMetadata(md *Metadata) error {
+	return putMetadata(db.backend, db.wo, md)
+}
+
 func (db *destroyedAccountDB) Put(key []byte, value []byte) error {
 	return db.backend.Put(key, value, db.wo)
 }
@@ -205,6 +205,21 @@ func (mr *MockDestroyedAccountDBMockRecorder) GetLastKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastKey", reflect.TypeOf((*MockDestroyedAccountDB)(nil).GetLastKey))
 }
 
+// GetMetadata mocks base method.
+func (m *MockDestroyedAccountDB) GetMetadata() (*Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(*Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockDestroyedAccountDBMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockDestroyedAccountDB)(nil).GetMetadata))
+}
+
 // GetSubstateEncoding mocks base method.
 func (m *MockDestroyedAccountDB) GetSubstateEncoding() SubstateEncodingSchema {
 	m.ctrl.T.Helper()
@@ -290,6 +305,20 @@ func (mr *MockDestroyedAccountDBMockRecorder) SetDestroyedAccounts(block, tx, de
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDestroyedAccounts", reflect.TypeOf((*MockDestroyedAccountDB)(nil).SetDestroyedAccounts), block, tx, destroyed, resurrected)
 }
 
+// SetMetadata mocks base method.
+func (m *MockDestroyedAccountDB) SetMetadata(md *Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", md)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockDestroyedAccountDBMockRecorder) SetMetadata(md any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockDestroyedAccountDB)(nil).SetMetadata), md)
+}
+
 // SetSubstateEncoding mocks base method.
 func (m *MockDestroyedAccountDB) SetSubstateEncoding(schema SubstateEncodingSchema) error {
 	m.ctrl.T.Helper()
@@ -304,6 +333,21 @@ func (mr *MockDestroyedAccountDBMockRecorder) SetSubstateEncoding(schema any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSubstateEncoding", reflect.TypeOf((*MockDestroyedAccountDB)(nil).SetSubstateEncoding), schema)
 }
 
+// Snapshot mocks base method.
+func (m *MockDestroyedAccountDB) Snapshot() (DBSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(DBSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockDestroyedAccountDBMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockDestroyedAccountDB)(nil).Snapshot))
+}
+
 // Stat mocks base method.
 func (m *MockDestroyedAccountDB) Stat(property string) (string, error) {
 	m.ctrl.T.Helper()
@@ -25,6 +25,36 @@ func newTestDestroyedAccountDB(t *testing.T, db DbAdapter, schema SubstateEncodi
 	}
 }
 
+func TestDestroyedAccountDB_SetAndGetDestroyedAccounts(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := NewDestroyedAccountDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			require.NoError(t, db.SetDestroyedAccounts(1, 0, []types.Address{{1}, {2}}, nil))
+			require.NoError(t, db.SetDestroyedAccounts(2, 3, []types.Address{{3}}, []types.Address{{1}}))
+			require.NoError(t, db.SetDestroyedAccounts(5, 1, []types.Address{{4}}, nil))
+
+			destroyed, resurrected, err := db.GetDestroyedAccounts(2, 3)
+			require.NoError(t, err)
+			assert.Equal(t, []types.Address{{3}}, destroyed)
+			assert.Equal(t, []types.Address{{1}}, resurrected)
+
+			inRange, err := db.GetAccountsDestroyedInRange(1, 2)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []types.Address{{2}, {3}}, inRange)
+
+			first, err := db.GetFirstKey()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), first)
+			last, err := db.GetLastKey()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(5), last)
+		})
+	}
+}
+
 func TestDestroyedAccountDB_SetDestroyedAccountsSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -57,6 +57,20 @@ func newDestroyedAccountEncoding(encoding SubstateEncodingSchema) (*destroyedAcc
 }
 
 func encodeSuicidedAccountListPB(list SuicidedAccountLists) ([]byte, error) {
+	return proto.Marshal(newSuicidedAccountListMessage(list))
+}
+
+func decodeSuicidedAccountListPB(data []byte) (SuicidedAccountLists, error) {
+	pbAccountList := &protobuf.SuicidedAccountLists{}
+	err := proto.Unmarshal(data, pbAccountList)
+	if err != nil {
+		return SuicidedAccountLists{}, err
+	}
+	return suicidedAccountListFromMessage(pbAccountList), nil
+}
+
+// newSuicidedAccountListMessage converts list into protobuf SuicidedAccountLists message.
+func newSuicidedAccountListMessage(list SuicidedAccountLists) *protobuf.SuicidedAccountLists {
 	pbAccountList := &protobuf.SuicidedAccountLists{}
 	for _, addr := range list.DestroyedAccounts {
 		pbAccountList.DestroyedAccounts = append(pbAccountList.DestroyedAccounts, addr.Bytes())
@@ -64,15 +78,11 @@ func encodeSuicidedAccountListPB(list SuicidedAccountLists) ([]byte, error) {
 	for _, addr := range list.ResurrectedAccounts {
 		pbAccountList.ResurrectedAccounts = append(pbAccountList.ResurrectedAccounts, addr.Bytes())
 	}
-	return proto.Marshal(pbAccountList)
+	return pbAccountList
 }
 
-func decodeSuicidedAccountListPB(data []byte) (SuicidedAccountLists, error) {
-	pbAccountList := &protobuf.SuicidedAccountLists{}
-	err := proto.Unmarshal(data, pbAccountList)
-	if err != nil {
-		return SuicidedAccountLists{}, err
-	}
+// suicidedAccountListFromMessage converts protobuf SuicidedAccountLists message into list.
+func suicidedAccountListFromMessage(pbAccountList *protobuf.SuicidedAccountLists) SuicidedAccountLists {
 	list := SuicidedAccountLists{}
 	for _, addr := range pbAccountList.DestroyedAccounts {
 		list.DestroyedAccounts = append(list.DestroyedAccounts, types.BytesToAddress(addr))
@@ -80,7 +90,7 @@ func decodeSuicidedAccountListPB(data []byte) (SuicidedAccountLists, error) {
 	for _, addr := range pbAccountList.ResurrectedAccounts {
 		list.ResurrectedAccounts = append(list.ResurrectedAccounts, types.BytesToAddress(addr))
 	}
-	return list, nil
+	return list
 }
 
 func encodeSuicidedAccountListRLP(list SuicidedAccountLists) ([]byte, error) {
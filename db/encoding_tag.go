@@ -0,0 +1,193 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// encodingTagMarker starts a record tagged with its encoding (marker + encoding id + payload).
+// Neither protobuf messages, RLP lists nor compressed values start with it, hence untagged
+// records written before tagging was enabled can be told apart.
+const encodingTagMarker = 0x00
+
+// encoding ids of the tag, they are stored in the db and must not be changed
+const (
+	protobufEncodingID byte = 1
+	rlpEncodingID      byte = 2
+)
+
+// encodingID returns id of schema used in the encoding tag.
+func encodingID(schema SubstateEncodingSchema) (byte, error) {
+	switch schema {
+	case DefaultEncodingSchema, ProtobufEncodingSchema, LegacyProtobufEncodingAlias:
+		return protobufEncodingID, nil
+	case RLPEncodingSchema:
+		return rlpEncodingID, nil
+	default:
+		return 0, fmt.Errorf("encoding not supported: %s", schema)
+	}
+}
+
+// encodingOfID returns schema of given encoding tag id.
+func encodingOfID(id byte) (SubstateEncodingSchema, error) {
+	switch id {
+	case protobufEncodingID:
+		return ProtobufEncodingSchema, nil
+	case rlpEncodingID:
+		return RLPEncodingSchema, nil
+	default:
+		return "", fmt.Errorf("unknown encoding tag %v", id)
+	}
+}
+
+// tagEncoding returns value prefixed with the encoding tag of schema.
+func tagEncoding(schema SubstateEncodingSchema, value []byte) ([]byte, error) {
+	id, err := encodingID(schema)
+	if err != nil {
+		return nil, err
+	}
+	tagged := make([]byte, 0, len(value)+2)
+	tagged = append(tagged, encodingTagMarker, id)
+	return append(tagged, value...), nil
+}
+
+// splitEncodingTag returns schema and payload of an uncompressed tagged value.
+// Untagged values are returned as they are with tagged set to false.
+func splitEncodingTag(value []byte) (schema SubstateEncodingSchema, payload []byte, tagged bool, err error) {
+	if len(value) == 0 || value[0] != encodingTagMarker {
+		return "", value, false, nil
+	}
+	if len(value) < 2 {
+		return "", nil, true, errors.New("truncated encoding tag")
+	}
+	schema, err = encodingOfID(value[1])
+	if err != nil {
+		return "", nil, true, err
+	}
+	return schema, value[2:], true, nil
+}
+
+// recordEncoding returns encoding and payload of an uncompressed value. Encoding of
+// untagged values is detected by their first byte.
+func recordEncoding(value []byte) (SubstateEncodingSchema, []byte, error) {
+	schema, payload, tagged, err := splitEncodingTag(value)
+	if err != nil || tagged {
+		return schema, payload, err
+	}
+	return detectEncodingSchema(value), value, nil
+}
+
+// detectEncodingSchema tells encoding of an uncompressed value by its first byte,
+// RLP lists start with at least 0xc0 while protobuf messages start with a field tag.
+func detectEncodingSchema(value []byte) SubstateEncodingSchema {
+	if len(value) > 0 && value[0] >= 0xc0 {
+		return RLPEncodingSchema
+	}
+	return ProtobufEncodingSchema
+}
+
+// EncodingStats counts records of a block range by their encoding.
+type EncodingStats struct {
+	// First and Last are the blocks of the range (inclusive).
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+
+	// Substates and UpdateSets are numbers of records per encoding.
+	Substates  map[SubstateEncodingSchema]int `json:"substates,omitempty"`
+	UpdateSets map[SubstateEncodingSchema]int `json:"updateSets,omitempty"`
+
+	// Tagged is the number of records carrying the encoding tag.
+	Tagged int `json:"tagged"`
+
+	// Compressed is the number of compressed records.
+	Compressed int `json:"compressed"`
+}
+
+// GetEncodingStats returns encoding statistics of substates and update sets
+// in ranges of rangeSize blocks. Ranges without any record are left out.
+func GetEncodingStats(db BaseDB, rangeSize uint64) ([]*EncodingStats, error) {
+	if rangeSize == 0 {
+		return nil, errors.New("range size must be positive")
+	}
+	compression := newValueCompression(db)
+	ranges := make(map[uint64]*EncodingStats)
+
+	count := func(block uint64, value []byte, counts func(*EncodingStats) map[SubstateEncodingSchema]int) error {
+		first := block - block%rangeSize
+		stats, found := ranges[first]
+		if !found {
+			stats = &EncodingStats{First: first, Last: first + (rangeSize - 1)}
+			if stats.Last < first {
+				stats.Last = ^uint64(0)
+			}
+			ranges[first] = stats
+		}
+
+		if isCompressed(value) {
+			stats.Compressed++
+		}
+		value, err := compression.decompress(value)
+		if err != nil {
+			return err
+		}
+		schema, _, tagged, err := splitEncodingTag(value)
+		if err != nil {
+			return err
+		}
+		if tagged {
+			stats.Tagged++
+		} else {
+			schema = detectEncodingSchema(value)
+		}
+		counts(stats)[schema]++
+		return nil
+	}
+
+	err := scanPrefix(db, SubstateDBPrefix, func(key, value []byte) error {
+		block, tx, err := DecodeSubstateDBKey(key)
+		if err != nil {
+			return err
+		}
+		err = count(block, value, func(stats *EncodingStats) map[SubstateEncodingSchema]int {
+			if stats.Substates == nil {
+				stats.Substates = make(map[SubstateEncodingSchema]int)
+			}
+			return stats.Substates
+		})
+		if err != nil {
+			return fmt.Errorf("invalid substate block: %v, tx %v; %w", block, tx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot count substate encodings; %w", err)
+	}
+
+	err = scanPrefix(db, UpdateDBPrefix, func(key, value []byte) error {
+		block, err := DecodeUpdateSetKey(key)
+		if err != nil {
+			return err
+		}
+		err = count(block, value, func(stats *EncodingStats) map[SubstateEncodingSchema]int {
+			if stats.UpdateSets == nil {
+				stats.UpdateSets = make(map[SubstateEncodingSchema]int)
+			}
+			return stats.UpdateSets
+		})
+		if err != nil {
+			return fmt.Errorf("invalid update-set block: %v; %w", block, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot count update-set encodings; %w", err)
+	}
+
+	res := make([]*EncodingStats, 0, len(ranges))
+	for _, stats := range ranges {
+		res = append(res, stats)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].First < res[j].First })
+	return res, nil
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createMixedEncodingTestDB creates a db with untagged rlp substates of blocks 1 to 5
+// and protobuf substates of blocks 6 to 10 tagged with their encoding.
+func createMixedEncodingTestDB(t *testing.T) string {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(RLPEncodingSchema))
+	for block := uint64(1); block <= 5; block++ {
+		ss := getTestSubstate(RLPEncodingSchema)
+		ss.Block = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+
+	sdb.SetEncodingTag(true)
+	require.NoError(t, sdb.SetSubstateEncoding(ProtobufEncodingSchema))
+	for block := uint64(6); block <= 10; block++ {
+		ss := getTestSubstate(ProtobufEncodingSchema)
+		ss.Block = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(sdb, RLPEncodingSchema)
+	require.NoError(t, err)
+	require.NoError(t, udb.PutUpdateSet(getCompressionTestUpdateSet(5), nil))
+	udb.SetEncodingTag(true)
+	require.NoError(t, udb.SetSubstateEncoding(ProtobufEncodingSchema))
+	require.NoError(t, udb.PutUpdateSet(getCompressionTestUpdateSet(10), nil))
+
+	require.NoError(t, sdb.Close())
+	return path
+}
+
+func TestEncodingTag_MixedEncodingsAreDecoded(t *testing.T) {
+	path := createMixedEncodingTestDB(t)
+
+	sdb, err := NewReadOnlySubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	// untagged records keep the recorded encoding
+	assert.Equal(t, RLPEncodingSchema, sdb.GetSubstateEncoding())
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, &BlockRange{First: 1, Last: 10}, md.Blocks)
+
+	blocks := 0
+	iter := sdb.NewSubstateIterator(0, 2)
+	for iter.Next() {
+		want := getTestSubstate(ProtobufEncodingSchema)
+		if iter.Value().Block <= 5 {
+			want = getTestSubstate(RLPEncodingSchema)
+		}
+		want.Block = iter.Value().Block
+		assert.NoError(t, want.Equal(iter.Value()))
+		blocks++
+	}
+	iter.Release()
+	require.NoError(t, iter.Error())
+	assert.Equal(t, 10, blocks)
+
+	got, err := sdb.GetSubstate(8, getTestSubstate(ProtobufEncodingSchema).Transaction)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), got.Block)
+
+	udb, err := NewReadOnlyUpdateDB(path)
+	require.NoError(t, err)
+	defer udb.Close()
+	for _, block := range []uint64{5, 10} {
+		us, err := udb.GetUpdateSet(block)
+		require.NoError(t, err)
+		assert.True(t, getCompressionTestUpdateSet(block).WorldState.Equal(us.WorldState))
+	}
+}
+
+func TestEncodingTag_TaggedAndCompressed(t *testing.T) {
+	db := createCompressionTestDB(t, ProtobufEncodingSchema)
+	dict, err := TrainCompressionDictionary(db, DefaultCompressionSamples)
+	require.NoError(t, err)
+	require.NoError(t, PutCompressionDictionary(db, dict))
+
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, RLPEncodingSchema)
+	require.NoError(t, err)
+	sdb.SetEncodingTag(true)
+	ss := getTestSubstate(RLPEncodingSchema)
+	ss.Block = 60
+	require.NoError(t, sdb.PutSubstate(ss))
+
+	value, err := db.Get(SubstateDBKey(60, ss.Transaction))
+	require.NoError(t, err)
+	assert.True(t, isCompressed(value))
+
+	reader, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, ProtobufEncodingSchema)
+	require.NoError(t, err)
+	got, err := reader.GetSubstate(60, ss.Transaction)
+	require.NoError(t, err)
+	assert.NoError(t, ss.Equal(got))
+
+	stats, err := GetEncodingStats(db, 100)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, map[SubstateEncodingSchema]int{ProtobufEncodingSchema: 50, RLPEncodingSchema: 1}, stats[0].Substates)
+	assert.Equal(t, 1, stats[0].Tagged)
+	assert.Equal(t, 1, stats[0].Compressed)
+}
+
+func TestEncodingTag_UntaggedRecordsMustMatchMetadata(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, RLPEncodingSchema)
+	require.NoError(t, err)
+	require.NoError(t, sdb.PutSubstate(getTestSubstate(RLPEncodingSchema)))
+
+	require.NoError(t, sdb.SetSubstateEncoding(ProtobufEncodingSchema))
+	assert.ErrorContains(t, sdb.PutSubstate(getTestSubstate(ProtobufEncodingSchema)), "into db of rlp encoding")
+
+	// the tag setting is kept when the encoding changes
+	sdb.SetEncodingTag(true)
+	require.NoError(t, sdb.SetSubstateEncoding(ProtobufEncodingSchema))
+	assert.NoError(t, sdb.PutSubstate(getTestSubstate(ProtobufEncodingSchema)))
+}
+
+func TestEncodingTag_GetEncodingStats(t *testing.T) {
+	path := createMixedEncodingTestDB(t)
+	db, err := NewReadOnlyCodeDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	stats, err := GetEncodingStats(db, 5)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+
+	assert.Equal(t, &EncodingStats{
+		First:      0,
+		Last:       4,
+		Substates:  map[SubstateEncodingSchema]int{RLPEncodingSchema: 4},
+		UpdateSets: nil,
+	}, stats[0])
+	assert.Equal(t, &EncodingStats{
+		First:      5,
+		Last:       9,
+		Substates:  map[SubstateEncodingSchema]int{RLPEncodingSchema: 1, ProtobufEncodingSchema: 4},
+		UpdateSets: map[SubstateEncodingSchema]int{RLPEncodingSchema: 1},
+		Tagged:     4,
+	}, stats[1])
+	assert.Equal(t, &EncodingStats{
+		First:      10,
+		Last:       14,
+		Substates:  map[SubstateEncodingSchema]int{ProtobufEncodingSchema: 1},
+		UpdateSets: map[SubstateEncodingSchema]int{ProtobufEncodingSchema: 1},
+		Tagged:     2,
+	}, stats[2])
+
+	_, err = GetEncodingStats(db, 0)
+	assert.ErrorContains(t, err, "range size must be positive")
+}
+
+func TestEncodingTag_CodeOfTaggedRecordsIsReferenced(t *testing.T) {
+	path := createMixedEncodingTestDB(t)
+	db, err := NewCodeDB(path, nil, nil, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	report, err := CollectOrphanedCode(db, CodeGCOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, report.Orphans)
+}
+
+func TestEncodingTag_SplitEncodingTag(t *testing.T) {
+	tagged, err := tagEncoding(RLPEncodingSchema, []byte{0xc1, 0x01})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{encodingTagMarker, rlpEncodingID, 0xc1, 0x01}, tagged)
+
+	schema, payload, isTagged, err := splitEncodingTag(tagged)
+	require.NoError(t, err)
+	assert.True(t, isTagged)
+	assert.Equal(t, RLPEncodingSchema, schema)
+	assert.Equal(t, []byte{0xc1, 0x01}, payload)
+
+	_, payload, isTagged, err = splitEncodingTag([]byte{0x0a, 0x01})
+	require.NoError(t, err)
+	assert.False(t, isTagged)
+	assert.Equal(t, []byte{0x0a, 0x01}, payload)
+
+	_, _, _, err = splitEncodingTag([]byte{encodingTagMarker})
+	assert.ErrorContains(t, err, "truncated encoding tag")
+	_, _, _, err = splitEncodingTag([]byte{encodingTagMarker, 9})
+	assert.ErrorContains(t, err, "unknown encoding tag 9")
+
+	_, err = tagEncoding("xml", nil)
+	assert.ErrorContains(t, err, "encoding not supported")
+}
@@ -48,12 +48,18 @@ func NewExceptionDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.R
 	return newExceptionDB(path, o, wo, ro)
 }
 
-func MakeDefaultExceptionDB(db *leveldb.DB) ExceptionDB {
+// NewExceptionDBWithBackend creates new instance of ExceptionDB stored in given backend.
+// Note: Any of three options is nillable. If that's the case a default value for the option is set.
+func NewExceptionDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (ExceptionDB, error) {
+	return newExceptionDBWithBackend(path, backend, o, wo, ro)
+}
+
+func MakeDefaultExceptionDB(db DbAdapter) ExceptionDB {
 	return &exceptionDB{&codeDB{backend: db}}
 }
 
 func MakeDefaultExceptionDBFromBaseDB(db BaseDB) ExceptionDB {
-	return &exceptionDB{&codeDB{backend: db.GetBackend()}}
+	return &exceptionDB{&codeDB{backend: db.GetBackend(), metadata: metadataCacheOf(db)}}
 }
 
 // NewReadOnlyExceptionDB creates a new instance of read-only ExceptionDB.
@@ -61,12 +67,16 @@ func NewReadOnlyExceptionDB(path string) (ExceptionDB, error) {
 	return newExceptionDB(path, &opt.Options{ReadOnly: true}, nil, nil)
 }
 
-func MakeExceptionDB(db *leveldb.DB, wo *opt.WriteOptions, ro *opt.ReadOptions) ExceptionDB {
+func MakeExceptionDB(db DbAdapter, wo *opt.WriteOptions, ro *opt.ReadOptions) ExceptionDB {
 	return &exceptionDB{&codeDB{backend: db, wo: wo, ro: ro}}
 }
 
 func newExceptionDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*exceptionDB, error) {
-	base, err := newCodeDB(path, o, wo, ro)
+	return newExceptionDBWithBackend(path, DefaultBackend, o, wo, ro)
+}
+
+func newExceptionDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*exceptionDB, error) {
+	base, err := newCodeDBWithBackend(path, backend, o, wo, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +87,10 @@ type exceptionDB struct {
 	CodeDB
 }
 
+func (db *exceptionDB) metadataCache() *metadataCache {
+	return metadataCacheOf(db.CodeDB)
+}
+
 func (db *exceptionDB) GetFirstKey() (uint64, error) {
 	r := util.BytesPrefix([]byte(ExceptionDBPrefix))
 
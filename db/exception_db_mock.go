@@ -159,6 +159,21 @@ func (mr *MockExceptionDBMockRecorder) GetLastKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastKey", reflect.TypeOf((*MockExceptionDB)(nil).GetLastKey))
 }
 
+// GetMetadata mocks base method.
+func (m *MockExceptionDB) GetMetadata() (*Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(*Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockExceptionDBMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockExceptionDB)(nil).GetMetadata))
+}
+
 // GetSubstateEncoding mocks base method.
 func (m *MockExceptionDB) GetSubstateEncoding() SubstateEncodingSchema {
 	m.ctrl.T.Helper()
@@ -258,6 +273,35 @@ func (mr *MockExceptionDBMockRecorder) PutException(e any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutException", reflect.TypeOf((*MockExceptionDB)(nil).PutException), e)
 }
 
+// SetMetadata mocks base method.
+func (m *MockExceptionDB) SetMetadata(md *Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", md)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockExceptionDBMockRecorder) SetMetadata(md any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockExceptionDB)(nil).SetMetadata), md)
+}
+
+// Snapshot mocks base method.
+func (m *MockExceptionDB) Snapshot() (DBSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(DBSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockExceptionDBMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockExceptionDB)(nil).Snapshot))
+}
+
 // Stat mocks base method.
 func (m *MockExceptionDB) Stat(property string) (string, error) {
 	m.ctrl.T.Helper()
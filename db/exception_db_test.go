@@ -10,6 +10,7 @@ import (
 	"github.com/0xsoniclabs/substate/types"
 	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
@@ -240,8 +241,8 @@ func TestExceptionDb_GetLastKeyFail(t *testing.T) {
 	assert.Equal(t, uint64(0), result)
 }
 
-func createDbAndPutException(dbPath string) (*exceptionDB, error) {
-	db, err := newExceptionDB(dbPath, nil, nil, nil)
+func createDbAndPutException(dbPath string, backend Backend) (*exceptionDB, error) {
+	db, err := newExceptionDBWithBackend(dbPath, backend, nil, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open db; %v", err)
 	}
@@ -254,6 +255,35 @@ func createDbAndPutException(dbPath string) (*exceptionDB, error) {
 	return db, nil
 }
 
+func TestExceptionDB_PutGetException(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := createDbAndPutException(t.TempDir(), backend)
+			require.NoError(t, err)
+			defer db.Close()
+			second := &substate.Exception{Block: testException.Block + 1, Data: testException.Data}
+			require.NoError(t, db.PutException(second))
+
+			got, err := db.GetException(testException.Block)
+			require.NoError(t, err)
+			require.NotNil(t, got)
+			assert.Equal(t, testException.Block, got.Block)
+			assert.True(t, testException.Data.Equal(got.Data))
+
+			got, err = db.GetException(testException.Block + 2)
+			require.NoError(t, err)
+			assert.Nil(t, got)
+
+			first, err := db.GetFirstKey()
+			require.NoError(t, err)
+			assert.Equal(t, testException.Block, first)
+			last, err := db.GetLastKey()
+			require.NoError(t, err)
+			assert.Equal(t, second.Block, last)
+		})
+	}
+}
+
 func TestNewDefaultExceptionDB(t *testing.T) {
 	dir := t.TempDir()
 	db, err := NewDefaultExceptionDB(filepath.Join(dir, "testdb"))
@@ -272,14 +302,18 @@ func TestNewExceptionDB(t *testing.T) {
 }
 
 func TestMakeDefaultExceptionDB(t *testing.T) {
-	dir := t.TempDir()
-	dbPath := filepath.Join(dir, "ldb")
-	ldb, err := leveldb.OpenFile(dbPath, nil)
-	assert.NoError(t, err)
-	exdb := MakeDefaultExceptionDB(ldb)
-	assert.NotNil(t, exdb)
-	err = ldb.Close()
-	assert.NoError(t, err)
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			backendDB, err := OpenBackend(backend, t.TempDir(), nil)
+			require.NoError(t, err)
+			exdb := MakeDefaultExceptionDB(backendDB)
+			require.NoError(t, exdb.PutException(testException))
+			got, err := exdb.GetException(testException.Block)
+			require.NoError(t, err)
+			assert.NotNil(t, got)
+			assert.NoError(t, backendDB.Close())
+		})
+	}
 }
 
 func TestMakeDefaultExceptionDBFromBaseDB(t *testing.T) {
@@ -312,14 +346,16 @@ func TestNewReadOnlyExceptionDB(t *testing.T) {
 }
 
 func TestMakeExceptionDB(t *testing.T) {
-	dir := t.TempDir()
-	dbPath := filepath.Join(dir, "ldb2")
-	ldb, err := leveldb.OpenFile(dbPath, nil)
-	assert.NoError(t, err)
-	wo := &opt.WriteOptions{}
-	ro := &opt.ReadOptions{}
-	exdb := MakeExceptionDB(ldb, wo, ro)
-	assert.NotNil(t, exdb)
-	err = ldb.Close()
-	assert.NoError(t, err)
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			backendDB, err := OpenBackend(backend, t.TempDir(), nil)
+			require.NoError(t, err)
+			exdb := MakeExceptionDB(backendDB, &opt.WriteOptions{}, &opt.ReadOptions{})
+			require.NoError(t, exdb.PutException(testException))
+			got, err := exdb.GetException(testException.Block)
+			require.NoError(t, err)
+			assert.NotNil(t, got)
+			assert.NoError(t, backendDB.Close())
+		})
+	}
 }
@@ -16,7 +16,7 @@ import (
 
 func TestExceptionIterator_Next(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutException(path)
+	db, err := createDbAndPutException(path, DefaultBackend)
 	if err != nil {
 		return
 	}
@@ -33,7 +33,7 @@ func TestExceptionIterator_Next(t *testing.T) {
 
 func TestExceptionIterator_Value(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutException(path)
+	db, err := createDbAndPutException(path, DefaultBackend)
 	if err != nil {
 		return
 	}
@@ -61,7 +61,7 @@ func testExceptionIterator_Value(db *exceptionDB, t *testing.T) {
 
 func TestExceptionIterator_FromBlock(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutException(path)
+	db, err := createDbAndPutException(path, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -109,7 +109,7 @@ func TestExceptionIterator_FromBlock(t *testing.T) {
 
 func TestExceptionIterator_Release(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutException(path)
+	db, err := createDbAndPutException(path, DefaultBackend)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,444 @@
+package db
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultFsckBatchSize is the number of corrupted entries deleted by a single batch of a repair.
+const DefaultFsckBatchSize = 1000
+
+// FsckOptions configures CheckIntegrity.
+type FsckOptions struct {
+	// Encoding is the encoding of untagged records. The recorded encoding is used if not set,
+	// databases without it use the encoding of their first substate.
+	Encoding SubstateEncodingSchema
+
+	// Repair deletes entries which cannot be decoded and code entries not matching their hash.
+	// Metadata entries are never deleted, missing code and gaps cannot be repaired.
+	Repair bool
+
+	// BatchSize is the number of entries deleted by a single batch,
+	// DefaultFsckBatchSize is used if not set.
+	BatchSize int
+}
+
+// FsckIssue is a corrupted entry of a database.
+type FsckIssue struct {
+	// Prefix is the prefix of the record family of the entry.
+	Prefix string `json:"prefix"`
+
+	// Key is the hex encoded key of the entry.
+	Key string `json:"key"`
+
+	// Problem describes what is wrong with the entry.
+	Problem string `json:"problem"`
+
+	// Repaired is set if the entry was deleted.
+	Repaired bool `json:"repaired,omitempty"`
+}
+
+// FsckTxGap is a range of missing transactions of a block, Last is inclusive.
+type FsckTxGap struct {
+	Block uint64 `json:"block"`
+	First int    `json:"first"`
+	Last  int    `json:"last"`
+}
+
+// FsckReport is the result of an integrity check.
+type FsckReport struct {
+	// Encoding is the encoding used for untagged records.
+	Encoding SubstateEncodingSchema `json:"encoding"`
+
+	// Entries is the number of checked entries per prefix.
+	Entries map[string]int `json:"entries"`
+
+	// Unknown is the number of entries with a prefix not known to this package, they are not checked.
+	Unknown int `json:"unknown"`
+
+	// Issues are the corrupted entries.
+	Issues []*FsckIssue `json:"issues"`
+
+	// MissingCode are hashes of code referenced by records but not found in the database.
+	MissingCode []types.Hash `json:"missingCode"`
+
+	// TxGaps are ranges of transactions missing within the recorded substate blocks.
+	TxGaps []*FsckTxGap `json:"txGaps"`
+
+	// Repaired is the number of deleted entries.
+	Repaired int `json:"repaired"`
+}
+
+// Healthy returns true if every corrupted entry was repaired and no code is missing.
+// Gaps in transaction indexes do not make a database unhealthy since transactions
+// may be left out of a recording on purpose.
+func (r *FsckReport) Healthy() bool {
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			return false
+		}
+	}
+	return len(r.MissingCode) == 0
+}
+
+// fsckPrefixes are the prefixes of record families checked by CheckIntegrity.
+var fsckPrefixes = []string{
+	SubstateDBPrefix,
+	CodeDBPrefix,
+	UpdateDBPrefix,
+	StateRootHashPrefix,
+	DestroyedAccountPrefix,
+	MetadataPrefix,
+	BlockHashPrefix,
+	ExceptionDBPrefix,
+}
+
+// prefixOf returns the prefix of the record family of key, empty if it is not known.
+func prefixOf(key []byte) string {
+	for _, prefix := range fsckPrefixes {
+		if bytes.HasPrefix(key, []byte(prefix)) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// CheckIntegrity walks all entries of db and checks that their keys and values can be decoded,
+// that code referenced by substates, update sets and exceptions exists and that stored code
+// matches its hash. Gaps in transaction indexes of substate blocks are reported as well.
+// In repair mode corrupted entries are deleted once they are found.
+//
+// Note: db must not be written to while the check runs.
+func CheckIntegrity(db BaseDB, options FsckOptions) (*FsckReport, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultFsckBatchSize
+	}
+	schema, err := getEncodingSchema(db, options.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newIntegrityCheck(db, schema, options)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.run(); err != nil {
+		return c.report, err
+	}
+	return c.report, nil
+}
+
+// getEncodingSchema returns the encoding of untagged records of db, which is schema if set,
+// otherwise the recorded encoding or the encoding of the first substate.
+func getEncodingSchema(db BaseDB, schema SubstateEncodingSchema) (SubstateEncodingSchema, error) {
+	if schema == "" {
+		md, err := db.GetMetadata()
+		if err != nil {
+			return "", err
+		}
+		if md != nil {
+			schema = md.EncodingSchema
+		}
+	}
+	if schema == "" {
+		iter := db.newIterator(util.BytesPrefix([]byte(SubstateDBPrefix)))
+		if iter.Next() {
+			value, err := newValueCompression(db).decompress(iter.Value())
+			if err == nil {
+				schema, _, _ = recordEncoding(value)
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return "", err
+		}
+	}
+	encoding, err := newSubstateEncoding(schema, nil)
+	if err != nil {
+		return "", err
+	}
+	return encoding.schema, nil
+}
+
+type integrityCheck struct {
+	db          BaseDB
+	compression *valueCompression
+	options     FsckOptions
+
+	substates         *substateEncoding
+	updateSets        *updateSetEncoding
+	destroyedAccounts *destroyedAccountEncoding
+
+	// code maps checked code hashes to their existence
+	code map[types.Hash]bool
+
+	// last substate block and tx, used to find gaps
+	lastBlock uint64
+	lastTx    int
+	seen      bool
+
+	batch   Batch
+	pending []*FsckIssue
+
+	report *FsckReport
+}
+
+func newIntegrityCheck(db BaseDB, schema SubstateEncodingSchema, options FsckOptions) (*integrityCheck, error) {
+	c := &integrityCheck{
+		db:          db,
+		compression: newValueCompression(db),
+		options:     options,
+		code:        make(map[types.Hash]bool),
+		batch:       db.NewBatch(),
+		report: &FsckReport{
+			Encoding:    schema,
+			Entries:     make(map[string]int),
+			Issues:      []*FsckIssue{},
+			MissingCode: []types.Hash{},
+			TxGaps:      []*FsckTxGap{},
+		},
+	}
+	var err error
+	if c.substates, err = newSubstateEncoding(schema, c.lookupCode); err != nil {
+		return nil, err
+	}
+	if c.updateSets, err = newUpdateSetEncoding(schema); err != nil {
+		return nil, err
+	}
+	if c.destroyedAccounts, err = newDestroyedAccountEncoding(schema); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// lookupCode records whether code of codeHash exists, no code is returned as it is not needed for the check.
+func (c *integrityCheck) lookupCode(codeHash types.Hash) ([]byte, error) {
+	if _, found := c.code[codeHash]; found {
+		return nil, nil
+	}
+	has, err := c.db.Has(CodeDBKey(codeHash))
+	if err != nil {
+		return nil, err
+	}
+	c.code[codeHash] = has
+	if !has {
+		c.report.MissingCode = append(c.report.MissingCode, codeHash)
+	}
+	return nil, nil
+}
+
+func (c *integrityCheck) run() error {
+	iter := c.db.newIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		key, value := iter.Key(), iter.Value()
+		prefix := prefixOf(key)
+		if prefix == "" {
+			c.report.Unknown++
+			continue
+		}
+
+		c.report.Entries[prefix]++
+		if problem := c.check(prefix, key, value); problem != nil {
+			if err := c.addIssue(prefix, key, problem); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("cannot iterate database; %w", err)
+	}
+	if err := c.flush(); err != nil {
+		return err
+	}
+
+	sort.Slice(c.report.MissingCode, func(i, j int) bool {
+		return bytes.Compare(c.report.MissingCode[i][:], c.report.MissingCode[j][:]) < 0
+	})
+	return nil
+}
+
+// check returns the problem of an entry, nil if it is valid.
+func (c *integrityCheck) check(prefix string, key, value []byte) error {
+	switch prefix {
+	case SubstateDBPrefix:
+		block, tx, err := DecodeSubstateDBKey(key)
+		if err != nil {
+			return err
+		}
+		c.checkTxGap(block, tx)
+		return c.checkSubstate(block, tx, value)
+	case CodeDBPrefix:
+		codeHash, err := DecodeCodeDBKey(key)
+		if err != nil {
+			return err
+		}
+		if got := hash.Keccak256Hash(value); got != codeHash {
+			return fmt.Errorf("code hash mismatch, got %v", got)
+		}
+		return nil
+	case UpdateDBPrefix:
+		block, err := DecodeUpdateSetKey(key)
+		if err != nil {
+			return err
+		}
+		return c.checkUpdateSet(block, value)
+	case DestroyedAccountPrefix:
+		if _, _, err := DecodeDestroyedAccountKey(key); err != nil {
+			return err
+		}
+		_, err := c.destroyedAccounts.decode(value)
+		return err
+	case ExceptionDBPrefix:
+		block, err := DecodeExceptionDBKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = decodeException(c.lookupCode, block, value)
+		return err
+	case BlockHashPrefix:
+		if _, err := DecodeBlockHashDBKey(key); err != nil {
+			return err
+		}
+		return checkHashValue(value)
+	case StateRootHashPrefix:
+		if _, err := StateHashKeyToUint64(key); err != nil {
+			return err
+		}
+		return checkHashValue(value)
+	case MetadataPrefix:
+		return c.checkMetadata(key, value)
+	}
+	return nil
+}
+
+// checkTxGap reports transactions missing between the previous substate and the substate of block and tx.
+func (c *integrityCheck) checkTxGap(block uint64, tx int) {
+	first := 0
+	if c.seen && c.lastBlock == block {
+		first = c.lastTx + 1
+	}
+	if tx > first {
+		c.report.TxGaps = append(c.report.TxGaps, &FsckTxGap{Block: block, First: first, Last: tx - 1})
+	}
+	c.lastBlock, c.lastTx, c.seen = block, tx, true
+}
+
+func (c *integrityCheck) checkSubstate(block uint64, tx int, value []byte) error {
+	value, err := c.compression.decompress(value)
+	if err != nil {
+		return err
+	}
+	schema, payload, tagged, err := splitEncodingTag(value)
+	if err != nil {
+		return err
+	}
+	encoding := c.substates
+	if tagged && schema != encoding.schema {
+		if encoding, err = newSubstateEncoding(schema, c.lookupCode); err != nil {
+			return err
+		}
+	}
+	_, err = encoding.decode(payload, block, tx)
+	return err
+}
+
+func (c *integrityCheck) checkUpdateSet(block uint64, value []byte) error {
+	value, err := c.compression.decompress(value)
+	if err != nil {
+		return err
+	}
+	schema, payload, tagged, err := splitEncodingTag(value)
+	if err != nil {
+		return err
+	}
+	encoding := c.updateSets
+	if tagged && schema != encoding.schema {
+		if encoding, err = newUpdateSetEncoding(schema); err != nil {
+			return err
+		}
+	}
+	_, err = encoding.decode(block, c.lookupCode, payload)
+	return err
+}
+
+func (c *integrityCheck) checkMetadata(key, value []byte) error {
+	switch string(key) {
+	case MetadataKey:
+		md := new(Metadata)
+		if err := json.Unmarshal(value, md); err != nil {
+			return err
+		}
+		return md.validate()
+	case UpdatesetIntervalKey, UpdatesetSizeKey:
+		if len(value) != 8 {
+			return fmt.Errorf("invalid length of value, expected 8, got %d", len(value))
+		}
+		return nil
+	case CompressionDictionaryKey:
+		return c.compression.load()
+	case MigrationCheckpointKey:
+		checkpoint := new(migrationCheckpoint)
+		return json.Unmarshal(value, checkpoint)
+	default:
+		return fmt.Errorf("unknown metadata key")
+	}
+}
+
+// formatKey returns key hex encoded for reports.
+func formatKey(key []byte) string {
+	return "0x" + hex.EncodeToString(key)
+}
+
+// checkHashValue checks that value is a 32 byte hash.
+func checkHashValue(value []byte) error {
+	if len(value) != len(types.Hash{}) {
+		return fmt.Errorf("invalid length of hash, expected %d, got %d", len(types.Hash{}), len(value))
+	}
+	return nil
+}
+
+// addIssue reports a corrupted entry and deletes it in repair mode unless it is a metadata entry.
+func (c *integrityCheck) addIssue(prefix string, key []byte, problem error) error {
+	issue := &FsckIssue{
+		Prefix:  prefix,
+		Key:     formatKey(key),
+		Problem: problem.Error(),
+	}
+	c.report.Issues = append(c.report.Issues, issue)
+	if !c.options.Repair || prefix == MetadataPrefix {
+		return nil
+	}
+
+	// the iterator is not affected by deletions of visited keys
+	if err := c.batch.Delete(key); err != nil {
+		return err
+	}
+	if c.pending = append(c.pending, issue); len(c.pending) >= c.options.BatchSize {
+		return c.flush()
+	}
+	return nil
+}
+
+// flush writes pending deletions of corrupted entries.
+func (c *integrityCheck) flush() error {
+	if len(c.pending) == 0 {
+		return nil
+	}
+	if err := c.batch.Write(); err != nil {
+		return fmt.Errorf("cannot delete corrupted entries; %w", err)
+	}
+	for _, issue := range c.pending {
+		issue.Repaired = true
+	}
+	c.report.Repaired += len(c.pending)
+	c.batch.Reset()
+	c.pending = c.pending[:0]
+	return nil
+}
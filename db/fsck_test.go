@@ -0,0 +1,99 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIntegrity_HealthyDB(t *testing.T) {
+	for _, schema := range []SubstateEncodingSchema{RLPEncodingSchema, ProtobufEncodingSchema} {
+		t.Run(string(schema), func(t *testing.T) {
+			db := createMigrationTestDB(t, schema)
+
+			report, err := CheckIntegrity(db, FsckOptions{})
+			require.NoError(t, err)
+			assert.True(t, report.Healthy())
+			assert.Equal(t, schema, report.Encoding)
+			assert.Empty(t, report.Issues)
+			assert.Empty(t, report.MissingCode)
+			assert.Equal(t, 5, report.Entries[SubstateDBPrefix])
+			assert.Equal(t, 3, report.Entries[UpdateDBPrefix])
+			assert.Equal(t, 1, report.Entries[DestroyedAccountPrefix])
+			assert.Equal(t, 1, report.Entries[ExceptionDBPrefix])
+			assert.Equal(t, 1, report.Entries[BlockHashPrefix])
+
+			// substates of the test db are recorded for the second transaction only
+			require.Len(t, report.TxGaps, 5)
+			assert.Equal(t, &FsckTxGap{Block: 1, First: 0, Last: 0}, report.TxGaps[0])
+		})
+	}
+}
+
+func TestCheckIntegrity_FindsAndRepairsCorruption(t *testing.T) {
+	db := createMigrationTestDB(t, ProtobufEncodingSchema)
+	tx := getTestSubstate(RLPEncodingSchema).Transaction
+
+	require.NoError(t, db.Put(SubstateDBKey(2, tx), []byte{0x0a, 0xff}))
+	require.NoError(t, db.Put([]byte(SubstateDBPrefix+"x"), []byte{0x0a}))
+	require.NoError(t, db.Put(CodeDBKey(types.Hash{1}), []byte{1}))
+	require.NoError(t, db.Put(BlockHashDBKey(2), []byte{1}))
+	require.NoError(t, db.Put([]byte(MetadataPrefix+"xx"), []byte{1}))
+	missing := hash.Keccak256Hash([]byte{0x60, 3})
+	require.NoError(t, db.Delete(CodeDBKey(missing)))
+	require.NoError(t, db.Put([]byte("unknown"), []byte{1}))
+
+	report, err := CheckIntegrity(db, FsckOptions{})
+	require.NoError(t, err)
+	assert.False(t, report.Healthy())
+	assert.Equal(t, 1, report.Unknown)
+	assert.Equal(t, []types.Hash{missing}, report.MissingCode)
+
+	prefixes := make(map[string]int)
+	for _, issue := range report.Issues {
+		assert.False(t, issue.Repaired)
+		prefixes[issue.Prefix]++
+	}
+	assert.Equal(t, map[string]int{
+		SubstateDBPrefix: 2,
+		CodeDBPrefix:     1,
+		BlockHashPrefix:  1,
+		MetadataPrefix:   1,
+	}, prefixes)
+
+	report, err = CheckIntegrity(db, FsckOptions{Repair: true, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Repaired)
+
+	// metadata and missing code are left for manual repair
+	report, err = CheckIntegrity(db, FsckOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, MetadataPrefix, report.Issues[0].Prefix)
+	assert.Equal(t, []types.Hash{missing}, report.MissingCode)
+
+	has, err := db.Has(SubstateDBKey(2, tx))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestCheckIntegrity_EncodingOfLegacyDB(t *testing.T) {
+	db := createMigrationTestDB(t, RLPEncodingSchema)
+	require.NoError(t, db.Delete([]byte(MetadataKey)))
+
+	report, err := CheckIntegrity(db, FsckOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, RLPEncodingSchema, report.Encoding)
+	assert.True(t, report.Healthy())
+
+	// records fail to decode with a wrong encoding
+	report, err = CheckIntegrity(db, FsckOptions{Encoding: ProtobufEncodingSchema})
+	require.NoError(t, err)
+	assert.False(t, report.Healthy())
+
+	_, err = CheckIntegrity(db, FsckOptions{Encoding: "xml"})
+	assert.ErrorContains(t, err, "encoding not supported")
+}
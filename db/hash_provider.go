@@ -70,8 +70,7 @@ func (p *hashProvider) GetBlockHash(number int) (types.Hash, error) {
 }
 
 func (p *hashProvider) GetStateRootHash(number int) (types.Hash, error) {
-	hex := strconv.FormatUint(uint64(number), 16)
-	stateRoot, err := p.db.Get([]byte(StateRootHashPrefix + "0x" + hex))
+	stateRoot, err := p.db.Get(StateRootHashDBKey(uint64(number)))
 	if err != nil {
 		return types.Hash{}, err
 	}
@@ -196,6 +195,11 @@ func GetLastBlockHash(db BaseDB) (uint64, error) {
 	return lastBlock, nil
 }
 
+// StateRootHashDBKey returns the key of the state root of a block, the block number is hex encoded
+func StateRootHashDBKey(block uint64) []byte {
+	return []byte(StateRootHashPrefix + "0x" + strconv.FormatUint(block, 16))
+}
+
 func BlockHashDBKey(block uint64) []byte {
 	prefix := []byte(BlockHashPrefix)
 	blockByte := make([]byte, 8)
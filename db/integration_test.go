@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
@@ -234,27 +235,33 @@ func TestSubstateDB_MakeDefaultSubstateDBFromBaseDBWithEncoding(t *testing.T) {
 	})
 }
 func TestSubstateDB_MakeDefaultSubstateDB(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := NewDefaultSubstateDB(dbPath)
-	assert.NoError(t, err)
-
-	// cast interface to struct
-	backend := db.GetBackend().(*leveldb.DB)
-	db2, err := MakeDefaultSubstateDB(backend)
-	assert.NoError(t, err)
-	assert.NotNil(t, db2)
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			backendDB, err := OpenBackend(backend, t.TempDir(), nil)
+			require.NoError(t, err)
+			defer backendDB.Close()
+
+			db, err := MakeDefaultSubstateDB(backendDB)
+			require.NoError(t, err)
+			ss := getTestSubstate(DefaultEncodingSchema)
+			require.NoError(t, db.PutSubstate(ss))
+			has, err := db.HasSubstate(ss.Block, ss.Transaction)
+			require.NoError(t, err)
+			assert.True(t, has)
+		})
+	}
 }
 
 func TestSubstateDB_MakeSubstateDB(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := NewDefaultSubstateDB(dbPath)
-	if err != nil {
-		t.Fatal(err)
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			backendDB, err := OpenBackend(backend, t.TempDir(), nil)
+			require.NoError(t, err)
+			defer backendDB.Close()
+
+			db, err := MakeSubstateDB(backendDB, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, backendDB, db.GetBackend())
+		})
 	}
-
-	// cast interface to struct
-	backend := db.GetBackend().(*leveldb.DB)
-	db2, err := MakeSubstateDB(backend, nil, nil)
-	assert.NotNil(t, db2)
-	assert.NoError(t, err)
 }
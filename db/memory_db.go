@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// copyBatchSize is the amount of data after which CopyBaseDB flushes its batch.
+const copyBatchSize = 4 * opt.MiB
+
+// MemoryDB is a BaseDB which keeps all its data in memory. It is meant for tests and
+// ephemeral pipelines, any of MakeDefault*FromBaseDB functions can be used on top of it.
+//
+// Note: Content of MemoryDB is lost once it is closed unless Dump is called beforehand.
+type MemoryDB interface {
+	CodeDB
+
+	// Dump writes the whole content of MemoryDB into a database at given path.
+	Dump(path string, backend Backend) error
+}
+
+// NewMemoryDB creates an empty MemoryDB.
+func NewMemoryDB() (MemoryDB, error) {
+	db, err := newMemoryDB()
+	if err != nil {
+		return nil, err
+	}
+	if err = initMetadata(db.backend, nil); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// LoadMemoryDB creates a MemoryDB containing the whole content of a database at given path.
+// The database at path is not modified.
+func LoadMemoryDB(path string, backend Backend) (MemoryDB, error) {
+	src, err := newCodeDBWithBackend(path, backend, &opt.Options{ReadOnly: true}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	db, err := newMemoryDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = CopyBaseDB(db, src); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot load %v into memory; %w", path, err)
+	}
+	return db, nil
+}
+
+func newMemoryDB() (*memoryDB, error) {
+	backend, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open memory db; %w", err)
+	}
+	return &memoryDB{&codeDB{backend: backend, metadata: newMetadataCache(backend, nil, nil, nil)}}, nil
+}
+
+type memoryDB struct {
+	*codeDB
+}
+
+func (db *memoryDB) Dump(path string, backend Backend) error {
+	if err := db.metadata.flush(); err != nil {
+		return err
+	}
+	// metadata are copied from the memory db, a new record must not be written
+	b, err := OpenBackend(backend, path, nil)
+	if err != nil {
+		return err
+	}
+	dst := &codeDB{backend: b}
+
+	if err = CopyBaseDB(dst, db); err != nil {
+		dst.Close()
+		return fmt.Errorf("cannot dump memory db into %v; %w", path, err)
+	}
+	return dst.Close()
+}
+
+// CopyBaseDB copies every key-value pair of src into dst. Keys already present
+// in dst are overwritten, other keys of dst are kept intact.
+func CopyBaseDB(dst BaseDB, src BaseDB) error {
+	iter := src.newIterator(nil)
+	defer iter.Release()
+
+	batch := dst.NewBatch()
+	for iter.Next() {
+		if err := batch.Put(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= copyBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("cannot iterate source db; %w", err)
+	}
+	return batch.Write()
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// testDB is an in-memory database of a test, substates and update sets are written
+// through its views in a common encoding.
+type testDB struct {
+	MemoryDB
+	substates  SubstateDB
+	updateSets UpdateDB
+}
+
+// newTestMemoryDB returns an empty in-memory database which is closed when the test ends.
+func newTestMemoryDB(t *testing.T) MemoryDB {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// newTestDB returns an empty testDB writing records in schema.
+func newTestDB(t *testing.T, schema SubstateEncodingSchema) *testDB {
+	db := newTestMemoryDB(t)
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	return &testDB{db, sdb, udb}
+}
+
+func TestMemoryDB_PutGetHasDelete(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Put([]byte("key"), []byte("value")))
+
+	has, err := db.Has([]byte("key"))
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	value, err := db.Get([]byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	require.NoError(t, db.Delete([]byte("key")))
+	_, err = db.Get([]byte("key"))
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+}
+
+func TestMemoryDB_Batch(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	batch := db.NewBatch()
+	require.NoError(t, batch.Put([]byte("a"), []byte{1}))
+	require.NoError(t, batch.Put([]byte("b"), []byte{2}))
+	require.NoError(t, batch.Delete([]byte("a")))
+	require.NoError(t, batch.Write())
+
+	has, err := db.Has([]byte("a"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	has, err = db.Has([]byte("b"))
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestMemoryDB_MakeDefaultDBs(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	substateDB, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, ProtobufEncodingSchema)
+	require.NoError(t, err)
+	ss := getTestSubstate(ProtobufEncodingSchema)
+	require.NoError(t, substateDB.PutSubstate(ss))
+
+	got, err := substateDB.GetSubstate(ss.Block, ss.Transaction)
+	require.NoError(t, err)
+	assert.NoError(t, ss.Equal(got))
+
+	iter := substateDB.NewSubstateIterator(0, 1)
+	require.True(t, iter.Next())
+	assert.Equal(t, ss.Block, iter.Value().Block)
+	assert.False(t, iter.Next())
+	iter.Release()
+	require.NoError(t, iter.Error())
+
+	updateDB, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, ProtobufEncodingSchema)
+	require.NoError(t, err)
+	require.NoError(t, updateDB.PutUpdateSet(testUpdateSet, testDeletedAccounts))
+	updateSet, err := updateDB.GetUpdateSet(testUpdateSet.Block)
+	require.NoError(t, err)
+	assert.Equal(t, testUpdateSet.Block, updateSet.Block)
+
+	destroyedAccountDB, err := MakeDefaultDestroyedAccountDBFromBaseDB(db)
+	require.NoError(t, err)
+	require.NoError(t, destroyedAccountDB.SetDestroyedAccounts(1, 2, []types.Address{{1}}, []types.Address{{2}}))
+	destroyed, resurrected, err := destroyedAccountDB.GetDestroyedAccounts(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Address{{1}}, destroyed)
+	assert.Equal(t, []types.Address{{2}}, resurrected)
+
+	exceptionDB := MakeDefaultExceptionDBFromBaseDB(db)
+	exception := &substate.Exception{Block: 5, Data: substate.ExceptionBlock{PreBlock: &substate.WorldState{}}}
+	require.NoError(t, exceptionDB.PutException(exception))
+	gotException, err := exceptionDB.GetException(5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), gotException.Block)
+}
+
+func TestMemoryDB_Snapshot(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Put([]byte("a"), []byte{1}))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Put([]byte("a"), []byte{2}))
+	require.NoError(t, db.Put([]byte("b"), []byte{3}))
+
+	value, err := snapshot.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	has, err := snapshot.Has([]byte("b"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	iter := snapshot.NewIterator(nil, nil)
+	count := 0
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	require.NoError(t, iter.Error())
+	// "a" and the metadata record
+	assert.Equal(t, 2, count)
+
+	assert.True(t, errors.Is(snapshot.Put([]byte("c"), nil), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(snapshot.Delete([]byte("a")), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(snapshot.NewBatch().Write(), leveldb.ErrReadOnly))
+	assert.NoError(t, snapshot.Compact(nil, nil))
+
+	stats, err := snapshot.Stat("leveldb.stats")
+	require.NoError(t, err)
+	assert.NotEmpty(t, stats)
+
+	require.NoError(t, snapshot.Close())
+	assert.Equal(t, leveldb.ErrClosed, snapshot.Close())
+
+	// closing snapshot does not close the db
+	value, err = db.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, value)
+}
+
+func TestMemoryDB_SnapshotOfClosedDB(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = db.Snapshot()
+	assert.ErrorContains(t, err, "cannot get snapshot")
+}
+
+func TestMemoryDB_DumpAndLoad(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			path := t.TempDir()
+
+			db, err := NewMemoryDB()
+			require.NoError(t, err)
+			substateDB, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, ProtobufEncodingSchema)
+			require.NoError(t, err)
+			ss := getTestSubstate(ProtobufEncodingSchema)
+			require.NoError(t, substateDB.PutSubstate(ss))
+
+			require.NoError(t, db.Dump(path, backend))
+			require.NoError(t, db.Close())
+
+			// dumped database is usable on its own
+			diskDB, err := NewSubstateDBWithBackend(path, backend, nil, nil, nil)
+			require.NoError(t, err)
+			got, err := diskDB.GetSubstate(ss.Block, ss.Transaction)
+			require.NoError(t, err)
+			assert.NoError(t, ss.Equal(got))
+			require.NoError(t, diskDB.Close())
+
+			loaded, err := LoadMemoryDB(path, backend)
+			require.NoError(t, err)
+			defer loaded.Close()
+			substateDB, err = MakeDefaultSubstateDBFromBaseDBWithEncoding(loaded, ProtobufEncodingSchema)
+			require.NoError(t, err)
+			got, err = substateDB.GetSubstate(ss.Block, ss.Transaction)
+			require.NoError(t, err)
+			assert.NoError(t, ss.Equal(got))
+		})
+	}
+}
+
+func TestMemoryDB_LoadMissingDB(t *testing.T) {
+	_, err := LoadMemoryDB(t.TempDir()+"/missing", LevelDBBackend)
+	assert.Error(t, err)
+}
+
+func TestMemoryDB_DumpIntoLockedDB(t *testing.T) {
+	path := t.TempDir()
+	diskDB, err := NewDefaultCodeDB(path)
+	require.NoError(t, err)
+	defer diskDB.Close()
+
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.ErrorContains(t, db.Dump(path, LevelDBBackend), "cannot open leveldb")
+}
+
+func TestCopyBaseDB(t *testing.T) {
+	src, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer src.Close()
+	dst, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer dst.Close()
+
+	// exceed the batch size so that the batch is flushed in between
+	value := make([]byte, opt.MiB)
+	for i := byte(0); i < 10; i++ {
+		require.NoError(t, src.Put([]byte{i}, value))
+	}
+	require.NoError(t, dst.Put([]byte("other"), []byte{1}))
+
+	require.NoError(t, CopyBaseDB(dst, src))
+
+	for i := byte(0); i < 10; i++ {
+		has, err := dst.Has([]byte{i})
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+	has, err := dst.Has([]byte("other"))
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestCopyBaseDB_ClosedSource(t *testing.T) {
+	src, err := NewMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, src.Close())
+	dst, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer dst.Close()
+
+	assert.ErrorContains(t, CopyBaseDB(dst, src), "cannot iterate source db")
+}
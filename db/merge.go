@@ -0,0 +1,446 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// MergeConflictPolicy tells how MergeDatabases handles records which differ between databases.
+type MergeConflictPolicy string
+
+const (
+	// FailOnConflict stops the merge once the first conflict is found.
+	FailOnConflict MergeConflictPolicy = "fail"
+
+	// KeepFirstOnConflict keeps the record merged first.
+	KeepFirstOnConflict MergeConflictPolicy = "keep-first"
+
+	// KeepLastOnConflict keeps the record merged last.
+	KeepLastOnConflict MergeConflictPolicy = "keep-last"
+
+	// ReportConflicts keeps the record merged first, the merge finishes and fails once
+	// all conflicts are reported.
+	ReportConflicts MergeConflictPolicy = "report"
+)
+
+// MergeOptions configures MergeDatabases.
+type MergeOptions struct {
+	// Policy handles conflicting records, FailOnConflict is used if not set.
+	Policy MergeConflictPolicy
+
+	// Encoding is the encoding of records written into the target. The recorded encoding
+	// of the target is used if not set, otherwise the encoding of the first source.
+	Encoding SubstateEncodingSchema
+}
+
+// MergeConflict is a record of a source differing from the record already in the target.
+type MergeConflict struct {
+	// Prefix is the prefix of the record family of the record.
+	Prefix string `json:"prefix"`
+
+	// Key is the hex encoded key of the record.
+	Key string `json:"key"`
+
+	// Source is the index of the source the record comes from.
+	Source int `json:"source"`
+
+	// Difference describes how the records differ.
+	Difference string `json:"difference"`
+}
+
+// MergeReport summarizes a merge.
+type MergeReport struct {
+	// Merged is the number of records written into the target per prefix.
+	Merged map[string]int `json:"merged"`
+
+	// Duplicates is the number of records found in the target already.
+	Duplicates int `json:"duplicates"`
+
+	// Conflicts are the records which differ between databases.
+	Conflicts []*MergeConflict `json:"conflicts"`
+
+	// UpdateSetInterval and UpdateSetSize are the reconciled update-set metadata of the target,
+	// they are zero if no database has any.
+	UpdateSetInterval uint64 `json:"updateSetInterval"`
+	UpdateSetSize     uint64 `json:"updateSetSize"`
+}
+
+// MergeDatabases merges substates, code, update sets, destroyed accounts, exceptions, block hashes
+// and state root hashes of sources into target, in the order of sources. Records are decoded with
+// the encoding of their source and written with the encoding of the target. Records found in more
+// databases are compared and handled according to options.Policy if they differ. Update-set metadata
+// of all databases must match, they are handled as a conflict otherwise.
+//
+// Note: sources and target must not be written to while the merge runs.
+func MergeDatabases(target BaseDB, sources []BaseDB, options MergeOptions) (*MergeReport, error) {
+	switch options.Policy {
+	case "":
+		options.Policy = FailOnConflict
+	case FailOnConflict, KeepFirstOnConflict, KeepLastOnConflict, ReportConflicts:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %v", options.Policy)
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no source database")
+	}
+
+	m, err := newMerge(target, sources, options)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.reconcileMetadata(); err != nil {
+		return m.report, err
+	}
+	for i := range sources {
+		if err = m.mergeSource(i); err != nil {
+			return m.report, err
+		}
+	}
+	if options.Policy == ReportConflicts && len(m.report.Conflicts) > 0 {
+		return m.report, fmt.Errorf("%d conflicts found", len(m.report.Conflicts))
+	}
+	return m.report, nil
+}
+
+type merge struct {
+	sources          []*substateDB
+	sourceUpdateSets []*updateDB
+	policy           MergeConflictPolicy
+
+	target            BaseDB
+	substates         SubstateDB
+	updateSets        *updateDB
+	destroyedAccounts DestroyedAccountDB
+	exceptions        ExceptionDB
+
+	report *MergeReport
+}
+
+func newMerge(target BaseDB, sources []BaseDB, options MergeOptions) (*merge, error) {
+	m := &merge{
+		policy:     options.Policy,
+		target:     target,
+		exceptions: MakeDefaultExceptionDBFromBaseDB(target),
+		report: &MergeReport{
+			Merged:    make(map[string]int),
+			Conflicts: []*MergeConflict{},
+		},
+	}
+	for i, src := range sources {
+		sdb := &substateDB{&codeDB{backend: src.GetBackend(), metadata: metadataCacheOf(src)}, nil}
+		if err := sdb.findAndSetEncoding(); err != nil {
+			return nil, fmt.Errorf("cannot open source %d; %w", i, err)
+		}
+		udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(src, sdb.GetSubstateEncoding())
+		if err != nil {
+			return nil, err
+		}
+		m.sources = append(m.sources, sdb)
+		m.sourceUpdateSets = append(m.sourceUpdateSets, udb.(*updateDB))
+	}
+
+	schema := options.Encoding
+	if schema == "" {
+		md, err := target.GetMetadata()
+		if err != nil {
+			return nil, err
+		}
+		if md != nil {
+			schema = md.EncodingSchema
+		}
+	}
+	if schema == "" {
+		schema = m.sources[0].GetSubstateEncoding()
+	}
+
+	var err error
+	if m.substates, err = MakeDefaultSubstateDBFromBaseDBWithEncoding(target, schema); err != nil {
+		return nil, err
+	}
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(target, schema)
+	if err != nil {
+		return nil, err
+	}
+	m.updateSets = udb.(*updateDB)
+	if m.destroyedAccounts, err = MakeDefaultDestroyedAccountDBFromBaseDBWithEncoding(target, schema); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// conflict reports a record of source differing from the record of the target and
+// returns whether the record of the source is to be written.
+func (m *merge) conflict(prefix string, key []byte, source int, difference string) (bool, error) {
+	m.report.Conflicts = append(m.report.Conflicts, &MergeConflict{
+		Prefix:     prefix,
+		Key:        formatKey(key),
+		Source:     source,
+		Difference: difference,
+	})
+	switch m.policy {
+	case FailOnConflict:
+		return false, fmt.Errorf("record %v of source %d conflicts with merged record; %v", formatKey(key), source, difference)
+	case KeepLastOnConflict:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// reconcileMetadata sets update-set metadata of the target to the metadata shared by all databases.
+func (m *merge) reconcileMetadata() error {
+	var interval, size uint64
+	found := false
+	for i, db := range append([]BaseDB{m.target}, m.baseSources()...) {
+		md, err := db.GetMetadata()
+		if err != nil {
+			return err
+		}
+		if md == nil || md.UpdateSetInterval == 0 {
+			continue
+		}
+		if !found {
+			interval, size, found = md.UpdateSetInterval, md.UpdateSetSize, true
+			continue
+		}
+		if md.UpdateSetInterval == interval && md.UpdateSetSize == size {
+			continue
+		}
+		overwrite, err := m.conflict(MetadataPrefix, []byte(UpdatesetIntervalKey), i-1, fmt.Sprintf(
+			"update-set interval %v and size %v differ from interval %v and size %v",
+			md.UpdateSetInterval, md.UpdateSetSize, interval, size))
+		if err != nil {
+			return err
+		}
+		if overwrite {
+			interval, size = md.UpdateSetInterval, md.UpdateSetSize
+		}
+	}
+	if !found {
+		return nil
+	}
+	m.report.UpdateSetInterval, m.report.UpdateSetSize = interval, size
+	return m.updateSets.PutMetadata(interval, size)
+}
+
+func (m *merge) baseSources() []BaseDB {
+	res := make([]BaseDB, 0, len(m.sources))
+	for _, src := range m.sources {
+		res = append(res, src)
+	}
+	return res
+}
+
+// mergeSource merges all records of source i into the target.
+func (m *merge) mergeSource(i int) error {
+	for _, step := range []struct {
+		prefix string
+		merge  func(i int, key, value []byte) error
+	}{
+		{CodeDBPrefix, m.mergeRaw},
+		{SubstateDBPrefix, m.mergeSubstate},
+		{UpdateDBPrefix, m.mergeUpdateSet},
+		{DestroyedAccountPrefix, m.mergeDestroyedAccounts},
+		{ExceptionDBPrefix, m.mergeException},
+		{BlockHashPrefix, m.mergeRaw},
+		{StateRootHashPrefix, m.mergeRaw},
+	} {
+		err := scanPrefix(m.sources[i], step.prefix, func(key, value []byte) error {
+			return step.merge(i, key, value)
+		})
+		if err != nil {
+			return fmt.Errorf("cannot merge %v records of source %d; %w", step.prefix, i, err)
+		}
+	}
+	return nil
+}
+
+// get returns value of key in the target, nil if there is none.
+func (m *merge) get(key []byte) ([]byte, error) {
+	value, err := m.target.Get(key)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	return value, err
+}
+
+// mergeRaw merges records which are the same in all encodings.
+func (m *merge) mergeRaw(i int, key, value []byte) error {
+	existing, err := m.get(key)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if bytes.Equal(existing, value) {
+			m.report.Duplicates++
+			return nil
+		}
+		overwrite, err := m.conflict(prefixOf(key), key, i, "values differ")
+		if err != nil || !overwrite {
+			return err
+		}
+	}
+	if err = m.target.Put(key, value); err != nil {
+		return err
+	}
+	m.report.Merged[prefixOf(key)]++
+	return nil
+}
+
+func (m *merge) mergeSubstate(i int, key, value []byte) error {
+	block, tx, err := DecodeSubstateDBKey(key)
+	if err != nil {
+		return err
+	}
+	ss, err := m.sources[i].decodeToSubstate(value, block, tx)
+	if err != nil {
+		return err
+	}
+	existingValue, err := m.get(key)
+	if err != nil {
+		return err
+	}
+	if existingValue != nil {
+		existing, err := m.substates.decodeToSubstate(existingValue, block, tx)
+		if err != nil {
+			return err
+		}
+		diff := existing.Equal(ss)
+		if diff == nil {
+			m.report.Duplicates++
+			return nil
+		}
+		overwrite, err := m.conflict(SubstateDBPrefix, key, i, diff.Error())
+		if err != nil || !overwrite {
+			return err
+		}
+	}
+	if err = m.substates.PutSubstate(ss); err != nil {
+		return err
+	}
+	m.report.Merged[SubstateDBPrefix]++
+	return nil
+}
+
+func (m *merge) mergeUpdateSet(i int, key, value []byte) error {
+	block, err := DecodeUpdateSetKey(key)
+	if err != nil {
+		return err
+	}
+	src := m.sourceUpdateSets[i]
+	us, err := src.decodeUpdateSet(block, src.GetCode, value)
+	if err != nil {
+		return err
+	}
+	deleted, err := src.decodeDeletedAccounts(value)
+	if err != nil {
+		return err
+	}
+
+	existingValue, err := m.get(key)
+	if err != nil {
+		return err
+	}
+	if existingValue != nil {
+		existing, err := m.updateSets.decodeUpdateSet(block, m.updateSets.GetCode, existingValue)
+		if err != nil {
+			return err
+		}
+		existingDeleted, err := m.updateSets.decodeDeletedAccounts(existingValue)
+		if err != nil {
+			return err
+		}
+		if existing.WorldState.Equal(us.WorldState) && slices.Equal(existingDeleted, deleted) {
+			m.report.Duplicates++
+			return nil
+		}
+		overwrite, err := m.conflict(UpdateDBPrefix, key, i, "update sets differ")
+		if err != nil || !overwrite {
+			return err
+		}
+	}
+	if err = m.updateSets.PutUpdateSet(us, deleted); err != nil {
+		return err
+	}
+	m.report.Merged[UpdateDBPrefix]++
+	return nil
+}
+
+func (m *merge) mergeDestroyedAccounts(i int, key, value []byte) error {
+	block, tx, err := DecodeDestroyedAccountKey(key)
+	if err != nil {
+		return err
+	}
+	list, err := decodeDestroyedAccounts(value)
+	if err != nil {
+		return err
+	}
+
+	existingValue, err := m.get(key)
+	if err != nil {
+		return err
+	}
+	if existingValue != nil {
+		existing, err := decodeDestroyedAccounts(existingValue)
+		if err != nil {
+			return err
+		}
+		if slices.Equal(existing.DestroyedAccounts, list.DestroyedAccounts) &&
+			slices.Equal(existing.ResurrectedAccounts, list.ResurrectedAccounts) {
+			m.report.Duplicates++
+			return nil
+		}
+		overwrite, err := m.conflict(DestroyedAccountPrefix, key, i, "destroyed accounts differ")
+		if err != nil || !overwrite {
+			return err
+		}
+	}
+	if err = m.destroyedAccounts.SetDestroyedAccounts(block, tx, list.DestroyedAccounts, list.ResurrectedAccounts); err != nil {
+		return err
+	}
+	m.report.Merged[DestroyedAccountPrefix]++
+	return nil
+}
+
+// decodeDestroyedAccounts decodes destroyed accounts of value in the encoding they were written with.
+func decodeDestroyedAccounts(value []byte) (SuicidedAccountLists, error) {
+	encoding, err := newDestroyedAccountEncoding(detectEncodingSchema(value))
+	if err != nil {
+		return SuicidedAccountLists{}, err
+	}
+	return encoding.decode(value)
+}
+
+func (m *merge) mergeException(i int, key, value []byte) error {
+	block, err := DecodeExceptionDBKey(key)
+	if err != nil {
+		return err
+	}
+	exception, err := decodeException(m.sources[i].GetCode, block, value)
+	if err != nil {
+		return err
+	}
+	existing, err := m.exceptions.GetException(block)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return err
+	}
+	if existing != nil {
+		if existing.Equal(*exception) {
+			m.report.Duplicates++
+			return nil
+		}
+		overwrite, err := m.conflict(ExceptionDBPrefix, key, i, "exceptions differ")
+		if err != nil || !overwrite {
+			return err
+		}
+	}
+	if err = m.exceptions.PutException(exception); err != nil {
+		return err
+	}
+	m.report.Merged[ExceptionDBPrefix]++
+	return nil
+}
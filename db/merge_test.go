@@ -0,0 +1,144 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createMergeTestDB creates a db with substates of blocks first to last encoded with schema, their
+// Env.Coinbase is set to coinbase. An update set and destroyed accounts are written for the last block.
+func createMergeTestDB(t *testing.T, schema SubstateEncodingSchema, first, last uint64, coinbase types.Address) MemoryDB {
+	db := newTestDB(t, schema)
+	for block := first; block <= last; block++ {
+		ss := getTestSubstate(RLPEncodingSchema)
+		ss.Block = block
+		ss.Env.Coinbase = coinbase
+		ss.InputSubstate = substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60, byte(block)})
+		require.NoError(t, db.substates.PutSubstate(ss))
+		require.NoError(t, db.Put(BlockHashDBKey(block), types.Hash{byte(block)}.Bytes()))
+	}
+
+	require.NoError(t, db.updateSets.PutUpdateSet(getCompressionTestUpdateSet(last), []types.Address{{9}}))
+	require.NoError(t, db.updateSets.PutMetadata(100, 1000))
+
+	ddb, err := MakeDefaultDestroyedAccountDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	require.NoError(t, ddb.SetDestroyedAccounts(last, 1, []types.Address{{3}}, nil))
+	return db.MemoryDB
+}
+
+func getMergedCoinbase(t *testing.T, db BaseDB, block uint64) types.Address {
+	md, err := db.GetMetadata()
+	require.NoError(t, err)
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, md.EncodingSchema)
+	require.NoError(t, err)
+	ss, err := sdb.GetSubstate(block, getTestSubstate(RLPEncodingSchema).Transaction)
+	require.NoError(t, err)
+	return ss.Env.Coinbase
+}
+
+func TestMergeDatabases_MergesAllRecords(t *testing.T) {
+	first := createMergeTestDB(t, RLPEncodingSchema, 1, 3, types.Address{1})
+	second := createMergeTestDB(t, ProtobufEncodingSchema, 3, 5, types.Address{1})
+	target := newTestMemoryDB(t)
+
+	report, err := MergeDatabases(target, []BaseDB{first, second}, MergeOptions{Encoding: ProtobufEncodingSchema})
+	require.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+	assert.Equal(t, 5, report.Merged[SubstateDBPrefix])
+	assert.Equal(t, 2, report.Merged[UpdateDBPrefix])
+	assert.Equal(t, 2, report.Merged[DestroyedAccountPrefix])
+	assert.Equal(t, 5, report.Merged[BlockHashPrefix])
+	assert.Equal(t, uint64(100), report.UpdateSetInterval)
+	assert.Equal(t, uint64(1000), report.UpdateSetSize)
+
+	// substate and block hash of block 3 and code of the substate and the update set are in both sources
+	assert.Equal(t, 4, report.Duplicates)
+
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(target, ProtobufEncodingSchema)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 5; block++ {
+		ss, err := sdb.GetSubstate(block, getTestSubstate(RLPEncodingSchema).Transaction)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x60, byte(block)}, ss.InputSubstate[types.Address{1}].Code)
+	}
+
+	udb, err := MakeDefaultUpdateDBFromBaseDB(target)
+	require.NoError(t, err)
+	value, err := target.Get(UpdateDBKey(3))
+	require.NoError(t, err)
+	deleted, err := udb.(*updateDB).decodeDeletedAccounts(value)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Address{{9}}, deleted)
+
+	md, err := target.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, md.EncodingSchema)
+	assert.Equal(t, &BlockRange{First: 1, Last: 5}, md.Blocks)
+	assert.Equal(t, uint64(100), md.UpdateSetInterval)
+}
+
+func TestMergeDatabases_ConflictPolicies(t *testing.T) {
+	tests := []struct {
+		policy    MergeConflictPolicy
+		wantErr   string
+		conflicts int
+		coinbase  types.Address
+	}{
+		{FailOnConflict, "conflicts with merged record", 1, types.Address{1}},
+		{KeepFirstOnConflict, "", 2, types.Address{1}},
+		{KeepLastOnConflict, "", 2, types.Address{2}},
+		{ReportConflicts, "2 conflicts found", 2, types.Address{1}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			first := createMergeTestDB(t, RLPEncodingSchema, 1, 3, types.Address{1})
+			second := createMergeTestDB(t, RLPEncodingSchema, 2, 3, types.Address{2})
+			target := newTestMemoryDB(t)
+
+			report, err := MergeDatabases(target, []BaseDB{first, second}, MergeOptions{Policy: test.policy})
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// substates of blocks 2 and 3 conflict, the fail policy stops at the first one
+			require.Len(t, report.Conflicts, test.conflicts)
+			assert.Equal(t, SubstateDBPrefix, report.Conflicts[0].Prefix)
+			assert.Equal(t, 1, report.Conflicts[0].Source)
+			assert.Contains(t, report.Conflicts[0].Difference, "env is different")
+			assert.Equal(t, test.coinbase, getMergedCoinbase(t, target, 2))
+		})
+	}
+}
+
+func TestMergeDatabases_UpdateSetMetadataConflict(t *testing.T) {
+	first := createMergeTestDB(t, RLPEncodingSchema, 1, 2, types.Address{1})
+	second := createMergeTestDB(t, RLPEncodingSchema, 3, 4, types.Address{1})
+	udb, err := MakeDefaultUpdateDBFromBaseDB(second)
+	require.NoError(t, err)
+	require.NoError(t, udb.PutMetadata(200, 1000))
+
+	_, err = MergeDatabases(newTestMemoryDB(t), []BaseDB{first, second}, MergeOptions{})
+	assert.ErrorContains(t, err, "update-set interval 200 and size 1000 differ from interval 100 and size 1000")
+
+	report, err := MergeDatabases(newTestMemoryDB(t), []BaseDB{first, second}, MergeOptions{Policy: KeepLastOnConflict})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(200), report.UpdateSetInterval)
+	assert.Len(t, report.Conflicts, 1)
+}
+
+func TestMergeDatabases_InvalidOptions(t *testing.T) {
+	target := newTestMemoryDB(t)
+	_, err := MergeDatabases(target, []BaseDB{createMergeTestDB(t, RLPEncodingSchema, 1, 1, types.Address{})}, MergeOptions{Policy: "random"})
+	assert.ErrorContains(t, err, "unknown conflict policy random")
+
+	_, err = MergeDatabases(target, nil, MergeOptions{})
+	assert.ErrorContains(t, err, "no source database")
+}
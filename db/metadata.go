@@ -2,15 +2,398 @@ package db
 
 import (
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
 	MetadataPrefix       = "md"
+	MetadataKey          = MetadataPrefix + "db" // MetadataKey -> json encoded Metadata
 	UpdatesetPrefix      = "us"
 	UpdatesetIntervalKey = MetadataPrefix + UpdatesetPrefix + "in"
 	UpdatesetSizeKey     = MetadataPrefix + UpdatesetPrefix + "si"
 )
 
+// MetadataVersion is the version of the Metadata record written by this package.
+const MetadataVersion = 1
+
+// SubstateSchemaVersion is the version of the record layout within the encoding schemas.
+const SubstateSchemaVersion = 1
+
+// BlockRange is an inclusive range of blocks.
+type BlockRange struct {
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+}
+
+// Contains returns true if block is within the range.
+func (r *BlockRange) Contains(block uint64) bool {
+	return r != nil && r.First <= block && block <= r.Last
+}
+
+// Metadata describes content of a database. The record is written once a database
+// is created and kept up to date by writes of substates and update sets.
+type Metadata struct {
+	// Version is the version of the record, it is 0 for databases created
+	// before the record was introduced which only have update-set metadata.
+	Version uint32 `json:"version"`
+
+	// EncodingSchema is the encoding of substates and update sets, empty until the first record
+	// is written. Records tagged with their encoding may use other encodings.
+	EncodingSchema SubstateEncodingSchema `json:"encodingSchema,omitempty"`
+
+	// SchemaVersion is the version of the record layout within EncodingSchema.
+	SchemaVersion uint32 `json:"schemaVersion,omitempty"`
+
+	// ChainID is the id of the recorded chain, 0 if unknown.
+	ChainID uint64 `json:"chainId,omitempty"`
+
+	// Blocks is the range of recorded substate blocks, nil if no substate was recorded.
+	Blocks *BlockRange `json:"blocks,omitempty"`
+
+	// UpdateSetInterval is the interval of blocks between update sets.
+	UpdateSetInterval uint64 `json:"updateSetInterval,omitempty"`
+
+	// UpdateSetSize is the size limit of update sets.
+	UpdateSetSize uint64 `json:"updateSetSize,omitempty"`
+
+	// Tool and ToolVersion identify the program which created the database.
+	Tool        string `json:"tool,omitempty"`
+	ToolVersion string `json:"toolVersion,omitempty"`
+
+	// CreatedAt is the time of creation of the database.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewMetadata returns metadata of a database created now by the running program.
+func NewMetadata() *Metadata {
+	md := &Metadata{
+		Version:   MetadataVersion,
+		Tool:      filepath.Base(os.Args[0]),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		md.ToolVersion = info.Main.Version
+	}
+	return md
+}
+
+// validate checks whether the record can be understood by this package.
+func (md *Metadata) validate() error {
+	if md.Version > MetadataVersion {
+		return fmt.Errorf("unsupported metadata version %v", md.Version)
+	}
+	if md.EncodingSchema == "" {
+		return nil
+	}
+	if _, err := newSubstateEncoding(md.EncodingSchema, nil); err != nil {
+		return fmt.Errorf("invalid metadata; %w", err)
+	}
+	if md.SchemaVersion > SubstateSchemaVersion {
+		return fmt.Errorf("unsupported %v schema version %v", md.EncodingSchema, md.SchemaVersion)
+	}
+	return nil
+}
+
+// clone returns a copy of md.
+func (md *Metadata) clone() *Metadata {
+	c := *md
+	if md.Blocks != nil {
+		blocks := *md.Blocks
+		c.Blocks = &blocks
+	}
+	return &c
+}
+
+// getMetadata reads the metadata record of backend. Databases without the record
+// get metadata of version 0 made of update-set metadata, nil is returned if there is none.
+func getMetadata(backend DbAdapter, ro *opt.ReadOptions) (*Metadata, error) {
+	value, err := backend.Get([]byte(MetadataKey), ro)
+	if err == nil {
+		md := new(Metadata)
+		if err = json.Unmarshal(value, md); err != nil {
+			return nil, fmt.Errorf("cannot decode metadata; %w", err)
+		}
+		if err = md.validate(); err != nil {
+			return nil, err
+		}
+		return md, nil
+	}
+	if !errors.Is(err, leveldb.ErrNotFound) {
+		return nil, fmt.Errorf("cannot get metadata; %w", err)
+	}
+
+	interval, err := backend.Get([]byte(UpdatesetIntervalKey), ro)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metadata; %w", err)
+	}
+	size, err := backend.Get([]byte(UpdatesetSizeKey), ro)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metadata; %w", err)
+	}
+	return &Metadata{
+		UpdateSetInterval: binary.BigEndian.Uint64(interval),
+		UpdateSetSize:     binary.BigEndian.Uint64(size),
+	}, nil
+}
+
+// putMetadata stores md as the metadata record of backend.
+func putMetadata(backend DbAdapter, wo *opt.WriteOptions, md *Metadata) error {
+	if md == nil {
+		return errors.New("cannot put nil metadata")
+	}
+	if md.Version == 0 {
+		md.Version = MetadataVersion
+	}
+	if err := md.validate(); err != nil {
+		return err
+	}
+	value, err := json.Marshal(md)
+	if err != nil {
+		return fmt.Errorf("cannot encode metadata; %w", err)
+	}
+	if err = backend.Put([]byte(MetadataKey), value, wo); err != nil {
+		return fmt.Errorf("cannot put metadata; %w", err)
+	}
+	return nil
+}
+
+// initMetadata writes new metadata into backend if it is an empty writable database.
+func initMetadata(backend DbAdapter, o *opt.Options) error {
+	if o != nil && o.ReadOnly {
+		return nil
+	}
+	iter := backend.NewIterator(nil, nil)
+	empty := !iter.First()
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("cannot init metadata; %w", err)
+	}
+	if !empty {
+		return nil
+	}
+	return putMetadata(backend, nil, NewMetadata())
+}
+
+// getSubstateBlockRange returns the range of blocks of substates in db, nil if there is none.
+func getSubstateBlockRange(db BaseDB) (*BlockRange, error) {
+	iter := db.newIterator(util.BytesPrefix([]byte(SubstateDBPrefix)))
+	defer iter.Release()
+	if !iter.First() {
+		return nil, iter.Error()
+	}
+	first, _, err := DecodeSubstateDBKey(iter.Key())
+	if err != nil {
+		return nil, err
+	}
+	if !iter.Last() {
+		return nil, iter.Error()
+	}
+	last, _, err := DecodeSubstateDBKey(iter.Key())
+	if err != nil {
+		return nil, err
+	}
+	return &BlockRange{First: first, Last: last}, nil
+}
+
+// metadataCache keeps the metadata record of a database in memory, hence writes of records
+// do not read and decode it. It is shared by all databases made from the same database.
+// The encoding is written at once, extensions of the block range are written by flush.
+type metadataCache struct {
+	backend DbAdapter
+	wo      *opt.WriteOptions
+	ro      *opt.ReadOptions
+	// readOnly caches are not flushed, repairs of the block range are kept in memory
+	readOnly bool
+
+	mu     sync.Mutex
+	loaded bool
+	md     *Metadata
+	dirty  bool
+}
+
+// newMetadataCache returns a cache of the metadata record of backend. Note: o is nillable.
+func newMetadataCache(backend DbAdapter, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) *metadataCache {
+	return &metadataCache{backend: backend, wo: wo, ro: ro, readOnly: o != nil && o.ReadOnly}
+}
+
+// metadataCacher is implemented by databases caching their metadata record.
+type metadataCacher interface {
+	metadataCache() *metadataCache
+}
+
+// metadataCacheOf returns the metadata cache of db, nil if it has none.
+func metadataCacheOf(db BaseDB) *metadataCache {
+	if c, ok := db.(metadataCacher); ok {
+		return c.metadataCache()
+	}
+	return nil
+}
+
+// load returns the cached metadata, it is read from the backend until a record is found
+// as it may be written around the cache, e.g. once the first shard is created.
+func (c *metadataCache) load() (*Metadata, error) {
+	if !c.loaded {
+		md, err := getMetadata(c.backend, c.ro)
+		if err != nil {
+			return nil, err
+		}
+		c.md, c.loaded = md, md != nil
+	}
+	return c.md, nil
+}
+
+// get returns a copy of the metadata, pending changes included.
+func (c *metadataCache) get() (*Metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	md, err := c.load()
+	if err != nil || md == nil {
+		return nil, err
+	}
+	return md.clone(), nil
+}
+
+// set writes md and replaces the cached metadata by it.
+func (c *metadataCache) set(md *Metadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := putMetadata(c.backend, c.wo, md); err != nil {
+		return err
+	}
+	c.md, c.loaded, c.dirty = md.clone(), true, false
+	return nil
+}
+
+// record keeps the metadata in line with a record of block, see updateMetadata.
+// The encoding is written at once, an extension of the block range is kept pending.
+func (c *metadataCache) record(schema SubstateEncodingSchema, tagged bool, block uint64, extendRange bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	md, err := c.load()
+	if err != nil || md == nil || md.Version == 0 {
+		return err
+	}
+	updated := md.clone()
+	schemaChanged, rangeChanged, err := updateMetadata(updated, schema, tagged, block, extendRange)
+	if err != nil {
+		return err
+	}
+	// readers rely on the recorded encoding, hence it is written at once
+	if schemaChanged {
+		if err = putMetadata(c.backend, c.wo, updated); err != nil {
+			return err
+		}
+		c.md, c.dirty = updated, false
+		return nil
+	}
+	if rangeChanged {
+		c.md, c.dirty = updated, true
+	}
+	return nil
+}
+
+// flush writes pending changes of the metadata, it is a no-op for a nil cache.
+func (c *metadataCache) flush() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty || c.readOnly {
+		return nil
+	}
+	if err := putMetadata(c.backend, c.wo, c.md); err != nil {
+		return fmt.Errorf("cannot flush metadata; %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// drop drops the cached metadata, hence it is read again on next use. It is a no-op for a nil cache.
+func (c *metadataCache) drop() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.md, c.loaded, c.dirty = nil, false, false
+}
+
+// updateMetadata updates md with a record of block written with schema, the substate block
+// range is extended if extendRange is set. Untagged records must match the recorded
+// encoding, tagged records may use any encoding.
+func updateMetadata(md *Metadata, schema SubstateEncodingSchema, tagged bool, block uint64, extendRange bool) (schemaChanged bool, rangeChanged bool, err error) {
+	switch md.EncodingSchema {
+	case "":
+		md.EncodingSchema = schema
+		md.SchemaVersion = SubstateSchemaVersion
+		schemaChanged = true
+	case schema:
+	default:
+		if !tagged {
+			return false, false, fmt.Errorf("cannot write %v encoded record into db of %v encoding", schema, md.EncodingSchema)
+		}
+	}
+	if extendRange && !md.Blocks.Contains(block) {
+		if md.Blocks == nil {
+			md.Blocks = &BlockRange{First: block, Last: block}
+		}
+		md.Blocks.First = min(md.Blocks.First, block)
+		md.Blocks.Last = max(md.Blocks.Last, block)
+		rangeChanged = true
+	}
+	return schemaChanged, rangeChanged, nil
+}
+
+// recordMetadata keeps the metadata record of db in line with a record of block, see updateMetadata.
+// Databases without a metadata cache have their record updated at once,
+// databases without the metadata record are left as they are.
+func recordMetadata(db BaseDB, schema SubstateEncodingSchema, tagged bool, block uint64, extendRange bool) error {
+	if c := metadataCacheOf(db); c != nil {
+		return c.record(schema, tagged, block, extendRange)
+	}
+	md, err := db.GetMetadata()
+	if err != nil || md == nil || md.Version == 0 {
+		return err
+	}
+	schemaChanged, rangeChanged, err := updateMetadata(md, schema, tagged, block, extendRange)
+	if err != nil || !(schemaChanged || rangeChanged) {
+		return err
+	}
+	return db.SetMetadata(md)
+}
+
+// RecordChainID records chainID as the chain of the substates in db. A db recording
+// a different chain is rejected, databases without the metadata record are left as they are.
+func RecordChainID(db BaseDB, chainID uint64) error {
+	md, err := db.GetMetadata()
+	if err != nil {
+		return fmt.Errorf("cannot get metadata; %w", err)
+	}
+	if md == nil || md.Version == 0 || md.ChainID == chainID {
+		return nil
+	}
+	if md.ChainID != 0 {
+		return fmt.Errorf("cannot record chain id %v into db of chain id %v", chainID, md.ChainID)
+	}
+	md.ChainID = chainID
+	return db.SetMetadata(md)
+}
+
 // PutMetadata into db
 func (db *updateDB) PutMetadata(interval, size uint64) error {
 
@@ -28,20 +411,12 @@ func (db *updateDB) PutMetadata(interval, size uint64) error {
 		return err
 	}
 
-	return nil
-}
-
-// GetMetadata from db
-func (db *updateDB) GetMetadata() (uint64, uint64, error) {
-	byteInterval, err := db.Get([]byte(UpdatesetIntervalKey))
-	if err != nil {
-		return 0, 0, err
-	}
-
-	byteSize, err := db.Get([]byte(UpdatesetSizeKey))
-	if err != nil {
-		return 0, 0, err
+	// databases with the metadata record keep the values in it as well
+	md, err := db.GetMetadata()
+	if err != nil || md == nil || md.Version == 0 {
+		return err
 	}
-
-	return binary.BigEndian.Uint64(byteInterval), binary.BigEndian.Uint64(byteSize), nil
+	md.UpdateSetInterval = interval
+	md.UpdateSetSize = size
+	return db.SetMetadata(md)
 }
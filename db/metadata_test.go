@@ -6,6 +6,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	"go.uber.org/mock/gomock"
 )
 
@@ -32,11 +35,30 @@ func TestUpdateDB_PutMetadataSuccess(t *testing.T) {
 	// Set expectations
 	codeDb.EXPECT().Put([]byte(UpdatesetIntervalKey), intervalBytes).Return(nil)
 	codeDb.EXPECT().Put([]byte(UpdatesetSizeKey), sizeBytes).Return(nil)
+	codeDb.EXPECT().GetMetadata().Return(nil, nil)
 
 	err := db.PutMetadata(interval, size)
 	assert.Nil(t, err)
 }
 
+func TestUpdateDB_PutMetadataUpdatesRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	codeDb := NewMockCodeDB(ctrl)
+	db := &updateDB{
+		CodeDB: codeDb,
+	}
+
+	codeDb.EXPECT().Put([]byte(UpdatesetIntervalKey), gomock.Any()).Return(nil)
+	codeDb.EXPECT().Put([]byte(UpdatesetSizeKey), gomock.Any()).Return(nil)
+	codeDb.EXPECT().GetMetadata().Return(&Metadata{Version: MetadataVersion}, nil)
+	codeDb.EXPECT().SetMetadata(&Metadata{Version: MetadataVersion, UpdateSetInterval: 1000, UpdateSetSize: 500}).Return(nil)
+
+	err := db.PutMetadata(1000, 500)
+	assert.Nil(t, err)
+}
+
 func TestUpdateDB_PutMetadataPutIntervalKeyFail(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -78,76 +100,318 @@ func TestUpdateDB_PutMetadataPutSizeKeyFail(t *testing.T) {
 	assert.Equal(t, expectedErr, err)
 }
 
-func TestUpdateDB_GetMetadataSuccess(t *testing.T) {
+func TestMetadata_GetLegacyUpdateSetMetadata(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	codeDb := NewMockCodeDB(ctrl)
-	db := &updateDB{
-		CodeDB: codeDb,
-	}
-
-	// Test successful get
-	interval := uint64(1000)
-	size := uint64(500)
+	backend := NewMockDbAdapter(ctrl)
 
 	// Create byte slices to return
 	intervalBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(intervalBytes, interval)
+	binary.BigEndian.PutUint64(intervalBytes, 1000)
 
 	sizeBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(sizeBytes, size)
+	binary.BigEndian.PutUint64(sizeBytes, 500)
 
-	// Set expectations
-	codeDb.EXPECT().Get([]byte(UpdatesetIntervalKey)).Return(intervalBytes, nil)
-	codeDb.EXPECT().Get([]byte(UpdatesetSizeKey)).Return(sizeBytes, nil)
+	backend.EXPECT().Get([]byte(MetadataKey), nil).Return(nil, leveldb.ErrNotFound)
+	backend.EXPECT().Get([]byte(UpdatesetIntervalKey), nil).Return(intervalBytes, nil)
+	backend.EXPECT().Get([]byte(UpdatesetSizeKey), nil).Return(sizeBytes, nil)
 
-	resultInterval, resultSize, err := db.GetMetadata()
+	md, err := getMetadata(backend, nil)
 	assert.Nil(t, err)
-	assert.Equal(t, interval, resultInterval)
-	assert.Equal(t, size, resultSize)
+	assert.Equal(t, &Metadata{UpdateSetInterval: 1000, UpdateSetSize: 500}, md)
 }
 
-func TestUpdateDB_GetMetadataIntervalKeyFail(t *testing.T) {
+func TestMetadata_GetWithoutAnyMetadata(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	codeDb := NewMockCodeDB(ctrl)
-	db := &updateDB{
-		CodeDB: codeDb,
-	}
+	backend := NewMockDbAdapter(ctrl)
+	backend.EXPECT().Get([]byte(MetadataKey), nil).Return(nil, leveldb.ErrNotFound)
+	backend.EXPECT().Get([]byte(UpdatesetIntervalKey), nil).Return(nil, leveldb.ErrNotFound)
 
-	// Test error when getting interval
-	expectedErr := errors.New("interval get error")
-	codeDb.EXPECT().Get([]byte(UpdatesetIntervalKey)).Return(nil, expectedErr)
-
-	resultInterval, resultSize, err := db.GetMetadata()
-	assert.Equal(t, expectedErr, err)
-	assert.Equal(t, uint64(0), resultInterval)
-	assert.Equal(t, uint64(0), resultSize)
+	md, err := getMetadata(backend, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, md)
 }
 
-func TestUpdateDB_GetMetadataSizeKeyFail(t *testing.T) {
+func TestMetadata_GetLegacyUpdateSetMetadataFail(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	codeDb := NewMockCodeDB(ctrl)
-	db := &updateDB{
-		CodeDB: codeDb,
-	}
-
-	// Test error when getting size after successful interval get
+	backend := NewMockDbAdapter(ctrl)
 	expectedErr := errors.New("size get error")
 
-	// Create byte slice for interval
 	intervalBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(intervalBytes, uint64(1000))
 
-	codeDb.EXPECT().Get([]byte(UpdatesetIntervalKey)).Return(intervalBytes, nil)
-	codeDb.EXPECT().Get([]byte(UpdatesetSizeKey)).Return(nil, expectedErr)
+	backend.EXPECT().Get([]byte(MetadataKey), nil).Return(nil, leveldb.ErrNotFound)
+	backend.EXPECT().Get([]byte(UpdatesetIntervalKey), nil).Return(intervalBytes, nil)
+	backend.EXPECT().Get([]byte(UpdatesetSizeKey), nil).Return(nil, expectedErr)
 
-	resultInterval, resultSize, err := db.GetMetadata()
-	assert.Equal(t, expectedErr, err)
-	assert.Equal(t, uint64(0), resultInterval)
-	assert.Equal(t, uint64(0), resultSize)
+	_, err := getMetadata(backend, nil)
+	assert.ErrorIs(t, err, expectedErr)
+}
+
+func TestMetadata_GetInvalidRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewMockDbAdapter(ctrl)
+	backend.EXPECT().Get([]byte(MetadataKey), nil).Return([]byte("{"), nil)
+	_, err := getMetadata(backend, nil)
+	assert.ErrorContains(t, err, "cannot decode metadata")
+
+	backend.EXPECT().Get([]byte(MetadataKey), nil).Return([]byte(`{"version": 2}`), nil)
+	_, err = getMetadata(backend, nil)
+	assert.ErrorContains(t, err, "unsupported metadata version 2")
+
+	backend.EXPECT().Get([]byte(MetadataKey), nil).Return([]byte(`{"version": 1, "encodingSchema": "xml"}`), nil)
+	_, err = getMetadata(backend, nil)
+	assert.ErrorContains(t, err, "encoding not supported: xml")
+}
+
+func TestMetadata_WrittenOnCreation(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	require.NotNil(t, md)
+	assert.Equal(t, uint32(MetadataVersion), md.Version)
+	assert.Empty(t, md.EncodingSchema)
+	assert.Nil(t, md.Blocks)
+	assert.NotEmpty(t, md.Tool)
+	assert.False(t, md.CreatedAt.IsZero())
+
+	// every db type exposes the record
+	for _, view := range []BaseDB{
+		MakeDefaultCodeDBFromBaseDB(sdb),
+		MakeDefaultExceptionDBFromBaseDB(sdb),
+	} {
+		got, err := view.GetMetadata()
+		require.NoError(t, err)
+		assert.Equal(t, md, got)
+	}
+}
+
+func TestMetadata_RecordsEncodingAndBlockRange(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(RLPEncodingSchema))
+	for _, block := range []uint64{20, 10, 30} {
+		ss := getTestSubstate(RLPEncodingSchema)
+		ss.Block = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, RLPEncodingSchema, md.EncodingSchema)
+	assert.Equal(t, uint32(SubstateSchemaVersion), md.SchemaVersion)
+	assert.Equal(t, &BlockRange{First: 10, Last: 30}, md.Blocks)
+
+	// a record of other encoding is refused
+	require.NoError(t, sdb.SetSubstateEncoding(ProtobufEncodingSchema))
+	err = sdb.PutSubstate(getTestSubstate(ProtobufEncodingSchema))
+	assert.ErrorContains(t, err, "cannot write protobuf encoded record into db of rlp encoding")
+	require.NoError(t, sdb.Close())
+
+	// the encoding is read from metadata on open, update sets use it as well
+	udb, err := NewDefaultUpdateDB(path)
+	require.NoError(t, err)
+	assert.Equal(t, RLPEncodingSchema, udb.GetSubstateEncoding())
+	require.NoError(t, udb.Close())
+
+	sdb, err = NewReadOnlySubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+	assert.Equal(t, RLPEncodingSchema, sdb.GetSubstateEncoding())
+}
+
+func TestMetadata_BlockRangeIsWrittenOnClose(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(ProtobufEncodingSchema))
+	udb, err := MakeDefaultUpdateDBFromBaseDB(sdb)
+	require.NoError(t, err)
+	for _, block := range []uint64{10, 20} {
+		ss := getTestSubstate(ProtobufEncodingSchema)
+		ss.Block = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+
+	// the encoding is written at once, the block range is kept in memory
+	stored, err := getMetadata(sdb.GetBackend(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, stored.EncodingSchema)
+	assert.Equal(t, &BlockRange{First: 10, Last: 10}, stored.Blocks)
+
+	// databases made from sdb share its metadata
+	md, err := udb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, &BlockRange{First: 10, Last: 20}, md.Blocks)
+
+	// returned metadata are copies
+	md.Blocks.Last = 100
+	md, err = sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), md.Blocks.Last)
+	require.NoError(t, sdb.Close())
+
+	sdb, err = NewReadOnlySubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+	md, err = sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, &BlockRange{First: 10, Last: 20}, md.Blocks)
+}
+
+func TestMetadata_ValidatedOnOpen(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	ss := getTestSubstate(ProtobufEncodingSchema)
+	require.NoError(t, sdb.PutSubstate(ss))
+
+	// recorded range does not cover the data
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	md.Blocks = &BlockRange{First: ss.Block + 1, Last: ss.Block + 10}
+	require.NoError(t, sdb.SetMetadata(md))
+	require.NoError(t, sdb.Close())
+
+	// the range is repaired on open
+	sdb, err = NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	md, err = sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, &BlockRange{First: ss.Block, Last: ss.Block + 10}, md.Blocks)
+	require.NoError(t, sdb.Close())
+
+	// recorded encoding does not match the data
+	cdb, err := NewDefaultCodeDB(path)
+	require.NoError(t, err)
+	md.Blocks = &BlockRange{First: ss.Block, Last: ss.Block}
+	md.EncodingSchema = RLPEncodingSchema
+	require.NoError(t, cdb.SetMetadata(md))
+	require.NoError(t, cdb.Close())
+
+	_, err = NewDefaultSubstateDB(path)
+	assert.ErrorContains(t, err, "cannot be decoded with recorded rlp encoding")
+}
+
+func TestMetadata_BlockRangeIsRepairedAfterUncleanExit(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	for block := uint64(10); block <= 12; block++ {
+		ss := getTestSubstate(ProtobufEncodingSchema)
+		ss.Block = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+	// the db is not closed, pending metadata changes are lost
+	require.NoError(t, sdb.GetBackend().Close())
+
+	for _, readOnly := range []bool{true, false} {
+		sdb, err = NewSubstateDB(path, &opt.Options{ReadOnly: readOnly}, nil, nil)
+		require.NoError(t, err)
+		md, err := sdb.GetMetadata()
+		require.NoError(t, err)
+		assert.Equal(t, &BlockRange{First: 10, Last: 12}, md.Blocks)
+		require.NoError(t, sdb.Close())
+	}
+
+	// the repaired range is written on close of a writable db
+	backend, err := OpenBackend(DefaultBackend, path, nil)
+	require.NoError(t, err)
+	defer backend.Close()
+	stored, err := getMetadata(backend, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &BlockRange{First: 10, Last: 12}, stored.Blocks)
+}
+
+func TestMetadata_RecordChainID(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	require.NoError(t, RecordChainID(sdb, 146))
+	require.NoError(t, RecordChainID(sdb, 146))
+	assert.ErrorContains(t, RecordChainID(sdb, 1), "cannot record chain id 1 into db of chain id 146")
+	require.NoError(t, sdb.Close())
+
+	sdb, err = NewReadOnlySubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(146), md.ChainID)
+}
+
+func TestMetadata_LegacyDBIsProbed(t *testing.T) {
+	path := t.TempDir()
+	sdb, err := NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(RLPEncodingSchema))
+	require.NoError(t, sdb.PutSubstate(getTestSubstate(RLPEncodingSchema)))
+	require.NoError(t, sdb.Delete([]byte(MetadataKey)))
+	require.NoError(t, sdb.Close())
+
+	sdb, err = NewDefaultSubstateDB(path)
+	require.NoError(t, err)
+	defer sdb.Close()
+	assert.Equal(t, RLPEncodingSchema, sdb.GetSubstateEncoding())
+
+	// no record is created for existing data
+	require.NoError(t, sdb.PutSubstate(getTestSubstate(RLPEncodingSchema)))
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Nil(t, md)
+}
+
+func TestMetadata_SharedByShards(t *testing.T) {
+	root := t.TempDir()
+	sdb, err := NewDefaultShardedSubstateDB(root, 100)
+	require.NoError(t, err)
+	for _, block := range []uint64{5, 150, 250} {
+		ss := getTestSubstate(ProtobufEncodingSchema)
+		ss.Block = block
+		require.NoError(t, sdb.PutSubstate(ss))
+	}
+	require.NoError(t, sdb.Close())
+
+	sdb, err = NewDefaultShardedSubstateDB(root, 100)
+	require.NoError(t, err)
+	defer sdb.Close()
+	require.Len(t, sdb.Shards(), 3)
+	md, err := sdb.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, &BlockRange{First: 5, Last: 250}, md.Blocks)
+	assert.Equal(t, ProtobufEncodingSchema, md.EncodingSchema)
+}
+
+func TestMetadata_MemoryDBDumpKeepsRecord(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+	sdb, err := MakeDefaultSubstateDBFromBaseDB(db)
+	require.NoError(t, err)
+	ss := getTestSubstate(ProtobufEncodingSchema)
+	require.NoError(t, sdb.PutSubstate(ss))
+	require.NoError(t, db.SetMetadata(&Metadata{ChainID: 250, EncodingSchema: ProtobufEncodingSchema, Blocks: &BlockRange{First: ss.Block, Last: ss.Block}}))
+
+	path := t.TempDir()
+	require.NoError(t, db.Dump(path, DefaultBackend))
+
+	loaded, err := NewReadOnlySubstateDB(path)
+	require.NoError(t, err)
+	defer loaded.Close()
+	md, err := loaded.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(250), md.ChainID)
+	assert.Equal(t, uint32(MetadataVersion), md.Version)
 }
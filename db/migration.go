@@ -0,0 +1,431 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// MigrationCheckpointKey -> json encoded checkpoint of an unfinished migration
+const MigrationCheckpointKey = MetadataPrefix + "mc"
+
+// DefaultMigrationBatchSize is the number of records written by a single batch of a migration.
+const DefaultMigrationBatchSize = 1000
+
+// MigrationOptions configures MigrateEncoding.
+type MigrationOptions struct {
+	// Encoding is the encoding records are converted to.
+	Encoding SubstateEncodingSchema
+
+	// Tag tags converted substates and update sets with their encoding.
+	Tag bool
+
+	// BatchSize is the number of records written by a single batch, a checkpoint
+	// is stored with every batch. DefaultMigrationBatchSize is used if not set.
+	BatchSize int
+}
+
+// MigrationReport summarizes a migration.
+type MigrationReport struct {
+	// Resumed is set if the migration continued from a checkpoint.
+	Resumed bool
+
+	// Substates, UpdateSets, DestroyedAccounts and Exceptions are numbers of migrated records.
+	Substates         int
+	UpdateSets        int
+	DestroyedAccounts int
+	Exceptions        int
+
+	// Copied is the number of other entries copied into a new database.
+	Copied int
+}
+
+// migrationCheckpoint is the last key written by an unfinished migration.
+type migrationCheckpoint struct {
+	Encoding SubstateEncodingSchema `json:"encoding"`
+	Key      []byte                 `json:"key"`
+}
+
+// MigrateEncoding converts substates, update sets and destroyed accounts of src into given
+// encoding and writes them into dst. Exceptions have a single encoding, they are verified and
+// copied as they are. If dst is a different database, all other entries are copied as well,
+// otherwise src is migrated in place. Every converted record is decoded again and compared
+// with the original one before it is written.
+//
+// A checkpoint is stored in dst together with every batch, an interrupted migration continues
+// after the checkpoint once it is run again with the same encoding. Metadata of dst are updated
+// once the migration is finished.
+//
+// Note: src and dst must not be written to while the migration runs.
+func MigrateEncoding(src, dst BaseDB, options MigrationOptions) (*MigrationReport, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultMigrationBatchSize
+	}
+	m, err := newMigration(src, dst, options)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := getMigrationCheckpoint(dst)
+	if err != nil {
+		return nil, err
+	}
+	var start []byte
+	if checkpoint != nil {
+		if checkpoint.Encoding != m.target.schema {
+			return nil, fmt.Errorf("unfinished migration to %v encoding found, it must be finished first", checkpoint.Encoding)
+		}
+		m.report.Resumed = true
+		start = checkpoint.Key
+	} else if err = m.copyCompressionDictionary(); err != nil {
+		return nil, err
+	}
+
+	if err = m.run(start); err != nil {
+		return m.report, err
+	}
+	if err = m.finish(); err != nil {
+		return m.report, err
+	}
+	return m.report, nil
+}
+
+type migration struct {
+	src, dst BaseDB
+	getCode  codeLookupFunc
+	inPlace  bool
+	target   *substateEncoding
+	tag      bool
+
+	batch     Batch
+	batchSize int
+	pending   int
+
+	srcCompression *valueCompression
+	dstCompression *valueCompression
+
+	report *MigrationReport
+}
+
+func newMigration(src, dst BaseDB, options MigrationOptions) (*migration, error) {
+	getCode := MakeDefaultCodeDBFromBaseDB(src).GetCode
+	target, err := newSubstateEncoding(options.Encoding, getCode)
+	if err != nil {
+		return nil, err
+	}
+	return &migration{
+		src:            src,
+		getCode:        getCode,
+		dst:            dst,
+		inPlace:        src.GetBackend() == dst.GetBackend(),
+		target:         target,
+		tag:            options.Tag,
+		batch:          dst.NewBatch(),
+		batchSize:      options.BatchSize,
+		srcCompression: newValueCompression(src),
+		dstCompression: newValueCompression(dst),
+		report:         new(MigrationReport),
+	}, nil
+}
+
+// copyCompressionDictionary stores dictionary of src in a new dst so that records written
+// into dst are compressed as well.
+func (m *migration) copyCompressionDictionary() error {
+	if m.inPlace {
+		return nil
+	}
+	dict, err := GetCompressionDictionary(m.src)
+	if err != nil || dict == nil {
+		return err
+	}
+	existing, err := GetCompressionDictionary(m.dst)
+	if err != nil || existing != nil {
+		return err
+	}
+	return PutCompressionDictionary(m.dst, dict)
+}
+
+// run migrates all entries of src following the start key.
+func (m *migration) run(start []byte) error {
+	iter := m.src.NewIterator(nil, start)
+	defer iter.Release()
+	last := start
+	for iter.Next() {
+		if start != nil && bytes.Equal(iter.Key(), start) {
+			continue
+		}
+		if err := m.migrate(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+		last = bytes.Clone(iter.Key())
+		if m.pending >= m.batchSize {
+			if err := m.flush(last); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if last == nil {
+		return nil
+	}
+	return m.flush(last)
+}
+
+// migrate converts or copies a single entry.
+func (m *migration) migrate(key, value []byte) error {
+	var (
+		converted []byte
+		err       error
+	)
+	switch {
+	case bytes.HasPrefix(key, []byte(SubstateDBPrefix)):
+		converted, err = m.convertSubstate(key, value)
+		m.report.Substates++
+	case bytes.HasPrefix(key, []byte(UpdateDBPrefix)):
+		converted, err = m.convertUpdateSet(key, value)
+		m.report.UpdateSets++
+	case bytes.HasPrefix(key, []byte(DestroyedAccountPrefix)):
+		converted, err = m.convertDestroyedAccounts(key, value)
+		m.report.DestroyedAccounts++
+	case bytes.HasPrefix(key, []byte(ExceptionDBPrefix)):
+		converted, err = m.verifyException(key, value)
+		m.report.Exceptions++
+	default:
+		// metadata of dst are updated once the migration is finished
+		if m.inPlace || string(key) == MetadataKey || string(key) == MigrationCheckpointKey {
+			return nil
+		}
+		converted = value
+		m.report.Copied++
+	}
+	if err != nil {
+		return err
+	}
+	if m.inPlace && bytes.Equal(converted, value) {
+		return nil
+	}
+	if err = m.batch.Put(slices.Clone(key), slices.Clone(converted)); err != nil {
+		return err
+	}
+	m.pending++
+	return nil
+}
+
+// flush writes the batch together with a checkpoint at key.
+func (m *migration) flush(key []byte) error {
+	value, err := json.Marshal(migrationCheckpoint{Encoding: m.target.schema, Key: key})
+	if err != nil {
+		return err
+	}
+	if err = m.batch.Put([]byte(MigrationCheckpointKey), value); err != nil {
+		return err
+	}
+	if err = m.batch.Write(); err != nil {
+		return fmt.Errorf("cannot write migrated records; %w", err)
+	}
+	m.batch.Reset()
+	m.pending = 0
+	return nil
+}
+
+// decodeRecord undoes compression and the encoding tag of a substate or update-set value.
+func (m *migration) decodeRecord(value []byte) (SubstateEncodingSchema, []byte, error) {
+	value, err := m.srcCompression.decompress(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return recordEncoding(value)
+}
+
+// encodeRecord applies the encoding tag and compression on a converted substate or update-set value.
+func (m *migration) encodeRecord(value []byte) ([]byte, error) {
+	var err error
+	if m.tag {
+		if value, err = tagEncoding(m.target.schema, value); err != nil {
+			return nil, err
+		}
+	}
+	return m.dstCompression.compress(value)
+}
+
+func (m *migration) convertSubstate(key, value []byte) ([]byte, error) {
+	block, tx, err := DecodeSubstateDBKey(key)
+	if err != nil {
+		return nil, err
+	}
+	schema, payload, err := m.decodeRecord(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	source, err := newSubstateEncoding(schema, m.getCode)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := source.decode(payload, block, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := m.target.encode(ss, block, tx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	got, err := m.target.decode(converted, block, tx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify substate block: %v, tx %v; %w", block, tx, err)
+	}
+	if err = ss.Equal(got); err != nil {
+		return nil, fmt.Errorf("substate block: %v, tx %v changed by conversion to %v; %w", block, tx, m.target.schema, err)
+	}
+	return m.encodeRecord(converted)
+}
+
+func (m *migration) convertUpdateSet(key, value []byte) ([]byte, error) {
+	block, err := DecodeUpdateSetKey(key)
+	if err != nil {
+		return nil, err
+	}
+	schema, payload, err := m.decodeRecord(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	source, err := newUpdateSetEncoding(schema)
+	if err != nil {
+		return nil, err
+	}
+	target, err := newUpdateSetEncoding(m.target.schema)
+	if err != nil {
+		return nil, err
+	}
+	us, err := source.decode(block, m.getCode, payload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	deleted, err := source.deletedAccounts(payload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+
+	converted, err := target.encode(*us, deleted)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode update-set block: %v; %w", block, err)
+	}
+	got, err := target.decode(block, m.getCode, converted)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify update-set block: %v; %w", block, err)
+	}
+	gotDeleted, err := target.deletedAccounts(converted)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify update-set block: %v; %w", block, err)
+	}
+	if !got.WorldState.Equal(us.WorldState) || !slices.Equal(deleted, gotDeleted) {
+		return nil, fmt.Errorf("update-set block: %v changed by conversion to %v", block, m.target.schema)
+	}
+	return m.encodeRecord(converted)
+}
+
+func (m *migration) convertDestroyedAccounts(key, value []byte) ([]byte, error) {
+	block, tx, err := DecodeDestroyedAccountKey(key)
+	if err != nil {
+		return nil, err
+	}
+	source, err := newDestroyedAccountEncoding(detectEncodingSchema(value))
+	if err != nil {
+		return nil, err
+	}
+	target, err := newDestroyedAccountEncoding(m.target.schema)
+	if err != nil {
+		return nil, err
+	}
+	list, err := source.decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode destroyed accounts block: %v, tx %v; %w", block, tx, err)
+	}
+
+	converted, err := target.encode(list)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode destroyed accounts block: %v, tx %v; %w", block, tx, err)
+	}
+	got, err := target.decode(converted)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify destroyed accounts block: %v, tx %v; %w", block, tx, err)
+	}
+	if !slices.Equal(list.DestroyedAccounts, got.DestroyedAccounts) || !slices.Equal(list.ResurrectedAccounts, got.ResurrectedAccounts) {
+		return nil, fmt.Errorf("destroyed accounts block: %v, tx %v changed by conversion to %v", block, tx, m.target.schema)
+	}
+	return converted, nil
+}
+
+// verifyException checks that the exception can be decoded, exceptions are only encoded using protobuf.
+func (m *migration) verifyException(key, value []byte) ([]byte, error) {
+	block, err := DecodeExceptionDBKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = decodeException(func(types.Hash) ([]byte, error) { return nil, nil }, block, value); err != nil {
+		return nil, fmt.Errorf("cannot verify exception; %w", err)
+	}
+	return value, nil
+}
+
+// finish records the new encoding and the substate block range in metadata of dst.
+func (m *migration) finish() error {
+	md, err := m.dst.GetMetadata()
+	if err != nil {
+		return err
+	}
+	if !m.inPlace {
+		srcMetadata, err := m.src.GetMetadata()
+		if err != nil {
+			return err
+		}
+		switch {
+		case srcMetadata == nil:
+		case srcMetadata.Version > 0 || md == nil:
+			md = srcMetadata
+		default:
+			md.UpdateSetInterval, md.UpdateSetSize = srcMetadata.UpdateSetInterval, srcMetadata.UpdateSetSize
+		}
+	}
+	if md == nil {
+		md = NewMetadata()
+	} else if md.Version == 0 {
+		// update-set metadata of databases created before the metadata record are kept
+		created := NewMetadata()
+		created.UpdateSetInterval, created.UpdateSetSize = md.UpdateSetInterval, md.UpdateSetSize
+		md = created
+	}
+
+	md.EncodingSchema = m.target.schema
+	md.SchemaVersion = SubstateSchemaVersion
+	if md.Blocks, err = getSubstateBlockRange(m.dst); err != nil {
+		return err
+	}
+	if err = m.dst.SetMetadata(md); err != nil {
+		return err
+	}
+	return m.dst.Delete([]byte(MigrationCheckpointKey))
+}
+
+// getMigrationCheckpoint returns checkpoint of an unfinished migration into db, nil if there is none.
+func getMigrationCheckpoint(db BaseDB) (*migrationCheckpoint, error) {
+	value, err := db.Get([]byte(MigrationCheckpointKey))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get migration checkpoint; %w", err)
+	}
+	checkpoint := new(migrationCheckpoint)
+	if err = json.Unmarshal(value, checkpoint); err != nil {
+		return nil, fmt.Errorf("cannot decode migration checkpoint; %w", err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createMigrationTestDB creates a db with records of all families encoded with schema,
+// substates of blocks 1 to 5, update sets of blocks 1 to 3 and destroyed accounts of block 2.
+func createMigrationTestDB(t *testing.T, schema SubstateEncodingSchema) MemoryDB {
+	db := newTestDB(t, schema)
+	for block := uint64(1); block <= 5; block++ {
+		ss := getTestSubstate(RLPEncodingSchema)
+		ss.Block = block
+		ss.Env.Number = block
+		ss.InputSubstate = substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60, byte(block)})
+		require.NoError(t, db.substates.PutSubstate(ss))
+	}
+
+	for block := uint64(1); block <= 3; block++ {
+		require.NoError(t, db.updateSets.PutUpdateSet(getCompressionTestUpdateSet(block), []types.Address{{9}}))
+	}
+
+	ddb, err := MakeDefaultDestroyedAccountDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	require.NoError(t, ddb.SetDestroyedAccounts(2, 1, []types.Address{{3}}, []types.Address{{4}}))
+
+	preBlock := substate.NewWorldState().Add(types.Address{3}, 1, uint256.NewInt(1), nil)
+	require.NoError(t, MakeDefaultExceptionDBFromBaseDB(db).PutException(&substate.Exception{
+		Block: 4,
+		Data:  substate.ExceptionBlock{PreBlock: &preBlock},
+	}))
+	require.NoError(t, db.Put(BlockHashDBKey(1), types.Hash{1}.Bytes()))
+	return db.MemoryDB
+}
+
+// checkMigratedDB checks that all records of db created by createMigrationTestDB are readable using schema.
+func checkMigratedDB(t *testing.T, db BaseDB, schema SubstateEncodingSchema) {
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 5; block++ {
+		ss, err := sdb.GetSubstate(block, getTestSubstate(RLPEncodingSchema).Transaction)
+		require.NoError(t, err)
+		assert.Equal(t, block, ss.Env.Number)
+		assert.Equal(t, []byte{0x60, byte(block)}, ss.InputSubstate[types.Address{1}].Code)
+	}
+
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	encoding, err := newUpdateSetEncoding(schema)
+	require.NoError(t, err)
+	for block := uint64(1); block <= 3; block++ {
+		us, err := udb.GetUpdateSet(block)
+		require.NoError(t, err)
+		assert.True(t, getCompressionTestUpdateSet(block).WorldState.Equal(us.WorldState))
+
+		// deleted accounts are not part of the decoded update set
+		value, err := db.Get(UpdateDBKey(block))
+		require.NoError(t, err)
+		value, err = newValueCompression(db).decompress(value)
+		require.NoError(t, err)
+		_, value, err = recordEncoding(value)
+		require.NoError(t, err)
+		deleted, err := encoding.deletedAccounts(value)
+		require.NoError(t, err)
+		assert.Equal(t, []types.Address{{9}}, deleted)
+	}
+
+	ddb, err := MakeDefaultDestroyedAccountDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	destroyed, resurrected, err := ddb.GetDestroyedAccounts(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Address{{3}}, destroyed)
+	assert.Equal(t, []types.Address{{4}}, resurrected)
+
+	exception, err := MakeDefaultExceptionDBFromBaseDB(db).GetException(4)
+	require.NoError(t, err)
+	require.NotNil(t, exception)
+
+	md, err := db.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, schema, md.EncodingSchema)
+	assert.Equal(t, &BlockRange{First: 1, Last: 5}, md.Blocks)
+
+	has, err := db.Has([]byte(MigrationCheckpointKey))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestMigrateEncoding_IntoNewDB(t *testing.T) {
+	for _, test := range []struct{ from, to SubstateEncodingSchema }{
+		{RLPEncodingSchema, ProtobufEncodingSchema},
+		{ProtobufEncodingSchema, RLPEncodingSchema},
+	} {
+		t.Run(string(test.from)+"-to-"+string(test.to), func(t *testing.T) {
+			src := createMigrationTestDB(t, test.from)
+			dst, err := NewMemoryDB()
+			require.NoError(t, err)
+			defer dst.Close()
+
+			report, err := MigrateEncoding(src, dst, MigrationOptions{Encoding: test.to})
+			require.NoError(t, err)
+			assert.Equal(t, &MigrationReport{Substates: 5, UpdateSets: 3, DestroyedAccounts: 1, Exceptions: 1, Copied: 7}, report)
+
+			checkMigratedDB(t, dst, test.to)
+			hash, err := dst.Get(BlockHashDBKey(1))
+			require.NoError(t, err)
+			assert.Equal(t, types.Hash{1}.Bytes(), hash)
+
+			// the source is left untouched
+			md, err := src.GetMetadata()
+			require.NoError(t, err)
+			assert.Equal(t, test.from, md.EncodingSchema)
+		})
+	}
+}
+
+func TestMigrateEncoding_InPlaceWithTags(t *testing.T) {
+	db := createMigrationTestDB(t, RLPEncodingSchema)
+
+	_, err := MigrateEncoding(db, db, MigrationOptions{Encoding: ProtobufEncodingSchema, Tag: true, BatchSize: 2})
+	require.NoError(t, err)
+	checkMigratedDB(t, db, ProtobufEncodingSchema)
+
+	stats, err := GetEncodingStats(db, 100)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, map[SubstateEncodingSchema]int{ProtobufEncodingSchema: 5}, stats[0].Substates)
+	assert.Equal(t, 8, stats[0].Tagged)
+
+	// tagged records can be read with any configured encoding
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, RLPEncodingSchema)
+	require.NoError(t, err)
+	_, err = sdb.GetSubstate(1, getTestSubstate(RLPEncodingSchema).Transaction)
+	assert.NoError(t, err)
+}
+
+func TestMigrateEncoding_ResumesFromCheckpoint(t *testing.T) {
+	db := createMigrationTestDB(t, RLPEncodingSchema)
+	invalid := SubstateDBKey(3, 7)
+	require.NoError(t, db.Put(invalid, []byte{0xc1}))
+
+	report, err := MigrateEncoding(db, db, MigrationOptions{Encoding: ProtobufEncodingSchema, BatchSize: 1})
+	require.Error(t, err)
+	assert.Equal(t, 4, report.Substates)
+
+	checkpoint, err := getMigrationCheckpoint(db)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, SubstateDBKey(3, getTestSubstate(RLPEncodingSchema).Transaction), checkpoint.Key)
+
+	// other encoding cannot be used until the migration is finished
+	_, err = MigrateEncoding(db, db, MigrationOptions{Encoding: RLPEncodingSchema})
+	assert.ErrorContains(t, err, "unfinished migration to protobuf encoding found")
+
+	require.NoError(t, db.Delete(invalid))
+	report, err = MigrateEncoding(db, db, MigrationOptions{Encoding: ProtobufEncodingSchema, BatchSize: 1})
+	require.NoError(t, err)
+	assert.True(t, report.Resumed)
+	assert.Equal(t, 2, report.Substates)
+	checkMigratedDB(t, db, ProtobufEncodingSchema)
+}
+
+func TestMigrateEncoding_VerificationFails(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// rlp encoding cannot hold all fields of the substate
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(db, ProtobufEncodingSchema)
+	require.NoError(t, err)
+	require.NoError(t, sdb.PutSubstate(getTestSubstate(ProtobufEncodingSchema)))
+
+	_, err = MigrateEncoding(db, db, MigrationOptions{Encoding: RLPEncodingSchema})
+	assert.ErrorContains(t, err, "changed by conversion to rlp")
+
+	// nothing was converted
+	_, err = sdb.GetSubstate(getTestSubstate(ProtobufEncodingSchema).Block, getTestSubstate(ProtobufEncodingSchema).Transaction)
+	assert.NoError(t, err)
+}
+
+func TestMigrateEncoding_LegacyDBGetsMetadata(t *testing.T) {
+	db := createMigrationTestDB(t, RLPEncodingSchema)
+	require.NoError(t, db.Delete([]byte(MetadataKey)))
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, RLPEncodingSchema)
+	require.NoError(t, err)
+	require.NoError(t, udb.PutMetadata(10, 20))
+
+	_, err = MigrateEncoding(db, db, MigrationOptions{Encoding: ProtobufEncodingSchema})
+	require.NoError(t, err)
+	checkMigratedDB(t, db, ProtobufEncodingSchema)
+
+	md, err := db.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(MetadataVersion), md.Version)
+	assert.Equal(t, uint64(10), md.UpdateSetInterval)
+	assert.Equal(t, uint64(20), md.UpdateSetSize)
+}
+
+func TestMigrateEncoding_UnknownEncoding(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = MigrateEncoding(db, db, MigrationOptions{Encoding: "xml"})
+	assert.ErrorContains(t, err, "encoding not supported")
+}
@@ -0,0 +1,479 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/syndtr/goleveldb/leveldb"
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// pebbleCompactLimit is used as the upper bound when compacting the whole key space,
+// as pebble has no notion of an open-ended range. All keys used by the substate DB
+// are shorter than this and start with an ASCII prefix, so none can be larger.
+var pebbleCompactLimit = bytes.Repeat([]byte{0xff}, 32)
+
+// pebbleDB adapts a pebble database to the DbAdapter interface so that it can be used
+// interchangeably with LevelDB. Errors are translated to their LevelDB counterparts
+// (e.g. leveldb.ErrNotFound), hence callers do not need to know which backend is in use.
+type pebbleDB struct {
+	db     *pebble.DB
+	closed atomic.Bool
+}
+
+var _ DbAdapter = (*pebbleDB)(nil)
+
+// pebbleLogger drops informational messages of pebble (e.g. WAL replay on every open),
+// fatal errors are still reported.
+type pebbleLogger struct{}
+
+func (pebbleLogger) Infof(string, ...interface{}) {}
+
+func (pebbleLogger) Fatalf(format string, args ...interface{}) {
+	log.Fatalf(format, args...)
+}
+
+func openPebbleDB(path string, o *opt.Options) (*pebbleDB, error) {
+	options := &pebble.Options{Logger: pebbleLogger{}}
+	if o != nil {
+		options.ReadOnly = o.ReadOnly
+		options.ErrorIfExists = o.ErrorIfExist
+		options.ErrorIfNotExists = o.ErrorIfMissing
+		if o.OpenFilesCacheCapacity > 0 {
+			options.MaxOpenFiles = o.OpenFilesCacheCapacity
+		}
+		if o.WriteBuffer > 0 {
+			options.MemTableSize = uint64(o.WriteBuffer)
+		}
+		if o.BlockCacheCapacity > 0 {
+			cache := pebble.NewCache(int64(o.BlockCacheCapacity))
+			// the DB holds its own reference to the cache
+			defer cache.Unref()
+			options.Cache = cache
+		}
+	}
+
+	db, err := pebble.Open(path, options)
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleDB{db: db}, nil
+}
+
+// writeOptions translates LevelDB write options, LevelDB does not sync by default.
+func (p *pebbleDB) writeOptions(wo *opt.WriteOptions) *pebble.WriteOptions {
+	if wo != nil && wo.Sync {
+		return pebble.Sync
+	}
+	return pebble.NoSync
+}
+
+// translatePebbleError maps pebble errors to the LevelDB errors expected by the callers.
+func translatePebbleError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pebble.ErrNotFound):
+		return leveldb.ErrNotFound
+	case errors.Is(err, pebble.ErrReadOnly):
+		return leveldb.ErrReadOnly
+	default:
+		return err
+	}
+}
+
+func (p *pebbleDB) Delete(key []byte, wo *opt.WriteOptions) error {
+	if p.closed.Load() {
+		return leveldb.ErrClosed
+	}
+	return translatePebbleError(p.db.Delete(key, p.writeOptions(wo)))
+}
+
+// DeleteRange removes all keys in range [start, limit) using a single range tombstone.
+func (p *pebbleDB) DeleteRange(start []byte, limit []byte, wo *opt.WriteOptions) error {
+	if p.closed.Load() {
+		return leveldb.ErrClosed
+	}
+	return translatePebbleError(p.db.DeleteRange(start, limit, p.writeOptions(wo)))
+}
+
+func (p *pebbleDB) Put(key []byte, value []byte, wo *opt.WriteOptions) error {
+	if p.closed.Load() {
+		return leveldb.ErrClosed
+	}
+	return translatePebbleError(p.db.Set(key, value, p.writeOptions(wo)))
+}
+
+// Close closes the database. Same as with LevelDB, closing an already closed database
+// returns leveldb.ErrClosed instead of panicking.
+func (p *pebbleDB) Close() error {
+	if p.closed.Swap(true) {
+		return leveldb.ErrClosed
+	}
+	return p.db.Close()
+}
+
+func (p *pebbleDB) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
+	_, err := p.Get(key, ro)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *pebbleDB) CompactRange(r util.Range) error {
+	if p.closed.Load() {
+		return leveldb.ErrClosed
+	}
+	limit := r.Limit
+	if limit == nil {
+		limit = pebbleCompactLimit
+	}
+	// LevelDB treats empty ranges as a no-op, pebble would return an error
+	if bytes.Compare(r.Start, limit) >= 0 {
+		return nil
+	}
+	return translatePebbleError(p.db.Compact(r.Start, limit, true))
+}
+
+func (p *pebbleDB) Get(key []byte, _ *opt.ReadOptions) ([]byte, error) {
+	if p.closed.Load() {
+		return nil, leveldb.ErrClosed
+	}
+	return pebbleGet(p.db, key)
+}
+
+// GetProperty supports LevelDB "leveldb.stats" property and pebble "pebble.metrics" property,
+// both return formatted pebble metrics.
+func (p *pebbleDB) GetProperty(property string) (string, error) {
+	if p.closed.Load() {
+		return "", leveldb.ErrClosed
+	}
+	switch property {
+	case "leveldb.stats", "pebble.metrics":
+		return p.db.Metrics().String(), nil
+	default:
+		return "", fmt.Errorf("pebble: GetProperty: unknown property: %s", property)
+	}
+}
+
+func (p *pebbleDB) NewIterator(r *util.Range, _ *opt.ReadOptions) ldbiterator.Iterator {
+	if p.closed.Load() {
+		return ldbiterator.NewEmptyIterator(leveldb.ErrClosed)
+	}
+	return pebbleNewIterator(p.db, r)
+}
+
+func (p *pebbleDB) Write(batch *leveldb.Batch, wo *opt.WriteOptions) error {
+	if p.closed.Load() {
+		return leveldb.ErrClosed
+	}
+	b := p.db.NewBatch()
+	defer b.Close()
+
+	replayer := &pebbleBatchReplayer{batch: b}
+	if err := batch.Replay(replayer); err != nil {
+		return err
+	}
+	if replayer.err != nil {
+		return replayer.err
+	}
+	return translatePebbleError(b.Commit(p.writeOptions(wo)))
+}
+
+// newSnapshot returns a read-only DbAdapter on top of a pebble snapshot.
+func (p *pebbleDB) newSnapshot() (DbAdapter, error) {
+	if p.closed.Load() {
+		return nil, fmt.Errorf("cannot get snapshot; %w", leveldb.ErrClosed)
+	}
+	snapshot := p.db.NewSnapshot()
+	return &pebbleSnapshot{
+		parent:   p,
+		snapshot: snapshot,
+		refs:     newSnapshotRefs(snapshot.Close),
+	}, nil
+}
+
+// Stats fills LevelDB statistics with the closest metrics pebble provides.
+func (p *pebbleDB) Stats(s *leveldb.DBStats) error {
+	if p.closed.Load() {
+		return leveldb.ErrClosed
+	}
+	m := p.db.Metrics()
+
+	s.BlockCacheSize = int(m.BlockCache.Size)
+	s.OpenedTablesCount = int(m.TableCache.Count)
+	s.MemComp = uint32(m.Flush.Count)
+	s.IOWrite = m.WAL.BytesWritten
+
+	s.LevelSizes = make(leveldb.Sizes, len(m.Levels))
+	s.LevelTablesCounts = make([]int, len(m.Levels))
+	s.LevelRead = make(leveldb.Sizes, len(m.Levels))
+	s.LevelWrite = make(leveldb.Sizes, len(m.Levels))
+	for i, level := range m.Levels {
+		s.LevelSizes[i] = level.Size
+		s.LevelTablesCounts[i] = int(level.NumFiles)
+		s.LevelRead[i] = int64(level.BytesRead)
+		s.LevelWrite[i] = int64(level.BytesFlushed + level.BytesCompacted)
+		s.IORead += level.BytesRead
+		s.IOWrite += level.BytesFlushed + level.BytesCompacted
+	}
+	s.Level0Comp = uint32(m.Compact.Count)
+	return nil
+}
+
+// pebbleGet reads a copy of the value of given key from reader.
+func pebbleGet(reader pebble.Reader, key []byte) ([]byte, error) {
+	value, closer, err := reader.Get(key)
+	if err != nil {
+		return nil, translatePebbleError(err)
+	}
+	defer closer.Close()
+
+	// value is only valid until closer is closed
+	res := make([]byte, len(value))
+	copy(res, value)
+	return res, nil
+}
+
+// pebbleNewIterator creates a LevelDB iterator over range r of reader.
+func pebbleNewIterator(reader pebble.Reader, r *util.Range) ldbiterator.Iterator {
+	options := &pebble.IterOptions{}
+	if r != nil {
+		options.LowerBound = r.Start
+		options.UpperBound = r.Limit
+	}
+	iter, err := reader.NewIter(options)
+	if err != nil {
+		return ldbiterator.NewEmptyIterator(err)
+	}
+	return newPebbleIterator(iter)
+}
+
+// pebbleSnapshot is a read-only DbAdapter on top of a pebble snapshot.
+// Write operations fail with leveldb.ErrReadOnly, statistics are those of the parent database.
+type pebbleSnapshot struct {
+	parent   *pebbleDB
+	snapshot *pebble.Snapshot
+	refs     *snapshotRefs
+	released atomic.Bool
+}
+
+// newSnapshot shares the pebble snapshot, as its content cannot change anyway.
+func (s *pebbleSnapshot) newSnapshot() (DbAdapter, error) {
+	if s.released.Load() {
+		return nil, fmt.Errorf("cannot get snapshot; %w", leveldb.ErrClosed)
+	}
+	s.refs.acquire()
+	return &pebbleSnapshot{
+		parent:   s.parent,
+		snapshot: s.snapshot,
+		refs:     s.refs,
+	}, nil
+}
+
+func (s *pebbleSnapshot) Delete([]byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (s *pebbleSnapshot) Put([]byte, []byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+// Close releases the snapshot, the parent database stays open.
+func (s *pebbleSnapshot) Close() error {
+	if s.released.Swap(true) {
+		return leveldb.ErrClosed
+	}
+	return s.refs.drop()
+}
+
+func (s *pebbleSnapshot) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
+	_, err := s.Get(key, ro)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompactRange is a no-op as the snapshot cannot change.
+func (s *pebbleSnapshot) CompactRange(util.Range) error {
+	return nil
+}
+
+func (s *pebbleSnapshot) Get(key []byte, _ *opt.ReadOptions) ([]byte, error) {
+	if s.released.Load() {
+		return nil, leveldb.ErrClosed
+	}
+	return pebbleGet(s.snapshot, key)
+}
+
+func (s *pebbleSnapshot) GetProperty(property string) (string, error) {
+	return s.parent.GetProperty(property)
+}
+
+func (s *pebbleSnapshot) NewIterator(r *util.Range, _ *opt.ReadOptions) ldbiterator.Iterator {
+	if s.released.Load() {
+		return ldbiterator.NewEmptyIterator(leveldb.ErrClosed)
+	}
+	return pebbleNewIterator(s.snapshot, r)
+}
+
+func (s *pebbleSnapshot) Write(*leveldb.Batch, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (s *pebbleSnapshot) Stats(stats *leveldb.DBStats) error {
+	return s.parent.Stats(stats)
+}
+
+// pebbleBatchReplayer copies content of a LevelDB batch into a pebble batch.
+type pebbleBatchReplayer struct {
+	batch *pebble.Batch
+	err   error
+}
+
+func (r *pebbleBatchReplayer) Put(key, value []byte) {
+	if r.err != nil {
+		return
+	}
+	r.err = r.batch.Set(key, value, nil)
+}
+
+func (r *pebbleBatchReplayer) Delete(key []byte) {
+	if r.err != nil {
+		return
+	}
+	r.err = r.batch.Delete(key, nil)
+}
+
+// iteratorPosition tracks where a pebbleIterator is, since LevelDB iterators
+// start before the first element and can be moved back from the end.
+type iteratorPosition byte
+
+const (
+	positionStart iteratorPosition = iota
+	positionValid
+	positionEnd
+)
+
+// pebbleIterator implements LevelDB iterator semantics on top of a pebble iterator.
+type pebbleIterator struct {
+	util.BasicReleaser
+	iter     *pebble.Iterator
+	position iteratorPosition
+	released bool
+	err      error
+}
+
+func newPebbleIterator(iter *pebble.Iterator) *pebbleIterator {
+	return &pebbleIterator{iter: iter}
+}
+
+// move updates the position after a movement of the underlying iterator.
+func (i *pebbleIterator) move(ok bool, onFail iteratorPosition) bool {
+	if ok {
+		i.position = positionValid
+	} else {
+		i.position = onFail
+	}
+	return ok
+}
+
+func (i *pebbleIterator) First() bool {
+	if i.released {
+		return false
+	}
+	return i.move(i.iter.First(), positionEnd)
+}
+
+func (i *pebbleIterator) Last() bool {
+	if i.released {
+		return false
+	}
+	return i.move(i.iter.Last(), positionStart)
+}
+
+func (i *pebbleIterator) Seek(key []byte) bool {
+	if i.released {
+		return false
+	}
+	return i.move(i.iter.SeekGE(key), positionEnd)
+}
+
+func (i *pebbleIterator) Next() bool {
+	if i.released {
+		return false
+	}
+	switch i.position {
+	case positionStart:
+		return i.First()
+	case positionEnd:
+		return false
+	default:
+		return i.move(i.iter.Next(), positionEnd)
+	}
+}
+
+func (i *pebbleIterator) Prev() bool {
+	if i.released {
+		return false
+	}
+	switch i.position {
+	case positionEnd:
+		return i.Last()
+	case positionStart:
+		return false
+	default:
+		return i.move(i.iter.Prev(), positionStart)
+	}
+}
+
+func (i *pebbleIterator) Valid() bool {
+	return !i.released && i.position == positionValid
+}
+
+func (i *pebbleIterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.iter.Key()
+}
+
+func (i *pebbleIterator) Value() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.iter.Value()
+}
+
+// Error returns error of the underlying iterator. As with LevelDB, the error
+// remains accessible after the iterator is released.
+func (i *pebbleIterator) Error() error {
+	if i.released {
+		return i.err
+	}
+	return i.iter.Error()
+}
+
+// Release closes the underlying pebble iterator. It can be called multiple times.
+func (i *pebbleIterator) Release() {
+	if i.released {
+		return
+	}
+	i.released = true
+	i.err = i.iter.Close()
+	i.BasicReleaser.Release()
+}
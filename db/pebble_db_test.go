@@ -0,0 +1,256 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+func createPebbleDB(t *testing.T, keys ...string) *pebbleDB {
+	db, err := openPebbleDB(t.TempDir(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	for _, key := range keys {
+		require.NoError(t, db.Put([]byte(key), []byte(key), nil))
+	}
+	return db
+}
+
+func TestPebbleDB_OpenOptions(t *testing.T) {
+	path := t.TempDir() + "/db"
+
+	_, err := openPebbleDB(path, &opt.Options{ErrorIfMissing: true})
+	assert.Error(t, err)
+
+	db, err := openPebbleDB(path, &opt.Options{
+		OpenFilesCacheCapacity: 16,
+		BlockCacheCapacity:     opt.MiB,
+		WriteBuffer:            4 * opt.MiB,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = openPebbleDB(path, &opt.Options{ErrorIfExist: true})
+	assert.Error(t, err)
+}
+
+func TestPebbleDB_GetNotFound(t *testing.T) {
+	db := createPebbleDB(t)
+
+	value, err := db.Get([]byte("missing"), nil)
+	assert.Nil(t, value)
+	assert.Equal(t, leveldb.ErrNotFound, err)
+
+	has, err := db.Has([]byte("missing"), nil)
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestPebbleDB_GetReturnsCopy(t *testing.T) {
+	db := createPebbleDB(t, "key")
+
+	value, err := db.Get([]byte("key"), &opt.ReadOptions{})
+	require.NoError(t, err)
+	value[0] = 'x'
+
+	value, err = db.Get([]byte("key"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), value)
+}
+
+func TestPebbleDB_SyncWrite(t *testing.T) {
+	db := createPebbleDB(t)
+	wo := &opt.WriteOptions{Sync: true}
+
+	require.NoError(t, db.Put([]byte("key"), []byte("value"), wo))
+	require.NoError(t, db.Delete([]byte("key"), wo))
+
+	has, err := db.Has([]byte("key"), nil)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestPebbleDB_ClosedDatabase(t *testing.T) {
+	db := createPebbleDB(t)
+	require.NoError(t, db.Close())
+
+	assert.Equal(t, leveldb.ErrClosed, db.Close())
+	assert.Equal(t, leveldb.ErrClosed, db.Put([]byte("key"), nil, nil))
+	assert.Equal(t, leveldb.ErrClosed, db.Delete([]byte("key"), nil))
+	assert.Equal(t, leveldb.ErrClosed, db.DeleteRange([]byte("a"), []byte("b"), nil))
+	assert.Equal(t, leveldb.ErrClosed, db.CompactRange(util.Range{}))
+	assert.Equal(t, leveldb.ErrClosed, db.Write(new(leveldb.Batch), nil))
+	assert.Equal(t, leveldb.ErrClosed, db.Stats(new(leveldb.DBStats)))
+
+	_, err := db.Get([]byte("key"), nil)
+	assert.Equal(t, leveldb.ErrClosed, err)
+	_, err = db.Has([]byte("key"), nil)
+	assert.Equal(t, leveldb.ErrClosed, err)
+	_, err = db.GetProperty("leveldb.stats")
+	assert.Equal(t, leveldb.ErrClosed, err)
+
+	iter := db.NewIterator(nil, nil)
+	assert.False(t, iter.Next())
+	assert.Equal(t, leveldb.ErrClosed, iter.Error())
+	iter.Release()
+}
+
+func TestPebbleDB_GetProperty(t *testing.T) {
+	db := createPebbleDB(t)
+
+	for _, property := range []string{"leveldb.stats", "pebble.metrics"} {
+		value, err := db.GetProperty(property)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, value)
+	}
+
+	_, err := db.GetProperty("unknown")
+	assert.ErrorContains(t, err, "unknown property")
+}
+
+func TestPebbleDB_CompactRange(t *testing.T) {
+	db := createPebbleDB(t, "a", "b", "c")
+
+	assert.NoError(t, db.CompactRange(util.Range{}))
+	assert.NoError(t, db.CompactRange(util.Range{Start: []byte("a"), Limit: []byte("c")}))
+	// empty range is a no-op
+	assert.NoError(t, db.CompactRange(util.Range{Start: []byte("c"), Limit: []byte("a")}))
+}
+
+func TestPebbleDB_Write(t *testing.T) {
+	db := createPebbleDB(t, "b")
+
+	batch := new(leveldb.Batch)
+	batch.Put([]byte("a"), []byte("a"))
+	batch.Delete([]byte("b"))
+	require.NoError(t, db.Write(batch, nil))
+
+	has, err := db.Has([]byte("a"), nil)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = db.Has([]byte("b"), nil)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestPebbleDB_ReadOnly(t *testing.T) {
+	path := t.TempDir()
+	db, err := openPebbleDB(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = openPebbleDB(path, &opt.Options{ReadOnly: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Put([]byte("key"), []byte("value"), nil)
+	assert.True(t, errors.Is(err, leveldb.ErrReadOnly))
+}
+
+func TestPebbleDB_Stats(t *testing.T) {
+	db := createPebbleDB(t, "a", "b")
+	require.NoError(t, db.CompactRange(util.Range{}))
+
+	s := new(leveldb.DBStats)
+	require.NoError(t, db.Stats(s))
+	assert.NotZero(t, s.IOWrite)
+	assert.Len(t, s.LevelSizes, len(s.LevelTablesCounts))
+	assert.NotZero(t, s.LevelSizes.Sum())
+}
+
+func TestPebbleIterator_Bounds(t *testing.T) {
+	db := createPebbleDB(t, "a", "b", "c", "d")
+
+	iter := db.NewIterator(&util.Range{Start: []byte("b"), Limit: []byte("d")}, nil)
+	defer iter.Release()
+
+	var keys []string
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	assert.Equal(t, []string{"b", "c"}, keys)
+	assert.NoError(t, iter.Error())
+}
+
+func TestPebbleIterator_Movement(t *testing.T) {
+	db := createPebbleDB(t, "a", "b", "c")
+
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	// fresh iterator is positioned before the first element
+	assert.False(t, iter.Valid())
+	assert.Nil(t, iter.Key())
+	assert.Nil(t, iter.Value())
+	assert.False(t, iter.Prev())
+
+	require.True(t, iter.Next())
+	assert.Equal(t, "a", string(iter.Key()))
+	require.True(t, iter.Next())
+	require.True(t, iter.Next())
+	assert.Equal(t, "c", string(iter.Value()))
+
+	// exhausted iterator stays at the end, moving back returns the last element
+	assert.False(t, iter.Next())
+	assert.False(t, iter.Next())
+	assert.False(t, iter.Valid())
+	require.True(t, iter.Prev())
+	assert.Equal(t, "c", string(iter.Key()))
+
+	require.True(t, iter.Seek([]byte("bb")))
+	assert.Equal(t, "c", string(iter.Key()))
+	assert.False(t, iter.Seek([]byte("d")))
+
+	require.True(t, iter.First())
+	assert.False(t, iter.Prev())
+	// iterator moved before the first element, next returns the first element again
+	require.True(t, iter.Next())
+	assert.Equal(t, "a", string(iter.Key()))
+}
+
+func TestPebbleIterator_Release(t *testing.T) {
+	db := createPebbleDB(t, "a")
+
+	released := false
+	iter := db.NewIterator(nil, nil)
+	iter.SetReleaser(releaserFunc(func() { released = true }))
+
+	iter.Release()
+	iter.Release()
+	assert.True(t, released)
+	assert.NoError(t, iter.Error())
+
+	assert.False(t, iter.Valid())
+	assert.False(t, iter.First())
+	assert.False(t, iter.Last())
+	assert.False(t, iter.Seek([]byte("a")))
+	assert.False(t, iter.Next())
+	assert.False(t, iter.Prev())
+	assert.Nil(t, iter.Key())
+}
+
+type releaserFunc func()
+
+func (f releaserFunc) Release() {
+	f()
+}
+
+func TestPebbleDB_DeleteRange(t *testing.T) {
+	db := createPebbleDB(t, "a", "b", "c")
+
+	require.NoError(t, db.DeleteRange([]byte("a"), []byte("c"), nil))
+
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	require.True(t, iter.Next())
+	assert.Equal(t, "c", string(iter.Key()))
+	assert.False(t, iter.Next())
+}
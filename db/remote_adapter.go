@@ -0,0 +1,279 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	pb "github.com/0xsoniclabs/substate/protobuf"
+	"github.com/syndtr/goleveldb/leveldb"
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// remoteAdapter is a read-only DbAdapter reading key-value pairs from a substate server.
+type remoteAdapter struct {
+	conn   *grpc.ClientConn
+	client pb.SubstateServiceClient
+}
+
+func newRemoteAdapter(conn *grpc.ClientConn) *remoteAdapter {
+	return &remoteAdapter{
+		conn:   conn,
+		client: pb.NewSubstateServiceClient(conn),
+	}
+}
+
+func (a *remoteAdapter) Get(key []byte, _ *opt.ReadOptions) ([]byte, error) {
+	res, err := a.client.Get(context.Background(), &pb.KeyRequest{Key: key})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return res.GetValue(), nil
+}
+
+func (a *remoteAdapter) Has(key []byte, _ *opt.ReadOptions) (bool, error) {
+	res, err := a.client.Has(context.Background(), &pb.KeyRequest{Key: key})
+	if err != nil {
+		return false, fromStatusError(err)
+	}
+	return res.GetFound(), nil
+}
+
+func (a *remoteAdapter) NewIterator(r *util.Range, _ *opt.ReadOptions) ldbiterator.Iterator {
+	if r == nil {
+		r = &util.Range{}
+	}
+	return newRemoteIterator(a.client, r)
+}
+
+func (a *remoteAdapter) GetProperty(property string) (string, error) {
+	res, err := a.client.GetProperty(context.Background(), &pb.PropertyRequest{Name: &property})
+	if err != nil {
+		return "", fromStatusError(err)
+	}
+	return res.GetValue(), nil
+}
+
+func (a *remoteAdapter) Stats(*leveldb.DBStats) error {
+	return errors.New("stats are not served by substate server")
+}
+
+func (a *remoteAdapter) Put([]byte, []byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (a *remoteAdapter) Delete([]byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (a *remoteAdapter) Write(*leveldb.Batch, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+// CompactRange is a no-op, the server is responsible for its database.
+func (a *remoteAdapter) CompactRange(util.Range) error {
+	return nil
+}
+
+func (a *remoteAdapter) Close() error {
+	return a.conn.Close()
+}
+
+// toStatusError converts database errors into gRPC status errors sent to remote clients.
+func toStatusError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, leveldb.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// fromStatusError converts gRPC status errors received from the server back into database errors.
+func fromStatusError(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return leveldb.ErrNotFound
+	}
+	return err
+}
+
+type remoteDirection byte
+
+const (
+	remoteDirReleased remoteDirection = iota
+	remoteDirSOI                      // before the first element
+	remoteDirEOI                      // after the last element
+	remoteDirForward
+	remoteDirBackward
+)
+
+// remoteIterator iterates over key-value pairs streamed by a substate server.
+// Pairs are streamed in a single direction, changing the direction or seeking
+// opens a new stream starting at the current key.
+type remoteIterator struct {
+	util.BasicReleaser
+	client pb.SubstateServiceClient
+	r      *util.Range
+	stream grpc.ServerStreamingClient[pb.KeyValue]
+	cancel context.CancelFunc
+	dir    remoteDirection
+	key    []byte
+	value  []byte
+	err    error
+}
+
+func newRemoteIterator(client pb.SubstateServiceClient, r *util.Range) *remoteIterator {
+	return &remoteIterator{
+		client: client,
+		r:      r,
+		dir:    remoteDirSOI,
+	}
+}
+
+// open replaces the current stream by a stream of pairs within [start, limit).
+func (i *remoteIterator) open(start, limit []byte, reverse bool) bool {
+	i.closeStream()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := i.client.Iterate(ctx, &pb.IterateRequest{Start: start, Limit: limit, Reverse: proto.Bool(reverse)})
+	if err != nil {
+		cancel()
+		i.err = fromStatusError(err)
+		i.dir = remoteDirEOI
+		return false
+	}
+	i.stream, i.cancel = stream, cancel
+	if reverse {
+		i.dir = remoteDirBackward
+	} else {
+		i.dir = remoteDirForward
+	}
+	return i.recv()
+}
+
+// recv moves to the next pair of the current stream.
+func (i *remoteIterator) recv() bool {
+	kv, err := i.stream.Recv()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			i.err = fromStatusError(err)
+		}
+		if i.dir == remoteDirBackward {
+			i.dir = remoteDirSOI
+		} else {
+			i.dir = remoteDirEOI
+		}
+		i.key, i.value = nil, nil
+		i.closeStream()
+		return false
+	}
+	i.key, i.value = kv.GetKey(), kv.GetValue()
+	return true
+}
+
+func (i *remoteIterator) closeStream() {
+	if i.cancel != nil {
+		i.cancel()
+		i.stream, i.cancel = nil, nil
+	}
+}
+
+func (i *remoteIterator) First() bool {
+	if i.dir == remoteDirReleased || i.err != nil {
+		return false
+	}
+	return i.open(i.r.Start, i.r.Limit, false)
+}
+
+func (i *remoteIterator) Last() bool {
+	if i.dir == remoteDirReleased || i.err != nil {
+		return false
+	}
+	return i.open(i.r.Start, i.r.Limit, true)
+}
+
+func (i *remoteIterator) Seek(key []byte) bool {
+	if i.dir == remoteDirReleased || i.err != nil {
+		return false
+	}
+	start := i.r.Start
+	if bytes.Compare(key, start) > 0 {
+		start = key
+	}
+	return i.open(start, i.r.Limit, false)
+}
+
+func (i *remoteIterator) Next() bool {
+	if i.err != nil {
+		return false
+	}
+	switch i.dir {
+	case remoteDirReleased, remoteDirEOI:
+		return false
+	case remoteDirSOI:
+		return i.First()
+	case remoteDirBackward:
+		// continue right after the current key
+		return i.open(append(append([]byte{}, i.key...), 0), i.r.Limit, false)
+	default:
+		return i.recv()
+	}
+}
+
+func (i *remoteIterator) Prev() bool {
+	if i.err != nil {
+		return false
+	}
+	switch i.dir {
+	case remoteDirReleased, remoteDirSOI:
+		return false
+	case remoteDirEOI:
+		return i.Last()
+	case remoteDirForward:
+		// continue right before the current key
+		return i.open(i.r.Start, append([]byte{}, i.key...), true)
+	default:
+		return i.recv()
+	}
+}
+
+func (i *remoteIterator) Valid() bool {
+	return i.dir == remoteDirForward || i.dir == remoteDirBackward
+}
+
+func (i *remoteIterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.key
+}
+
+func (i *remoteIterator) Value() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.value
+}
+
+func (i *remoteIterator) Error() error {
+	return i.err
+}
+
+// Release closes the open stream. It can be called multiple times.
+func (i *remoteIterator) Release() {
+	if i.dir == remoteDirReleased {
+		return
+	}
+	i.closeStream()
+	i.key, i.value = nil, nil
+	i.dir = remoteDirReleased
+	i.BasicReleaser.Release()
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func createRemoteTestAdapter(t *testing.T, keys ...string) DbAdapter {
+	mem, err := NewMemoryDB()
+	require.NoError(t, err)
+	// the adapter holds given keys only
+	require.NoError(t, mem.Delete([]byte(MetadataKey)))
+	for _, key := range keys {
+		require.NoError(t, mem.Put([]byte(key), []byte("v"+key)))
+	}
+	sdb, err := MakeDefaultSubstateDBFromBaseDB(mem)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sdb.Close()
+	})
+	return startRemoteTestDB(t, sdb).GetBackend()
+}
+
+func TestRemoteAdapter_GetAndHas(t *testing.T) {
+	adapter := createRemoteTestAdapter(t, "a", "b")
+
+	value, err := adapter.Get([]byte("a"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("va"), value)
+
+	_, err = adapter.Get([]byte("c"), nil)
+	assert.Equal(t, leveldb.ErrNotFound, err)
+
+	has, err := adapter.Has([]byte("b"), nil)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = adapter.Has([]byte("c"), nil)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestRemoteAdapter_IsReadOnly(t *testing.T) {
+	adapter := createRemoteTestAdapter(t, "a")
+
+	assert.Equal(t, leveldb.ErrReadOnly, adapter.Put([]byte("b"), nil, nil))
+	assert.Equal(t, leveldb.ErrReadOnly, adapter.Delete([]byte("a"), nil))
+	assert.Equal(t, leveldb.ErrReadOnly, adapter.Write(new(leveldb.Batch), nil))
+	assert.NoError(t, adapter.CompactRange(util.Range{}))
+	assert.Error(t, adapter.Stats(new(leveldb.DBStats)))
+}
+
+func TestRemoteAdapter_IteratorForwardAndBackward(t *testing.T) {
+	adapter := createRemoteTestAdapter(t, "a", "b", "c", "d", "e")
+
+	iter := adapter.NewIterator(&util.Range{Start: []byte("b"), Limit: []byte("e")}, nil)
+	defer iter.Release()
+
+	var got []string
+	for iter.Next() {
+		got = append(got, string(iter.Key()))
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, got)
+	assert.False(t, iter.Valid())
+
+	// after the end, Prev moves to the last key
+	require.True(t, iter.Prev())
+	assert.Equal(t, "d", string(iter.Key()))
+	assert.Equal(t, "vd", string(iter.Value()))
+	require.True(t, iter.Prev())
+	assert.Equal(t, "c", string(iter.Key()))
+
+	// changing direction continues next to the current key
+	require.True(t, iter.Next())
+	assert.Equal(t, "d", string(iter.Key()))
+	require.True(t, iter.Prev())
+	assert.Equal(t, "c", string(iter.Key()))
+	require.True(t, iter.Prev())
+	assert.Equal(t, "b", string(iter.Key()))
+	assert.False(t, iter.Prev())
+	assert.Nil(t, iter.Key())
+
+	// before the start, Next moves to the first key
+	require.True(t, iter.Next())
+	assert.Equal(t, "b", string(iter.Key()))
+	assert.NoError(t, iter.Error())
+}
+
+func TestRemoteAdapter_IteratorSeekFirstLast(t *testing.T) {
+	adapter := createRemoteTestAdapter(t, "a", "b", "c", "d")
+
+	iter := adapter.NewIterator(util.BytesPrefix(nil), nil)
+	defer iter.Release()
+
+	require.True(t, iter.Last())
+	assert.Equal(t, "d", string(iter.Key()))
+	require.True(t, iter.First())
+	assert.Equal(t, "a", string(iter.Key()))
+	require.True(t, iter.Seek([]byte("bb")))
+	assert.Equal(t, "c", string(iter.Key()))
+	assert.False(t, iter.Seek([]byte("e")))
+
+	// seeking before the start of the range starts at the range
+	iter = adapter.NewIterator(&util.Range{Start: []byte("c")}, nil)
+	defer iter.Release()
+	require.True(t, iter.Seek([]byte("a")))
+	assert.Equal(t, "c", string(iter.Key()))
+}
+
+func TestRemoteAdapter_IteratorRelease(t *testing.T) {
+	adapter := createRemoteTestAdapter(t, "a", "b")
+
+	iter := adapter.NewIterator(nil, nil)
+	require.True(t, iter.Next())
+
+	released := false
+	iter.SetReleaser(releaserFunc(func() { released = true }))
+	iter.Release()
+	iter.Release()
+
+	assert.True(t, released)
+	assert.False(t, iter.Valid())
+	assert.False(t, iter.Next())
+	assert.False(t, iter.First())
+	assert.NoError(t, iter.Error())
+}
+
+func TestRemoteAdapter_IteratorClosedConnection(t *testing.T) {
+	adapter := createRemoteTestAdapter(t, "a", "b")
+	require.NoError(t, adapter.Close())
+
+	iter := adapter.NewIterator(nil, nil)
+	defer iter.Release()
+	assert.False(t, iter.Next())
+	assert.Error(t, iter.Error())
+}
+
+func TestRemoteAdapter_StatusErrors(t *testing.T) {
+	assert.NoError(t, toStatusError(nil))
+	assert.Equal(t, codes.NotFound, status.Code(toStatusError(leveldb.ErrNotFound)))
+	assert.Equal(t, codes.Internal, status.Code(toStatusError(errors.New("failure"))))
+
+	assert.Equal(t, leveldb.ErrNotFound, fromStatusError(status.Error(codes.NotFound, "missing")))
+	err := status.Error(codes.Unavailable, "down")
+	assert.Equal(t, err, fromStatusError(err))
+}
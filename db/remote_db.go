@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	pb "github.com/0xsoniclabs/substate/protobuf"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// RemoteSubstateDB is a read-only SubstateDB served by a substate server, see RegisterSubstateServer.
+// Besides substates and code, it gives access to the other records of the served database.
+type RemoteSubstateDB interface {
+	SubstateDB
+
+	// GetUpdateSet returns update-set for given block.
+	GetUpdateSet(block uint64) (*updateset.UpdateSet, error)
+
+	// GetDestroyedAccounts returns accounts destroyed and resurrected by given transaction.
+	GetDestroyedAccounts(block uint64, tx int) ([]types.Address, []types.Address, error)
+
+	// GetException returns exception for given block, nil if there is none.
+	GetException(block uint64) (*substate.Exception, error)
+
+	// GetBlockHash returns hash of given block.
+	GetBlockHash(block uint64) (types.Hash, error)
+}
+
+// NewRemoteSubstateDB connects to a substate server listening at given address.
+// Without any options, the connection does not use transport security.
+func NewRemoteSubstateDB(address string, opts ...grpc.DialOption) (RemoteSubstateDB, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to substate server %v; %w", address, err)
+	}
+
+	adapter := newRemoteAdapter(conn)
+	db := &remoteSubstateDB{
+		substateDB: &substateDB{&codeDB{backend: adapter, metadata: newMetadataCache(adapter, &opt.Options{ReadOnly: true}, nil, nil)}, nil},
+		client:     adapter.client,
+	}
+	if _, err = db.Has([]byte(SubstateDBPrefix)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot reach substate server %v; %w", address, err)
+	}
+	if err = db.findAndSetEncoding(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set substate encoding: %w", err)
+	}
+	return db, nil
+}
+
+// remoteSubstateDB reads raw records through a remoteAdapter, while substates
+// and other decoded records are read using dedicated calls of the substate server.
+type remoteSubstateDB struct {
+	*substateDB
+	client pb.SubstateServiceClient
+}
+
+func (db *remoteSubstateDB) GetFirstSubstate() *substate.Substate {
+	iter := db.NewSubstateIterator(0, 1)
+
+	defer iter.Release()
+
+	if iter.Next() {
+		return iter.Value()
+	}
+
+	return nil
+}
+
+// GetSubstate returns substate for given block and tx number if exists within DB.
+func (db *remoteSubstateDB) GetSubstate(block uint64, tx int) (*substate.Substate, error) {
+	entry, err := db.client.GetSubstate(context.Background(), &pb.SubstateRequest{
+		Block:       proto.Uint64(block),
+		Transaction: proto.Int64(int64(tx)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get substate block: %v, tx: %v from db; %w", block, tx, fromStatusError(err))
+	}
+	return decodeSubstateEntry(entry)
+}
+
+// GetBlockSubstates returns substates for given block if exists within DB.
+func (db *remoteSubstateDB) GetBlockSubstates(block uint64) (map[int]*substate.Substate, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := db.client.GetBlockSubstates(ctx, &pb.BlockRequest{Block: proto.Uint64(block)})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+
+	txSubstate := make(map[int]*substate.Substate)
+	for {
+		entry, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return txSubstate, nil
+		}
+		if err != nil {
+			return nil, fromStatusError(err)
+		}
+		ss, err := decodeSubstateEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		txSubstate[ss.Transaction] = ss
+	}
+}
+
+// NewSubstateIterator returns iterator which iterates over Substates streamed by the server.
+// Substates are decoded by the server, hence numWorkers is only used to buffer substates.
+func (db *remoteSubstateDB) NewSubstateIterator(start int, numWorkers int) IIterator[*substate.Substate] {
+	iter := newRemoteSubstateIterator(db.client, uint64(start))
+
+	iter.start(numWorkers)
+
+	return iter
+}
+
+func (db *remoteSubstateDB) NewSubstateTaskPool(name string, taskFunc SubstateTaskFunc, first, last uint64, ctx *cli.Context) *SubstateTaskPool {
+	pool := db.substateDB.NewSubstateTaskPool(name, taskFunc, first, last, ctx)
+	pool.DB = db
+	return pool
+}
+
+// GetCode gets the code for the given hash.
+func (db *remoteSubstateDB) GetCode(codeHash types.Hash) ([]byte, error) {
+	if codeHash.IsEmpty() {
+		return nil, ErrorEmptyHash
+	}
+
+	res, err := db.client.GetCode(context.Background(), &pb.CodeRequest{CodeHash: codeHash.Bytes()})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get code %s: %w", codeHash, fromStatusError(err))
+	}
+	return res.GetValue(), nil
+}
+
+func (db *remoteSubstateDB) GetUpdateSet(block uint64) (*updateset.UpdateSet, error) {
+	res, err := db.client.GetUpdateSet(context.Background(), &pb.BlockRequest{Block: proto.Uint64(block)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get updateset block: %v; %w", block, fromStatusError(err))
+	}
+	updateSet, err := updateSetFromMessage(block, codeLookup(res.GetCodes()), res.GetUpdateSet())
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	return updateSet, nil
+}
+
+func (db *remoteSubstateDB) GetDestroyedAccounts(block uint64, tx int) ([]types.Address, []types.Address, error) {
+	res, err := db.client.GetDestroyedAccounts(context.Background(), &pb.SubstateRequest{
+		Block:       proto.Uint64(block),
+		Transaction: proto.Int64(int64(tx)),
+	})
+	if err != nil {
+		return nil, nil, fromStatusError(err)
+	}
+	list := suicidedAccountListFromMessage(res)
+	return list.DestroyedAccounts, list.ResurrectedAccounts, nil
+}
+
+func (db *remoteSubstateDB) GetException(block uint64) (*substate.Exception, error) {
+	res, err := db.client.GetException(context.Background(), &pb.BlockRequest{Block: proto.Uint64(block)})
+	if err != nil {
+		err = fromStatusError(err)
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot get exception for block %d; %w", block, err)
+	}
+	exceptionBlock, err := res.GetException().Decode(codeLookup(res.GetCodes()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode exception data for block %v; %w", block, err)
+	}
+	return &substate.Exception{
+		Block: block,
+		Data:  *exceptionBlock,
+	}, nil
+}
+
+func (db *remoteSubstateDB) GetBlockHash(block uint64) (types.Hash, error) {
+	res, err := db.client.GetBlockHash(context.Background(), &pb.BlockRequest{Block: proto.Uint64(block)})
+	if err != nil {
+		return types.Hash{}, fromStatusError(err)
+	}
+	return types.BytesToHash(res.GetValue()), nil
+}
+
+// decodeSubstateEntry decodes substate using the code attached to entry.
+func decodeSubstateEntry(entry *pb.SubstateEntry) (*substate.Substate, error) {
+	block, tx := entry.GetBlock(), int(entry.GetTransaction())
+	ss, err := entry.GetSubstate().Decode(codeLookup(entry.GetCodes()), block, tx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode substate block %v, tx %v; %w", block, tx, err)
+	}
+	return ss, nil
+}
+
+// codeLookup returns a lookup resolving code hashes of given code.
+func codeLookup(codes [][]byte) func(types.Hash) ([]byte, error) {
+	byHash := make(map[types.Hash][]byte, len(codes))
+	for _, code := range codes {
+		byHash[hash.Keccak256Hash(code)] = code
+	}
+	return func(codeHash types.Hash) ([]byte, error) {
+		code, found := byHash[codeHash]
+		if !found {
+			return nil, leveldb.ErrNotFound
+		}
+		return code, nil
+	}
+}
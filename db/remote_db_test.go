@@ -0,0 +1,290 @@
+package db
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"google.golang.org/grpc"
+)
+
+var remoteTestBlockHash = types.Hash{1, 2}
+
+// createRemoteTestDB fills a substate db with records of all kinds, serves it on a loopback
+// address and returns a client connected to it together with the served db.
+func createRemoteTestDB(t *testing.T) (RemoteSubstateDB, SubstateDB) {
+	local, err := newSubstateDB(t.TempDir(), nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, local.SetSubstateEncoding(ProtobufEncodingSchema))
+	t.Cleanup(func() {
+		_ = local.Close()
+	})
+
+	for _, key := range []struct {
+		block uint64
+		tx    int
+	}{{10, 0}, {10, 1}, {20, 0}} {
+		require.NoError(t, local.PutSubstate(getTestSubstateAt(key.block, key.tx)))
+	}
+
+	updateDB, err := MakeDefaultUpdateDBFromBaseDB(local)
+	require.NoError(t, err)
+	updateSet := &updateset.UpdateSet{
+		WorldState: substate.NewWorldState().Add(types.Address{3}, 3, uint256.NewInt(3), testSubstateCode),
+		Block:      5,
+	}
+	require.NoError(t, updateDB.PutUpdateSet(updateSet, nil))
+
+	destroyedDB, err := MakeDefaultDestroyedAccountDBFromBaseDB(local)
+	require.NoError(t, err)
+	require.NoError(t, destroyedDB.SetDestroyedAccounts(10, 1, []types.Address{{4}}, []types.Address{{5}}))
+
+	preBlock := substate.NewWorldState().Add(types.Address{6}, 6, uint256.NewInt(6), testSubstateCode)
+	require.NoError(t, MakeDefaultExceptionDBFromBaseDB(local).PutException(&substate.Exception{
+		Block: 10,
+		Data: substate.ExceptionBlock{
+			Transactions: map[int]substate.ExceptionTx{1: {VmException: true}},
+			PreBlock:     &preBlock,
+		},
+	}))
+
+	require.NoError(t, SaveBlockHash(local, "0xa", remoteTestBlockHash.String()))
+
+	return startRemoteTestDB(t, local), local
+}
+
+// startRemoteTestDB serves db on a loopback address and returns a client connected to it.
+func startRemoteTestDB(t *testing.T, db SubstateDB) RemoteSubstateDB {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	require.NoError(t, RegisterSubstateServer(server, db))
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	remote, err := NewRemoteSubstateDB(lis.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = remote.Close()
+	})
+	return remote
+}
+
+func TestRemoteSubstateDB_GetSubstate(t *testing.T) {
+	remote, local := createRemoteTestDB(t)
+	assert.Equal(t, ProtobufEncodingSchema, remote.GetSubstateEncoding())
+
+	want, err := local.GetSubstate(10, 1)
+	require.NoError(t, err)
+	got, err := remote.GetSubstate(10, 1)
+	require.NoError(t, err)
+	assert.NoError(t, want.Equal(got))
+	assert.Equal(t, testSubstateCode, got.InputSubstate[types.Address{1}].Code)
+
+	has, err := remote.HasSubstate(10, 1)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = remote.HasSubstate(10, 2)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	_, err = remote.GetSubstate(10, 2)
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+}
+
+func TestRemoteSubstateDB_GetBlockSubstates(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	block, err := remote.GetBlockSubstates(10)
+	require.NoError(t, err)
+	require.Len(t, block, 2)
+	for tx, ss := range block {
+		assert.NoError(t, getTestSubstateAt(10, tx).Equal(ss))
+	}
+
+	block, err = remote.GetBlockSubstates(11)
+	require.NoError(t, err)
+	assert.Empty(t, block)
+}
+
+func TestRemoteSubstateDB_Iterator(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	type key struct {
+		block uint64
+		tx    int
+	}
+	var got []key
+	iter := remote.NewSubstateIterator(0, 2)
+	for iter.Next() {
+		got = append(got, key{iter.Value().Block, iter.Value().Transaction})
+	}
+	iter.Release()
+	require.NoError(t, iter.Error())
+	assert.Equal(t, []key{{10, 0}, {10, 1}, {20, 0}}, got)
+
+	// releasing an unfinished iterator is not an error
+	iter = remote.NewSubstateIterator(15, 1)
+	require.True(t, iter.Next())
+	assert.Equal(t, uint64(20), iter.Value().Block)
+	iter.Release()
+	assert.NoError(t, iter.Error())
+}
+
+func TestRemoteSubstateDB_FirstAndLast(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	first := remote.GetFirstSubstate()
+	require.NotNil(t, first)
+	assert.Equal(t, uint64(10), first.Block)
+	assert.Equal(t, 0, first.Transaction)
+
+	last, err := remote.GetLastSubstate()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), last.Block)
+	assert.Equal(t, 0, last.Transaction)
+}
+
+func TestRemoteSubstateDB_TaskPool(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	var (
+		mu     sync.Mutex
+		blocks = map[uint64]int{}
+	)
+	pool := &SubstateTaskPool{
+		TaskFunc: func(block uint64, tx int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			mu.Lock()
+			defer mu.Unlock()
+			blocks[block]++
+			return nil
+		},
+		First:   0,
+		Last:    30,
+		Workers: 2,
+		DB:      remote,
+	}
+	require.NoError(t, pool.Execute())
+
+	assert.Equal(t, map[uint64]int{10: 2, 20: 1}, blocks)
+}
+
+func TestRemoteSubstateDB_GetCode(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	code, err := remote.GetCode(hash.Keccak256Hash(testSubstateCode))
+	require.NoError(t, err)
+	assert.Equal(t, testSubstateCode, code)
+
+	has, err := remote.HasCode(hash.Keccak256Hash(testSubstateCode))
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	_, err = remote.GetCode(hash.Keccak256Hash([]byte{1}))
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+
+	_, err = remote.GetCode(types.Hash{})
+	assert.Equal(t, ErrorEmptyHash, err)
+}
+
+func TestRemoteSubstateDB_GetUpdateSet(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	updateSet, err := remote.GetUpdateSet(5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), updateSet.Block)
+	assert.Equal(t, testSubstateCode, updateSet.WorldState[types.Address{3}].Code)
+
+	_, err = remote.GetUpdateSet(6)
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+
+	// records can also be read through the raw key-value access
+	updateDB, err := MakeDefaultUpdateDBFromBaseDB(remote)
+	require.NoError(t, err)
+	raw, err := updateDB.GetUpdateSet(5)
+	require.NoError(t, err)
+	assert.True(t, updateSet.Equal(raw))
+}
+
+func TestRemoteSubstateDB_GetDestroyedAccounts(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	destroyed, resurrected, err := remote.GetDestroyedAccounts(10, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Address{{4}}, destroyed)
+	assert.Equal(t, []types.Address{{5}}, resurrected)
+
+	destroyed, resurrected, err = remote.GetDestroyedAccounts(10, 0)
+	require.NoError(t, err)
+	assert.Nil(t, destroyed)
+	assert.Nil(t, resurrected)
+}
+
+func TestRemoteSubstateDB_GetException(t *testing.T) {
+	remote, local := createRemoteTestDB(t)
+
+	want, err := MakeDefaultExceptionDBFromBaseDB(local).GetException(10)
+	require.NoError(t, err)
+	got, err := remote.GetException(10)
+	require.NoError(t, err)
+	assert.True(t, want.Equal(*got))
+	assert.Equal(t, testSubstateCode, (*got.Data.PreBlock)[types.Address{6}].Code)
+
+	got, err = remote.GetException(11)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestRemoteSubstateDB_GetBlockHash(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	got, err := remote.GetBlockHash(10)
+	require.NoError(t, err)
+	assert.Equal(t, remoteTestBlockHash, got)
+
+	_, err = remote.GetBlockHash(11)
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+}
+
+func TestRemoteSubstateDB_IsReadOnly(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	err := remote.PutSubstate(getTestSubstateAt(30, 0))
+	assert.True(t, errors.Is(err, leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(remote.DeleteSubstate(10, 0), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(remote.NewBatch().Write(), leveldb.ErrReadOnly))
+	assert.NoError(t, remote.Compact(nil, nil))
+}
+
+func TestRemoteSubstateDB_Stat(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	stats, err := remote.Stat("leveldb.stats")
+	require.NoError(t, err)
+	assert.Contains(t, stats, "Compactions")
+
+	_, err = remote.Stat("unknown")
+	assert.Error(t, err)
+}
+
+func TestNewRemoteSubstateDB_UnreachableServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	_, err = NewRemoteSubstateDB(address)
+	assert.ErrorContains(t, err, "cannot reach substate server")
+}
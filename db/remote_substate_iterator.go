@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	pb "github.com/0xsoniclabs/substate/protobuf"
+	"github.com/0xsoniclabs/substate/substate"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func newRemoteSubstateIterator(client pb.SubstateServiceClient, start uint64) *remoteSubstateIterator {
+	ctx, cancel := context.WithCancel(context.Background())
+	iter := &remoteSubstateIterator{
+		genericIterator: newIterator[*substate.Substate](nil),
+		cancel:          cancel,
+	}
+	stream, err := client.IterateSubstates(ctx, &pb.BlockRequest{Block: proto.Uint64(start)})
+	if err != nil {
+		iter.setError(fromStatusError(err))
+	}
+	iter.stream = stream
+	return iter
+}
+
+// remoteSubstateIterator iterates over substates streamed by a substate server.
+type remoteSubstateIterator struct {
+	genericIterator[*substate.Substate]
+	stream grpc.ServerStreamingClient[pb.SubstateEntry]
+	cancel context.CancelFunc
+}
+
+// decode decodes a marshalled SubstateEntry.
+func (i *remoteSubstateIterator) decode(data rawEntry) (*substate.Substate, error) {
+	entry := &pb.SubstateEntry{}
+	if err := proto.Unmarshal(data.value, entry); err != nil {
+		return nil, fmt.Errorf("invalid substate entry; %w", err)
+	}
+	return decodeSubstateEntry(entry)
+}
+
+func (i *remoteSubstateIterator) start(numWorkers int) {
+	if i.getError() != nil {
+		close(i.resultCh)
+		return
+	}
+	if numWorkers > 1 {
+		i.resultCh = make(chan *substate.Substate, 10*numWorkers)
+	}
+
+	i.wg.Add(1)
+	go func() {
+		defer func() {
+			close(i.resultCh)
+			i.wg.Done()
+		}()
+		for {
+			entry, err := i.stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				select {
+				case <-i.stopCh:
+					// the stream was cancelled by Release
+				default:
+					i.setError(fromStatusError(err))
+				}
+				return
+			}
+			ss, err := decodeSubstateEntry(entry)
+			if err != nil {
+				i.setError(err)
+				return
+			}
+			select {
+			case <-i.stopCh:
+				return
+			case i.resultCh <- ss:
+			}
+		}
+	}()
+}
+
+// Error returns iterators error if any.
+func (i *remoteSubstateIterator) Error() error {
+	return i.getError()
+}
+
+// Release cancels the stream and waits until the receiving thread is closed gracefully.
+func (i *remoteSubstateIterator) Release() {
+	close(i.stopCh)
+	i.cancel()
+	i.wg.Wait()
+}
@@ -0,0 +1,693 @@
+package db
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ShardManifestFile is the name of the file within the root directory
+// of a sharded database which lists all its shards.
+const ShardManifestFile = "manifest.json"
+
+// blockKeyPrefixes lists prefixes of keys which are followed by a block number (64-bit)
+// and hence can be routed to the shard containing the block.
+var blockKeyPrefixes = []string{
+	SubstateDBPrefix,
+	UpdateDBPrefix,
+	DestroyedAccountPrefix,
+	ExceptionDBPrefix,
+	BlockHashPrefix,
+}
+
+// ShardInfo describes a single shard of a sharded database.
+type ShardInfo struct {
+	First uint64 `json:"first"` // first block stored in the shard
+	Last  uint64 `json:"last"`  // last block stored in the shard (inclusive)
+	Path  string `json:"path"`  // path of the shard, relative to the root directory unless absolute
+}
+
+func (s ShardInfo) contains(block uint64) bool {
+	return s.First <= block && block <= s.Last
+}
+
+func (s ShardInfo) overlaps(o ShardInfo) bool {
+	return s.First <= o.Last && o.First <= s.Last
+}
+
+type shardManifest struct {
+	ShardSize uint64      `json:"shardSize"`
+	Backend   Backend     `json:"backend"`
+	Shards    []ShardInfo `json:"shards"`
+}
+
+type shard struct {
+	ShardInfo
+	db DbAdapter
+}
+
+// shardedAdapter is a DbAdapter distributing keys over shards by block number. Keys
+// with a block number are stored in the shard containing the block, other keys
+// (e.g. code) are written to every shard so that each shard is self-contained.
+// New shards get a copy of the other keys, keys written while there is no shard
+// are kept in memory until the first shard is created.
+type shardedAdapter struct {
+	mu        sync.RWMutex
+	root      string
+	shardSize uint64
+	backend   Backend
+	options   *opt.Options
+	shards    []*shard  // ordered by block
+	pending   *memdb.DB // keys without block number written while there is no shard
+}
+
+// openShardedAdapter opens sharded database at root, creating it if it does not exist.
+func openShardedAdapter(root string, shardSize uint64, backend Backend, o *opt.Options) (*shardedAdapter, error) {
+	options := opt.Options{}
+	if o != nil {
+		options = *o
+	}
+	if backend == "" {
+		backend = DefaultBackend
+	}
+
+	manifest := shardManifest{ShardSize: shardSize, Backend: backend}
+	data, err := os.ReadFile(filepath.Join(root, ShardManifestFile))
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("cannot parse shard manifest; %w", err)
+		}
+		if shardSize != 0 && manifest.ShardSize != shardSize {
+			return nil, fmt.Errorf("shard size mismatch; manifest: %v, requested: %v", manifest.ShardSize, shardSize)
+		}
+		if manifest.Backend != backend {
+			return nil, fmt.Errorf("backend mismatch; manifest: %v, requested: %v", manifest.Backend, backend)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if options.ReadOnly || options.ErrorIfMissing {
+			return nil, fmt.Errorf("cannot read shard manifest; %w", err)
+		}
+		if err = os.MkdirAll(root, 0755); err != nil {
+			return nil, fmt.Errorf("cannot create root directory; %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("cannot read shard manifest; %w", err)
+	}
+
+	a := &shardedAdapter{
+		root:      root,
+		shardSize: manifest.ShardSize,
+		backend:   manifest.Backend,
+		options:   &options,
+		pending:   memdb.New(comparer.DefaultComparer, 0),
+	}
+	for _, info := range manifest.Shards {
+		if err = a.openShard(info); err != nil {
+			a.Close()
+			return nil, err
+		}
+	}
+	if !options.ReadOnly {
+		if err = a.saveManifest(); err != nil {
+			a.Close()
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// openShard opens shard described by info and inserts it among other shards.
+func (a *shardedAdapter) openShard(info ShardInfo) error {
+	if info.First > info.Last {
+		return fmt.Errorf("invalid shard range %v-%v", info.First, info.Last)
+	}
+	for _, s := range a.shards {
+		if s.overlaps(info) {
+			return fmt.Errorf("shard %v-%v overlaps with shard %v-%v", info.First, info.Last, s.First, s.Last)
+		}
+	}
+
+	db, err := OpenBackend(a.backend, a.shardPath(info), a.options)
+	if err != nil {
+		return fmt.Errorf("cannot open shard %v-%v; %w", info.First, info.Last, err)
+	}
+
+	i := sort.Search(len(a.shards), func(i int) bool { return a.shards[i].First > info.First })
+	a.shards = append(a.shards, nil)
+	copy(a.shards[i+1:], a.shards[i:])
+	a.shards[i] = &shard{ShardInfo: info, db: db}
+	return nil
+}
+
+func (a *shardedAdapter) shardPath(info ShardInfo) string {
+	if filepath.IsAbs(info.Path) {
+		return info.Path
+	}
+	return filepath.Join(a.root, info.Path)
+}
+
+// saveManifest atomically replaces the manifest with the current list of shards.
+func (a *shardedAdapter) saveManifest() error {
+	manifest := shardManifest{
+		ShardSize: a.shardSize,
+		Backend:   a.backend,
+		Shards:    a.shardInfos(),
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode shard manifest; %w", err)
+	}
+
+	path := filepath.Join(a.root, ShardManifestFile)
+	if err = os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("cannot write shard manifest; %w", err)
+	}
+	if err = os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("cannot write shard manifest; %w", err)
+	}
+	return nil
+}
+
+func (a *shardedAdapter) shardInfos() []ShardInfo {
+	infos := make([]ShardInfo, len(a.shards))
+	for i, s := range a.shards {
+		infos[i] = s.ShardInfo
+	}
+	return infos
+}
+
+// Shards returns all shards ordered by block.
+func (a *shardedAdapter) Shards() []ShardInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.shardInfos()
+}
+
+// findShard returns the shard containing given block or nil if there is none.
+func (a *shardedAdapter) findShard(block uint64) *shard {
+	i := sort.Search(len(a.shards), func(i int) bool { return a.shards[i].Last >= block })
+	if i < len(a.shards) && a.shards[i].contains(block) {
+		return a.shards[i]
+	}
+	return nil
+}
+
+// shardForWrite returns the shard containing given block, creating a new one if needed.
+func (a *shardedAdapter) shardForWrite(block uint64) (*shard, error) {
+	if a.options.ReadOnly {
+		return nil, leveldb.ErrReadOnly
+	}
+	a.mu.RLock()
+	s := a.findShard(block)
+	a.mu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if s = a.findShard(block); s != nil {
+		return s, nil
+	}
+	if a.shardSize == 0 {
+		return nil, fmt.Errorf("no shard for block %v", block)
+	}
+
+	info := ShardInfo{First: block - block%a.shardSize}
+	info.Last = info.First + (a.shardSize - 1)
+	if info.Last < info.First {
+		info.Last = math.MaxUint64
+	}
+	// new shard must not overlap with attached shards of custom ranges
+	for _, s := range a.shards {
+		if s.Last < block && s.Last >= info.First {
+			info.First = s.Last + 1
+		}
+		if s.First > block && s.First <= info.Last {
+			info.Last = s.First - 1
+		}
+	}
+	info.Path = fmt.Sprintf("%012d-%012d", info.First, info.Last)
+
+	if err := a.openShard(info); err != nil {
+		return nil, err
+	}
+	s = a.findShard(block)
+	if err := a.initShard(s); err != nil {
+		a.removeShard(s)
+		s.db.Close()
+		return nil, err
+	}
+	if err := a.saveManifest(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// initShard copies keys without block number shared by all shards into the new shard s.
+// The first shard gets keys written before it and a new metadata record.
+func (a *shardedAdapter) initShard(s *shard) error {
+	for _, other := range a.shards {
+		if other == s {
+			continue
+		}
+		iter := other.db.NewIterator(nil, nil)
+		defer iter.Release()
+		if err := copySharedKeys(s.db, iter); err != nil {
+			return fmt.Errorf("cannot copy shared keys of shard %v-%v; %w", other.First, other.Last, err)
+		}
+		return nil
+	}
+	if err := initMetadata(s.db, a.options); err != nil {
+		return err
+	}
+	iter := a.pending.NewIterator(nil)
+	defer iter.Release()
+	if err := copySharedKeys(s.db, iter); err != nil {
+		return fmt.Errorf("cannot write keys of sharded db; %w", err)
+	}
+	a.pending.Reset()
+	return nil
+}
+
+// copySharedKeys copies keys without block number of iter into dst, ranges of keys
+// with block number are skipped.
+func copySharedKeys(dst DbAdapter, iter ldbiterator.Iterator) error {
+	batch := newBatch(dst)
+	for ok := iter.First(); ok; {
+		if prefix, found := blockKeyPrefix(iter.Key()); found {
+			ok = iter.Seek(util.BytesPrefix([]byte(prefix)).Limit)
+			continue
+		}
+		if err := batch.Put(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= copyBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		ok = iter.Next()
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// attach adds an existing database at path as shard of blocks first to last.
+func (a *shardedAdapter) attach(path string, first, last uint64) error {
+	if a.options.ReadOnly {
+		return leveldb.ErrReadOnly
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info := ShardInfo{First: first, Last: last, Path: path}
+	if abs, err := filepath.Abs(path); err == nil {
+		info.Path = abs
+		if root, err := filepath.Abs(a.root); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				info.Path = rel
+			}
+		}
+	}
+
+	if err := a.openShard(info); err != nil {
+		return err
+	}
+	s := a.findShard(first)
+	if err := checkShardContent(s); err != nil {
+		a.removeShard(s)
+		s.db.Close()
+		return err
+	}
+	return a.saveManifest()
+}
+
+// checkShardContent checks that the shard does not contain blocks out of its range.
+func checkShardContent(s *shard) error {
+	for _, prefix := range blockKeyPrefixes {
+		iter := s.db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+		for _, move := range []func() bool{iter.First, iter.Last} {
+			if !move() {
+				break
+			}
+			if block, ok := keyBlock(iter.Key()); ok && !s.contains(block) {
+				iter.Release()
+				return fmt.Errorf("shard %v-%v contains block %v", s.First, s.Last, block)
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detach removes the shard containing given block, its data are kept on disk.
+func (a *shardedAdapter) detach(block uint64) (ShardInfo, error) {
+	if a.options.ReadOnly {
+		return ShardInfo{}, leveldb.ErrReadOnly
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.findShard(block)
+	if s == nil {
+		return ShardInfo{}, fmt.Errorf("no shard for block %v", block)
+	}
+	a.removeShard(s)
+	if err := s.db.Close(); err != nil {
+		return ShardInfo{}, fmt.Errorf("cannot close shard %v-%v; %w", s.First, s.Last, err)
+	}
+	return s.ShardInfo, a.saveManifest()
+}
+
+func (a *shardedAdapter) removeShard(s *shard) {
+	for i := range a.shards {
+		if a.shards[i] == s {
+			a.shards = append(a.shards[:i], a.shards[i+1:]...)
+			return
+		}
+	}
+}
+
+// keyBlock returns block number of keys which carry one.
+func keyBlock(key []byte) (uint64, bool) {
+	prefix, ok := blockKeyPrefix(key)
+	if !ok {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(key[len(prefix):]), true
+}
+
+// blockKeyPrefix returns the prefix of keys which carry a block number.
+func blockKeyPrefix(key []byte) (string, bool) {
+	for _, prefix := range blockKeyPrefixes {
+		if len(key) >= len(prefix)+8 && string(key[:len(prefix)]) == prefix {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// allShards returns a copy of current shards, so that shards can be used without holding the lock.
+func (a *shardedAdapter) allShards() []*shard {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]*shard{}, a.shards...)
+}
+
+// newSnapshot pins all shards, the returned adapter is read-only.
+func (a *shardedAdapter) newSnapshot() (DbAdapter, error) {
+	snapshot := &shardedAdapter{
+		root:      a.root,
+		shardSize: a.shardSize,
+		backend:   a.backend,
+		options:   &opt.Options{ReadOnly: true},
+		pending:   memdb.New(comparer.DefaultComparer, 0),
+	}
+	for _, s := range a.allShards() {
+		db, err := snapshotBackend(s.db)
+		if err != nil {
+			snapshot.Close()
+			return nil, fmt.Errorf("cannot get snapshot of shard %v-%v; %w", s.First, s.Last, err)
+		}
+		snapshot.shards = append(snapshot.shards, &shard{ShardInfo: s.ShardInfo, db: db})
+	}
+	return snapshot, nil
+}
+
+func (a *shardedAdapter) Delete(key []byte, wo *opt.WriteOptions) error {
+	if a.options.ReadOnly {
+		return leveldb.ErrReadOnly
+	}
+	if block, ok := keyBlock(key); ok {
+		a.mu.RLock()
+		s := a.findShard(block)
+		a.mu.RUnlock()
+		if s == nil {
+			return nil
+		}
+		return s.db.Delete(key, wo)
+	}
+
+	// keys are deleted while holding the lock so that new shards do not get a copy of them
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.shards) == 0 {
+		if err := a.pending.Delete(key); err != nil && !errors.Is(err, memdb.ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	for _, s := range a.shards {
+		if err := s.db.Delete(key, wo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *shardedAdapter) Put(key []byte, value []byte, wo *opt.WriteOptions) error {
+	if a.options.ReadOnly {
+		return leveldb.ErrReadOnly
+	}
+	if block, ok := keyBlock(key); ok {
+		s, err := a.shardForWrite(block)
+		if err != nil {
+			return err
+		}
+		return s.db.Put(key, value, wo)
+	}
+
+	// keys are written while holding the lock so that new shards do not miss them
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.shards) == 0 {
+		return a.pending.Put(key, value)
+	}
+	for _, s := range a.shards {
+		if err := s.db.Put(key, value, wo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all shards, keys written while there is no shard are lost.
+func (a *shardedAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for _, s := range a.shards {
+		errs = append(errs, s.db.Close())
+	}
+	if n := a.pending.Len(); n > 0 {
+		errs = append(errs, fmt.Errorf("%v keys written before the first block are lost", n))
+		a.pending.Reset()
+	}
+	return errors.Join(errs...)
+}
+
+func (a *shardedAdapter) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
+	_, err := a.Get(key, ro)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (a *shardedAdapter) CompactRange(r util.Range) error {
+	for _, s := range a.allShards() {
+		if err := s.db.CompactRange(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get looks up keys with block number in the shard containing the block,
+// other keys in all shards.
+func (a *shardedAdapter) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
+	if block, ok := keyBlock(key); ok {
+		a.mu.RLock()
+		s := a.findShard(block)
+		a.mu.RUnlock()
+		if s == nil {
+			return nil, leveldb.ErrNotFound
+		}
+		return s.db.Get(key, ro)
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.shards) == 0 {
+		return a.pending.Get(key)
+	}
+	for _, s := range a.shards {
+		value, err := s.db.Get(key, ro)
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		return value, err
+	}
+	return nil, leveldb.ErrNotFound
+}
+
+// GetProperty returns the property of every shard, one after another.
+func (a *shardedAdapter) GetProperty(property string) (string, error) {
+	var sb strings.Builder
+	for _, s := range a.allShards() {
+		value, err := s.db.GetProperty(property)
+		if err != nil {
+			return "", fmt.Errorf("cannot get property of shard %v-%v; %w", s.First, s.Last, err)
+		}
+		fmt.Fprintf(&sb, "Shard %v-%v:\n%s\n", s.First, s.Last, value)
+	}
+	return sb.String(), nil
+}
+
+// NewIterator merges iterators of all shards. Shards hold disjoint block ranges,
+// hence keys are returned in the same order as by a single database.
+func (a *shardedAdapter) NewIterator(r *util.Range, ro *opt.ReadOptions) ldbiterator.Iterator {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.shards) == 0 {
+		return a.pending.NewIterator(r)
+	}
+	shards := a.shards
+	iters := make([]ldbiterator.Iterator, len(shards))
+	for i, s := range shards {
+		iters[i] = s.db.NewIterator(r, ro)
+	}
+	return newUnionIterator(iters)
+}
+
+// Write splits the batch by shards. Note: the batch is atomic within a shard only.
+func (a *shardedAdapter) Write(batch *leveldb.Batch, wo *opt.WriteOptions) error {
+	if a.options.ReadOnly {
+		return leveldb.ErrReadOnly
+	}
+	splitter := &shardBatchSplitter{adapter: a, batches: make(map[*shard]*leveldb.Batch), shared: new(leveldb.Batch)}
+	if err := batch.Replay(splitter); err != nil {
+		return err
+	}
+	if splitter.err != nil {
+		return splitter.err
+	}
+
+	// keys without block number are written while holding the lock so that new shards do not miss them
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if splitter.shared.Len() > 0 {
+		if len(a.shards) == 0 {
+			return splitter.shared.Replay(pendingReplay{a.pending})
+		}
+		for _, sh := range a.shards {
+			if err := splitter.shared.Replay(splitter.batch(sh)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range splitter.shards {
+		if err := s.db.Write(splitter.batches[s], wo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats sums up IO, cache and size statistics of all shards.
+func (a *shardedAdapter) Stats(stats *leveldb.DBStats) error {
+	for _, s := range a.allShards() {
+		var ss leveldb.DBStats
+		if err := s.db.Stats(&ss); err != nil {
+			return fmt.Errorf("cannot get stats of shard %v-%v; %w", s.First, s.Last, err)
+		}
+		addDBStats(stats, &ss)
+	}
+	return nil
+}
+
+// shardBatchSplitter distributes operations of a batch into per-shard batches.
+type shardBatchSplitter struct {
+	adapter *shardedAdapter
+	batches map[*shard]*leveldb.Batch
+	shards  []*shard       // in order of first use
+	shared  *leveldb.Batch // operations on keys without block number
+	err     error
+}
+
+func (s *shardBatchSplitter) batch(sh *shard) *leveldb.Batch {
+	b, ok := s.batches[sh]
+	if !ok {
+		b = new(leveldb.Batch)
+		s.batches[sh] = b
+		s.shards = append(s.shards, sh)
+	}
+	return b
+}
+
+func (s *shardBatchSplitter) Put(key, value []byte) {
+	if s.err != nil {
+		return
+	}
+	if block, ok := keyBlock(key); ok {
+		sh, err := s.adapter.shardForWrite(block)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.batch(sh).Put(key, value)
+		return
+	}
+	s.shared.Put(key, value)
+}
+
+func (s *shardBatchSplitter) Delete(key []byte) {
+	if s.err != nil {
+		return
+	}
+	if block, ok := keyBlock(key); ok {
+		s.adapter.mu.RLock()
+		sh := s.adapter.findShard(block)
+		s.adapter.mu.RUnlock()
+		if sh != nil {
+			s.batch(sh).Delete(key)
+		}
+		return
+	}
+	s.shared.Delete(key)
+}
+
+// pendingReplay applies operations of a batch to keys written while there is no shard.
+type pendingReplay struct {
+	db *memdb.DB
+}
+
+func (p pendingReplay) Put(key, value []byte) {
+	_ = p.db.Put(key, value) // never fails
+}
+
+func (p pendingReplay) Delete(key []byte) {
+	_ = p.db.Delete(key) // a missing key is not an error
+}
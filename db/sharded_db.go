@@ -0,0 +1,104 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// ShardedSubstateDB is a SubstateDB storing contiguous block ranges in separate databases (shards)
+// under one root directory. Shards are listed in a manifest (see ShardManifestFile) and can
+// be attached and detached without rewriting any data. Keys keep the same format and ordering
+// as in a single database, hence all iterators work across shards.
+type ShardedSubstateDB interface {
+	SubstateDB
+
+	// Shards returns all attached shards ordered by block.
+	Shards() []ShardInfo
+
+	// AttachShard attaches an existing database at path as the shard of blocks first to last (inclusive).
+	// The range must not overlap with any other shard and the database must not contain any block out of it.
+	AttachShard(path string, first, last uint64) error
+
+	// DetachShard detaches the shard containing given block. Data of the shard are kept on disk.
+	DetachShard(block uint64) (ShardInfo, error)
+}
+
+// NewDefaultShardedSubstateDB opens or creates sharded SubstateDB at root with default options.
+func NewDefaultShardedSubstateDB(root string, shardSize uint64) (ShardedSubstateDB, error) {
+	return newShardedSubstateDB(root, shardSize, DefaultBackend, nil)
+}
+
+// NewShardedSubstateDB opens or creates sharded SubstateDB at root. Substates of blocks not covered
+// by any shard are written to a new shard of shardSize blocks. If shardSize is 0, the size
+// is read from an existing manifest and new shards are only created by AttachShard.
+// Note: o is nillable, the options are used for every shard.
+func NewShardedSubstateDB(root string, shardSize uint64, backend Backend, o *opt.Options) (ShardedSubstateDB, error) {
+	return newShardedSubstateDB(root, shardSize, backend, o)
+}
+
+func newShardedSubstateDB(root string, shardSize uint64, backend Backend, o *opt.Options) (*shardedSubstateDB, error) {
+	adapter, err := openShardedAdapter(root, shardSize, backend, o)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sharded db %v; %w", root, err)
+	}
+
+	db := &shardedSubstateDB{
+		substateDB: &substateDB{&codeDB{backend: adapter, metadata: newMetadataCache(adapter, o, nil, nil)}, nil},
+		adapter:    adapter,
+	}
+	if err = db.findAndSetEncoding(); err != nil {
+		adapter.Close()
+		return nil, fmt.Errorf("failed to set substate encoding: %w", err)
+	}
+	return db, nil
+}
+
+type shardedSubstateDB struct {
+	*substateDB
+	adapter *shardedAdapter
+}
+
+func (db *shardedSubstateDB) Shards() []ShardInfo {
+	return db.adapter.Shards()
+}
+
+func (db *shardedSubstateDB) AttachShard(path string, first, last uint64) error {
+	md, err := db.GetMetadata()
+	if err != nil {
+		return err
+	}
+	if err = db.adapter.attach(path, first, last); err != nil {
+		return err
+	}
+	if md == nil || md.Version == 0 {
+		return nil
+	}
+
+	// the shared record is extended by the range of the attached shard
+	if md.Blocks == nil {
+		md.Blocks = &BlockRange{First: first, Last: last}
+	}
+	md.Blocks.First = min(md.Blocks.First, first)
+	md.Blocks.Last = max(md.Blocks.Last, last)
+	return db.SetMetadata(md)
+}
+
+func (db *shardedSubstateDB) DetachShard(block uint64) (ShardInfo, error) {
+	return db.adapter.detach(block)
+}
+
+// PutSubstate stores the substate together with its code in the shard containing its block.
+func (db *shardedSubstateDB) PutSubstate(ss *substate.Substate) error {
+	s, err := db.adapter.shardForWrite(ss.Block)
+	if err != nil {
+		return err
+	}
+	// metadata are shared by all shards
+	if err = recordMetadata(db, db.GetSubstateEncoding(), db.encoding.tagged, ss.Block, true); err != nil {
+		return fmt.Errorf("cannot record substate block %v, tx %v in metadata; %w", ss.Block, ss.Transaction, err)
+	}
+	shardDB := &substateDB{&codeDB{backend: s.db}, db.encoding}
+	return shardDB.putSubstate(ss)
+}
@@ -0,0 +1,377 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+func putShardedTestSubstates(t *testing.T, db SubstateDB, blocks ...uint64) {
+	for _, block := range blocks {
+		for tx := 0; tx < 2; tx++ {
+			require.NoError(t, db.PutSubstate(getTestSubstateAt(block, tx)))
+		}
+	}
+}
+
+func createShardedTestDB(t *testing.T, root string, blocks ...uint64) ShardedSubstateDB {
+	db, err := NewDefaultShardedSubstateDB(root, 100)
+	require.NoError(t, err)
+	require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+	putShardedTestSubstates(t, db, blocks...)
+	return db
+}
+
+func TestShardedSubstateDB_RoutesByBlock(t *testing.T) {
+	root := t.TempDir()
+	db := createShardedTestDB(t, root, 5, 99, 100, 250)
+
+	assert.Equal(t, []ShardInfo{
+		{First: 0, Last: 99, Path: "000000000000-000000000099"},
+		{First: 100, Last: 199, Path: "000000000100-000000000199"},
+		{First: 200, Last: 299, Path: "000000000200-000000000299"},
+	}, db.Shards())
+
+	for _, block := range []uint64{5, 99, 100, 250} {
+		ss, err := db.GetSubstate(block, 1)
+		require.NoError(t, err)
+		assert.Equal(t, block, ss.Block)
+	}
+
+	require.NoError(t, db.Close())
+
+	// every shard is a standalone substate db containing its blocks and code only
+	shard, err := NewDefaultSubstateDB(filepath.Join(root, "000000000100-000000000199"))
+	require.NoError(t, err)
+	defer shard.Close()
+	has, err := shard.HasSubstate(100, 0)
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = shard.HasSubstate(99, 0)
+	require.NoError(t, err)
+	assert.False(t, has)
+	ss, err := shard.GetSubstate(100, 1)
+	require.NoError(t, err)
+	assert.NoError(t, getTestSubstateAt(100, 1).Equal(ss))
+}
+
+func TestShardedSubstateDB_IteratesAcrossShards(t *testing.T) {
+	db := createShardedTestDB(t, t.TempDir(), 250, 5, 120)
+	defer db.Close()
+
+	var blocks []uint64
+	iter := db.NewSubstateIterator(0, 2)
+	for iter.Next() {
+		blocks = append(blocks, iter.Value().Block)
+	}
+	iter.Release()
+	require.NoError(t, iter.Error())
+	assert.Equal(t, []uint64{5, 5, 120, 120, 250, 250}, blocks)
+
+	first := db.GetFirstSubstate()
+	require.NotNil(t, first)
+	assert.Equal(t, uint64(5), first.Block)
+
+	last, err := db.GetLastSubstate()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(250), last.Block)
+	assert.Equal(t, 1, last.Transaction)
+
+	substates, err := db.GetBlockSubstates(120)
+	require.NoError(t, err)
+	assert.Len(t, substates, 2)
+}
+
+func TestShardedSubstateDB_Reopen(t *testing.T) {
+	root := t.TempDir()
+	db := createShardedTestDB(t, root, 5, 150)
+	require.NoError(t, db.Close())
+
+	db, err := NewShardedSubstateDB(root, 0, LevelDBBackend, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, db.GetSubstateEncoding())
+	assert.Len(t, db.Shards(), 2)
+
+	// shard size is taken from the manifest
+	putShardedTestSubstates(t, db, 320)
+	assert.Equal(t, ShardInfo{First: 300, Last: 399, Path: "000000000300-000000000399"}, db.Shards()[2])
+	require.NoError(t, db.Close())
+
+	_, err = NewShardedSubstateDB(root, 50, LevelDBBackend, nil)
+	assert.ErrorContains(t, err, "shard size mismatch")
+
+	_, err = NewShardedSubstateDB(root, 100, PebbleBackend, nil)
+	assert.ErrorContains(t, err, "backend mismatch")
+}
+
+func TestShardedSubstateDB_ReadOnly(t *testing.T) {
+	root := t.TempDir()
+	db := createShardedTestDB(t, root, 5)
+	require.NoError(t, db.Close())
+
+	db, err := NewShardedSubstateDB(root, 0, LevelDBBackend, &opt.Options{ReadOnly: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.GetSubstate(5, 0)
+	require.NoError(t, err)
+
+	assert.True(t, errors.Is(db.PutSubstate(getTestSubstateAt(5, 3)), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(db.PutCode([]byte{1}), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(db.DeleteSubstate(5, 0), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(db.NewBatch().Write(), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(db.AttachShard(t.TempDir(), 100, 200), leveldb.ErrReadOnly))
+	_, err = db.DetachShard(5)
+	assert.True(t, errors.Is(err, leveldb.ErrReadOnly))
+
+	_, err = NewShardedSubstateDB(t.TempDir(), 0, LevelDBBackend, &opt.Options{ReadOnly: true})
+	assert.ErrorContains(t, err, "cannot read shard manifest")
+}
+
+func TestShardedSubstateDB_AttachAndDetach(t *testing.T) {
+	dir := t.TempDir()
+
+	// an existing database with blocks 1000-1999 recorded separately
+	external, err := newSubstateDB(dir+"/external", nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, external.SetSubstateEncoding(ProtobufEncodingSchema))
+	putShardedTestSubstates(t, external, 1000, 1500)
+	require.NoError(t, external.Close())
+
+	db := createShardedTestDB(t, dir+"/root", 5)
+	defer db.Close()
+
+	err = db.AttachShard(dir+"/external", 1200, 1999)
+	assert.ErrorContains(t, err, "shard 1200-1999 contains block 1000")
+	err = db.AttachShard(dir+"/external", 50, 1999)
+	assert.ErrorContains(t, err, "overlaps with shard 0-99")
+	err = db.AttachShard(dir+"/external", 2000, 1000)
+	assert.ErrorContains(t, err, "invalid shard range")
+
+	require.NoError(t, db.AttachShard(dir+"/external", 1000, 1999))
+	require.Len(t, db.Shards(), 2)
+	assert.Equal(t, ShardInfo{First: 1000, Last: 1999, Path: dir + "/external"}, db.Shards()[1])
+
+	ss, err := db.GetSubstate(1500, 1)
+	require.NoError(t, err)
+	assert.NoError(t, getTestSubstateAt(1500, 1).Equal(ss))
+
+	last, err := db.GetLastSubstate()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1500), last.Block)
+
+	// new shards do not overlap with attached shards
+	putShardedTestSubstates(t, db, 950, 2050)
+	assert.Equal(t, []ShardInfo{
+		{First: 0, Last: 99, Path: "000000000000-000000000099"},
+		{First: 900, Last: 999, Path: "000000000900-000000000999"},
+		{First: 1000, Last: 1999, Path: dir + "/external"},
+		{First: 2000, Last: 2099, Path: "000000002000-000000002099"},
+	}, db.Shards())
+
+	info, err := db.DetachShard(1700)
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/external", info.Path)
+	has, err := db.HasSubstate(1500, 1)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	_, err = db.DetachShard(1700)
+	assert.ErrorContains(t, err, "no shard for block 1700")
+
+	// detached data are kept on disk
+	external, err = newSubstateDB(dir+"/external", nil, nil, nil)
+	require.NoError(t, err)
+	has, err = external.HasSubstate(1500, 1)
+	require.NoError(t, err)
+	assert.True(t, has)
+	require.NoError(t, external.Close())
+}
+
+func TestShardedSubstateDB_AttachShardWithinRoot(t *testing.T) {
+	root := t.TempDir()
+	external, err := NewDefaultSubstateDB(filepath.Join(root, "imported"))
+	require.NoError(t, err)
+	require.NoError(t, external.Close())
+
+	db, err := NewShardedSubstateDB(root, 0, LevelDBBackend, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.AttachShard(filepath.Join(root, "imported"), 10, 20))
+	assert.Equal(t, []ShardInfo{{First: 10, Last: 20, Path: "imported"}}, db.Shards())
+
+	// without shard size blocks out of shards cannot be written
+	err = db.PutSubstate(getTestSubstateAt(30, 0))
+	assert.ErrorContains(t, err, "no shard for block 30")
+}
+
+func TestShardedSubstateDB_CodeIsWrittenToAllShards(t *testing.T) {
+	db := createShardedTestDB(t, t.TempDir(), 5, 150)
+	defer db.Close()
+
+	code := []byte{0xc0, 0xde}
+	require.NoError(t, db.PutCode(code))
+	for _, info := range db.Shards() {
+		s := db.(*shardedSubstateDB).adapter.findShard(info.First)
+		value, err := s.db.Get(CodeDBKey(hash.Keccak256Hash(code)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, code, value)
+	}
+
+	require.NoError(t, db.DeleteCode(hash.Keccak256Hash(code)))
+	has, err := db.HasCode(hash.Keccak256Hash(code))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestShardedSubstateDB_NewShardGetsSharedKeys(t *testing.T) {
+	db := createShardedTestDB(t, t.TempDir(), 5)
+	defer db.Close()
+
+	code := []byte{0xc0, 0xde}
+	require.NoError(t, db.PutCode(code))
+	require.NoError(t, SaveStateRoot(db, "0x5", "0x01"))
+	putShardedTestSubstates(t, db, 150)
+
+	s := db.(*shardedSubstateDB).adapter.findShard(150)
+	value, err := s.db.Get(CodeDBKey(hash.Keccak256Hash(code)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, code, value)
+	_, err = s.db.Get(StateRootHashDBKey(5), nil)
+	require.NoError(t, err)
+	_, err = s.db.Get([]byte(MetadataKey), nil)
+	require.NoError(t, err)
+	// keys with block number stay in their shard
+	has, err := s.db.Has(SubstateDBKey(5, 0), nil)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestShardedSubstateDB_KeysWrittenBeforeFirstShard(t *testing.T) {
+	root := t.TempDir()
+	db, err := NewDefaultShardedSubstateDB(root, 100)
+	require.NoError(t, err)
+
+	code := []byte{0xc0, 0xde}
+	require.NoError(t, db.PutCode(code))
+	batch := db.NewBatch()
+	require.NoError(t, batch.Put([]byte("key"), []byte("value")))
+	require.NoError(t, batch.Put([]byte("deleted"), []byte("value")))
+	require.NoError(t, batch.Write())
+	require.NoError(t, db.Delete([]byte("deleted")))
+	value, err := db.GetCode(hash.Keccak256Hash(code))
+	require.NoError(t, err)
+	assert.Equal(t, code, value)
+
+	require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+	putShardedTestSubstates(t, db, 5)
+	require.NoError(t, db.Close())
+
+	db, err = NewDefaultShardedSubstateDB(root, 100)
+	require.NoError(t, err)
+	defer db.Close()
+	value, err = db.GetCode(hash.Keccak256Hash(code))
+	require.NoError(t, err)
+	assert.Equal(t, code, value)
+	value, err = db.Get([]byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+	has, err := db.Has([]byte("deleted"))
+	require.NoError(t, err)
+	assert.False(t, has)
+	md, err := db.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, md.EncodingSchema)
+}
+
+func TestShardedSubstateDB_KeysWithoutShardAreLostOnClose(t *testing.T) {
+	db, err := NewDefaultShardedSubstateDB(t.TempDir(), 100)
+	require.NoError(t, err)
+	require.NoError(t, db.PutCode([]byte{1}))
+	assert.ErrorContains(t, db.Close(), "1 keys written before the first block are lost")
+}
+
+func TestShardedSubstateDB_Batch(t *testing.T) {
+	db := createShardedTestDB(t, t.TempDir(), 5, 150)
+	defer db.Close()
+
+	batch := db.NewBatch()
+	require.NoError(t, batch.Put(BlockHashDBKey(180), []byte{1}))
+	require.NoError(t, batch.Put([]byte("other"), []byte{2}))
+	require.NoError(t, batch.Delete(SubstateDBKey(5, 0)))
+	require.NoError(t, batch.Delete(SubstateDBKey(500, 0)))
+	require.NoError(t, batch.Delete([]byte("missing")))
+	require.NoError(t, batch.Write())
+
+	adapter := db.(*shardedSubstateDB).adapter
+	has, err := adapter.findShard(100).db.Has(BlockHashDBKey(180), nil)
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = adapter.findShard(0).db.Has([]byte("other"), nil)
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = db.HasSubstate(5, 0)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestShardedSubstateDB_StatCompactStats(t *testing.T) {
+	db := createShardedTestDB(t, t.TempDir(), 5, 150)
+	defer db.Close()
+
+	require.NoError(t, db.Compact(nil, nil))
+
+	stats, err := db.Stat("leveldb.stats")
+	require.NoError(t, err)
+	assert.Contains(t, stats, "Shard 0-99:")
+	assert.Contains(t, stats, "Shard 100-199:")
+
+	_, err = db.Stat("unknown")
+	assert.ErrorContains(t, err, "cannot get property of shard 0-99")
+
+	s := new(leveldb.DBStats)
+	require.NoError(t, db.stats(s))
+	assert.NotZero(t, s.IOWrite)
+}
+
+func TestShardedSubstateDB_InvalidManifest(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ShardManifestFile), []byte("{"), 0644))
+	_, err := NewDefaultShardedSubstateDB(root, 100)
+	assert.ErrorContains(t, err, "cannot parse shard manifest")
+
+	manifest := `{"shardSize": 100, "backend": "leveldb", "shards": [{"first": 0, "last": 99, "path": "a"}, {"first": 50, "last": 149, "path": "b"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(root, ShardManifestFile), []byte(manifest), 0644))
+	_, err = NewDefaultShardedSubstateDB(root, 100)
+	assert.ErrorContains(t, err, "overlaps with shard 0-99")
+}
+
+func TestKeyBlock(t *testing.T) {
+	tests := []struct {
+		key   []byte
+		block uint64
+		ok    bool
+	}{
+		{SubstateDBKey(7, 1), 7, true},
+		{UpdateDBKey(8), 8, true},
+		{EncodeDestroyedAccountKey(9, 1), 9, true},
+		{BlockHashDBKey(10), 10, true},
+		{append([]byte(ExceptionDBPrefix), BlockToBytes(11)...), 11, true},
+		{CodeDBKey(hash.Keccak256Hash([]byte{1})), 0, false},
+		{[]byte(UpdatesetIntervalKey), 0, false},
+		{[]byte(SubstateDBPrefix), 0, false},
+	}
+	for _, test := range tests {
+		block, ok := keyBlock(test.key)
+		assert.Equal(t, test.ok, ok, "%x", test.key)
+		assert.Equal(t, test.block, block, "%x", test.key)
+	}
+}
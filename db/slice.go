@@ -0,0 +1,286 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultSliceBatchSize is the number of entries written by a single batch of a slice.
+const DefaultSliceBatchSize = 1000
+
+// blockHashHistory is the number of preceding blocks whose hashes are accessible by the BLOCKHASH opcode.
+const blockHashHistory = 256
+
+// SliceOptions configures SliceDatabase.
+type SliceOptions struct {
+	// First and Last are the blocks of the slice (inclusive).
+	First, Last uint64
+
+	// BatchSize is the number of entries written by a single batch,
+	// DefaultSliceBatchSize is used if not set.
+	BatchSize int
+}
+
+// SliceReport summarizes a slice.
+type SliceReport struct {
+	Substates         int `json:"substates"`
+	Code              int `json:"code"`
+	UpdateSets        int `json:"updateSets"`
+	DestroyedAccounts int `json:"destroyedAccounts"`
+	Exceptions        int `json:"exceptions"`
+	BlockHashes       int `json:"blockHashes"`
+	StateRoots        int `json:"stateRoots"`
+}
+
+// SliceDatabase copies records of blocks options.First to options.Last of src into the empty database
+// dst, which can then be used on its own to replay the blocks. Substates, update sets, destroyed accounts
+// and exceptions of the blocks are copied as they are, together with the compression dictionary and
+// exactly the code they reference. Update sets are incremental, hence all update sets up to the last
+// block are copied, the state before the first block is built from the ones preceding it.
+// Block hashes are copied for the blocks and the preceding 256 blocks accessible by the BLOCKHASH
+// opcode, state roots for the blocks and the block before them.
+// The metadata of dst describes the copied blocks.
+//
+// Note: src must not be written to while the slice is copied.
+func SliceDatabase(src, dst BaseDB, options SliceOptions) (*SliceReport, error) {
+	if options.First > options.Last {
+		return nil, fmt.Errorf("first block %v is larger than last block %v", options.First, options.Last)
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultSliceBatchSize
+	}
+	if err := checkEmpty(dst); err != nil {
+		return nil, err
+	}
+	schema, err := getEncodingSchema(src, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &slice{
+		src:         src,
+		dst:         dst,
+		options:     options,
+		compression: newValueCompression(src),
+		code:        make(map[types.Hash]struct{}),
+		batch:       dst.NewBatch(),
+		report:      new(SliceReport),
+	}
+	if err = s.copyDictionary(); err != nil {
+		return nil, err
+	}
+	if err = s.copyRecords(); err != nil {
+		return s.report, err
+	}
+	if err = s.copyCode(); err != nil {
+		return s.report, err
+	}
+	if err = s.copyStateRoots(); err != nil {
+		return s.report, err
+	}
+	if err = s.flush(); err != nil {
+		return s.report, err
+	}
+	if err = s.writeMetadata(schema); err != nil {
+		return s.report, err
+	}
+	return s.report, nil
+}
+
+// checkEmpty returns an error if db contains any entry other than its metadata record.
+func checkEmpty(db BaseDB) error {
+	iter := db.newIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		if string(iter.Key()) != MetadataKey {
+			return errors.New("destination database is not empty")
+		}
+	}
+	return iter.Error()
+}
+
+type slice struct {
+	src, dst    BaseDB
+	options     SliceOptions
+	compression *valueCompression
+
+	// code are hashes of code referenced by copied records
+	code map[types.Hash]struct{}
+
+	batch   Batch
+	pending int
+
+	report *SliceReport
+}
+
+// blockRange returns the range of keys of prefix followed by a block between first and last.
+func blockRange(prefix string, first, last uint64) *util.Range {
+	r := &util.Range{Start: append([]byte(prefix), BlockToBytes(first)...)}
+	if last == math.MaxUint64 {
+		r.Limit = util.BytesPrefix([]byte(prefix)).Limit
+	} else {
+		r.Limit = append([]byte(prefix), BlockToBytes(last+1)...)
+	}
+	return r
+}
+
+func (s *slice) mark(codeHash types.Hash) ([]byte, error) {
+	s.code[codeHash] = struct{}{}
+	return nil, nil
+}
+
+func (s *slice) put(key, value []byte) error {
+	if err := s.batch.Put(key, value); err != nil {
+		return err
+	}
+	if s.pending++; s.pending >= s.options.BatchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *slice) flush() error {
+	if err := s.batch.Write(); err != nil {
+		return fmt.Errorf("cannot write slice; %w", err)
+	}
+	s.batch.Reset()
+	s.pending = 0
+	return nil
+}
+
+// copyDictionary copies the compression dictionary compressed records are stored with.
+func (s *slice) copyDictionary() error {
+	dict, err := GetCompressionDictionary(s.src)
+	if err != nil || dict == nil {
+		return err
+	}
+	return PutCompressionDictionary(s.dst, dict)
+}
+
+// copyRecords copies records of the blocks and marks code they reference.
+func (s *slice) copyRecords() error {
+	first, last := s.options.First, s.options.Last
+	hashesFrom := uint64(0)
+	if first > blockHashHistory {
+		hashesFrom = first - blockHashHistory
+	}
+
+	for _, family := range []struct {
+		prefix      string
+		first, last uint64
+		mark        func(key, value []byte) error
+		count       *int
+	}{
+		{SubstateDBPrefix, first, last, func(key, value []byte) error {
+			return markSubstateCode(s.compression, s.mark, key, value)
+		}, &s.report.Substates},
+		{UpdateDBPrefix, 0, last, func(key, value []byte) error {
+			return markUpdateSetCode(s.compression, s.mark, key, value)
+		}, &s.report.UpdateSets},
+		{DestroyedAccountPrefix, first, last, nil, &s.report.DestroyedAccounts},
+		{ExceptionDBPrefix, first, last, func(key, value []byte) error {
+			return markExceptionCode(s.mark, key, value)
+		}, &s.report.Exceptions},
+		{BlockHashPrefix, hashesFrom, last, nil, &s.report.BlockHashes},
+	} {
+		iter := s.src.newIterator(blockRange(family.prefix, family.first, family.last))
+		for iter.Next() {
+			if family.mark != nil {
+				if err := family.mark(iter.Key(), iter.Value()); err != nil {
+					iter.Release()
+					return fmt.Errorf("cannot copy %v record; %w", family.prefix, err)
+				}
+			}
+			if err := s.put(iter.Key(), iter.Value()); err != nil {
+				iter.Release()
+				return err
+			}
+			*family.count++
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return fmt.Errorf("cannot copy %v records; %w", family.prefix, err)
+		}
+	}
+	return nil
+}
+
+// copyCode copies code referenced by the copied records.
+func (s *slice) copyCode() error {
+	for codeHash := range s.code {
+		key := CodeDBKey(codeHash)
+		has, err := s.src.Has(key)
+		if err != nil {
+			return err
+		}
+		if !has {
+			// some records reference code which is not stored, e.g. init code of failed creations
+			continue
+		}
+		code, err := s.src.Get(key)
+		if err != nil {
+			return err
+		}
+		if err = s.put(key, code); err != nil {
+			return err
+		}
+		s.report.Code++
+	}
+	return nil
+}
+
+// copyStateRoots copies state roots of the blocks and of the block before them. Their keys
+// hold hex encoded block numbers which are not ordered, hence all of them are scanned.
+func (s *slice) copyStateRoots() error {
+	first := s.options.First
+	if first > 0 {
+		first--
+	}
+	return scanPrefix(s.src, StateRootHashPrefix, func(key, value []byte) error {
+		block, err := StateHashKeyToUint64(key)
+		if err != nil {
+			return err
+		}
+		if block < first || block > s.options.Last {
+			return nil
+		}
+		if err = s.put(key, value); err != nil {
+			return err
+		}
+		s.report.StateRoots++
+		return nil
+	})
+}
+
+// writeMetadata writes metadata of the slice based on metadata of the source.
+func (s *slice) writeMetadata(schema SubstateEncodingSchema) error {
+	md := NewMetadata()
+	srcMetadata, err := s.src.GetMetadata()
+	if err != nil {
+		return err
+	}
+	if srcMetadata != nil {
+		md.ChainID = srcMetadata.ChainID
+		md.UpdateSetInterval = srcMetadata.UpdateSetInterval
+		md.UpdateSetSize = srcMetadata.UpdateSetSize
+	}
+	if md.UpdateSetInterval != 0 {
+		udb, err := MakeDefaultUpdateDBFromBaseDB(s.dst)
+		if err != nil {
+			return err
+		}
+		if err = udb.PutMetadata(md.UpdateSetInterval, md.UpdateSetSize); err != nil {
+			return err
+		}
+	}
+	md.EncodingSchema = schema
+	md.SchemaVersion = SubstateSchemaVersion
+	if md.Blocks, err = getSubstateBlockRange(s.dst); err != nil {
+		return err
+	}
+	return s.dst.SetMetadata(md)
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSliceDatabase_CopiesSelfContainedRange(t *testing.T) {
+	src := createMigrationTestDB(t, RLPEncodingSchema)
+	for _, block := range []string{"0x1", "0x3", "0x5"} {
+		require.NoError(t, SaveStateRoot(src, block, types.Hash{1}.String()))
+	}
+	dict, err := TrainCompressionDictionary(src, DefaultCompressionSamples)
+	require.NoError(t, err)
+	require.NoError(t, PutCompressionDictionary(src, dict))
+
+	dst, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer dst.Close()
+
+	report, err := SliceDatabase(src, dst, SliceOptions{First: 2, Last: 4, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Substates)
+	assert.Equal(t, 3, report.UpdateSets)
+	assert.Equal(t, 1, report.DestroyedAccounts)
+	assert.Equal(t, 1, report.Exceptions)
+	assert.Equal(t, 1, report.BlockHashes)
+	assert.Equal(t, 2, report.StateRoots)
+
+	// only code of the sliced blocks is copied
+	for block, want := range map[byte]bool{1: false, 2: true, 4: true, 5: false} {
+		has, err := dst.Has(CodeDBKey(hash.Keccak256Hash([]byte{0x60, block})))
+		require.NoError(t, err)
+		assert.Equal(t, want, has, "code of block %v", block)
+	}
+
+	got, err := GetCompressionDictionary(dst)
+	require.NoError(t, err)
+	assert.Equal(t, dict, got)
+
+	md, err := dst.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, RLPEncodingSchema, md.EncodingSchema)
+	assert.Equal(t, &BlockRange{First: 2, Last: 4}, md.Blocks)
+
+	// the slice is complete on its own
+	fsck, err := CheckIntegrity(dst, FsckOptions{})
+	require.NoError(t, err)
+	assert.True(t, fsck.Healthy())
+	assert.Empty(t, fsck.MissingCode)
+
+	sdb, err := MakeDefaultSubstateDBFromBaseDBWithEncoding(dst, RLPEncodingSchema)
+	require.NoError(t, err)
+	ss, err := sdb.GetSubstate(3, getTestSubstate(RLPEncodingSchema).Transaction)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x60, 3}, ss.InputSubstate[types.Address{1}].Code)
+}
+
+// primeState builds the world state before block from the update sets of db.
+func primeState(t *testing.T, db BaseDB, schema SubstateEncodingSchema, block uint64) substate.WorldState {
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, schema)
+	require.NoError(t, err)
+	ws := substate.NewWorldState()
+	iter := udb.NewUpdateSetIterator(0, block-1)
+	defer iter.Release()
+	for iter.Next() {
+		us := iter.Value()
+		for _, addr := range us.DeletedAccounts {
+			delete(ws, addr)
+		}
+		ws.Merge(us.WorldState)
+	}
+	require.NoError(t, iter.Error())
+	return ws
+}
+
+func TestSliceDatabase_CopiesUpdateSetsBeforeFirstBlock(t *testing.T) {
+	src := createMigrationTestDB(t, ProtobufEncodingSchema)
+	// account 7 is only part of the first update set
+	udb, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(src, ProtobufEncodingSchema)
+	require.NoError(t, err)
+	us := getCompressionTestUpdateSet(1)
+	us.WorldState.Add(types.Address{7}, 1, uint256.NewInt(7), nil)
+	require.NoError(t, udb.PutUpdateSet(us, nil))
+
+	dst, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer dst.Close()
+
+	report, err := SliceDatabase(src, dst, SliceOptions{First: 4, Last: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Substates)
+	assert.Equal(t, 3, report.UpdateSets)
+
+	// the state of block 4 is primed from the slice alone
+	want := primeState(t, src, ProtobufEncodingSchema, 4)
+	require.Contains(t, want, types.Address{7})
+	got := primeState(t, dst, ProtobufEncodingSchema, 4)
+	assert.True(t, want.Equal(got), "got %v, want %v", got, want)
+
+	fsck, err := CheckIntegrity(dst, FsckOptions{})
+	require.NoError(t, err)
+	assert.True(t, fsck.Healthy())
+}
+
+func TestSliceDatabase_KeepsUpdateSetMetadata(t *testing.T) {
+	src := createMigrationTestDB(t, ProtobufEncodingSchema)
+	udb, err := MakeDefaultUpdateDBFromBaseDB(src)
+	require.NoError(t, err)
+	require.NoError(t, udb.PutMetadata(10, 20))
+
+	dst, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer dst.Close()
+
+	_, err = SliceDatabase(src, dst, SliceOptions{First: 5, Last: 10})
+	require.NoError(t, err)
+
+	md, err := dst.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, md.EncodingSchema)
+	assert.Equal(t, &BlockRange{First: 5, Last: 5}, md.Blocks)
+	assert.Equal(t, uint64(10), md.UpdateSetInterval)
+	assert.Equal(t, uint64(20), md.UpdateSetSize)
+}
+
+func TestSliceDatabase_InvalidArguments(t *testing.T) {
+	src := createMigrationTestDB(t, RLPEncodingSchema)
+
+	_, err := SliceDatabase(src, src, SliceOptions{First: 1, Last: 2})
+	assert.ErrorContains(t, err, "destination database is not empty")
+
+	dst, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer dst.Close()
+	_, err = SliceDatabase(src, dst, SliceOptions{First: 3, Last: 2})
+	assert.ErrorContains(t, err, "first block 3 is larger than last block 2")
+}
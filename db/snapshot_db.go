@@ -0,0 +1,201 @@
+package db
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DBSnapshot is a read-only view of a database pinned to the point in time it was taken at,
+// subsequent writes to the database are not visible through it and writes to the snapshot
+// fail with leveldb.ErrReadOnly.
+//
+// SubstateDB, UpdateDB and CodeDB views share the snapshot, hence a whole pass over them
+// (including code lookups while decoding substates) sees one consistent state. Closing the
+// snapshot or any of its views releases it, the database itself stays open.
+type DBSnapshot interface {
+	BaseDB
+
+	// SubstateDB returns a SubstateDB reading from the snapshot.
+	SubstateDB() (SubstateDB, error)
+
+	// UpdateDB returns an UpdateDB reading from the snapshot.
+	UpdateDB() (UpdateDB, error)
+
+	// CodeDB returns a CodeDB reading from the snapshot.
+	CodeDB() CodeDB
+}
+
+// newDBSnapshot pins content of backend, views of the snapshot use given encoding schema.
+func newDBSnapshot(backend DbAdapter, schema SubstateEncodingSchema) (*dbSnapshot, error) {
+	adapter, err := snapshotBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	return &dbSnapshot{
+		codeDB: &codeDB{backend: adapter},
+		schema: schema,
+	}, nil
+}
+
+type dbSnapshot struct {
+	*codeDB
+	schema SubstateEncodingSchema
+}
+
+func (s *dbSnapshot) GetSubstateEncoding() SubstateEncodingSchema {
+	return s.schema
+}
+
+// Snapshot returns another handle of the same snapshot, which has to be closed separately.
+func (s *dbSnapshot) Snapshot() (DBSnapshot, error) {
+	return newDBSnapshot(s.backend, s.schema)
+}
+
+func (s *dbSnapshot) SubstateDB() (SubstateDB, error) {
+	return MakeDefaultSubstateDBFromBaseDBWithEncoding(s, s.schema)
+}
+
+func (s *dbSnapshot) UpdateDB() (UpdateDB, error) {
+	return MakeDefaultUpdateDBFromBaseDBWithEncoding(s, s.schema)
+}
+
+func (s *dbSnapshot) CodeDB() CodeDB {
+	return MakeDefaultCodeDBFromBaseDB(s)
+}
+
+// snapshotter is implemented by DbAdapters able to pin their content.
+type snapshotter interface {
+	// newSnapshot returns a read-only DbAdapter pinned to the current content.
+	newSnapshot() (DbAdapter, error)
+}
+
+// snapshotBackend returns a read-only DbAdapter pinned to the current content of backend.
+func snapshotBackend(backend DbAdapter) (DbAdapter, error) {
+	switch b := backend.(type) {
+	case *leveldb.DB:
+		snapshot, err := b.GetSnapshot()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get snapshot; %w", err)
+		}
+		return newSnapshotAdapter(b, snapshot), nil
+	case snapshotter:
+		return b.newSnapshot()
+	default:
+		return nil, fmt.Errorf("backend %T does not support snapshots", backend)
+	}
+}
+
+// snapshotRefs counts adapters sharing one backend snapshot, the last one closed releases it.
+type snapshotRefs struct {
+	count   atomic.Int32
+	release func() error
+}
+
+func newSnapshotRefs(release func() error) *snapshotRefs {
+	refs := &snapshotRefs{release: release}
+	refs.count.Store(1)
+	return refs
+}
+
+func (r *snapshotRefs) acquire() {
+	r.count.Add(1)
+}
+
+func (r *snapshotRefs) drop() error {
+	if r.count.Add(-1) == 0 {
+		return r.release()
+	}
+	return nil
+}
+
+// snapshotAdapter is a read-only DbAdapter on top of a LevelDB snapshot.
+// Write operations fail with leveldb.ErrReadOnly, statistics are those of the parent database.
+type snapshotAdapter struct {
+	parent   DbAdapter
+	snapshot *leveldb.Snapshot
+	refs     *snapshotRefs
+	released atomic.Bool
+}
+
+func newSnapshotAdapter(parent DbAdapter, snapshot *leveldb.Snapshot) *snapshotAdapter {
+	return &snapshotAdapter{
+		parent:   parent,
+		snapshot: snapshot,
+		refs: newSnapshotRefs(func() error {
+			snapshot.Release()
+			return nil
+		}),
+	}
+}
+
+// newSnapshot shares the LevelDB snapshot, as its content cannot change anyway.
+func (s *snapshotAdapter) newSnapshot() (DbAdapter, error) {
+	if s.released.Load() {
+		return nil, fmt.Errorf("cannot get snapshot; %w", leveldb.ErrClosed)
+	}
+	s.refs.acquire()
+	return &snapshotAdapter{
+		parent:   s.parent,
+		snapshot: s.snapshot,
+		refs:     s.refs,
+	}, nil
+}
+
+func (s *snapshotAdapter) Delete([]byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (s *snapshotAdapter) Put([]byte, []byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+// Close releases the snapshot, the parent database stays open.
+func (s *snapshotAdapter) Close() error {
+	if s.released.Swap(true) {
+		return leveldb.ErrClosed
+	}
+	return s.refs.drop()
+}
+
+func (s *snapshotAdapter) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
+	if s.released.Load() {
+		return false, leveldb.ErrClosed
+	}
+	return s.snapshot.Has(key, ro)
+}
+
+// CompactRange is a no-op as the snapshot cannot change.
+func (s *snapshotAdapter) CompactRange(util.Range) error {
+	return nil
+}
+
+func (s *snapshotAdapter) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
+	if s.released.Load() {
+		return nil, leveldb.ErrClosed
+	}
+	return s.snapshot.Get(key, ro)
+}
+
+func (s *snapshotAdapter) GetProperty(property string) (string, error) {
+	return s.parent.GetProperty(property)
+}
+
+func (s *snapshotAdapter) NewIterator(r *util.Range, ro *opt.ReadOptions) ldbiterator.Iterator {
+	if s.released.Load() {
+		return ldbiterator.NewEmptyIterator(leveldb.ErrClosed)
+	}
+	return s.snapshot.NewIterator(r, ro)
+}
+
+func (s *snapshotAdapter) Write(*leveldb.Batch, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (s *snapshotAdapter) Stats(stats *leveldb.DBStats) error {
+	return s.parent.Stats(stats)
+}
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func TestSubstateDB_SnapshotIsConsistent(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := newSubstateDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+			require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+
+			require.NoError(t, db.PutSubstate(getTestSubstateAt(10, 0)))
+			require.NoError(t, db.PutSubstate(getTestSubstateAt(10, 1)))
+
+			snapshot, err := db.Snapshot()
+			require.NoError(t, err)
+			defer snapshot.Close()
+			assert.Equal(t, ProtobufEncodingSchema, snapshot.GetSubstateEncoding())
+
+			// changes made after the snapshot, including removal of the code
+			require.NoError(t, db.PutSubstate(getTestSubstateAt(10, 2)))
+			require.NoError(t, db.PutSubstate(getTestSubstateAt(20, 0)))
+			require.NoError(t, db.DeleteCode(hash.Keccak256Hash(testSubstateCode)))
+
+			sdb, err := snapshot.SubstateDB()
+			require.NoError(t, err)
+
+			block, err := sdb.GetBlockSubstates(10)
+			require.NoError(t, err)
+			require.Len(t, block, 2)
+			assert.Equal(t, testSubstateCode, block[1].InputSubstate[types.Address{1}].Code)
+
+			last, err := sdb.GetLastSubstate()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(10), last.Block)
+			assert.Equal(t, 1, last.Transaction)
+
+			count := 0
+			iter := sdb.NewSubstateIterator(0, 2)
+			for iter.Next() {
+				count++
+			}
+			iter.Release()
+			require.NoError(t, iter.Error())
+			assert.Equal(t, 2, count)
+
+			code, err := snapshot.CodeDB().GetCode(hash.Keccak256Hash(testSubstateCode))
+			require.NoError(t, err)
+			assert.Equal(t, testSubstateCode, code)
+
+			// the database itself sees the changes
+			last, err = db.GetLastSubstate()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(20), last.Block)
+		})
+	}
+}
+
+func TestSubstateDB_SnapshotIsReadOnly(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir(), nil, nil, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(t, err)
+	sdb, err := snapshot.SubstateDB()
+	require.NoError(t, err)
+
+	assert.True(t, errors.Is(sdb.PutSubstate(getTestSubstateAt(10, 0)), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(sdb.PutCode([]byte{1}), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(snapshot.NewBatch().Write(), leveldb.ErrReadOnly))
+	assert.NoError(t, snapshot.Compact(nil, nil))
+
+	// closing a view releases the snapshot, the database stays open
+	require.NoError(t, sdb.Close())
+	assert.Equal(t, leveldb.ErrClosed, snapshot.Close())
+	_, err = snapshot.Get([]byte("a"))
+	assert.Equal(t, leveldb.ErrClosed, err)
+	assert.NoError(t, db.PutCode([]byte{1}))
+}
+
+func TestUpdateDB_Snapshot(t *testing.T) {
+	db, err := newUpdateDB(t.TempDir(), nil, nil, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+
+	updateSet := &updateset.UpdateSet{
+		WorldState: substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), testSubstateCode),
+		Block:      5,
+	}
+	require.NoError(t, db.PutUpdateSet(updateSet, nil))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	updateSet.Block = 6
+	require.NoError(t, db.PutUpdateSet(updateSet, nil))
+
+	udb, err := snapshot.UpdateDB()
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, udb.GetSubstateEncoding())
+	last, err := udb.GetLastKey()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), last)
+
+	got, err := udb.GetUpdateSet(5)
+	require.NoError(t, err)
+	assert.Equal(t, testSubstateCode, got.WorldState[types.Address{1}].Code)
+}
+
+func TestDBSnapshot_SnapshotOfSnapshot(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			db, err := newCodeDBWithBackend(t.TempDir(), backend, nil, nil, nil)
+			require.NoError(t, err)
+			defer db.Close()
+			require.NoError(t, db.Put([]byte("a"), []byte{1}))
+
+			first, err := db.Snapshot()
+			require.NoError(t, err)
+			require.NoError(t, db.Put([]byte("a"), []byte{2}))
+
+			second, err := first.Snapshot()
+			require.NoError(t, err)
+
+			// the snapshot is released by the last of its handles
+			require.NoError(t, first.Close())
+			assert.Equal(t, leveldb.ErrClosed, first.Close())
+			_, err = first.Snapshot()
+			assert.Error(t, err)
+
+			value, err := second.Get([]byte("a"))
+			require.NoError(t, err)
+			assert.Equal(t, []byte{1}, value)
+			has, err := second.Has([]byte("b"))
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			require.NoError(t, second.Close())
+			iter := second.NewIterator(nil, nil)
+			assert.False(t, iter.Next())
+			assert.Equal(t, leveldb.ErrClosed, iter.Error())
+			iter.Release()
+		})
+	}
+}
+
+func TestShardedSubstateDB_Snapshot(t *testing.T) {
+	db, err := newShardedSubstateDB(t.TempDir(), 10, DefaultBackend, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+	require.NoError(t, db.PutSubstate(getTestSubstateAt(5, 0)))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	// a new shard is not part of the snapshot
+	require.NoError(t, db.PutSubstate(getTestSubstateAt(15, 0)))
+
+	sdb, err := snapshot.SubstateDB()
+	require.NoError(t, err)
+	last, err := sdb.GetLastSubstate()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), last.Block)
+	assert.True(t, errors.Is(sdb.PutSubstate(getTestSubstateAt(6, 0)), leveldb.ErrReadOnly))
+}
+
+func TestUnionAdapter_Snapshot(t *testing.T) {
+	first, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer second.Close()
+	require.NoError(t, second.Put([]byte("a"), []byte{1}))
+
+	adapter, err := newUnionAdapter([]DbAdapter{first.GetBackend(), second.GetBackend()}, PreferFirstLayer)
+	require.NoError(t, err)
+	snapshot, err := (&codeDB{backend: adapter}).Snapshot()
+	require.NoError(t, err)
+
+	require.NoError(t, first.Put([]byte("a"), []byte{2}))
+
+	value, err := snapshot.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	// closing the snapshot keeps the layers open
+	require.NoError(t, snapshot.Close())
+	value, err = first.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, value)
+}
+
+func TestRemoteSubstateDB_SnapshotIsNotSupported(t *testing.T) {
+	remote, _ := createRemoteTestDB(t)
+
+	_, err := remote.Snapshot()
+	assert.ErrorContains(t, err, "does not support snapshots")
+}
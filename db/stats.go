@@ -0,0 +1,515 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultStatsRangeSize is the number of blocks analysed by a single task of CollectStats.
+const DefaultStatsRangeSize = 10_000
+
+// txTypeNames maps transaction types to their names used by DatabaseStats.
+var txTypeNames = map[int]string{
+	substate.LegacyTxType:     "legacy",
+	substate.AccessListTxType: "accessList",
+	substate.DynamicFeeTxType: "dynamicFee",
+	substate.BlobTxType:       "blob",
+	substate.SetCodeTxType:    "setCode",
+}
+
+// codeSizeBuckets are the largest sizes of buckets of the code size distribution,
+// the last one is the maximum contract size set by EIP-170.
+var codeSizeBuckets = []int{128, 1024, 4096, 16384, 24576}
+
+// StatsOptions configures CollectStats.
+type StatsOptions struct {
+	// Blocks restricts records keyed by blocks to the range, all blocks are analysed if not set.
+	Blocks *BlockRange
+
+	// Workers is the number of block ranges analysed in parallel, 1 is used if not set.
+	Workers int
+
+	// RangeSize is the number of blocks analysed by a single task,
+	// DefaultStatsRangeSize is used if not set.
+	RangeSize uint64
+}
+
+// PrefixStats describes entries of a single record family.
+type PrefixStats struct {
+	Keys       int    `json:"keys"`
+	KeyBytes   uint64 `json:"keyBytes"`
+	ValueBytes uint64 `json:"valueBytes"`
+
+	// Blocks is the range of blocks of the entries, nil if the family is not keyed by blocks.
+	Blocks *BlockRange `json:"blocks,omitempty"`
+}
+
+func (s *PrefixStats) add(key, value []byte) {
+	s.Keys++
+	s.KeyBytes += uint64(len(key))
+	s.ValueBytes += uint64(len(value))
+}
+
+func (s *PrefixStats) addBlock(block uint64) {
+	if s.Blocks == nil {
+		s.Blocks = &BlockRange{First: block, Last: block}
+		return
+	}
+	s.Blocks.First = min(s.Blocks.First, block)
+	s.Blocks.Last = max(s.Blocks.Last, block)
+}
+
+func (s *PrefixStats) merge(y *PrefixStats) {
+	s.Keys += y.Keys
+	s.KeyBytes += y.KeyBytes
+	s.ValueBytes += y.ValueBytes
+	if y.Blocks != nil {
+		s.addBlock(y.Blocks.First)
+		s.addBlock(y.Blocks.Last)
+	}
+}
+
+// TxsPerBlockStats describes numbers of transactions of blocks with substates.
+type TxsPerBlockStats struct {
+	Min     int     `json:"min"`
+	Max     int     `json:"max"`
+	Average float64 `json:"average"`
+}
+
+// WorldStateStats holds average sizes of world states of a transaction.
+type WorldStateStats struct {
+	Accounts     float64 `json:"accounts"`
+	StorageSlots float64 `json:"storageSlots"`
+}
+
+// SizeBucket counts code of sizes up to Max bytes which are larger than Max of the previous bucket.
+type SizeBucket struct {
+	// Max is the largest size of the bucket, zero for the last unbounded bucket.
+	Max   int `json:"max,omitempty"`
+	Count int `json:"count"`
+}
+
+// CodeStats describes stored code.
+type CodeStats struct {
+	Count        int          `json:"count"`
+	Bytes        uint64       `json:"bytes"`
+	MinSize      int          `json:"minSize"`
+	MaxSize      int          `json:"maxSize"`
+	AverageSize  float64      `json:"averageSize"`
+	Distribution []SizeBucket `json:"distribution"`
+}
+
+// DatabaseStats is an inventory of a substate database returned by CollectStats.
+type DatabaseStats struct {
+	// Prefixes describes entries of record families by their prefix.
+	Prefixes map[string]*PrefixStats `json:"prefixes"`
+
+	// Blocks and Transactions are numbers of blocks with substates and of substates.
+	Blocks       int              `json:"blocks"`
+	Transactions int              `json:"transactions"`
+	TxsPerBlock  TxsPerBlockStats `json:"txsPerBlock"`
+
+	// TxTypes counts transactions by names of their types.
+	TxTypes map[string]int `json:"txTypes"`
+
+	// Creations, Calls and Transfers count transactions creating a contract, calling
+	// an account with code and calling an account without code respectively.
+	Creations int `json:"creations"`
+	Calls     int `json:"calls"`
+	Transfers int `json:"transfers"`
+
+	InputSubstate  WorldStateStats `json:"inputSubstate"`
+	OutputSubstate WorldStateStats `json:"outputSubstate"`
+
+	Code CodeStats `json:"code"`
+}
+
+// CollectStats walks db and returns an inventory of its records. Records keyed by blocks are
+// analysed by options.Workers workers in parallel, each of them handling options.RangeSize blocks
+// at once. Code, state roots and metadata are analysed by an additional worker.
+//
+// Note: db must not be written to while statistics are collected.
+func CollectStats(db BaseDB, options StatsOptions) (*DatabaseStats, error) {
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.RangeSize == 0 {
+		options.RangeSize = DefaultStatsRangeSize
+	}
+	blocks := options.Blocks
+	if blocks == nil {
+		var err error
+		if blocks, err = getBlockRange(db); err != nil {
+			return nil, err
+		}
+	} else if blocks.First > blocks.Last {
+		return nil, fmt.Errorf("first block %v is larger than last block %v", blocks.First, blocks.Last)
+	}
+
+	c := &statsCollector{
+		db:          db,
+		compression: newValueCompression(db),
+		getCode:     MakeDefaultCodeDBFromBaseDB(db).GetCode,
+		total:       newStats(),
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+		errMu  sync.Mutex
+		err    error
+	)
+	done := func(stats *stats, e error) {
+		errMu.Lock()
+		defer errMu.Unlock()
+		if e != nil {
+			failed.Store(true)
+			if err == nil {
+				err = e
+			}
+			return
+		}
+		c.total.merge(stats)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		done(c.collectOther(options.Blocks))
+	}()
+
+	tasks := make(chan BlockRange, options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if failed.Load() {
+					continue
+				}
+				done(c.collectBlocks(task))
+			}
+		}()
+	}
+	if blocks != nil {
+		for first := blocks.First; !failed.Load(); first += options.RangeSize {
+			last := first + options.RangeSize - 1
+			if last > blocks.Last || last < first {
+				last = blocks.Last
+			}
+			tasks <- BlockRange{First: first, Last: last}
+			if last == blocks.Last {
+				break
+			}
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	return c.total.finish(), nil
+}
+
+// getBlockRange returns the range of blocks of all records keyed by blocks, nil if there are none.
+func getBlockRange(db BaseDB) (*BlockRange, error) {
+	var blocks *BlockRange
+	for _, prefix := range blockKeyPrefixes {
+		iter := db.newIterator(util.BytesPrefix([]byte(prefix)))
+		for _, move := range []func() bool{iter.First, iter.Last} {
+			if !move() {
+				break
+			}
+			block, err := blockOfKey(prefix, iter.Key())
+			if err != nil {
+				iter.Release()
+				return nil, err
+			}
+			if blocks == nil {
+				blocks = &BlockRange{First: block, Last: block}
+			}
+			blocks.First = min(blocks.First, block)
+			blocks.Last = max(blocks.Last, block)
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// blockOfKey returns the block number following prefix in key.
+func blockOfKey(prefix string, key []byte) (uint64, error) {
+	if len(key) < len(prefix)+8 {
+		return 0, fmt.Errorf("invalid %v key: %v", prefix, formatKey(key))
+	}
+	return binary.BigEndian.Uint64(key[len(prefix):]), nil
+}
+
+type statsCollector struct {
+	db          BaseDB
+	compression *valueCompression
+	getCode     codeLookupFunc
+
+	total *stats
+}
+
+// collectBlocks analyses records of blocks keyed by blocks of the range.
+func (c *statsCollector) collectBlocks(blocks BlockRange) (*stats, error) {
+	s := newStats()
+	for _, prefix := range blockKeyPrefixes {
+		visit := func(key, value []byte) error {
+			block, err := blockOfKey(prefix, key)
+			if err != nil {
+				return err
+			}
+			s.addEntry(prefix, key, value)
+			s.Prefixes[prefix].addBlock(block)
+			if prefix == SubstateDBPrefix {
+				return c.addSubstate(s, block, key, value)
+			}
+			return nil
+		}
+		if err := scanRange(c.db, blockRange(prefix, blocks.First, blocks.Last), visit); err != nil {
+			return nil, fmt.Errorf("cannot collect %v stats of blocks %v-%v; %w", prefix, blocks.First, blocks.Last, err)
+		}
+	}
+	s.endBlock()
+	return s, nil
+}
+
+// addSubstate adds a substate record to s.
+func (c *statsCollector) addSubstate(s *stats, block uint64, key, value []byte) error {
+	_, tx, err := DecodeSubstateDBKey(key)
+	if err != nil {
+		return err
+	}
+	if value, err = c.compression.decompress(value); err != nil {
+		return fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	schema, payload, err := recordEncoding(value)
+	if err != nil {
+		return fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	encoding, err := newSubstateEncoding(schema, c.getCode)
+	if err != nil {
+		return err
+	}
+	ss, err := encoding.decode(payload, block, tx)
+	if err != nil {
+		return err
+	}
+	s.addSubstate(block, ss)
+	return nil
+}
+
+// collectOther analyses code, state roots and metadata, state roots are restricted to blocks if set.
+func (c *statsCollector) collectOther(blocks *BlockRange) (*stats, error) {
+	s := newStats()
+	err := scanPrefix(c.db, CodeDBPrefix, func(key, value []byte) error {
+		s.addEntry(CodeDBPrefix, key, value)
+		s.addCode(value)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot collect code stats; %w", err)
+	}
+	err = scanPrefix(c.db, StateRootHashPrefix, func(key, value []byte) error {
+		block, err := StateHashKeyToUint64(key)
+		if err != nil {
+			return err
+		}
+		if blocks != nil && !blocks.Contains(block) {
+			return nil
+		}
+		s.addEntry(StateRootHashPrefix, key, value)
+		s.Prefixes[StateRootHashPrefix].addBlock(block)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot collect state root stats; %w", err)
+	}
+	err = scanPrefix(c.db, MetadataPrefix, func(key, value []byte) error {
+		s.addEntry(MetadataPrefix, key, value)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot collect metadata stats; %w", err)
+	}
+	return s, nil
+}
+
+// stats accumulates DatabaseStats together with the totals its averages are derived from.
+type stats struct {
+	DatabaseStats
+
+	// block and blockTxs are the block currently added and the number of its transactions
+	block    uint64
+	blockTxs int
+
+	inputAccounts, inputSlots   int
+	outputAccounts, outputSlots int
+	codeSizes                   []int
+}
+
+func newStats() *stats {
+	return &stats{
+		DatabaseStats: DatabaseStats{
+			Prefixes: make(map[string]*PrefixStats),
+			TxTypes:  make(map[string]int),
+			Code:     CodeStats{MinSize: math.MaxInt},
+		},
+		codeSizes: make([]int, len(codeSizeBuckets)+1),
+	}
+}
+
+func (s *stats) addEntry(prefix string, key, value []byte) {
+	prefixStats, found := s.Prefixes[prefix]
+	if !found {
+		prefixStats = new(PrefixStats)
+		s.Prefixes[prefix] = prefixStats
+	}
+	prefixStats.add(key, value)
+}
+
+// addSubstate adds a substate of block, substates must be added in block order.
+func (s *stats) addSubstate(block uint64, ss *substate.Substate) {
+	if s.blockTxs > 0 && block != s.block {
+		s.endBlock()
+	}
+	s.block = block
+	s.blockTxs++
+	s.Transactions++
+
+	if msg := ss.Message; msg != nil {
+		name, found := txTypeNames[msg.TxType()]
+		if !found {
+			name = fmt.Sprintf("unknown(%v)", msg.TxType())
+		}
+		s.TxTypes[name]++
+
+		switch {
+		case msg.To == nil:
+			s.Creations++
+		case ss.InputSubstate[*msg.To] != nil && len(ss.InputSubstate[*msg.To].Code) > 0:
+			s.Calls++
+		default:
+			s.Transfers++
+		}
+	}
+
+	s.inputAccounts += len(ss.InputSubstate)
+	for _, account := range ss.InputSubstate {
+		s.inputSlots += len(account.Storage)
+	}
+	s.outputAccounts += len(ss.OutputSubstate)
+	for _, account := range ss.OutputSubstate {
+		s.outputSlots += len(account.Storage)
+	}
+}
+
+// endBlock completes the block currently added.
+func (s *stats) endBlock() {
+	if s.blockTxs == 0 {
+		return
+	}
+	s.addBlockTxs(s.blockTxs)
+	s.blockTxs = 0
+}
+
+func (s *stats) addBlockTxs(txs int) {
+	if s.Blocks == 0 || txs < s.TxsPerBlock.Min {
+		s.TxsPerBlock.Min = txs
+	}
+	s.TxsPerBlock.Max = max(s.TxsPerBlock.Max, txs)
+	s.Blocks++
+}
+
+func (s *stats) addCode(code []byte) {
+	size := len(code)
+	s.Code.Count++
+	s.Code.Bytes += uint64(size)
+	s.Code.MinSize = min(s.Code.MinSize, size)
+	s.Code.MaxSize = max(s.Code.MaxSize, size)
+	bucket := 0
+	for bucket < len(codeSizeBuckets) && size > codeSizeBuckets[bucket] {
+		bucket++
+	}
+	s.codeSizes[bucket]++
+}
+
+// merge adds completed stats y to s.
+func (s *stats) merge(y *stats) {
+	for prefix, prefixStats := range y.Prefixes {
+		if _, found := s.Prefixes[prefix]; !found {
+			s.Prefixes[prefix] = new(PrefixStats)
+		}
+		s.Prefixes[prefix].merge(prefixStats)
+	}
+	if y.Blocks > 0 {
+		if s.Blocks == 0 || y.TxsPerBlock.Min < s.TxsPerBlock.Min {
+			s.TxsPerBlock.Min = y.TxsPerBlock.Min
+		}
+		s.TxsPerBlock.Max = max(s.TxsPerBlock.Max, y.TxsPerBlock.Max)
+	}
+	s.Blocks += y.Blocks
+	s.Transactions += y.Transactions
+	for name, count := range y.TxTypes {
+		s.TxTypes[name] += count
+	}
+	s.Creations += y.Creations
+	s.Calls += y.Calls
+	s.Transfers += y.Transfers
+	s.inputAccounts += y.inputAccounts
+	s.inputSlots += y.inputSlots
+	s.outputAccounts += y.outputAccounts
+	s.outputSlots += y.outputSlots
+
+	s.Code.Count += y.Code.Count
+	s.Code.Bytes += y.Code.Bytes
+	s.Code.MinSize = min(s.Code.MinSize, y.Code.MinSize)
+	s.Code.MaxSize = max(s.Code.MaxSize, y.Code.MaxSize)
+	for i, count := range y.codeSizes {
+		s.codeSizes[i] += count
+	}
+}
+
+// finish derives averages and distributions of s.
+func (s *stats) finish() *DatabaseStats {
+	average := func(total, count int) float64 {
+		if count == 0 {
+			return 0
+		}
+		return float64(total) / float64(count)
+	}
+	s.TxsPerBlock.Average = average(s.Transactions, s.Blocks)
+	s.InputSubstate = WorldStateStats{
+		Accounts:     average(s.inputAccounts, s.Transactions),
+		StorageSlots: average(s.inputSlots, s.Transactions),
+	}
+	s.OutputSubstate = WorldStateStats{
+		Accounts:     average(s.outputAccounts, s.Transactions),
+		StorageSlots: average(s.outputSlots, s.Transactions),
+	}
+
+	if s.Code.Count == 0 {
+		s.Code.MinSize = 0
+	}
+	s.Code.AverageSize = average(int(s.Code.Bytes), s.Code.Count)
+	s.Code.Distribution = make([]SizeBucket, len(s.codeSizes))
+	for i, count := range s.codeSizes {
+		s.Code.Distribution[i].Count = count
+		if i < len(codeSizeBuckets) {
+			s.Code.Distribution[i].Max = codeSizeBuckets[i]
+		}
+	}
+	return &s.DatabaseStats
+}
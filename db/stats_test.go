@@ -0,0 +1,108 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createStatsTestDB creates a db with a creation and a call in block 1 and a transfer in block 3.
+func createStatsTestDB(t *testing.T) MemoryDB {
+	db := newTestDB(t, ProtobufEncodingSchema)
+	for _, tx := range []struct {
+		block uint64
+		tx    int
+		to    *types.Address
+	}{
+		{1, 0, nil},
+		{1, 1, &types.Address{1}},
+		{3, 0, &types.Address{2}},
+	} {
+		ss := getTestSubstate(ProtobufEncodingSchema)
+		ss.Block, ss.Transaction = tx.block, tx.tx
+		ss.Env.Number = tx.block
+		ss.Message.To = tx.to
+		ss.InputSubstate = substate.NewWorldState().
+			Add(types.Address{1}, 1, uint256.NewInt(1), make([]byte, 200)).
+			Add(types.Address{2}, 1, uint256.NewInt(1), nil)
+		ss.InputSubstate[types.Address{1}].Storage[types.Hash{1}] = types.Hash{2}
+		require.NoError(t, db.substates.PutSubstate(ss))
+	}
+	require.NoError(t, db.Put(BlockHashDBKey(2), types.Hash{1}.Bytes()))
+	require.NoError(t, SaveStateRoot(db, "0x3", types.Hash{1}.String()))
+	return db.MemoryDB
+}
+
+func TestCollectStats_DescribesDatabase(t *testing.T) {
+	db := createStatsTestDB(t)
+
+	stats, err := CollectStats(db, StatsOptions{Workers: 2, RangeSize: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, stats.Prefixes[SubstateDBPrefix].Keys)
+	assert.Equal(t, &BlockRange{First: 1, Last: 3}, stats.Prefixes[SubstateDBPrefix].Blocks)
+	assert.Equal(t, &BlockRange{First: 2, Last: 2}, stats.Prefixes[BlockHashPrefix].Blocks)
+	assert.Equal(t, &BlockRange{First: 3, Last: 3}, stats.Prefixes[StateRootHashPrefix].Blocks)
+	assert.Equal(t, 1, stats.Prefixes[MetadataPrefix].Keys)
+	assert.Nil(t, stats.Prefixes[MetadataPrefix].Blocks)
+
+	assert.Equal(t, 2, stats.Blocks)
+	assert.Equal(t, 3, stats.Transactions)
+	assert.Equal(t, TxsPerBlockStats{Min: 1, Max: 2, Average: 1.5}, stats.TxsPerBlock)
+	assert.Equal(t, map[string]int{"setCode": 3}, stats.TxTypes)
+	assert.Equal(t, 1, stats.Creations)
+	assert.Equal(t, 1, stats.Calls)
+	assert.Equal(t, 1, stats.Transfers)
+	assert.Equal(t, WorldStateStats{Accounts: 2, StorageSlots: 1}, stats.InputSubstate)
+	assert.Equal(t, WorldStateStats{Accounts: 1, StorageSlots: 0}, stats.OutputSubstate)
+
+	// empty code, init code of the creation and code of the called account
+	assert.Equal(t, 3, stats.Code.Count)
+	assert.Equal(t, 0, stats.Code.MinSize)
+	assert.Equal(t, 200, stats.Code.MaxSize)
+	assert.Equal(t, []SizeBucket{{128, 2}, {1024, 1}, {4096, 0}, {16384, 0}, {24576, 0}, {0, 0}}, stats.Code.Distribution)
+}
+
+func TestCollectStats_RestrictsBlocks(t *testing.T) {
+	db := createStatsTestDB(t)
+
+	stats, err := CollectStats(db, StatsOptions{Blocks: &BlockRange{First: 2, Last: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Prefixes[SubstateDBPrefix].Keys)
+	assert.Equal(t, 1, stats.Prefixes[BlockHashPrefix].Keys)
+	assert.Equal(t, 1, stats.Transactions)
+	assert.Equal(t, 1, stats.Transfers)
+
+	stats, err = CollectStats(db, StatsOptions{Blocks: &BlockRange{First: 4, Last: 5}})
+	require.NoError(t, err)
+	assert.Nil(t, stats.Prefixes[SubstateDBPrefix])
+	assert.Nil(t, stats.Prefixes[StateRootHashPrefix])
+	assert.Equal(t, TxsPerBlockStats{}, stats.TxsPerBlock)
+
+	_, err = CollectStats(db, StatsOptions{Blocks: &BlockRange{First: 3, Last: 2}})
+	assert.ErrorContains(t, err, "first block 3 is larger than last block 2")
+}
+
+func TestCollectStats_EmptyDatabase(t *testing.T) {
+	db, err := NewMemoryDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stats, err := CollectStats(db, StatsOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, stats.Transactions)
+	assert.Zero(t, stats.Code.MinSize)
+	assert.Empty(t, stats.TxTypes)
+}
+
+func TestCollectStats_ReportsCorruptedSubstate(t *testing.T) {
+	db := createStatsTestDB(t)
+	require.NoError(t, db.Put(SubstateDBKey(2, 0), []byte{0xff}))
+
+	_, err := CollectStats(db, StatsOptions{Workers: 4, RangeSize: 1})
+	assert.Error(t, err)
+}
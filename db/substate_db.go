@@ -6,7 +6,6 @@ import (
 	"log"
 
 	"github.com/0xsoniclabs/substate/substate"
-	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/urfave/cli/v2"
@@ -51,6 +50,12 @@ type SubstateDB interface {
 	// GetSubstateEncoding returns the currently configured encoding
 	GetSubstateEncoding() SubstateEncodingSchema
 
+	// SetEncodingTag enables or disables tagging of written substates with their encoding.
+	// Tagged substates are decoded with their own encoding regardless of the configured one,
+	// hence one db can hold substates of multiple encodings. Untagged substates are decoded
+	// with the configured encoding.
+	SetEncodingTag(enabled bool)
+
 	// decodeSubstate defensively defaults to "default" if nil
 	decodeToSubstate(bytes []byte, block uint64, tx int) (*substate.Substate, error)
 }
@@ -66,8 +71,14 @@ func NewSubstateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.Re
 	return newSubstateDB(path, o, wo, ro)
 }
 
-func MakeDefaultSubstateDB(db *leveldb.DB) (SubstateDB, error) {
-	sdb := &substateDB{&codeDB{db, nil, nil}, nil}
+// NewSubstateDBWithBackend creates new instance of SubstateDB stored in given backend.
+// Note: Any of three options is nillable. If that's the case a default value for the option is set.
+func NewSubstateDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (SubstateDB, error) {
+	return newSubstateDBWithBackend(path, backend, o, wo, ro)
+}
+
+func MakeDefaultSubstateDB(db DbAdapter) (SubstateDB, error) {
+	sdb := &substateDB{&codeDB{backend: db}, nil}
 	err := sdb.findAndSetEncoding()
 	if err != nil {
 		return nil, err
@@ -80,7 +91,7 @@ func MakeDefaultSubstateDBFromBaseDB(db BaseDB) (SubstateDB, error) {
 }
 
 func MakeDefaultSubstateDBFromBaseDBWithEncoding(db BaseDB, schema SubstateEncodingSchema) (SubstateDB, error) {
-	sdb := &substateDB{&codeDB{db.GetBackend(), nil, nil}, nil}
+	sdb := &substateDB{&codeDB{backend: db.GetBackend(), metadata: metadataCacheOf(db)}, nil}
 	err := sdb.SetSubstateEncoding(schema)
 	if err != nil {
 		return nil, err
@@ -93,7 +104,7 @@ func NewReadOnlySubstateDB(path string) (SubstateDB, error) {
 	return newSubstateDB(path, &opt.Options{ReadOnly: true}, nil, nil)
 }
 
-func MakeSubstateDB(db *leveldb.DB, wo *opt.WriteOptions, ro *opt.ReadOptions) (SubstateDB, error) {
+func MakeSubstateDB(db DbAdapter, wo *opt.WriteOptions, ro *opt.ReadOptions) (SubstateDB, error) {
 	sdb := &substateDB{&codeDB{backend: db, wo: wo, ro: ro}, nil}
 	err := sdb.findAndSetEncoding()
 	if err != nil {
@@ -103,7 +114,11 @@ func MakeSubstateDB(db *leveldb.DB, wo *opt.WriteOptions, ro *opt.ReadOptions) (
 }
 
 func newSubstateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*substateDB, error) {
-	base, err := newCodeDB(path, o, wo, ro)
+	return newSubstateDBWithBackend(path, DefaultBackend, o, wo, ro)
+}
+
+func newSubstateDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*substateDB, error) {
+	base, err := newCodeDBWithBackend(path, backend, o, wo, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +126,7 @@ func newSubstateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.Re
 	sdb := &substateDB{base, nil}
 	err = sdb.findAndSetEncoding()
 	if err != nil {
+		base.Close()
 		return nil, fmt.Errorf("failed to set substate encoding: %w", err)
 	}
 	return sdb, nil
@@ -121,8 +137,20 @@ type substateDB struct {
 	encoding *substateEncoding
 }
 
-// findAndSetEncoding finds the encoding of the substateDB and sets it.
+// findAndSetEncoding finds the encoding of the substateDB and sets it. The encoding
+// is read from metadata if recorded, otherwise it is found by decoding the first substate.
 func (db *substateDB) findAndSetEncoding() error {
+	md, err := db.GetMetadata()
+	if err != nil {
+		return err
+	}
+	if md != nil && md.EncodingSchema != "" {
+		if err = db.SetSubstateEncoding(md.EncodingSchema); err != nil {
+			return err
+		}
+		return db.validateMetadata(md)
+	}
+
 	for _, encoding := range allSubstateEncodings {
 		if err := db.SetSubstateEncoding(encoding); err != nil {
 			return err
@@ -137,6 +165,42 @@ func (db *substateDB) findAndSetEncoding() error {
 	return db.SetSubstateEncoding(DefaultEncodingSchema)
 }
 
+// validateMetadata checks that substates of the db can be decoded with the recorded
+// encoding and extends the recorded block range to cover them. The range lags behind
+// the substates if the db was not closed after writing them.
+func (db *substateDB) validateMetadata(md *Metadata) error {
+	if !db.hasKeyValuesFor([]byte(SubstateDBPrefix), nil) {
+		return nil
+	}
+	first := db.GetFirstSubstate()
+	if first == nil {
+		return fmt.Errorf("substates cannot be decoded with recorded %v encoding", md.EncodingSchema)
+	}
+	last, err := db.GetLastSubstate()
+	if err != nil {
+		return fmt.Errorf("substates cannot be decoded with recorded %v encoding; %w", md.EncodingSchema, err)
+	}
+	for _, block := range []uint64{first.Block, last.Block} {
+		if err = recordMetadata(db, md.EncodingSchema, true, block, true); err != nil {
+			return fmt.Errorf("cannot repair recorded block range; %w", err)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns a read-only view pinned to the current content, substates
+// read through it are decoded with code of the same point in time.
+func (db *substateDB) Snapshot() (DBSnapshot, error) {
+	if err := metadataCacheOf(db).flush(); err != nil {
+		return nil, err
+	}
+	return newDBSnapshot(db.GetBackend(), db.GetSubstateEncoding())
+}
+
+func (db *substateDB) metadataCache() *metadataCache {
+	return metadataCacheOf(db.CodeDB)
+}
+
 func (db *substateDB) GetFirstSubstate() *substate.Substate {
 	iter := db.NewSubstateIterator(0, 1)
 
@@ -202,6 +266,14 @@ func (db *substateDB) GetBlockSubstates(block uint64) (map[int]*substate.Substat
 }
 
 func (db *substateDB) PutSubstate(ss *substate.Substate) error {
+	if err := recordMetadata(db, db.GetSubstateEncoding(), db.encoding.tagged, ss.Block, true); err != nil {
+		return fmt.Errorf("cannot record substate block %v, tx %v in metadata; %w", ss.Block, ss.Transaction, err)
+	}
+	return db.putSubstate(ss)
+}
+
+// putSubstate stores the substate together with its code without updating metadata.
+func (db *substateDB) putSubstate(ss *substate.Substate) error {
 	for i, account := range ss.InputSubstate {
 		err := db.PutCode(account.Code)
 		if err != nil {
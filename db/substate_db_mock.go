@@ -203,6 +203,21 @@ func (mr *MockSubstateDBMockRecorder) GetLastSubstate() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastSubstate", reflect.TypeOf((*MockSubstateDB)(nil).GetLastSubstate))
 }
 
+// GetMetadata mocks base method.
+func (m *MockSubstateDB) GetMetadata() (*Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(*Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockSubstateDBMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockSubstateDB)(nil).GetMetadata))
+}
+
 // GetSubstate mocks base method.
 func (m *MockSubstateDB) GetSubstate(block uint64, tx int) (*substate.Substate, error) {
 	m.ctrl.T.Helper()
@@ -362,17 +377,43 @@ func (mr *MockSubstateDBMockRecorder) PutCode(arg0 any) *gomock.Call {
 }
 
 // PutSubstate mocks base method.
-func (m *MockSubstateDB) PutSubstate(arg0 *substate.Substate) error {
+func (m *MockSubstateDB) PutSubstate(substate *substate.Substate) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PutSubstate", arg0)
+	ret := m.ctrl.Call(m, "PutSubstate", substate)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PutSubstate indicates an expected call of PutSubstate.
-func (mr *MockSubstateDBMockRecorder) PutSubstate(arg0 any) *gomock.Call {
+func (mr *MockSubstateDBMockRecorder) PutSubstate(substate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutSubstate", reflect.TypeOf((*MockSubstateDB)(nil).PutSubstate), substate)
+}
+
+// SetEncodingTag mocks base method.
+func (m *MockSubstateDB) SetEncodingTag(enabled bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetEncodingTag", enabled)
+}
+
+// SetEncodingTag indicates an expected call of SetEncodingTag.
+func (mr *MockSubstateDBMockRecorder) SetEncodingTag(enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncodingTag", reflect.TypeOf((*MockSubstateDB)(nil).SetEncodingTag), enabled)
+}
+
+// SetMetadata mocks base method.
+func (m *MockSubstateDB) SetMetadata(md *Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", md)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockSubstateDBMockRecorder) SetMetadata(md any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutSubstate", reflect.TypeOf((*MockSubstateDB)(nil).PutSubstate), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockSubstateDB)(nil).SetMetadata), md)
 }
 
 // SetSubstateEncoding mocks base method.
@@ -389,6 +430,21 @@ func (mr *MockSubstateDBMockRecorder) SetSubstateEncoding(encoding any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSubstateEncoding", reflect.TypeOf((*MockSubstateDB)(nil).SetSubstateEncoding), encoding)
 }
 
+// Snapshot mocks base method.
+func (m *MockSubstateDB) Snapshot() (DBSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(DBSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockSubstateDBMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockSubstateDB)(nil).Snapshot))
+}
+
 // Stat mocks base method.
 func (m *MockSubstateDB) Stat(property string) (string, error) {
 	m.ctrl.T.Helper()
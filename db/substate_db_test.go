@@ -77,51 +77,76 @@ func getTestSubstate(encoding SubstateEncodingSchema) *substate.Substate {
 	return ss
 }
 
-func TestSubstateDB_PutSubstate(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	s := new(leveldb.DBStats)
-	err = db.stats(s)
-	if err != nil {
-		t.Fatalf("cannot get db stats; %v", err)
-	}
+// testSubstateCode is the code of the account in input substates of getTestSubstateAt.
+var testSubstateCode = []byte{0x60, 0x01}
+
+// getTestSubstateAt returns the protobuf test substate of block and tx, its input substate
+// holds account 1 with testSubstateCode.
+func getTestSubstateAt(block uint64, tx int) *substate.Substate {
+	ss := getTestSubstate(ProtobufEncodingSchema)
+	ss.Block = block
+	ss.Transaction = tx
+	ss.InputSubstate = substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), testSubstateCode)
+	return ss
+}
 
-	// 54 is the base write when creating levelDB
-	if s.IOWrite <= 54 {
-		t.Fatal("db file should have something inside")
+func TestSubstateDB_PutSubstate(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := new(leveldb.DBStats)
+			err = db.stats(s)
+			if err != nil {
+				t.Fatalf("cannot get db stats; %v", err)
+			}
+
+			// 54 is the base write when creating levelDB
+			if s.IOWrite <= 54 {
+				t.Fatal("db file should have something inside")
+			}
+		})
 	}
 }
 
 func TestSubstateDB_HasSubstate(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	has, err := db.HasSubstate(37_534_834, 1)
-	if err != nil {
-		t.Fatalf("has substate returned error; %v", err)
-	}
-
-	if !has {
-		t.Fatal("substate is not within db")
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			has, err := db.HasSubstate(37_534_834, 1)
+			if err != nil {
+				t.Fatalf("has substate returned error; %v", err)
+			}
+
+			if !has {
+				t.Fatal("substate is not within db")
+			}
+		})
 	}
 }
 func TestSubstateDB_GetSubstate(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	err = testSubstateDB_GetSubstate(db, *getTestSubstate("default"))
-	if err != nil {
-		t.Fatal(err)
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = testSubstateDB_GetSubstate(db, *getTestSubstate("default"))
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
 	}
 }
 
@@ -142,107 +167,123 @@ func testSubstateDB_GetSubstate(db *substateDB, want substate.Substate) error {
 }
 
 func TestSubstateDB_DeleteSubstate(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	err = db.DeleteSubstate(37_534_834, 1)
-	if err != nil {
-		t.Fatalf("delete substate returned error; %v", err)
-	}
-
-	ss, err := db.GetSubstate(37_534_834, 1)
-	if err == nil {
-		t.Fatal("get substate must fail")
-	}
-
-	if got, want := err, leveldb.ErrNotFound; !errors.Is(got, want) {
-		t.Fatalf("unexpected err, got: %v, want: %v", got, want)
-	}
-
-	if ss != nil {
-		t.Fatal("substate was not deleted")
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = db.DeleteSubstate(37_534_834, 1)
+			if err != nil {
+				t.Fatalf("delete substate returned error; %v", err)
+			}
+
+			ss, err := db.GetSubstate(37_534_834, 1)
+			if err == nil {
+				t.Fatal("get substate must fail")
+			}
+
+			if got, want := err, leveldb.ErrNotFound; !errors.Is(got, want) {
+				t.Fatalf("unexpected err, got: %v, want: %v", got, want)
+			}
+
+			if ss != nil {
+				t.Fatal("substate was not deleted")
+			}
+		})
 	}
 }
 
 func TestSubstateDB_getLastBlock(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	ts := getTestSubstate("default")
-	// add one more substate
-	if err = addSubstate(db, ts.Block+1); err != nil {
-		t.Fatal(err)
-	}
-
-	block, err := db.getLastBlock()
-	if err != nil {
-		t.Fatal(err)
-	}
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ts := getTestSubstate("default")
+			// add one more substate
+			if err = addSubstate(db, ts.Block+1); err != nil {
+				t.Fatal(err)
+			}
+
+			block, err := db.getLastBlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if block != 37534835 {
+				t.Fatalf("incorrect block number\ngot: %v\nwant: %v", block, ts.Block+1)
+			}
 
-	if block != 37534835 {
-		t.Fatalf("incorrect block number\ngot: %v\nwant: %v", block, ts.Block+1)
+		})
 	}
-
 }
 
 func TestSubstateDB_GetFirstSubstate(t *testing.T) {
-	// save data for comparison
-	want := *getTestSubstate("default")
-	want.Block = 1
-
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			// save data for comparison
+			want := *getTestSubstate("default")
+			want.Block = 1
+
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// add one more substate
+			if err = addSubstate(db, 2); err != nil {
+				t.Fatal(err)
+			}
 
-	// add one more substate
-	if err = addSubstate(db, 2); err != nil {
-		t.Fatal(err)
-	}
+			got := db.GetFirstSubstate()
 
-	got := db.GetFirstSubstate()
+			if err = (&want).Equal(got); err != nil {
+				t.Fatalf("substates are different\nerr: %v\ngot: %s\nwant: %s", err, got, &want)
+			}
 
-	if err = (&want).Equal(got); err != nil {
-		t.Fatalf("substates are different\nerr: %v\ngot: %s\nwant: %s", err, got, &want)
+		})
 	}
-
 }
 
 func TestSubstateDB_GetLastSubstate(t *testing.T) {
-	// save data for comparison
-	want := *getTestSubstate("default")
-	want.Block = 2
-
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
-	if err != nil {
-		t.Fatal(err)
-	}
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			// save data for comparison
+			want := *getTestSubstate("default")
+			want.Block = 2
+
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutSubstate(dbPath, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// add one more substate
+			if err = addSubstate(db, 2); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := db.GetLastSubstate()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = (&want).Equal(got); err != nil {
+				t.Fatalf("substates are different\nerr: %v\ngot: %s\nwant: %s", err, got, &want)
+			}
 
-	// add one more substate
-	if err = addSubstate(db, 2); err != nil {
-		t.Fatal(err)
-	}
-
-	got, err := db.GetLastSubstate()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	if err = (&want).Equal(got); err != nil {
-		t.Fatalf("substates are different\nerr: %v\ngot: %s\nwant: %s", err, got, &want)
+		})
 	}
-
 }
-func createDbAndPutSubstate(dbPath string) (*substateDB, error) {
-	db, err := newSubstateDB(dbPath, nil, nil, nil)
+func createDbAndPutSubstate(dbPath string, backend Backend) (*substateDB, error) {
+	db, err := newSubstateDBWithBackend(dbPath, backend, nil, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open db; %v", err)
 	}
@@ -363,6 +404,7 @@ func TestSubstateDB_GetSubstateSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDb := NewMockCodeDB(ctrl)
+	mockDb.EXPECT().Get([]byte(CompressionDictionaryKey)).Return(nil, leveldb.ErrNotFound)
 	db := &substateDB{
 		CodeDB:   mockDb,
 		encoding: nil,
@@ -417,6 +459,7 @@ func TestSubstateDB_GetBlockSubstatesSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDb := NewMockCodeDB(ctrl)
+	mockDb.EXPECT().Get([]byte(CompressionDictionaryKey)).Return(nil, leveldb.ErrNotFound)
 	db := &substateDB{
 		CodeDB:   mockDb,
 		encoding: nil,
@@ -509,6 +552,8 @@ func TestSubstateDB_PutSubstateSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDb := NewMockCodeDB(ctrl)
+	mockDb.EXPECT().Get([]byte(CompressionDictionaryKey)).Return(nil, leveldb.ErrNotFound)
+	mockDb.EXPECT().GetMetadata().Return(nil, nil).AnyTimes()
 	db := &substateDB{
 		CodeDB:   mockDb,
 		encoding: nil,
@@ -533,6 +578,8 @@ func TestSubstateDB_PutSubstateFail(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDb := NewMockCodeDB(ctrl)
+	mockDb.EXPECT().Get([]byte(CompressionDictionaryKey)).Return(nil, leveldb.ErrNotFound)
+	mockDb.EXPECT().GetMetadata().Return(nil, nil).AnyTimes()
 	db := &substateDB{
 		CodeDB:   mockDb,
 		encoding: nil,
@@ -782,6 +829,7 @@ func TestSubstateDB_GetLastSubstateSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDb := NewMockCodeDB(ctrl)
+	mockDb.EXPECT().Get([]byte(CompressionDictionaryKey)).Return(nil, leveldb.ErrNotFound)
 	db := &substateDB{
 		CodeDB:   mockDb,
 		encoding: nil,
@@ -898,43 +946,47 @@ func TestDecodeSubstateDBKey_Error(t *testing.T) {
 }
 
 func TestSubstateDB_setEncoding(t *testing.T) {
-	tests := []struct {
-		name     string
-		encoding SubstateEncodingSchema
-	}{
-		{
-			name:     "ProtobufEncodingSchema",
-			encoding: ProtobufEncodingSchema,
-		},
-		{
-			name:     "RLPEncodingSchema",
-			encoding: RLPEncodingSchema,
-		},
-		{
-			name:     "LegacyProtobufEncodingSchema",
-			encoding: LegacyProtobufEncodingAlias,
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			path := t.TempDir()
-			ss := getTestSubstate(test.encoding)
-			sdb, err := NewDefaultSubstateDB(path)
-			require.NoError(t, err)
-			err = sdb.SetSubstateEncoding(test.encoding)
-			require.NoError(t, err)
-			err = sdb.PutSubstate(ss)
-			require.NoError(t, err)
-			require.NoError(t, sdb.Close())
-			cdb, err := NewDefaultCodeDB(path)
-			require.NoError(t, err)
-			db := &substateDB{CodeDB: cdb}
-			require.NoError(t, err)
-			err = db.findAndSetEncoding()
-			require.NoError(t, err)
-			got := db.GetFirstSubstate()
-			require.Equal(t, ss.Block, got.Block)
-			require.Equal(t, ss.Transaction, got.Transaction)
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			tests := []struct {
+				name     string
+				encoding SubstateEncodingSchema
+			}{
+				{
+					name:     "ProtobufEncodingSchema",
+					encoding: ProtobufEncodingSchema,
+				},
+				{
+					name:     "RLPEncodingSchema",
+					encoding: RLPEncodingSchema,
+				},
+				{
+					name:     "LegacyProtobufEncodingSchema",
+					encoding: LegacyProtobufEncodingAlias,
+				},
+			}
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					path := t.TempDir()
+					ss := getTestSubstate(test.encoding)
+					sdb, err := NewSubstateDBWithBackend(path, backend, nil, nil, nil)
+					require.NoError(t, err)
+					err = sdb.SetSubstateEncoding(test.encoding)
+					require.NoError(t, err)
+					err = sdb.PutSubstate(ss)
+					require.NoError(t, err)
+					require.NoError(t, sdb.Close())
+					cdb, err := NewCodeDBWithBackend(path, backend, nil, nil, nil)
+					require.NoError(t, err)
+					db := &substateDB{CodeDB: cdb}
+					require.NoError(t, err)
+					err = db.findAndSetEncoding()
+					require.NoError(t, err)
+					got := db.GetFirstSubstate()
+					require.Equal(t, ss.Block, got.Block)
+					require.Equal(t, ss.Transaction, got.Transaction)
+				})
+			}
 		})
 	}
 }
@@ -38,10 +38,19 @@ func (db *substateDB) SetSubstateEncoding(schema SubstateEncodingSchema) error {
 		return fmt.Errorf("failed to set decoder; %w", err)
 	}
 
+	encoding.compression = newValueCompression(db)
+	if db.encoding != nil {
+		encoding.tagged = db.encoding.tagged
+	}
 	db.encoding = encoding
 	return nil
 }
 
+// SetEncodingTag enables or disables tagging of written substates with their encoding.
+func (db *substateDB) SetEncodingTag(enabled bool) {
+	db.encoding.tagged = enabled
+}
+
 // GetSubstateEncoding returns the encoding schema in use.
 func (db *substateDB) GetSubstateEncoding() SubstateEncodingSchema {
 	if db.encoding == nil {
@@ -54,6 +63,12 @@ type substateEncoding struct {
 	schema SubstateEncodingSchema
 	decode decodeFunc
 	encode encodeFunc
+
+	// compression is applied on top of encode and undone before decode, nil disables it
+	compression *valueCompression
+
+	// tagged prefixes encoded records with the encoding tag
+	tagged bool
 }
 
 // decodeFunc aliases the common function used to decode substate
@@ -95,12 +110,38 @@ func newSubstateEncoding(encoding SubstateEncodingSchema, lookup codeLookupFunc)
 
 // decodeSubstate defensively defaults to "default" if nil
 func (db *substateDB) decodeToSubstate(bytes []byte, block uint64, tx int) (*substate.Substate, error) {
-	return db.encoding.decode(bytes, block, tx)
+	bytes, err := db.encoding.compression.decompress(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	schema, bytes, tagged, err := splitEncodingTag(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode substate block: %v, tx %v; %w", block, tx, err)
+	}
+	if !tagged || schema == db.encoding.schema {
+		return db.encoding.decode(bytes, block, tx)
+	}
+
+	// tagged records are decoded with their own encoding
+	encoding, err := newSubstateEncoding(schema, db.GetCode)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.decode(bytes, block, tx)
 }
 
 // encodeSubstate defensively defaults to "default" if nil
 func (db *substateDB) encodeSubstate(ss *substate.Substate, block uint64, tx int) ([]byte, error) {
-	return db.encoding.encode(ss, block, tx)
+	bytes, err := db.encoding.encode(ss, block, tx)
+	if err != nil {
+		return nil, err
+	}
+	if db.encoding.tagged {
+		if bytes, err = tagEncoding(db.encoding.schema, bytes); err != nil {
+			return nil, err
+		}
+	}
+	return db.encoding.compression.compress(bytes)
 }
 
 // decodeRlp decodes into substate the provided rlp-encoded bytecode
@@ -12,6 +12,7 @@ import (
 	"github.com/0xsoniclabs/substate/substate"
 	"github.com/0xsoniclabs/substate/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
 	"go.uber.org/mock/gomock"
 	"google.golang.org/protobuf/proto"
 
@@ -327,6 +328,7 @@ func TestSubstateDB_EncodeToSubstate(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDb := NewMockCodeDB(ctrl)
+	mockDb.EXPECT().Get([]byte(CompressionDictionaryKey)).Return(nil, leveldb.ErrNotFound)
 	mockDb.EXPECT().GetCode(gomock.Any()).Return(nil, nil).AnyTimes()
 	db := &substateDB{
 		CodeDB:   mockDb,
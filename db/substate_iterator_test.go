@@ -16,7 +16,7 @@ import (
 
 func TestSubstateIterator_Next(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(path)
+	db, err := createDbAndPutSubstate(path, DefaultBackend)
 	if err != nil {
 		return
 	}
@@ -33,7 +33,7 @@ func TestSubstateIterator_Next(t *testing.T) {
 
 func TestSubstateIterator_Value(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(path)
+	db, err := createDbAndPutSubstate(path, DefaultBackend)
 	if err != nil {
 		return
 	}
@@ -66,7 +66,7 @@ func testSubstatorIterator_Value(db *substateDB, t *testing.T) {
 
 func TestSubstateIterator_Release(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(path)
+	db, err := createDbAndPutSubstate(path, DefaultBackend)
 	if err != nil {
 		return
 	}
@@ -91,7 +91,7 @@ func TestSubstateIterator_Release(t *testing.T) {
 
 func TestSubstateIterator_FromBlock(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(path)
+	db, err := createDbAndPutSubstate(path, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
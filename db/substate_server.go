@@ -0,0 +1,272 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	pb "github.com/0xsoniclabs/substate/protobuf"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// substateServerWorkers is the number of workers decoding substates for a single IterateSubstates stream.
+const substateServerWorkers = 4
+
+// RegisterSubstateServer registers a service serving db read-only on given gRPC server.
+// Use NewRemoteSubstateDB to connect to the service. The db must stay open while the server is running.
+func RegisterSubstateServer(s grpc.ServiceRegistrar, db SubstateDB) error {
+	// clients read the metadata record from the backend
+	if err := metadataCacheOf(db).flush(); err != nil {
+		return err
+	}
+	updateDB, err := MakeDefaultUpdateDBFromBaseDB(db)
+	if err != nil {
+		return fmt.Errorf("cannot create update-set db; %w", err)
+	}
+	destroyedAccountDB, err := MakeDefaultDestroyedAccountDBFromBaseDB(db)
+	if err != nil {
+		return fmt.Errorf("cannot create destroyed account db; %w", err)
+	}
+
+	pb.RegisterSubstateServiceServer(s, &substateServer{
+		db:                 db,
+		updateDB:           updateDB,
+		destroyedAccountDB: destroyedAccountDB,
+		exceptionDB:        MakeDefaultExceptionDBFromBaseDB(db),
+		hashProvider:       MakeHashProvider(db),
+	})
+	return nil
+}
+
+// substateServer implements the gRPC SubstateService on top of a SubstateDB.
+type substateServer struct {
+	pb.UnimplementedSubstateServiceServer
+	db                 SubstateDB
+	updateDB           UpdateDB
+	destroyedAccountDB DestroyedAccountDB
+	exceptionDB        ExceptionDB
+	hashProvider       HashProvider
+}
+
+func (s *substateServer) Get(_ context.Context, req *pb.KeyRequest) (*pb.ValueResponse, error) {
+	value, err := s.db.Get(req.GetKey())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.ValueResponse{Value: value}, nil
+}
+
+func (s *substateServer) Has(_ context.Context, req *pb.KeyRequest) (*pb.HasResponse, error) {
+	found, err := s.db.Has(req.GetKey())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.HasResponse{Found: &found}, nil
+}
+
+// Iterate streams all key-value pairs within the requested range in ascending
+// or, if requested, descending order.
+func (s *substateServer) Iterate(req *pb.IterateRequest, stream grpc.ServerStreamingServer[pb.KeyValue]) error {
+	iter := s.db.newIterator(&util.Range{Start: req.GetStart(), Limit: req.GetLimit()})
+	defer iter.Release()
+
+	first, next := iter.First, iter.Next
+	if req.GetReverse() {
+		first, next = iter.Last, iter.Prev
+	}
+	for ok := first(); ok; ok = next() {
+		if err := stream.Send(&pb.KeyValue{Key: iter.Key(), Value: iter.Value()}); err != nil {
+			return err
+		}
+	}
+	return toStatusError(iter.Error())
+}
+
+func (s *substateServer) GetProperty(_ context.Context, req *pb.PropertyRequest) (*pb.PropertyResponse, error) {
+	value, err := s.db.Stat(req.GetName())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.PropertyResponse{Value: &value}, nil
+}
+
+func (s *substateServer) GetSubstate(_ context.Context, req *pb.SubstateRequest) (*pb.SubstateEntry, error) {
+	ss, err := s.db.GetSubstate(req.GetBlock(), int(req.GetTransaction()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	entry, err := newSubstateEntry(ss)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return entry, nil
+}
+
+// GetBlockSubstates streams all substates of the requested block ordered by transaction.
+func (s *substateServer) GetBlockSubstates(req *pb.BlockRequest, stream grpc.ServerStreamingServer[pb.SubstateEntry]) error {
+	substates, err := s.db.GetBlockSubstates(req.GetBlock())
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	txs := make([]int, 0, len(substates))
+	for tx := range substates {
+		txs = append(txs, tx)
+	}
+	sort.Ints(txs)
+
+	for _, tx := range txs {
+		entry, err := newSubstateEntry(substates[tx])
+		if err != nil {
+			return toStatusError(err)
+		}
+		if err = stream.Send(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IterateSubstates streams all substates starting at the requested block.
+// The stream ends when the client cancels it or all substates are sent.
+func (s *substateServer) IterateSubstates(req *pb.BlockRequest, stream grpc.ServerStreamingServer[pb.SubstateEntry]) error {
+	iter := s.db.NewSubstateIterator(int(req.GetBlock()), substateServerWorkers)
+	defer iter.Release()
+
+	for iter.Next() {
+		entry, err := newSubstateEntry(iter.Value())
+		if err != nil {
+			return toStatusError(err)
+		}
+		if err = stream.Send(entry); err != nil {
+			return err
+		}
+	}
+	return toStatusError(iter.Error())
+}
+
+func (s *substateServer) GetCode(_ context.Context, req *pb.CodeRequest) (*pb.ValueResponse, error) {
+	code, err := s.db.GetCode(types.BytesToHash(req.GetCodeHash()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.ValueResponse{Value: code}, nil
+}
+
+func (s *substateServer) GetUpdateSet(_ context.Context, req *pb.BlockRequest) (*pb.UpdateSetResponse, error) {
+	updateSet, err := s.updateDB.GetUpdateSet(req.GetBlock())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if updateSet == nil {
+		return nil, status.Errorf(codes.NotFound, "update-set for block %v not found", req.GetBlock())
+	}
+
+	msg, err := newUpdateSetMessage(*updateSet, updateSet.DeletedAccounts)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	code := make(codeSet)
+	code.addWorldState(updateSet.WorldState)
+	return &pb.UpdateSetResponse{UpdateSet: msg, Codes: code.list()}, nil
+}
+
+func (s *substateServer) GetDestroyedAccounts(_ context.Context, req *pb.SubstateRequest) (*pb.SuicidedAccountLists, error) {
+	destroyed, resurrected, err := s.destroyedAccountDB.GetDestroyedAccounts(req.GetBlock(), int(req.GetTransaction()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newSuicidedAccountListMessage(SuicidedAccountLists{
+		DestroyedAccounts:   destroyed,
+		ResurrectedAccounts: resurrected,
+	}), nil
+}
+
+func (s *substateServer) GetException(_ context.Context, req *pb.BlockRequest) (*pb.ExceptionResponse, error) {
+	exception, err := s.exceptionDB.GetException(req.GetBlock())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if exception == nil {
+		return nil, status.Errorf(codes.NotFound, "exception for block %v not found", req.GetBlock())
+	}
+
+	msg, err := pb.NewExceptionBlock(&exception.Data)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	code := make(codeSet)
+	code.addWorldStatePtr(exception.Data.PreBlock)
+	code.addWorldStatePtr(exception.Data.PostBlock)
+	for _, tx := range exception.Data.Transactions {
+		code.addWorldStatePtr(tx.PreTransaction)
+		code.addWorldStatePtr(tx.PostTransaction)
+	}
+	return &pb.ExceptionResponse{Exception: msg, Codes: code.list()}, nil
+}
+
+func (s *substateServer) GetBlockHash(_ context.Context, req *pb.BlockRequest) (*pb.ValueResponse, error) {
+	hash, err := s.hashProvider.GetBlockHash(int(req.GetBlock()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.ValueResponse{Value: hash.Bytes()}, nil
+}
+
+// newSubstateEntry converts ss into a message carrying all code referenced by the substate.
+func newSubstateEntry(ss *substate.Substate) (*pb.SubstateEntry, error) {
+	msg, err := pb.NewSubstate(ss)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode substate block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+	}
+
+	code := make(codeSet)
+	code.addWorldState(ss.InputSubstate)
+	code.addWorldState(ss.OutputSubstate)
+	if ss.Message.To == nil {
+		code.add(ss.Message.Data)
+	}
+
+	return &pb.SubstateEntry{
+		Block:       proto.Uint64(ss.Block),
+		Transaction: proto.Int64(int64(ss.Transaction)),
+		Substate:    msg,
+		Codes:       code.list(),
+	}, nil
+}
+
+// codeSet collects distinct non-empty code sent along with a record,
+// so that clients can resolve code hashes without further requests.
+type codeSet map[string]struct{}
+
+func (c codeSet) add(code []byte) {
+	if len(code) > 0 {
+		c[string(code)] = struct{}{}
+	}
+}
+
+func (c codeSet) addWorldState(ws substate.WorldState) {
+	for _, account := range ws {
+		c.add(account.Code)
+	}
+}
+
+func (c codeSet) addWorldStatePtr(ws *substate.WorldState) {
+	if ws != nil {
+		c.addWorldState(*ws)
+	}
+}
+
+func (c codeSet) list() [][]byte {
+	res := make([][]byte, 0, len(c))
+	for code := range c {
+		res = append(res, []byte(code))
+	}
+	return res
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/0xsoniclabs/substate/protobuf"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// testServiceRegistrar captures the service registered by RegisterSubstateServer.
+type testServiceRegistrar struct {
+	impl any
+}
+
+func (r *testServiceRegistrar) RegisterService(_ *grpc.ServiceDesc, impl any) {
+	r.impl = impl
+}
+
+func newTestSubstateServer(t *testing.T) *substateServer {
+	sdb, err := newSubstateDB(t.TempDir(), nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetSubstateEncoding(ProtobufEncodingSchema))
+	t.Cleanup(func() {
+		_ = sdb.Close()
+	})
+	for tx := 3; tx >= 0; tx-- {
+		require.NoError(t, sdb.PutSubstate(getTestSubstateAt(10, tx)))
+	}
+
+	registrar := new(testServiceRegistrar)
+	require.NoError(t, RegisterSubstateServer(registrar, sdb))
+	return registrar.impl.(*substateServer)
+}
+
+// testSubstateStream collects substate entries sent by the server.
+type testSubstateStream struct {
+	grpc.ServerStream
+	entries []*pb.SubstateEntry
+}
+
+func (s *testSubstateStream) Send(entry *pb.SubstateEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestSubstateServer_GetBlockSubstatesAreOrdered(t *testing.T) {
+	server := newTestSubstateServer(t)
+
+	stream := new(testSubstateStream)
+	require.NoError(t, server.GetBlockSubstates(&pb.BlockRequest{Block: proto.Uint64(10)}, stream))
+
+	require.Len(t, stream.entries, 4)
+	for i, entry := range stream.entries {
+		assert.Equal(t, uint64(10), entry.GetBlock())
+		assert.Equal(t, int64(i), entry.GetTransaction())
+		assert.Equal(t, [][]byte{testSubstateCode}, entry.GetCodes())
+	}
+}
+
+func TestSubstateServer_NotFound(t *testing.T) {
+	server := newTestSubstateServer(t)
+	ctx := context.Background()
+
+	_, err := server.Get(ctx, &pb.KeyRequest{Key: []byte("missing")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetSubstate(ctx, &pb.SubstateRequest{Block: proto.Uint64(11), Transaction: proto.Int64(0)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetUpdateSet(ctx, &pb.BlockRequest{Block: proto.Uint64(10)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetException(ctx, &pb.BlockRequest{Block: proto.Uint64(10)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetBlockHash(ctx, &pb.BlockRequest{Block: proto.Uint64(10)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSubstateServer_CodeSet(t *testing.T) {
+	code := make(codeSet)
+	code.add(nil)
+	code.add([]byte{1})
+	code.addWorldState(substate.NewWorldState().
+		Add(types.Address{1}, 1, uint256.NewInt(1), []byte{1}).
+		Add(types.Address{2}, 1, uint256.NewInt(1), []byte{2}))
+	code.addWorldStatePtr(nil)
+
+	assert.ElementsMatch(t, [][]byte{{1}, {2}}, code.list())
+}
@@ -15,7 +15,7 @@ import (
 
 func TestSubstateTaskPool_Execute(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
+	db, err := createDbAndPutSubstate(dbPath, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -48,7 +48,7 @@ func TestSubstateTaskPool_Execute(t *testing.T) {
 
 func TestSubstateTaskPool_ExecuteBlock(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
+	db, err := createDbAndPutSubstate(dbPath, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -76,7 +76,7 @@ func TestSubstateTaskPool_ExecuteBlock(t *testing.T) {
 
 func TestSubstateTaskPool_ExecuteBlock_TaskFuncErr(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
+	db, err := createDbAndPutSubstate(dbPath, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,7 +102,7 @@ func TestSubstateTaskPool_ExecuteBlock_TaskFuncErr(t *testing.T) {
 
 func TestSubstateTaskPool_ExecuteBlockNilTaskFunc(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
+	db, err := createDbAndPutSubstate(dbPath, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,7 +156,7 @@ func TestSubstateTaskPool_ExecuteBlockDBError(t *testing.T) {
 
 func TestSubstateTaskPool_ExecuteBlockSkipTransferTx(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutSubstate(dbPath)
+	db, err := createDbAndPutSubstate(dbPath, DefaultBackend)
 	if err != nil {
 		t.Fatal(err)
 	}
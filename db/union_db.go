@@ -0,0 +1,236 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LayerPrecedence decides which layer of a union database is used
+// when the same key (e.g. the same block and transaction) exists in more than one layer.
+type LayerPrecedence string
+
+const (
+	PreferFirstLayer LayerPrecedence = "first" // the earliest given layer wins
+	PreferLastLayer  LayerPrecedence = "last"  // the latest given layer wins, i.e. re-recordings override
+)
+
+// ParseLayerPrecedence parses precedence given by its name.
+func ParseLayerPrecedence(name string) (LayerPrecedence, error) {
+	switch p := LayerPrecedence(strings.ToLower(name)); p {
+	case PreferFirstLayer, PreferLastLayer:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown layer precedence: %s", name)
+	}
+}
+
+// NewUnionSubstateDB opens substate databases at given paths read-only and layers them into a single
+// read-only SubstateDB. See MakeUnionSubstateDB for details.
+// Note: o is nillable, ReadOnly option is always set.
+func NewUnionSubstateDB(paths []string, backend Backend, o *opt.Options, precedence LayerPrecedence) (SubstateDB, error) {
+	options := opt.Options{}
+	if o != nil {
+		options = *o
+	}
+	options.ReadOnly = true
+
+	layers := make([]SubstateDB, 0, len(paths))
+	for _, path := range paths {
+		layer, err := newSubstateDBWithBackend(path, backend, &options, nil, nil)
+		if err != nil {
+			for _, l := range layers {
+				l.Close()
+			}
+			return nil, fmt.Errorf("cannot open layer %v; %w", path, err)
+		}
+		layers = append(layers, layer)
+	}
+
+	db, err := MakeUnionSubstateDB(layers, precedence)
+	if err != nil {
+		for _, l := range layers {
+			l.Close()
+		}
+		return nil, err
+	}
+	return db, nil
+}
+
+// MakeUnionSubstateDB layers given substate databases into a single read-only SubstateDB.
+// Substates, code and any other records are looked up in all layers, if a key exists
+// in more than one layer, precedence decides which layer is used. Iterators return
+// records of all layers in order, each key only once.
+//
+// The union takes ownership of the layers, closing the union closes all layers.
+// All non-empty layers must use the same substate encoding.
+func MakeUnionSubstateDB(layers []SubstateDB, precedence LayerPrecedence) (SubstateDB, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("union needs at least one layer")
+	}
+
+	encoding := DefaultEncodingSchema
+	var found bool
+	for i, layer := range layers {
+		if layer.GetFirstSubstate() == nil {
+			continue
+		}
+		if !found {
+			encoding, found = layer.GetSubstateEncoding(), true
+			continue
+		}
+		if layer.GetSubstateEncoding() != encoding {
+			return nil, fmt.Errorf("layer %d uses encoding %v, expected %v", i, layer.GetSubstateEncoding(), encoding)
+		}
+	}
+
+	adapters := make([]DbAdapter, len(layers))
+	for i, layer := range layers {
+		adapters[i] = layer.GetBackend()
+	}
+	backend, err := newUnionAdapter(adapters, precedence)
+	if err != nil {
+		return nil, err
+	}
+	return MakeDefaultSubstateDBFromBaseDBWithEncoding(&codeDB{backend: backend}, encoding)
+}
+
+// unionAdapter is a read-only DbAdapter layering several DbAdapters. Layers are
+// kept sorted from the most to the least preferred one.
+type unionAdapter struct {
+	layers []DbAdapter
+}
+
+func newUnionAdapter(layers []DbAdapter, precedence LayerPrecedence) (*unionAdapter, error) {
+	ordered := make([]DbAdapter, len(layers))
+	switch precedence {
+	case PreferFirstLayer:
+		copy(ordered, layers)
+	case PreferLastLayer:
+		for i, layer := range layers {
+			ordered[len(layers)-1-i] = layer
+		}
+	default:
+		return nil, fmt.Errorf("unknown layer precedence: %s", precedence)
+	}
+	return &unionAdapter{layers: ordered}, nil
+}
+
+// newSnapshot pins all layers.
+func (u *unionAdapter) newSnapshot() (DbAdapter, error) {
+	layers := make([]DbAdapter, 0, len(u.layers))
+	for i, layer := range u.layers {
+		snapshot, err := snapshotBackend(layer)
+		if err != nil {
+			(&unionAdapter{layers: layers}).Close()
+			return nil, fmt.Errorf("cannot get snapshot of layer %d; %w", i, err)
+		}
+		layers = append(layers, snapshot)
+	}
+	return &unionAdapter{layers: layers}, nil
+}
+
+func (u *unionAdapter) Delete([]byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+func (u *unionAdapter) Put([]byte, []byte, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+// Close closes all layers.
+func (u *unionAdapter) Close() error {
+	var errs []error
+	for _, layer := range u.layers {
+		errs = append(errs, layer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (u *unionAdapter) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
+	for _, layer := range u.layers {
+		has, err := layer.Has(key, ro)
+		if err != nil {
+			return false, err
+		}
+		if has {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CompactRange is a no-op as union is read-only.
+func (u *unionAdapter) CompactRange(util.Range) error {
+	return nil
+}
+
+// Get returns the value of the most preferred layer containing given key.
+func (u *unionAdapter) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
+	for _, layer := range u.layers {
+		value, err := layer.Get(key, ro)
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		return value, err
+	}
+	return nil, leveldb.ErrNotFound
+}
+
+// GetProperty returns the property of every layer, one after another.
+func (u *unionAdapter) GetProperty(property string) (string, error) {
+	var sb strings.Builder
+	for i, layer := range u.layers {
+		value, err := layer.GetProperty(property)
+		if err != nil {
+			return "", fmt.Errorf("cannot get property of layer %d; %w", i, err)
+		}
+		fmt.Fprintf(&sb, "Layer %d:\n%s\n", i, value)
+	}
+	return sb.String(), nil
+}
+
+func (u *unionAdapter) NewIterator(r *util.Range, ro *opt.ReadOptions) ldbiterator.Iterator {
+	iters := make([]ldbiterator.Iterator, len(u.layers))
+	for i, layer := range u.layers {
+		iters[i] = layer.NewIterator(r, ro)
+	}
+	return newUnionIterator(iters)
+}
+
+func (u *unionAdapter) Write(*leveldb.Batch, *opt.WriteOptions) error {
+	return leveldb.ErrReadOnly
+}
+
+// Stats sums up IO, cache and size statistics of all layers.
+func (u *unionAdapter) Stats(s *leveldb.DBStats) error {
+	for i, layer := range u.layers {
+		var ls leveldb.DBStats
+		if err := layer.Stats(&ls); err != nil {
+			return fmt.Errorf("cannot get stats of layer %d; %w", i, err)
+		}
+		addDBStats(s, &ls)
+	}
+	return nil
+}
+
+// addDBStats adds IO, cache and size statistics of src to dst.
+func addDBStats(dst *leveldb.DBStats, src *leveldb.DBStats) {
+	dst.AliveSnapshots += src.AliveSnapshots
+	dst.AliveIterators += src.AliveIterators
+	dst.IOWrite += src.IOWrite
+	dst.IORead += src.IORead
+	dst.BlockCacheSize += src.BlockCacheSize
+	dst.OpenedTablesCount += src.OpenedTablesCount
+	for level, size := range src.LevelSizes {
+		if level == len(dst.LevelSizes) {
+			dst.LevelSizes = append(dst.LevelSizes, 0)
+		}
+		dst.LevelSizes[level] += size
+	}
+}
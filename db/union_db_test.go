@@ -0,0 +1,243 @@
+package db
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// createUnionTestLayer creates a substate db at path containing given blocks (tx 0 and 1 each),
+// gas of every substate's result is set to gas so that layers can be told apart.
+func createUnionTestLayer(t *testing.T, path string, gas uint64, blocks ...uint64) {
+	db, err := newSubstateDB(path, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.SetSubstateEncoding(ProtobufEncodingSchema))
+	for _, block := range blocks {
+		for tx := 0; tx < 2; tx++ {
+			ss := getTestSubstate(ProtobufEncodingSchema)
+			ss.Block = block
+			ss.Transaction = tx
+			ss.Result.GasUsed = gas
+			require.NoError(t, db.PutSubstate(ss))
+		}
+	}
+	require.NoError(t, db.PutCode([]byte{byte(gas)}))
+	require.NoError(t, db.Close())
+}
+
+func createUnionTestDB(t *testing.T, precedence LayerPrecedence) SubstateDB {
+	dir := t.TempDir()
+	createUnionTestLayer(t, dir+"/a", 1, 10, 20)
+	createUnionTestLayer(t, dir+"/b", 2, 20, 30)
+	db, err := NewUnionSubstateDB([]string{dir + "/a", dir + "/b"}, LevelDBBackend, nil, precedence)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestParseLayerPrecedence(t *testing.T) {
+	p, err := ParseLayerPrecedence("First")
+	require.NoError(t, err)
+	assert.Equal(t, PreferFirstLayer, p)
+
+	p, err = ParseLayerPrecedence("last")
+	require.NoError(t, err)
+	assert.Equal(t, PreferLastLayer, p)
+
+	_, err = ParseLayerPrecedence("middle")
+	assert.ErrorContains(t, err, "unknown layer precedence: middle")
+}
+
+func TestUnionSubstateDB_GetSubstate(t *testing.T) {
+	tests := []struct {
+		precedence     LayerPrecedence
+		overlappingGas uint64
+	}{
+		{PreferFirstLayer, 1},
+		{PreferLastLayer, 2},
+	}
+	for _, test := range tests {
+		t.Run(string(test.precedence), func(t *testing.T) {
+			db := createUnionTestDB(t, test.precedence)
+
+			ss, err := db.GetSubstate(10, 1)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), ss.Result.GasUsed)
+
+			ss, err = db.GetSubstate(30, 0)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(2), ss.Result.GasUsed)
+
+			ss, err = db.GetSubstate(20, 1)
+			require.NoError(t, err)
+			assert.Equal(t, test.overlappingGas, ss.Result.GasUsed)
+
+			has, err := db.HasSubstate(40, 0)
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			block, err := db.GetBlockSubstates(20)
+			require.NoError(t, err)
+			require.Len(t, block, 2)
+			for _, ss := range block {
+				assert.Equal(t, test.overlappingGas, ss.Result.GasUsed)
+			}
+		})
+	}
+}
+
+func TestUnionSubstateDB_Iterator(t *testing.T) {
+	db := createUnionTestDB(t, PreferLastLayer)
+
+	type key struct {
+		block uint64
+		tx    int
+	}
+	var got []key
+	iter := db.NewSubstateIterator(15, 2)
+	for iter.Next() {
+		got = append(got, key{iter.Value().Block, iter.Value().Transaction})
+	}
+	iter.Release()
+	require.NoError(t, iter.Error())
+
+	assert.Equal(t, []key{{20, 0}, {20, 1}, {30, 0}, {30, 1}}, got)
+}
+
+func TestUnionSubstateDB_FirstAndLast(t *testing.T) {
+	db := createUnionTestDB(t, PreferFirstLayer)
+
+	first := db.GetFirstSubstate()
+	require.NotNil(t, first)
+	assert.Equal(t, uint64(10), first.Block)
+	assert.Equal(t, 0, first.Transaction)
+
+	last, err := db.GetLastSubstate()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), last.Block)
+	assert.Equal(t, 1, last.Transaction)
+}
+
+func TestUnionSubstateDB_GetCode(t *testing.T) {
+	db := createUnionTestDB(t, PreferFirstLayer)
+
+	for _, code := range [][]byte{{1}, {2}} {
+		got, err := db.GetCode(hash.Keccak256Hash(code))
+		require.NoError(t, err)
+		assert.Equal(t, code, got)
+	}
+
+	_, err := db.GetCode(hash.Keccak256Hash([]byte{3}))
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+}
+
+func TestUnionSubstateDB_IsReadOnly(t *testing.T) {
+	db := createUnionTestDB(t, PreferFirstLayer)
+
+	err := db.PutSubstate(getTestSubstate(ProtobufEncodingSchema))
+	assert.True(t, errors.Is(err, leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(db.DeleteSubstate(10, 0), leveldb.ErrReadOnly))
+	assert.True(t, errors.Is(db.NewBatch().Write(), leveldb.ErrReadOnly))
+	assert.NoError(t, db.Compact(nil, nil))
+}
+
+func TestUnionSubstateDB_TaskPool(t *testing.T) {
+	db := createUnionTestDB(t, PreferFirstLayer)
+
+	var (
+		mu     sync.Mutex
+		blocks = map[uint64]int{}
+	)
+	pool := &SubstateTaskPool{
+		TaskFunc: func(block uint64, tx int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			mu.Lock()
+			defer mu.Unlock()
+			blocks[block]++
+			return nil
+		},
+		First:   0,
+		Last:    40,
+		Workers: 2,
+		DB:      db,
+	}
+	require.NoError(t, pool.Execute())
+
+	assert.Equal(t, map[uint64]int{10: 2, 20: 2, 30: 2}, blocks)
+}
+
+func TestUnionSubstateDB_StatAndStats(t *testing.T) {
+	db := createUnionTestDB(t, PreferFirstLayer)
+
+	stats, err := db.Stat("leveldb.stats")
+	require.NoError(t, err)
+	assert.Contains(t, stats, "Layer 0:")
+	assert.Contains(t, stats, "Layer 1:")
+
+	_, err = db.Stat("unknown")
+	assert.ErrorContains(t, err, "cannot get property of layer 0")
+
+	s := new(leveldb.DBStats)
+	require.NoError(t, db.stats(s))
+	assert.NotZero(t, s.IORead)
+}
+
+func TestUnionSubstateDB_Close(t *testing.T) {
+	dir := t.TempDir()
+	createUnionTestLayer(t, dir+"/a", 1, 10)
+	db, err := NewUnionSubstateDB([]string{dir + "/a"}, LevelDBBackend, nil, PreferFirstLayer)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// layers are closed, hence they can be opened again
+	layer, err := NewDefaultSubstateDB(dir + "/a")
+	require.NoError(t, err)
+	require.NoError(t, layer.Close())
+
+	assert.True(t, errors.Is(db.Close(), leveldb.ErrClosed))
+}
+
+func TestUnionSubstateDB_MixedEncodingsFail(t *testing.T) {
+	dir := t.TempDir()
+	createUnionTestLayer(t, dir+"/a", 1, 10)
+
+	rlpDB, err := newSubstateDB(dir+"/b", nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, rlpDB.SetSubstateEncoding(RLPEncodingSchema))
+	require.NoError(t, rlpDB.PutSubstate(getTestSubstate(RLPEncodingSchema)))
+	require.NoError(t, rlpDB.Close())
+
+	// an empty layer does not influence the encoding
+	emptyDB, err := NewDefaultSubstateDB(dir + "/c")
+	require.NoError(t, err)
+	require.NoError(t, emptyDB.Close())
+
+	_, err = NewUnionSubstateDB([]string{dir + "/c", dir + "/a", dir + "/b"}, LevelDBBackend, nil, PreferFirstLayer)
+	assert.ErrorContains(t, err, "layer 2 uses encoding")
+
+	// failed union does not keep any layer open
+	db, err := NewUnionSubstateDB([]string{dir + "/c", dir + "/a"}, LevelDBBackend, nil, PreferFirstLayer)
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufEncodingSchema, db.GetSubstateEncoding())
+	require.NoError(t, db.Close())
+}
+
+func TestUnionSubstateDB_InvalidArguments(t *testing.T) {
+	_, err := MakeUnionSubstateDB(nil, PreferFirstLayer)
+	assert.ErrorContains(t, err, "at least one layer")
+
+	dir := t.TempDir()
+	createUnionTestLayer(t, dir+"/a", 1, 10)
+	_, err = NewUnionSubstateDB([]string{dir + "/a"}, LevelDBBackend, nil, "middle")
+	assert.ErrorContains(t, err, "unknown layer precedence")
+
+	_, err = NewUnionSubstateDB([]string{dir + "/a", dir + "/missing"}, LevelDBBackend, nil, PreferFirstLayer)
+	assert.ErrorContains(t, err, "cannot open layer")
+}
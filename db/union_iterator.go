@@ -0,0 +1,207 @@
+package db
+
+import (
+	"bytes"
+
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+type unionDirection byte
+
+const (
+	unionDirReleased unionDirection = iota
+	unionDirSOI                     // before the first element
+	unionDirEOI                     // after the last element
+	unionDirForward
+	unionDirBackward
+)
+
+// unionIterator merges iterators of several layers into a single ordered iterator.
+// Keys present in more than one layer are returned only once, with the value
+// of the layer which comes first in iters.
+type unionIterator struct {
+	util.BasicReleaser
+	iters []ldbiterator.Iterator
+	dir   unionDirection
+	cur   int // index of the iterator holding the current key
+	err   error
+}
+
+func newUnionIterator(iters []ldbiterator.Iterator) *unionIterator {
+	return &unionIterator{
+		iters: iters,
+		dir:   unionDirSOI,
+		cur:   -1,
+	}
+}
+
+// pickSmallest sets the current iterator to the one with the smallest key,
+// preferring layers with lower index on equal keys.
+func (i *unionIterator) pickSmallest() bool {
+	i.cur = -1
+	for idx, iter := range i.iters {
+		if !iter.Valid() {
+			continue
+		}
+		if i.cur < 0 || bytes.Compare(iter.Key(), i.iters[i.cur].Key()) < 0 {
+			i.cur = idx
+		}
+	}
+	if i.cur < 0 {
+		i.dir = unionDirEOI
+		return false
+	}
+	i.dir = unionDirForward
+	return true
+}
+
+// pickLargest sets the current iterator to the one with the largest key,
+// preferring layers with lower index on equal keys.
+func (i *unionIterator) pickLargest() bool {
+	i.cur = -1
+	for idx, iter := range i.iters {
+		if !iter.Valid() {
+			continue
+		}
+		if i.cur < 0 || bytes.Compare(iter.Key(), i.iters[i.cur].Key()) > 0 {
+			i.cur = idx
+		}
+	}
+	if i.cur < 0 {
+		i.dir = unionDirSOI
+		return false
+	}
+	i.dir = unionDirBackward
+	return true
+}
+
+func (i *unionIterator) First() bool {
+	if i.dir == unionDirReleased {
+		return false
+	}
+	for _, iter := range i.iters {
+		iter.First()
+	}
+	return i.pickSmallest()
+}
+
+func (i *unionIterator) Last() bool {
+	if i.dir == unionDirReleased {
+		return false
+	}
+	for _, iter := range i.iters {
+		iter.Last()
+	}
+	return i.pickLargest()
+}
+
+func (i *unionIterator) Seek(key []byte) bool {
+	if i.dir == unionDirReleased {
+		return false
+	}
+	for _, iter := range i.iters {
+		iter.Seek(key)
+	}
+	return i.pickSmallest()
+}
+
+func (i *unionIterator) Next() bool {
+	switch i.dir {
+	case unionDirReleased, unionDirEOI:
+		return false
+	case unionDirSOI:
+		return i.First()
+	case unionDirBackward:
+		// move every layer after the current key
+		key := append([]byte{}, i.iters[i.cur].Key()...)
+		for _, iter := range i.iters {
+			if iter.Seek(key) && bytes.Equal(iter.Key(), key) {
+				iter.Next()
+			}
+		}
+	default:
+		key := append([]byte{}, i.iters[i.cur].Key()...)
+		for _, iter := range i.iters {
+			if iter.Valid() && bytes.Equal(iter.Key(), key) {
+				iter.Next()
+			}
+		}
+	}
+	return i.pickSmallest()
+}
+
+func (i *unionIterator) Prev() bool {
+	switch i.dir {
+	case unionDirReleased, unionDirSOI:
+		return false
+	case unionDirEOI:
+		return i.Last()
+	case unionDirForward:
+		// move every layer before the current key
+		key := append([]byte{}, i.iters[i.cur].Key()...)
+		for _, iter := range i.iters {
+			if iter.Seek(key) {
+				iter.Prev()
+			} else {
+				iter.Last()
+			}
+		}
+	default:
+		key := append([]byte{}, i.iters[i.cur].Key()...)
+		for _, iter := range i.iters {
+			if iter.Valid() && bytes.Equal(iter.Key(), key) {
+				iter.Prev()
+			}
+		}
+	}
+	return i.pickLargest()
+}
+
+func (i *unionIterator) Valid() bool {
+	return (i.dir == unionDirForward || i.dir == unionDirBackward) && i.cur >= 0
+}
+
+func (i *unionIterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.iters[i.cur].Key()
+}
+
+func (i *unionIterator) Value() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.iters[i.cur].Value()
+}
+
+// Error returns the first error of any of the layers.
+func (i *unionIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+	for _, iter := range i.iters {
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release releases iterators of all layers. It can be called multiple times.
+func (i *unionIterator) Release() {
+	if i.dir == unionDirReleased {
+		return
+	}
+	for _, iter := range i.iters {
+		iter.Release()
+		if err := iter.Error(); err != nil && i.err == nil {
+			i.err = err
+		}
+	}
+	i.iters = nil
+	i.cur = -1
+	i.dir = unionDirReleased
+	i.BasicReleaser.Release()
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/testutil"
+)
+
+func newTestArrayIterator(kvs ...string) ldbiterator.Iterator {
+	kv := &testutil.KeyValue{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		kv.PutU([]byte(kvs[i]), []byte(kvs[i+1]))
+	}
+	return ldbiterator.NewArrayIterator(kv)
+}
+
+func newTestUnionIterator() *unionIterator {
+	return newUnionIterator([]ldbiterator.Iterator{
+		newTestArrayIterator("a", "1", "c", "1", "e", "1"),
+		newTestArrayIterator("b", "2", "c", "2", "f", "2"),
+		newTestArrayIterator(),
+	})
+}
+
+func TestUnionIterator_Next(t *testing.T) {
+	iter := newTestUnionIterator()
+	defer iter.Release()
+
+	var got []string
+	for iter.Next() {
+		got = append(got, string(iter.Key())+string(iter.Value()))
+	}
+	assert.Equal(t, []string{"a1", "b2", "c1", "e1", "f2"}, got)
+	assert.False(t, iter.Valid())
+	assert.False(t, iter.Next())
+	assert.NoError(t, iter.Error())
+}
+
+func TestUnionIterator_Prev(t *testing.T) {
+	iter := newTestUnionIterator()
+	defer iter.Release()
+
+	assert.False(t, iter.Prev())
+
+	iter.Last()
+	got := []string{string(iter.Key()) + string(iter.Value())}
+	for iter.Prev() {
+		got = append(got, string(iter.Key())+string(iter.Value()))
+	}
+	assert.Equal(t, []string{"f2", "e1", "c1", "b2", "a1"}, got)
+	assert.False(t, iter.Prev())
+
+	// iterator before the first element moves to the first one
+	require.True(t, iter.Next())
+	assert.Equal(t, "a", string(iter.Key()))
+}
+
+func TestUnionIterator_ChangeDirection(t *testing.T) {
+	iter := newTestUnionIterator()
+	defer iter.Release()
+
+	require.True(t, iter.Seek([]byte("c")))
+	assert.Equal(t, "c1", string(iter.Key())+string(iter.Value()))
+
+	require.True(t, iter.Prev())
+	assert.Equal(t, "b", string(iter.Key()))
+	require.True(t, iter.Next())
+	assert.Equal(t, "c1", string(iter.Key())+string(iter.Value()))
+	require.True(t, iter.Next())
+	assert.Equal(t, "e", string(iter.Key()))
+	require.True(t, iter.Prev())
+	assert.Equal(t, "c1", string(iter.Key())+string(iter.Value()))
+
+	// exhausted iterator moves back to the last element
+	require.True(t, iter.Seek([]byte("f")))
+	assert.False(t, iter.Next())
+	require.True(t, iter.Prev())
+	assert.Equal(t, "f", string(iter.Key()))
+
+	assert.False(t, iter.Seek([]byte("g")))
+	require.True(t, iter.First())
+	assert.Equal(t, "a", string(iter.Key()))
+}
+
+func TestUnionIterator_Release(t *testing.T) {
+	iter := newTestUnionIterator()
+	released := false
+	iter.SetReleaser(releaserFunc(func() { released = true }))
+
+	require.True(t, iter.Next())
+	iter.Release()
+	iter.Release()
+	assert.True(t, released)
+
+	assert.False(t, iter.Valid())
+	assert.Nil(t, iter.Key())
+	assert.Nil(t, iter.Value())
+	assert.False(t, iter.First())
+	assert.False(t, iter.Last())
+	assert.False(t, iter.Seek([]byte("a")))
+	assert.False(t, iter.Next())
+	assert.False(t, iter.Prev())
+	assert.NoError(t, iter.Error())
+}
+
+func TestUnionIterator_Error(t *testing.T) {
+	wantErr := errors.New("layer error")
+	iter := newUnionIterator([]ldbiterator.Iterator{
+		newTestArrayIterator("a", "1"),
+		ldbiterator.NewEmptyIterator(wantErr),
+	})
+
+	assert.Equal(t, wantErr, iter.Error())
+	iter.Release()
+	// error is kept after release
+	assert.Equal(t, wantErr, iter.Error())
+}
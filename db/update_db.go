@@ -27,6 +27,11 @@ type UpdateDB interface {
 	// GetSubstateEncoding returns the encoding schema in use.
 	GetSubstateEncoding() SubstateEncodingSchema
 
+	// SetEncodingTag enables or disables tagging of written update sets with their encoding.
+	// Tagged update sets are decoded with their own encoding regardless of the encoding in use,
+	// hence one db can hold update sets of multiple encodings.
+	SetEncodingTag(enabled bool)
+
 	// GetFirstKey returns block number of first UpdateSet. It returns an error if no UpdateSet is found.
 	GetFirstKey() (uint64, error)
 
@@ -61,6 +66,12 @@ func NewUpdateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.Read
 	return newUpdateDB(path, o, wo, ro)
 }
 
+// NewUpdateDBWithBackend creates new instance of UpdateDB stored in given backend.
+// Note: Any of three options is nillable. If that's the case a default value for the option is set.
+func NewUpdateDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (UpdateDB, error) {
+	return newUpdateDBWithBackend(path, backend, o, wo, ro)
+}
+
 func MakeDefaultUpdateDBFromBaseDB(db BaseDB) (UpdateDB, error) {
 	value, err := MakeDefaultUpdateDBFromBaseDBWithEncoding(db, db.GetSubstateEncoding())
 	if err != nil {
@@ -74,10 +85,12 @@ func MakeDefaultUpdateDBFromBaseDBWithEncoding(db BaseDB, schema SubstateEncodin
 	if err != nil {
 		return nil, err
 	}
-	return &updateDB{
-		&codeDB{db.GetBackend(), nil, nil},
+	udb := &updateDB{
+		&codeDB{backend: db.GetBackend(), metadata: metadataCacheOf(db)},
 		*encoding,
-	}, nil
+	}
+	udb.encoding.compression = newValueCompression(udb)
+	return udb, nil
 }
 
 // NewReadOnlyUpdateDB creates a new instance of read-only UpdateDB.
@@ -86,7 +99,11 @@ func NewReadOnlyUpdateDB(path string) (UpdateDB, error) {
 }
 
 func newUpdateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*updateDB, error) {
-	base, err := newCodeDB(path, o, wo, ro)
+	return newUpdateDBWithBackend(path, DefaultBackend, o, wo, ro)
+}
+
+func newUpdateDBWithBackend(path string, backend Backend, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*updateDB, error) {
+	base, err := newCodeDBWithBackend(path, backend, o, wo, ro)
 	if err != nil {
 		return nil, err
 	}
@@ -94,10 +111,24 @@ func newUpdateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.Read
 	if err != nil {
 		return nil, fmt.Errorf("failed to create default update-db encoding: %v", err)
 	}
-	return &updateDB{
+	udb := &updateDB{
 		base,
 		*encoding,
-	}, nil
+	}
+	udb.encoding.compression = newValueCompression(udb)
+
+	md, err := udb.GetMetadata()
+	if err != nil {
+		base.Close()
+		return nil, err
+	}
+	if md != nil && md.EncodingSchema != "" {
+		if err = udb.SetSubstateEncoding(md.EncodingSchema); err != nil {
+			base.Close()
+			return nil, fmt.Errorf("failed to set recorded update-db encoding: %w", err)
+		}
+	}
+	return udb, nil
 }
 
 type updateDB struct {
@@ -105,6 +136,17 @@ type updateDB struct {
 	encoding updateSetEncoding
 }
 
+func (db *updateDB) Snapshot() (DBSnapshot, error) {
+	if err := metadataCacheOf(db).flush(); err != nil {
+		return nil, err
+	}
+	return newDBSnapshot(db.GetBackend(), db.GetSubstateEncoding())
+}
+
+func (db *updateDB) metadataCache() *metadataCache {
+	return metadataCacheOf(db.CodeDB)
+}
+
 func (db *updateDB) GetFirstKey() (uint64, error) {
 	r := util.BytesPrefix([]byte(UpdateDBPrefix))
 
@@ -156,7 +198,7 @@ func (db *updateDB) GetUpdateSet(block uint64) (*updateset.UpdateSet, error) {
 	}
 
 	// decode value
-	data, err := db.encoding.decode(block, db.GetCode, value)
+	data, err := db.decodeUpdateSet(block, db.GetCode, value)
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode update-set block: %v, key %v; %w", block, key, err)
 	}
@@ -164,6 +206,10 @@ func (db *updateDB) GetUpdateSet(block uint64) (*updateset.UpdateSet, error) {
 }
 
 func (db *updateDB) PutUpdateSet(updateSet *updateset.UpdateSet, deletedAccounts []types.Address) error {
+	if err := recordMetadata(db, db.GetSubstateEncoding(), db.encoding.tagged, updateSet.Block, false); err != nil {
+		return fmt.Errorf("cannot record update-set block %v in metadata; %w", updateSet.Block, err)
+	}
+
 	// put deployed/creation code
 	for _, account := range updateSet.WorldState {
 		err := db.PutCode(account.Code)
@@ -177,6 +223,15 @@ func (db *updateDB) PutUpdateSet(updateSet *updateset.UpdateSet, deletedAccounts
 	if err != nil {
 		return fmt.Errorf("cannot encode update-set; %v", err)
 	}
+	if db.encoding.tagged {
+		if value, err = tagEncoding(db.encoding.schema, value); err != nil {
+			return fmt.Errorf("cannot encode update-set; %v", err)
+		}
+	}
+	value, err = db.encoding.compression.compress(value)
+	if err != nil {
+		return fmt.Errorf("cannot compress update-set; %w", err)
+	}
 
 	return db.Put(key, value)
 }
@@ -187,7 +242,7 @@ func (db *updateDB) DeleteUpdateSet(block uint64) error {
 }
 
 func (db *updateDB) NewUpdateSetIterator(start, end uint64) IIterator[*updateset.UpdateSet] {
-	iter := newUpdateSetIterator(db, start, end, db.encoding.decode)
+	iter := newUpdateSetIterator(db, start, end, db.decodeUpdateSet)
 
 	iter.start(0)
 
@@ -188,6 +188,21 @@ func (mr *MockUpdateDBMockRecorder) GetLastKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastKey", reflect.TypeOf((*MockUpdateDB)(nil).GetLastKey))
 }
 
+// GetMetadata mocks base method.
+func (m *MockUpdateDB) GetMetadata() (*Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(*Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockUpdateDBMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockUpdateDB)(nil).GetMetadata))
+}
+
 // GetSubstateEncoding mocks base method.
 func (m *MockUpdateDB) GetSubstateEncoding() SubstateEncodingSchema {
 	m.ctrl.T.Helper()
@@ -360,6 +375,32 @@ func (mr *MockUpdateDBMockRecorder) PutUpdateSet(updateSet, deletedAccounts any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUpdateSet", reflect.TypeOf((*MockUpdateDB)(nil).PutUpdateSet), updateSet, deletedAccounts)
 }
 
+// SetEncodingTag mocks base method.
+func (m *MockUpdateDB) SetEncodingTag(enabled bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetEncodingTag", enabled)
+}
+
+// SetEncodingTag indicates an expected call of SetEncodingTag.
+func (mr *MockUpdateDBMockRecorder) SetEncodingTag(enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncodingTag", reflect.TypeOf((*MockUpdateDB)(nil).SetEncodingTag), enabled)
+}
+
+// SetMetadata mocks base method.
+func (m *MockUpdateDB) SetMetadata(md *Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", md)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockUpdateDBMockRecorder) SetMetadata(md any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockUpdateDB)(nil).SetMetadata), md)
+}
+
 // SetSubstateEncoding mocks base method.
 func (m *MockUpdateDB) SetSubstateEncoding(schema SubstateEncodingSchema) error {
 	m.ctrl.T.Helper()
@@ -374,6 +415,21 @@ func (mr *MockUpdateDBMockRecorder) SetSubstateEncoding(schema any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSubstateEncoding", reflect.TypeOf((*MockUpdateDB)(nil).SetSubstateEncoding), schema)
 }
 
+// Snapshot mocks base method.
+func (m *MockUpdateDB) Snapshot() (DBSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(DBSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockUpdateDBMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockUpdateDB)(nil).Snapshot))
+}
+
 // Stat mocks base method.
 func (m *MockUpdateDB) Stat(property string) (string, error) {
 	m.ctrl.T.Helper()
@@ -43,152 +43,176 @@ func newTestUpdateDB(t *testing.T, db *MockCodeDB, schema SubstateEncodingSchema
 }
 
 func TestUpdateDB_PutUpdateSet(t *testing.T) {
-	testCases := []struct {
-		name   string
-		schema SubstateEncodingSchema
-	}{
-		{"RLP", RLPEncodingSchema},
-		{"PB", ProtobufEncodingSchema},
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			testCases := []struct {
+				name   string
+				schema SubstateEncodingSchema
+			}{
+				{"RLP", RLPEncodingSchema},
+				{"PB", ProtobufEncodingSchema},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					dbPath := t.TempDir() + "test-db"
+					db, err := createDbAndPutUpdateSet(dbPath, backend, tc.schema)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					s := new(leveldb.DBStats)
+					err = db.stats(s)
+					if err != nil {
+						t.Fatalf("cannot get db stats; %v", err)
+					}
+
+					// 54 is the base write when creating levelDB
+					if s.IOWrite <= 54 {
+						t.Fatal("db file should have something inside")
+					}
+				})
+			}
+		})
 	}
+}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
+func TestUpdateDB_HasUpdateSet(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
 			dbPath := t.TempDir() + "test-db"
-			db, err := createDbAndPutUpdateSet(dbPath, tc.schema)
+			db, err := createDbAndPutUpdateSet(dbPath, backend, DefaultEncodingSchema)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			s := new(leveldb.DBStats)
-			err = db.stats(s)
+			has, err := db.HasUpdateSet(testUpdateSet.Block)
 			if err != nil {
-				t.Fatalf("cannot get db stats; %v", err)
+				t.Fatalf("has update-set returned error; %v", err)
 			}
 
-			// 54 is the base write when creating levelDB
-			if s.IOWrite <= 54 {
-				t.Fatal("db file should have something inside")
+			if !has {
+				t.Fatal("update-set is not within db")
 			}
 		})
 	}
 }
 
-func TestUpdateDB_HasUpdateSet(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(dbPath, DefaultEncodingSchema)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	has, err := db.HasUpdateSet(testUpdateSet.Block)
-	if err != nil {
-		t.Fatalf("has update-set returned error; %v", err)
-	}
+func TestUpdateDB_GetUpdateSet(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			testCases := []struct {
+				name   string
+				schema SubstateEncodingSchema
+			}{
+				{"RLP", RLPEncodingSchema},
+				{"PB", ProtobufEncodingSchema},
+			}
 
-	if !has {
-		t.Fatal("update-set is not within db")
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					dbPath := t.TempDir() + "test-db"
+					db, err := createDbAndPutUpdateSet(dbPath, backend, tc.schema)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					us, err := db.GetUpdateSet(testUpdateSet.Block)
+					if err != nil {
+						t.Fatalf("get update-set returned error; %v", err)
+					}
+
+					if us == nil {
+						t.Fatal("update-set is nil")
+					}
+
+					if !us.Equal(testUpdateSet) {
+						t.Fatal("substates are different")
+					}
+				})
+			}
+		})
 	}
 }
 
-func TestUpdateDB_GetUpdateSet(t *testing.T) {
-	testCases := []struct {
-		name   string
-		schema SubstateEncodingSchema
-	}{
-		{"RLP", RLPEncodingSchema},
-		{"PB", ProtobufEncodingSchema},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
+func TestUpdateDB_DeleteUpdateSet(t *testing.T) {
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
 			dbPath := t.TempDir() + "test-db"
-			db, err := createDbAndPutUpdateSet(dbPath, tc.schema)
+			db, err := createDbAndPutUpdateSet(dbPath, backend, DefaultEncodingSchema)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			us, err := db.GetUpdateSet(testUpdateSet.Block)
+			err = db.DeleteUpdateSet(testUpdateSet.Block)
 			if err != nil {
-				t.Fatalf("get update-set returned error; %v", err)
+				t.Fatalf("delete update-set returned error; %v", err)
 			}
 
-			if us == nil {
-				t.Fatal("update-set is nil")
+			us, err := db.GetUpdateSet(testUpdateSet.Block)
+			if err == nil {
+				t.Fatal("get update-set must fail")
 			}
 
-			if !us.Equal(testUpdateSet) {
-				t.Fatal("substates are different")
+			if got, want := err, leveldb.ErrNotFound; !errors.Is(got, want) {
+				t.Fatalf("unexpected err, got: %v, want: %v", got, want)
 			}
-		})
-	}
-}
-
-func TestUpdateDB_DeleteUpdateSet(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(dbPath, DefaultEncodingSchema)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	err = db.DeleteUpdateSet(testUpdateSet.Block)
-	if err != nil {
-		t.Fatalf("delete update-set returned error; %v", err)
-	}
-
-	us, err := db.GetUpdateSet(testUpdateSet.Block)
-	if err == nil {
-		t.Fatal("get update-set must fail")
-	}
-
-	if got, want := err, leveldb.ErrNotFound; !errors.Is(got, want) {
-		t.Fatalf("unexpected err, got: %v, want: %v", got, want)
-	}
 
-	if us != nil {
-		t.Fatal("update-set was not deleted")
+			if us != nil {
+				t.Fatal("update-set was not deleted")
+			}
+		})
 	}
 }
 
 func TestUpdateDB_GetFirstKey(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(dbPath, DefaultEncodingSchema)
-	if err != nil {
-		t.Fatal(err)
-	}
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutUpdateSet(dbPath, backend, DefaultEncodingSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	got, err := db.GetFirstKey()
-	if err != nil {
-		t.Fatalf("cannot get first key; %v", err)
-	}
+			got, err := db.GetFirstKey()
+			if err != nil {
+				t.Fatalf("cannot get first key; %v", err)
+			}
 
-	var want = testUpdateSet.Block
+			var want = testUpdateSet.Block
 
-	if want != got {
-		t.Fatalf("incorrect first key\nwant: %v\ngot: %v", want, got)
+			if want != got {
+				t.Fatalf("incorrect first key\nwant: %v\ngot: %v", want, got)
+			}
+		})
 	}
 }
 
 func TestUpdateDB_GetLastKey(t *testing.T) {
-	dbPath := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(dbPath, DefaultEncodingSchema)
-	if err != nil {
-		t.Fatal(err)
-	}
+	for _, backend := range AllBackends {
+		t.Run(string(backend), func(t *testing.T) {
+			dbPath := t.TempDir() + "test-db"
+			db, err := createDbAndPutUpdateSet(dbPath, backend, DefaultEncodingSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	got, err := db.GetLastKey()
-	if err != nil {
-		t.Fatalf("cannot get last key; %v", err)
-	}
+			got, err := db.GetLastKey()
+			if err != nil {
+				t.Fatalf("cannot get last key; %v", err)
+			}
 
-	var want = testUpdateSet.Block
+			var want = testUpdateSet.Block
 
-	if want != got {
-		t.Fatalf("incorrect last key\nwant: %v\ngot: %v", want, got)
+			if want != got {
+				t.Fatalf("incorrect last key\nwant: %v\ngot: %v", want, got)
+			}
+		})
 	}
 }
 
-func createDbAndPutUpdateSet(dbPath string, encoding SubstateEncodingSchema) (*updateDB, error) {
-	db, err := newUpdateDB(dbPath, nil, nil, nil)
+func createDbAndPutUpdateSet(dbPath string, backend Backend, encoding SubstateEncodingSchema) (*updateDB, error) {
+	db, err := newUpdateDBWithBackend(dbPath, backend, nil, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open db; %v", err)
 	}
@@ -410,6 +434,7 @@ func TestUpdateDB_PutUpdateSetSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDB := NewMockCodeDB(ctrl)
+	mockDB.EXPECT().GetMetadata().Return(nil, nil).AnyTimes()
 
 	updateSet := &updateset.UpdateSet{
 		WorldState: substate.WorldState{
@@ -440,6 +465,7 @@ func TestUpdateDB_PutUpdateSetFail(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDB := NewMockCodeDB(ctrl)
+	mockDB.EXPECT().GetMetadata().Return(nil, nil).AnyTimes()
 
 	updateSet := &updateset.UpdateSet{
 		WorldState: substate.WorldState{
@@ -21,16 +21,76 @@ func (db *updateDB) SetSubstateEncoding(schema SubstateEncodingSchema) error {
 		return fmt.Errorf("failed to set decoder; %w", err)
 	}
 
+	encoding.compression = newValueCompression(db)
+	encoding.tagged = db.encoding.tagged
 	db.encoding = *encoding
 	return nil
 }
 
+// SetEncodingTag enables or disables tagging of written update sets with their encoding.
+func (db *updateDB) SetEncodingTag(enabled bool) {
+	db.encoding.tagged = enabled
+}
+
+// decodeUpdateSet decompresses data if needed and decodes it using the configured encoding,
+// records tagged with their encoding are decoded with it.
+func (db *updateDB) decodeUpdateSet(block uint64, getCode func(codeHash types.Hash) ([]byte, error), data []byte) (*updateset.UpdateSet, error) {
+	data, err := db.encoding.compression.decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	schema, data, tagged, err := splitEncodingTag(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+	}
+	if !tagged || schema == db.encoding.schema {
+		return db.encoding.decode(block, getCode, data)
+	}
+
+	encoding, err := newUpdateSetEncoding(schema)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.decode(block, getCode, data)
+}
+
+// decodeDeletedAccounts returns the deleted accounts stored along the update set of data,
+// they are not part of update sets returned by decodeUpdateSet.
+func (db *updateDB) decodeDeletedAccounts(data []byte) ([]types.Address, error) {
+	data, err := db.encoding.compression.decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	schema, data, tagged, err := splitEncodingTag(data)
+	if err != nil {
+		return nil, err
+	}
+	if !tagged || schema == db.encoding.schema {
+		return db.encoding.deletedAccounts(data)
+	}
+
+	encoding, err := newUpdateSetEncoding(schema)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.deletedAccounts(data)
+}
+
 type UpdateSetEncoderFunc = func(updateSet updateset.UpdateSet, deletedAccounts []types.Address) ([]byte, error)
 type UpdateSetDecoderFunc = func(block uint64, getCode func(codeHash types.Hash) ([]byte, error), data []byte) (*updateset.UpdateSet, error)
 type updateSetEncoding struct {
 	schema SubstateEncodingSchema
 	encode UpdateSetEncoderFunc
 	decode UpdateSetDecoderFunc
+
+	// deletedAccounts returns the deleted accounts stored along the update set, they are not part of the decoded update set
+	deletedAccounts func(data []byte) ([]types.Address, error)
+
+	// compression is applied on top of encode and undone before decode, nil disables it
+	compression *valueCompression
+
+	// tagged prefixes encoded records with the encoding tag
+	tagged bool
 }
 
 func newUpdateSetEncoding(encoding SubstateEncodingSchema) (*updateSetEncoding, error) {
@@ -40,12 +100,16 @@ func newUpdateSetEncoding(encoding SubstateEncodingSchema) (*updateSetEncoding,
 			schema: ProtobufEncodingSchema,
 			encode: encodeUpdateSetPB,
 			decode: decodeUpdateSetPB,
+
+			deletedAccounts: decodeDeletedAccountsPB,
 		}, nil
 	case RLPEncodingSchema:
 		return &updateSetEncoding{
 			schema: RLPEncodingSchema,
 			encode: encodeUpdateSetRLP,
 			decode: decodeUpdateSetRLP,
+
+			deletedAccounts: decodeDeletedAccountsRLP,
 		}, nil
 	default:
 		return nil, fmt.Errorf("encoding not supported: %s", encoding)
@@ -53,18 +117,10 @@ func newUpdateSetEncoding(encoding SubstateEncodingSchema) (*updateSetEncoding,
 }
 
 func encodeUpdateSetPB(updateSet updateset.UpdateSet, deletedAccounts []types.Address) ([]byte, error) {
-	up, err := protobuf.NewUpdateSetPB(updateSet.WorldState, deletedAccounts)
+	obj, err := newUpdateSetMessage(updateSet, deletedAccounts)
 	if err != nil {
 		return nil, err
 	}
-	addrs := make([][]byte, 0, len(up.DeletedAccounts))
-	for _, addr := range up.DeletedAccounts {
-		addrs = append(addrs, addr.Bytes())
-	}
-	obj := &protobuf.UpdateSet{
-		WorldState:      up.WorldState,
-		DeletedAccounts: addrs,
-	}
 	return proto.Marshal(obj)
 }
 
@@ -74,6 +130,39 @@ func decodeUpdateSetPB(block uint64, getCode func(codeHash types.Hash) ([]byte,
 	if err != nil {
 		return nil, err
 	}
+	return updateSetFromMessage(block, getCode, obj)
+}
+
+func decodeDeletedAccountsPB(data []byte) ([]types.Address, error) {
+	obj := &protobuf.UpdateSet{}
+	if err := proto.Unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	addrs := make([]types.Address, 0, len(obj.DeletedAccounts))
+	for _, addr := range obj.DeletedAccounts {
+		addrs = append(addrs, types.BytesToAddress(addr))
+	}
+	return addrs, nil
+}
+
+// newUpdateSetMessage converts update set into protobuf UpdateSet message.
+func newUpdateSetMessage(updateSet updateset.UpdateSet, deletedAccounts []types.Address) (*protobuf.UpdateSet, error) {
+	up, err := protobuf.NewUpdateSetPB(updateSet.WorldState, deletedAccounts)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([][]byte, 0, len(up.DeletedAccounts))
+	for _, addr := range up.DeletedAccounts {
+		addrs = append(addrs, addr.Bytes())
+	}
+	return &protobuf.UpdateSet{
+		WorldState:      up.WorldState,
+		DeletedAccounts: addrs,
+	}, nil
+}
+
+// updateSetFromMessage converts protobuf UpdateSet message into update set of given block.
+func updateSetFromMessage(block uint64, getCode func(codeHash types.Hash) ([]byte, error), obj *protobuf.UpdateSet) (*updateset.UpdateSet, error) {
 	addrs := make([]types.Address, 0, len(obj.DeletedAccounts))
 	for _, addr := range obj.DeletedAccounts {
 		addrs = append(addrs, types.BytesToAddress(addr))
@@ -112,3 +201,11 @@ func decodeUpdateSetRLP(block uint64, getCode func(codeHash types.Hash) ([]byte,
 	}
 	return updateset.NewUpdateSet(*ws, block), nil
 }
+
+func decodeDeletedAccountsRLP(data []byte) ([]types.Address, error) {
+	var up rlp.UpdateSetRLP
+	if err := trlp.DecodeBytes(data, &up); err != nil {
+		return nil, err
+	}
+	return up.DeletedAccounts, nil
+}
@@ -17,7 +17,7 @@ import (
 
 func TestUpdateSetIterator_Next(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(path, DefaultEncodingSchema)
+	db, err := createDbAndPutUpdateSet(path, DefaultBackend, DefaultEncodingSchema)
 	if err != nil {
 		return
 	}
@@ -34,7 +34,7 @@ func TestUpdateSetIterator_Next(t *testing.T) {
 
 func TestUpdateSetIterator_Value(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(path, DefaultEncodingSchema)
+	db, err := createDbAndPutUpdateSet(path, DefaultBackend, DefaultEncodingSchema)
 	if err != nil {
 		return
 	}
@@ -59,7 +59,7 @@ func TestUpdateSetIterator_Value(t *testing.T) {
 
 func TestUpdateSetIterator_Release(t *testing.T) {
 	path := t.TempDir() + "test-db"
-	db, err := createDbAndPutUpdateSet(path, DefaultEncodingSchema)
+	db, err := createDbAndPutUpdateSet(path, DefaultBackend, DefaultEncodingSchema)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,414 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/updateset"
+)
+
+// Comparer compares values and returns their differences. The zero value compares
+// the same fields as the Equal methods of the compared types.
+type Comparer struct {
+	// ZeroSlotsAreMissing considers storage slots holding zero equal to missing slots as WorldState.Diff does.
+	ZeroSlotsAreMissing bool
+}
+
+// Substate returns the differences of got from want using the zero Comparer.
+func Substate(want, got *substate.Substate) Differences {
+	return Comparer{}.Substate(want, got)
+}
+
+// WorldState returns the differences of got from want using the zero Comparer.
+func WorldState(want, got substate.WorldState) Differences {
+	return Comparer{}.WorldState(want, got)
+}
+
+// Env returns the differences of got from want using the zero Comparer.
+func Env(want, got *substate.Env) Differences {
+	return Comparer{}.Env(want, got)
+}
+
+// Message returns the differences of got from want using the zero Comparer.
+func Message(want, got *substate.Message) Differences {
+	return Comparer{}.Message(want, got)
+}
+
+// Result returns the differences of got from want using the zero Comparer.
+func Result(want, got *substate.Result) Differences {
+	return Comparer{}.Result(want, got)
+}
+
+// UpdateSet returns the differences of got from want using the zero Comparer.
+func UpdateSet(want, got *updateset.UpdateSet) Differences {
+	return Comparer{}.UpdateSet(want, got)
+}
+
+// Exception returns the differences of got from want using the zero Comparer.
+func Exception(want, got *substate.Exception) Differences {
+	return Comparer{}.Exception(want, got)
+}
+
+// Substate returns the differences of got from want, paths start with the field of the substate.
+func (c Comparer) Substate(want, got *substate.Substate) Differences {
+	col := &collector{options: c}
+	col.substate(want, got)
+	return col.differences
+}
+
+// WorldState returns the differences of got from want, paths start with WorldState.
+func (c Comparer) WorldState(want, got substate.WorldState) Differences {
+	col := &collector{options: c}
+	col.worldState("WorldState", want, got)
+	return col.differences
+}
+
+// Env returns the differences of got from want, paths start with Env.
+func (c Comparer) Env(want, got *substate.Env) Differences {
+	col := &collector{options: c}
+	col.env("Env", want, got)
+	return col.differences
+}
+
+// Message returns the differences of got from want, paths start with Message.
+func (c Comparer) Message(want, got *substate.Message) Differences {
+	col := &collector{options: c}
+	col.message("Message", want, got)
+	return col.differences
+}
+
+// Result returns the differences of got from want, paths start with Result.
+func (c Comparer) Result(want, got *substate.Result) Differences {
+	col := &collector{options: c}
+	col.result("Result", want, got)
+	return col.differences
+}
+
+// UpdateSet returns the differences of got from want, paths start with UpdateSet.
+func (c Comparer) UpdateSet(want, got *updateset.UpdateSet) Differences {
+	col := &collector{options: c}
+	path := "UpdateSet"
+	if col.nil(path, want == nil, got == nil) {
+		return col.differences
+	}
+	if got.Block != want.Block {
+		col.add(path+".Block", got.Block, want.Block)
+	}
+	col.worldState(path+".WorldState", want.WorldState, got.WorldState)
+	col.list(path+".DeletedAccounts", len(want.DeletedAccounts), len(got.DeletedAccounts), func(i int, path string) {
+		if got.DeletedAccounts[i] != want.DeletedAccounts[i] {
+			col.add(path, got.DeletedAccounts[i], want.DeletedAccounts[i])
+		}
+	})
+	return col.differences
+}
+
+// Exception returns the differences of got from want, paths start with Exception.
+func (c Comparer) Exception(want, got *substate.Exception) Differences {
+	col := &collector{options: c}
+	path := "Exception"
+	if col.nil(path, want == nil, got == nil) {
+		return col.differences
+	}
+	if got.Block != want.Block {
+		col.add(path+".Block", got.Block, want.Block)
+	}
+	col.optionalWorldState(path+".PreBlock", want.Data.PreBlock, got.Data.PreBlock)
+	col.optionalWorldState(path+".PostBlock", want.Data.PostBlock, got.Data.PostBlock)
+
+	txs := make(map[int]struct{}, len(want.Data.Transactions))
+	for tx := range want.Data.Transactions {
+		txs[tx] = struct{}{}
+	}
+	for tx := range got.Data.Transactions {
+		txs[tx] = struct{}{}
+	}
+	sorted := make([]int, 0, len(txs))
+	for tx := range txs {
+		sorted = append(sorted, tx)
+	}
+	sort.Ints(sorted)
+	for _, tx := range sorted {
+		txPath := fmt.Sprintf("%v.Transactions[%v]", path, tx)
+		w, wantFound := want.Data.Transactions[tx]
+		g, gotFound := got.Data.Transactions[tx]
+		if col.nil(txPath, !wantFound, !gotFound) {
+			continue
+		}
+		col.optionalWorldState(txPath+".PreTransaction", w.PreTransaction, g.PreTransaction)
+		col.optionalWorldState(txPath+".PostTransaction", w.PostTransaction, g.PostTransaction)
+		if g.VmException != w.VmException {
+			col.add(txPath+".VmException", g.VmException, w.VmException)
+		}
+	}
+	return col.differences
+}
+
+// nil adds a difference if exactly one value is nil, it reports whether any value is nil.
+func (c *collector) nil(path string, wantNil, gotNil bool) bool {
+	if wantNil != gotNil {
+		c.add(path, presence(!gotNil), presence(!wantNil))
+	}
+	return wantNil || gotNil
+}
+
+func presence(present bool) string {
+	if present {
+		return Present
+	}
+	return Missing
+}
+
+// list compares the lengths of lists and calls compare for every index of equally long lists.
+func (c *collector) list(path string, wantLen, gotLen int, compare func(i int, path string)) {
+	if gotLen != wantLen {
+		c.add(path+".Length", gotLen, wantLen)
+		return
+	}
+	for i := 0; i < wantLen; i++ {
+		compare(i, fmt.Sprintf("%v[%v]", path, i))
+	}
+}
+
+func (c *collector) bigInt(path string, want, got *big.Int) {
+	if (want == nil) != (got == nil) || (want != nil && want.Cmp(got) != 0) {
+		c.add(path, got, want)
+	}
+}
+
+func (c *collector) bytes(path string, want, got []byte) {
+	if !bytes.Equal(got, want) {
+		c.add(path, got, want)
+	}
+}
+
+func (c *collector) substate(want, got *substate.Substate) {
+	if c.nil("Substate", want == nil, got == nil) {
+		return
+	}
+	if got.Block != want.Block {
+		c.add("Block", got.Block, want.Block)
+	}
+	if got.Transaction != want.Transaction {
+		c.add("Transaction", got.Transaction, want.Transaction)
+	}
+	c.worldState("InputSubstate", want.InputSubstate, got.InputSubstate)
+	c.worldState("OutputSubstate", want.OutputSubstate, got.OutputSubstate)
+	c.env("Env", want.Env, got.Env)
+	c.message("Message", want.Message, got.Message)
+	c.result("Result", want.Result, got.Result)
+}
+
+func (c *collector) worldState(path string, want, got substate.WorldState) {
+	addrs := make(map[types.Address]struct{}, len(want))
+	for addr := range want {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range got {
+		addrs[addr] = struct{}{}
+	}
+	for _, addr := range sortedAddresses(addrs) {
+		accPath := fmt.Sprintf("%v[%v]", path, addr)
+		w, g := want[addr], got[addr]
+		if c.nil(accPath, w == nil, g == nil) {
+			continue
+		}
+		if g.Nonce != w.Nonce {
+			c.add(accPath+".Nonce", g.Nonce, w.Nonce)
+		}
+		if (w.Balance == nil) != (g.Balance == nil) || (w.Balance != nil && w.Balance.Cmp(g.Balance) != 0) {
+			c.add(accPath+".Balance", g.Balance, w.Balance)
+		}
+		c.bytes(accPath+".Code", w.Code, g.Code)
+		c.storage(accPath+".Storage", w.Storage, g.Storage)
+	}
+}
+
+func (c *collector) optionalWorldState(path string, want, got *substate.WorldState) {
+	if !c.nil(path, want == nil, got == nil) {
+		c.worldState(path, *want, *got)
+	}
+}
+
+func (c *collector) storage(path string, want, got map[types.Hash]types.Hash) {
+	keys := make(map[types.Hash]struct{}, len(want))
+	for key := range want {
+		keys[key] = struct{}{}
+	}
+	for key := range got {
+		keys[key] = struct{}{}
+	}
+	for _, key := range sortedHashes(keys) {
+		slotPath := fmt.Sprintf("%v[%v]", path, key)
+		w, wantFound := want[key]
+		g, gotFound := got[key]
+		switch {
+		case wantFound && gotFound:
+			if g != w {
+				c.add(slotPath, g, w)
+			}
+		case c.options.ZeroSlotsAreMissing:
+			if g != w {
+				c.add(slotPath, g, w)
+			}
+		case wantFound:
+			c.add(slotPath, Missing, w)
+		default:
+			c.add(slotPath, g, Missing)
+		}
+	}
+}
+
+func (c *collector) env(path string, want, got *substate.Env) {
+	if c.nil(path, want == nil, got == nil) {
+		return
+	}
+	if got.Coinbase != want.Coinbase {
+		c.add(path+".Coinbase", got.Coinbase, want.Coinbase)
+	}
+	c.bigInt(path+".Difficulty", want.Difficulty, got.Difficulty)
+	if got.GasLimit != want.GasLimit {
+		c.add(path+".GasLimit", got.GasLimit, want.GasLimit)
+	}
+	if got.Number != want.Number {
+		c.add(path+".Number", got.Number, want.Number)
+	}
+	if got.Timestamp != want.Timestamp {
+		c.add(path+".Timestamp", got.Timestamp, want.Timestamp)
+	}
+	c.bigInt(path+".BaseFee", want.BaseFee, got.BaseFee)
+	c.bigInt(path+".BlobBaseFee", want.BlobBaseFee, got.BlobBaseFee)
+	if !c.nil(path+".Random", want.Random == nil, got.Random == nil) && *got.Random != *want.Random {
+		c.add(path+".Random", *got.Random, *want.Random)
+	}
+
+	numbers := make(map[uint64]struct{}, len(want.BlockHashes))
+	for number := range want.BlockHashes {
+		numbers[number] = struct{}{}
+	}
+	for number := range got.BlockHashes {
+		numbers[number] = struct{}{}
+	}
+	sorted := make([]uint64, 0, len(numbers))
+	for number := range numbers {
+		sorted = append(sorted, number)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, number := range sorted {
+		hashPath := fmt.Sprintf("%v.BlockHashes[%v]", path, number)
+		w, wantFound := want.BlockHashes[number]
+		g, gotFound := got.BlockHashes[number]
+		switch {
+		case !wantFound:
+			c.add(hashPath, g, Missing)
+		case !gotFound:
+			c.add(hashPath, Missing, w)
+		case g != w:
+			c.add(hashPath, g, w)
+		}
+	}
+}
+
+func (c *collector) message(path string, want, got *substate.Message) {
+	if c.nil(path, want == nil, got == nil) {
+		return
+	}
+	if got.Nonce != want.Nonce {
+		c.add(path+".Nonce", got.Nonce, want.Nonce)
+	}
+	if got.CheckNonce != want.CheckNonce {
+		c.add(path+".CheckNonce", got.CheckNonce, want.CheckNonce)
+	}
+	c.bigInt(path+".GasPrice", want.GasPrice, got.GasPrice)
+	if got.Gas != want.Gas {
+		c.add(path+".Gas", got.Gas, want.Gas)
+	}
+	if got.From != want.From {
+		c.add(path+".From", got.From, want.From)
+	}
+	if !c.nil(path+".To", want.To == nil, got.To == nil) && *got.To != *want.To {
+		c.add(path+".To", *got.To, *want.To)
+	}
+	c.bigInt(path+".Value", want.Value, got.Value)
+	c.bytes(path+".Data", want.Data, got.Data)
+	c.list(path+".AccessList", len(want.AccessList), len(got.AccessList), func(i int, path string) {
+		w, g := want.AccessList[i], got.AccessList[i]
+		if g.Address != w.Address {
+			c.add(path+".Address", g.Address, w.Address)
+		}
+		c.list(path+".StorageKeys", len(w.StorageKeys), len(g.StorageKeys), func(j int, path string) {
+			if g.StorageKeys[j] != w.StorageKeys[j] {
+				c.add(path, g.StorageKeys[j], w.StorageKeys[j])
+			}
+		})
+	})
+	c.bigInt(path+".GasFeeCap", want.GasFeeCap, got.GasFeeCap)
+	c.bigInt(path+".GasTipCap", want.GasTipCap, got.GasTipCap)
+	c.bigInt(path+".BlobGasFeeCap", want.BlobGasFeeCap, got.BlobGasFeeCap)
+	c.list(path+".BlobHashes", len(want.BlobHashes), len(got.BlobHashes), func(i int, path string) {
+		if got.BlobHashes[i] != want.BlobHashes[i] {
+			c.add(path, got.BlobHashes[i], want.BlobHashes[i])
+		}
+	})
+	c.list(path+".SetCodeAuthorizations", len(want.SetCodeAuthorizations), len(got.SetCodeAuthorizations), func(i int, path string) {
+		w, g := want.SetCodeAuthorizations[i], got.SetCodeAuthorizations[i]
+		if !w.Equal(g) {
+			c.add(path, fmt.Sprintf("%+v", g), fmt.Sprintf("%+v", w))
+		}
+	})
+}
+
+func (c *collector) result(path string, want, got *substate.Result) {
+	if c.nil(path, want == nil, got == nil) {
+		return
+	}
+	if got.Status != want.Status {
+		c.add(path+".Status", got.Status, want.Status)
+	}
+	if got.Bloom != want.Bloom {
+		c.add(path+".Bloom", got.Bloom.Bytes(), want.Bloom.Bytes())
+	}
+	if got.ContractAddress != want.ContractAddress {
+		c.add(path+".ContractAddress", got.ContractAddress, want.ContractAddress)
+	}
+	if got.GasUsed != want.GasUsed {
+		c.add(path+".GasUsed", got.GasUsed, want.GasUsed)
+	}
+	c.list(path+".Logs", len(want.Logs), len(got.Logs), func(i int, path string) {
+		w, g := want.Logs[i], got.Logs[i]
+		if c.nil(path, w == nil, g == nil) {
+			return
+		}
+		if g.Address != w.Address {
+			c.add(path+".Address", g.Address, w.Address)
+		}
+		c.list(path+".Topics", len(w.Topics), len(g.Topics), func(j int, path string) {
+			if g.Topics[j] != w.Topics[j] {
+				c.add(path, g.Topics[j], w.Topics[j])
+			}
+		})
+		c.bytes(path+".Data", w.Data, g.Data)
+	})
+}
+
+func sortedAddresses(set map[types.Address]struct{}) []types.Address {
+	sorted := make([]types.Address, 0, len(set))
+	for addr := range set {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	return sorted
+}
+
+func sortedHashes(set map[types.Hash]struct{}) []types.Hash {
+	sorted := make([]types.Hash, 0, len(set))
+	for key := range set {
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	return sorted
+}
@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/updateset"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestSubstate() *substate.Substate {
+	input := substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(100), []byte{0x60})
+	input[types.Address{1}].Storage[types.Hash{1}] = types.Hash{1}
+	output := substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(90), []byte{0x60})
+	return substate.NewSubstate(
+		input,
+		output,
+		substate.NewEnv(types.Address{3}, nil, 30_000_000, 10, 100, big.NewInt(7), nil, map[uint64]types.Hash{9: {9}}, &types.Hash{7}),
+		substate.NewMessage(1, true, big.NewInt(10), 21_000, types.Address{1}, &types.Address{2}, big.NewInt(5),
+			[]byte{1}, nil, nil, types.AccessList{{Address: types.Address{2}, StorageKeys: []types.Hash{{1}}}},
+			big.NewInt(10), big.NewInt(1), nil, []types.Hash{{4}}, nil),
+		substate.NewResult(1, types.Bloom{}, []*types.Log{
+			{Address: types.Address{2}, Topics: []types.Hash{{1}}, Data: []byte{1}},
+			{Address: types.Address{2}, Topics: []types.Hash{{1}, {2}}, Data: []byte{2}},
+		}, types.Address{}, 21_000),
+		10,
+		0,
+	)
+}
+
+func TestSubstate_EqualSubstatesHaveNoDifferences(t *testing.T) {
+	assert.Empty(t, Substate(getTestSubstate(), getTestSubstate()))
+	assert.Empty(t, Substate(nil, nil))
+	assert.Equal(t, Differences{{Path: "Substate", Got: Missing, Want: Present}}, Substate(getTestSubstate(), nil))
+}
+
+func TestSubstate_ReportsPathsOfDifferences(t *testing.T) {
+	want, got := getTestSubstate(), getTestSubstate()
+	got.Transaction = 1
+	got.InputSubstate[types.Address{1}].Storage[types.Hash{1}] = types.Hash{2}
+	got.InputSubstate[types.Address{1}].Storage[types.Hash{2}] = types.Hash{}
+	got.OutputSubstate[types.Address{1}].Balance = uint256.NewInt(80)
+	got.OutputSubstate.Add(types.Address{4}, 0, uint256.NewInt(0), nil)
+	got.Env.BlockHashes = map[uint64]types.Hash{8: {8}}
+	got.Env.Random = nil
+	got.Message.To = nil
+	got.Message.AccessList[0].StorageKeys[0] = types.Hash{2}
+	got.Message.BlobHashes = nil
+	got.Result.Logs[1].Topics[1] = types.Hash{3}
+
+	assert.Equal(t, []string{
+		"Transaction",
+		"InputSubstate[" + types.Address{1}.String() + "].Storage[" + types.Hash{1}.String() + "]",
+		"InputSubstate[" + types.Address{1}.String() + "].Storage[" + types.Hash{2}.String() + "]",
+		"OutputSubstate[" + types.Address{1}.String() + "].Balance",
+		"OutputSubstate[" + types.Address{4}.String() + "]",
+		"Env.Random",
+		"Env.BlockHashes[8]",
+		"Env.BlockHashes[9]",
+		"Message.To",
+		"Message.AccessList[0].StorageKeys[0]",
+		"Message.BlobHashes.Length",
+		"Result.Logs[1].Topics[1]",
+	}, Substate(want, got).Paths())
+}
+
+func TestSubstate_RendersValues(t *testing.T) {
+	want, got := getTestSubstate(), getTestSubstate()
+	got.OutputSubstate[types.Address{1}].Code = []byte{0x61}
+	got.Env.BaseFee = nil
+	got.Message.Data = nil
+
+	differences := Substate(want, got)
+	assert.Equal(t, Differences{
+		{Path: "OutputSubstate[" + types.Address{1}.String() + "].Code", Got: "0x61", Want: "0x60"},
+		{Path: "Env.BaseFee", Got: "nil", Want: "7"},
+		{Path: "Message.Data", Got: "0x", Want: "0x01"},
+	}, differences)
+	assert.Equal(t, "Env.BaseFee: got nil, want 7", differences[1].String())
+	assert.Contains(t, differences.String(), "\nEnv.BaseFee")
+
+	data, err := json.Marshal(differences[1:2])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"path":"Env.BaseFee","got":"nil","want":"7"}]`, string(data))
+}
+
+func TestComparer_ZeroSlotsAreMissing(t *testing.T) {
+	want := substate.NewWorldState().Add(types.Address{1}, 0, uint256.NewInt(0), nil)
+	got := substate.NewWorldState().Add(types.Address{1}, 0, uint256.NewInt(0), nil)
+	got[types.Address{1}].Storage[types.Hash{1}] = types.Hash{}
+
+	assert.Equal(t, Differences{{
+		Path: "WorldState[" + types.Address{1}.String() + "].Storage[" + types.Hash{1}.String() + "]",
+		Got:  types.Hash{}.String(),
+		Want: Missing,
+	}}, WorldState(want, got))
+	assert.Empty(t, Comparer{ZeroSlotsAreMissing: true}.WorldState(want, got))
+
+	got[types.Address{1}].Storage[types.Hash{1}] = types.Hash{1}
+	assert.Len(t, Comparer{ZeroSlotsAreMissing: true}.WorldState(want, got), 1)
+}
+
+func TestResult_ReportsLogs(t *testing.T) {
+	want, got := getTestSubstate().Result, getTestSubstate().Result
+	got.Logs = got.Logs[:1]
+	got.Bloom = types.BytesToBloom([]byte{1})
+	differences := Result(want, got)
+	assert.Equal(t, []string{"Result.Bloom", "Result.Logs.Length"}, differences.Paths())
+	assert.Equal(t, "1", differences[1].Got)
+}
+
+func TestEnvAndMessage_UseTheirPathRoots(t *testing.T) {
+	want, got := getTestSubstate(), getTestSubstate()
+	got.Env.GasLimit = 1
+	got.Message.GasTipCap = big.NewInt(2)
+	assert.Equal(t, []string{"Env.GasLimit"}, Env(want.Env, got.Env).Paths())
+	assert.Equal(t, []string{"Message.GasTipCap"}, Message(want.Message, got.Message).Paths())
+}
+
+func TestUpdateSet_ReportsDifferences(t *testing.T) {
+	want := &updateset.UpdateSet{
+		WorldState:      substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), nil),
+		Block:           5,
+		DeletedAccounts: []types.Address{{2}},
+	}
+	got := &updateset.UpdateSet{
+		WorldState:      substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(1), nil),
+		Block:           6,
+		DeletedAccounts: []types.Address{{3}},
+	}
+	assert.Equal(t, []string{
+		"UpdateSet.Block",
+		"UpdateSet.WorldState[" + types.Address{1}.String() + "].Nonce",
+		"UpdateSet.DeletedAccounts[0]",
+	}, UpdateSet(want, got).Paths())
+	assert.Empty(t, UpdateSet(want, want))
+}
+
+func TestException_ReportsDifferences(t *testing.T) {
+	pre := substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), nil)
+	post := substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(1), nil)
+	want := &substate.Exception{Block: 5, Data: substate.ExceptionBlock{
+		Transactions: map[int]substate.ExceptionTx{
+			0: {PreTransaction: &pre, PostTransaction: &post},
+			1: {PreTransaction: &pre, VmException: true},
+		},
+		PreBlock: &pre,
+	}}
+	got := &substate.Exception{Block: 5, Data: substate.ExceptionBlock{
+		Transactions: map[int]substate.ExceptionTx{
+			0: {PreTransaction: &post, PostTransaction: &post},
+			2: {},
+		},
+		PreBlock:  &pre,
+		PostBlock: &post,
+	}}
+	assert.Equal(t, Differences{
+		{Path: "Exception.PostBlock", Got: Present, Want: Missing},
+		{Path: "Exception.Transactions[0].PreTransaction[" + types.Address{1}.String() + "].Nonce", Got: "2", Want: "1"},
+		{Path: "Exception.Transactions[1]", Got: Missing, Want: Present},
+		{Path: "Exception.Transactions[2]", Got: Present, Want: Missing},
+	}, Exception(want, got))
+	assert.Empty(t, Exception(want, want))
+	assert.Equal(t, Differences{{Path: "Exception", Got: Missing, Want: Present}}, Exception(want, nil))
+}
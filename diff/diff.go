@@ -0,0 +1,79 @@
+// Package diff compares substates and their parts field by field and describes every
+// difference with the path of the field, e.g. OutputSubstate[0x01...].Storage[0x02...].
+package diff
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// Missing and Present are the values of accounts, slots and other entries missing on one side.
+	Missing = "missing"
+	Present = "present"
+)
+
+// Difference is a field whose value differs between two compared values.
+type Difference struct {
+	Path string `json:"path"`
+	Got  string `json:"got"`
+	Want string `json:"want"`
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%v: got %v, want %v", d.Path, d.Got, d.Want)
+}
+
+// Differences is a list of differences ordered by path components.
+type Differences []Difference
+
+// String renders d as text with one difference per line.
+func (d Differences) String() string {
+	var builder strings.Builder
+	for i, difference := range d {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString(difference.String())
+	}
+	return builder.String()
+}
+
+// Paths returns the paths of all differences.
+func (d Differences) Paths() []string {
+	paths := make([]string, len(d))
+	for i, difference := range d {
+		paths[i] = difference.Path
+	}
+	return paths
+}
+
+// collector collects differences of a comparison.
+type collector struct {
+	options     Comparer
+	differences Differences
+}
+
+func (c *collector) add(path string, got, want any) {
+	c.differences = append(c.differences, Difference{Path: path, Got: format(got), Want: format(want)})
+}
+
+// format renders values of differences, byte slices are hex encoded.
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return v
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case *big.Int:
+		if v == nil {
+			return "nil"
+		}
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
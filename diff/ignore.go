@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xsoniclabs/substate/types"
+)
+
+const (
+	// AnyKey is a key of ignore rules matching all keys, e.g. OutputSubstate[*].Balance.
+	AnyKey = "*"
+	// CoinbaseKey is a key of ignore rules matching the coinbase of the compared substate,
+	// e.g. OutputSubstate[coinbase].Balance.
+	CoinbaseKey = "coinbase"
+)
+
+// Ignore holds rules selecting differences which are dropped. A rule is a path which matches
+// itself and all paths below it, e.g. Env.BlockHashes matches Env.BlockHashes[5] and
+// Env.BlockHashes.Length. Keys in brackets are compared case-insensitively.
+type Ignore struct {
+	rules [][]string
+}
+
+// NewIgnore parses rules, rules must not be empty and brackets must be balanced.
+func NewIgnore(rules ...string) (*Ignore, error) {
+	ignore := &Ignore{rules: make([][]string, 0, len(rules))}
+	for _, rule := range rules {
+		components, err := splitPath(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore rule %q; %w", rule, err)
+		}
+		ignore.rules = append(ignore.rules, components)
+	}
+	return ignore, nil
+}
+
+// Filter returns differences of a substate with coinbase which are matched by no rule.
+func (ig *Ignore) Filter(differences Differences, coinbase types.Address) Differences {
+	if ig == nil || len(ig.rules) == 0 {
+		return differences
+	}
+	var kept Differences
+	for _, difference := range differences {
+		if !ig.matches(difference.Path, coinbase) {
+			kept = append(kept, difference)
+		}
+	}
+	return kept
+}
+
+func (ig *Ignore) matches(path string, coinbase types.Address) bool {
+	components, err := splitPath(path)
+	if err != nil {
+		return false
+	}
+	for _, rule := range ig.rules {
+		if matchRule(rule, components, coinbase) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRule reports whether rule is a prefix of the path components.
+func matchRule(rule, components []string, coinbase types.Address) bool {
+	if len(rule) > len(components) {
+		return false
+	}
+	for i, r := range rule {
+		c := components[i]
+		switch {
+		case r == "["+AnyKey+"]":
+			if !strings.HasPrefix(c, "[") {
+				return false
+			}
+		case r == "["+CoinbaseKey+"]":
+			if !strings.EqualFold(c, "["+coinbase.String()+"]") {
+				return false
+			}
+		case strings.HasPrefix(r, "["):
+			if !strings.EqualFold(r, c) {
+				return false
+			}
+		case r != c:
+			return false
+		}
+	}
+	return true
+}
+
+// splitPath splits a path into field names and keys in brackets,
+// e.g. OutputSubstate[0x01].Balance into OutputSubstate, [0x01] and Balance.
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+	var components []string
+	for rest := path; rest != ""; {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("bracket is not closed")
+			}
+			components = append(components, rest[:end+1])
+			rest = rest[end+1:]
+		case rest[0] == '.' && len(components) > 0:
+			rest = rest[1:]
+			fallthrough
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 || strings.ContainsRune(rest[:end], ']') {
+				return nil, fmt.Errorf("invalid field name")
+			}
+			components = append(components, rest[:end])
+			rest = rest[end:]
+		}
+	}
+	return components, nil
+}
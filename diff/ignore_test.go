@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnore_FilterDropsMatchedDifferences(t *testing.T) {
+	want := getTestSubstate()
+	got := getTestSubstate()
+	got.Result.Bloom = types.Bloom{1}
+	got.Env.BlockHashes = map[uint64]types.Hash{9: {8}, 10: {10}}
+	got.OutputSubstate.Add(types.Address{3}, 0, uint256.NewInt(1), nil)
+	want.OutputSubstate.Add(types.Address{3}, 0, uint256.NewInt(2), nil)
+	got.OutputSubstate[types.Address{1}].Balance = uint256.NewInt(1)
+	got.OutputSubstate[types.Address{1}].Nonce = 5
+	differences := Substate(want, got)
+	require.Len(t, differences, 6)
+
+	tests := map[string]struct {
+		rules []string
+		want  []string
+	}{
+		"no rules": {
+			want: differences.Paths(),
+		},
+		"field": {
+			rules: []string{"Result.Bloom", "Env.BlockHashes"},
+			want: []string{
+				"OutputSubstate[" + types.Address{1}.String() + "].Nonce",
+				"OutputSubstate[" + types.Address{1}.String() + "].Balance",
+				"OutputSubstate[" + types.Address{3}.String() + "].Balance",
+			},
+		},
+		"coinbase": {
+			rules: []string{"OutputSubstate[coinbase].Balance", "Result", "Env"},
+			want: []string{
+				"OutputSubstate[" + types.Address{1}.String() + "].Nonce",
+				"OutputSubstate[" + types.Address{1}.String() + "].Balance",
+			},
+		},
+		"any key": {
+			rules: []string{"OutputSubstate[*].Balance", "Result.Bloom", "Env.BlockHashes[10]", "Env.BlockHashes[9]"},
+			want: []string{
+				"OutputSubstate[" + types.Address{1}.String() + "].Nonce",
+			},
+		},
+		"case of keys": {
+			rules: []string{"OutputSubstate[" + strings.ToUpper(types.Address{1}.String()[2:]) + "]"},
+			want:  differences.Paths(),
+		},
+		"whole substate": {
+			rules: []string{"OutputSubstate", "Env", "Result"},
+		},
+		"prefix of field names": {
+			rules: []string{"Output", "Env.Block", "Result.Blo"},
+			want:  differences.Paths(),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ignore, err := NewIgnore(test.rules...)
+			require.NoError(t, err)
+			filtered := ignore.Filter(differences, types.Address{3})
+			assert.ElementsMatch(t, test.want, filtered.Paths())
+		})
+	}
+
+	ignore, err := NewIgnore("OutputSubstate[" + strings.ToUpper(types.Address{1}.String()) + "]")
+	require.NoError(t, err)
+	assert.Len(t, ignore.Filter(differences, types.Address{}), 4)
+	assert.Equal(t, differences, (*Ignore)(nil).Filter(differences, types.Address{}))
+}
+
+func TestNewIgnore_RejectsInvalidRules(t *testing.T) {
+	for _, rule := range []string{"", ".Result", "Result.", "Result..Bloom", "Env.BlockHashes[1", "Env]", "Env.[1]"} {
+		_, err := NewIgnore("Result", rule)
+		assert.ErrorContains(t, err, "invalid ignore rule", rule)
+	}
+}
+
+func TestSplitPath_SplitsFieldsAndKeys(t *testing.T) {
+	got, err := splitPath("Message.AccessList[0].StorageKeys[1].Length")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Message", "AccessList", "[0]", "StorageKeys", "[1]", "Length"}, got)
+}
@@ -0,0 +1,324 @@
+// Package importer builds substates from files produced by other tools: results of geth's
+// prestateTracer together with blocks and receipts of the RPC API, and inputs and outputs
+// of evm t8n.
+package importer
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/statetest"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+)
+
+const (
+	// minBlobBaseFee is the minimum blob base fee set by EIP-4844.
+	minBlobBaseFee = 1
+
+	// CancunBlobBaseFeeUpdateFraction and PragueBlobBaseFeeUpdateFraction are the blob
+	// base fee update fractions set by EIP-4844 and EIP-7691, Osaka keeps the latter.
+	CancunBlobBaseFeeUpdateFraction = 3_338_477
+	PragueBlobBaseFeeUpdateFraction = 5_007_716
+
+	// BPO1BlobBaseFeeUpdateFraction and BPO2BlobBaseFeeUpdateFraction are the blob base fee
+	// update fractions of the blob parameter only forks following Osaka (EIP-7892).
+	BPO1BlobBaseFeeUpdateFraction = 8_346_193
+	BPO2BlobBaseFeeUpdateFraction = 11_684_671
+)
+
+// mainnetBlobSchedule are the blob base fee update fractions of Ethereum mainnet
+// by the timestamp of their activation.
+var mainnetBlobSchedule = []struct {
+	timestamp uint64
+	fraction  uint64
+}{
+	{1_710_338_135, CancunBlobBaseFeeUpdateFraction},
+	{1_746_612_311, PragueBlobBaseFeeUpdateFraction},
+	{1_765_290_071, BPO1BlobBaseFeeUpdateFraction},
+	{1_767_747_671, BPO2BlobBaseFeeUpdateFraction},
+}
+
+// mainnetBlobBaseFeeUpdateFraction returns the blob base fee update fraction of the Ethereum
+// mainnet block of timestamp, 0 if blobs are not enabled yet.
+func mainnetBlobBaseFeeUpdateFraction(timestamp uint64) uint64 {
+	fraction := uint64(0)
+	for _, entry := range mainnetBlobSchedule {
+		if timestamp < entry.timestamp {
+			break
+		}
+		fraction = entry.fraction
+	}
+	return fraction
+}
+
+// Options configures the importers.
+type Options struct {
+	// ChainID is the id of the chain of the imported transactions.
+	ChainID uint64
+
+	// BlobBaseFeeUpdateFraction is used to derive blob base fees from the excess blob
+	// gas if no receipt of a block carries the blob gas price. The fraction of the blob
+	// schedule of Ethereum mainnet is used for its blocks if it is not set.
+	BlobBaseFeeUpdateFraction uint64
+}
+
+// Record is an imported transaction. Records with problems are incomplete,
+// their substate is nil if it could not be built at all.
+type Record struct {
+	Substate *substate.Substate
+	Block    uint64
+	Tx       int
+	TxHash   types.Hash
+	Problems []string
+}
+
+func (r *Record) addProblem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Issue reports an incomplete record.
+type Issue struct {
+	Block       uint64     `json:"block"`
+	Transaction int        `json:"transaction"`
+	TxHash      types.Hash `json:"txHash"`
+	Problems    []string   `json:"problems"`
+	Written     bool       `json:"written"`
+}
+
+// Report describes the outcome of Write.
+type Report struct {
+	Imported   int     `json:"imported"`
+	Incomplete []Issue `json:"incomplete,omitempty"`
+}
+
+// Write puts substates of records into sdb and reports incomplete records. Incomplete
+// records are skipped unless writeIncomplete is set and their substate could be built.
+func Write(sdb db.SubstateDB, records []*Record, writeIncomplete bool) (*Report, error) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Block != records[j].Block {
+			return records[i].Block < records[j].Block
+		}
+		return records[i].Tx < records[j].Tx
+	})
+
+	report := new(Report)
+	for _, record := range records {
+		write := record.Substate != nil && (len(record.Problems) == 0 || writeIncomplete)
+		if write {
+			if err := sdb.PutSubstate(record.Substate); err != nil {
+				return report, fmt.Errorf("cannot put substate block: %v, tx: %v; %w", record.Block, record.Tx, err)
+			}
+			report.Imported++
+		}
+		if len(record.Problems) > 0 {
+			report.Incomplete = append(report.Incomplete, Issue{
+				Block:       record.Block,
+				Transaction: record.Tx,
+				TxHash:      record.TxHash,
+				Problems:    record.Problems,
+				Written:     write,
+			})
+		}
+	}
+	return report, nil
+}
+
+// header holds the fields of a block the environment of its transactions is made of.
+type header struct {
+	Number        *quantity      `json:"number"`
+	ParentHash    *types.Hash    `json:"parentHash"`
+	Miner         types.Address  `json:"miner"`
+	Difficulty    *quantity      `json:"difficulty"`
+	MixHash       *types.Hash    `json:"mixHash"`
+	GasLimit      *quantity      `json:"gasLimit"`
+	Timestamp     *quantity      `json:"timestamp"`
+	BaseFee       *quantity      `json:"baseFeePerGas"`
+	ExcessBlobGas *quantity      `json:"excessBlobGas"`
+	BlobBaseFee   *quantity      `json:"-"`
+	Transactions  []*transaction `json:"transactions"`
+}
+
+// newEnv builds the environment of transactions of h. The randomness is the mix hash
+// of blocks without difficulty, the parent hash is the only known block hash.
+func newEnv(h *header, options Options) (*substate.Env, error) {
+	if h.Number == nil || h.GasLimit == nil || h.Timestamp == nil {
+		return nil, fmt.Errorf("block misses number, gas limit or timestamp")
+	}
+	number, err := h.Number.uint64()
+	if err != nil {
+		return nil, err
+	}
+	gasLimit, err := h.GasLimit.uint64()
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := h.Timestamp.uint64()
+	if err != nil {
+		return nil, err
+	}
+	difficulty := h.Difficulty.big()
+	if difficulty == nil {
+		difficulty = new(big.Int)
+	}
+	var random *types.Hash
+	if difficulty.Sign() == 0 && h.MixHash != nil {
+		mixHash := *h.MixHash
+		random = &mixHash
+	}
+	var blockHashes map[uint64]types.Hash
+	if h.ParentHash != nil && number > 0 {
+		blockHashes = map[uint64]types.Hash{number - 1: *h.ParentHash}
+	}
+	env := substate.NewEnv(h.Miner, difficulty, gasLimit, number, timestamp, h.BaseFee.big(), h.BlobBaseFee.big(), blockHashes, random)
+
+	if env.BlobBaseFee == nil && h.ExcessBlobGas != nil {
+		fraction := options.BlobBaseFeeUpdateFraction
+		if fraction == 0 && options.ChainID == statetest.MainnetChainID {
+			fraction = mainnetBlobBaseFeeUpdateFraction(timestamp)
+		}
+		if fraction == 0 {
+			return nil, fmt.Errorf("blob base fee of block %v is unknown, set the blob base fee update fraction", number)
+		}
+		env.BlobBaseFee = blobBaseFee((*big.Int)(h.ExcessBlobGas), fraction)
+	}
+	return env, nil
+}
+
+// blobBaseFee returns the blob base fee of a block with excessBlobGas as specified by EIP-4844.
+func blobBaseFee(excessBlobGas *big.Int, fraction uint64) *big.Int {
+	// fake exponential: factor * e ** (numerator / denominator)
+	var (
+		factor      = big.NewInt(minBlobBaseFee)
+		denominator = new(big.Int).SetUint64(fraction)
+		output      = new(big.Int)
+		accum       = new(big.Int).Mul(factor, denominator)
+	)
+	for i := 1; accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+		accum.Mul(accum, excessBlobGas)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(int64(i)))
+	}
+	return output.Div(output, denominator)
+}
+
+// newMessage builds the message of tx, the effective gas price of its receipt is used if known.
+func newMessage(tx *transaction, effectiveGasPrice *quantity, record *Record) *substate.Message {
+	from := tx.From
+	if from == nil {
+		from = tx.Sender
+	}
+	if from == nil || tx.Nonce == nil || tx.Gas == nil || tx.Value == nil || tx.Input == nil {
+		record.addProblem("transaction misses sender, nonce, gas, value or input")
+		return nil
+	}
+	nonce, err := tx.Nonce.uint64()
+	if err != nil {
+		record.addProblem("invalid nonce; %v", err)
+		return nil
+	}
+	gas, err := tx.Gas.uint64()
+	if err != nil {
+		record.addProblem("invalid gas; %v", err)
+		return nil
+	}
+
+	gasPrice, gasFeeCap, gasTipCap := tx.GasPrice.big(), tx.MaxFeePerGas.big(), tx.MaxPriorityFeePerGas.big()
+	if effectiveGasPrice != nil {
+		gasPrice = effectiveGasPrice.big()
+	}
+	if gasPrice == nil {
+		record.addProblem("gas price is unknown")
+		return nil
+	}
+	if gasFeeCap == nil || gasTipCap == nil {
+		gasFeeCap, gasTipCap = gasPrice, gasPrice
+	}
+
+	var txType *int32
+	if tx.Type != nil {
+		t, err := tx.Type.uint64()
+		if err != nil || t > 0x7f {
+			record.addProblem("invalid transaction type %v", (*big.Int)(tx.Type))
+			return nil
+		}
+		typ := int32(t)
+		txType = &typ
+	}
+	var accessList types.AccessList
+	if tx.AccessList != nil {
+		accessList = *tx.AccessList
+	}
+	return substate.NewMessage(
+		nonce,
+		true,
+		gasPrice,
+		gas,
+		*from,
+		tx.To,
+		tx.Value.big(),
+		*tx.Input,
+		nil,
+		txType,
+		accessList,
+		gasFeeCap,
+		gasTipCap,
+		tx.MaxFeePerBlobGas.big(),
+		tx.BlobVersionedHashes,
+		tx.AuthorizationList,
+	)
+}
+
+// newResult builds the result of a transaction from its receipt.
+func newResult(r *receipt, record *Record) *substate.Result {
+	if r.Status == nil || r.GasUsed == nil {
+		record.addProblem("receipt misses status or gas used")
+		return nil
+	}
+	status, err := r.Status.uint64()
+	if err != nil {
+		record.addProblem("invalid status; %v", err)
+		return nil
+	}
+	gasUsed, err := r.GasUsed.uint64()
+	if err != nil {
+		record.addProblem("invalid gas used; %v", err)
+		return nil
+	}
+	if len(r.LogsBloom) != types.BloomByteLength {
+		record.addProblem("logs bloom has %v bytes instead of %v", len(r.LogsBloom), types.BloomByteLength)
+		return nil
+	}
+	logs := make([]*types.Log, len(r.Logs))
+	for i, l := range r.Logs {
+		if l == nil {
+			record.addProblem("log %v is null", i)
+			return nil
+		}
+		logs[i] = &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data}
+	}
+	var contractAddress types.Address
+	if r.ContractAddress != nil {
+		contractAddress = *r.ContractAddress
+	}
+	return substate.NewResult(status, types.BytesToBloom(r.LogsBloom), logs, contractAddress, gasUsed)
+}
+
+// validate reports accounts needed to replay ss which are missing from its input substate.
+func validate(ss *substate.Substate, record *Record) {
+	msg := ss.Message
+	if _, found := ss.InputSubstate[msg.From]; !found {
+		record.addProblem("sender %v is missing from input substate", msg.From)
+	}
+	if msg.To != nil {
+		if _, found := ss.InputSubstate[*msg.To]; !found {
+			record.addProblem("recipient %v is missing from input substate", *msg.To)
+		}
+	}
+	if ss.Env.BaseFee != nil && msg.GasFeeCap.Cmp(ss.Env.BaseFee) < 0 {
+		record.addProblem("fee cap %v is below base fee %v", msg.GasFeeCap, ss.Env.BaseFee)
+	}
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnv_UsesMixHashAsRandomWithoutDifficulty(t *testing.T) {
+	mixHash, parent := types.Hash{1}, types.Hash{2}
+	h := &header{
+		Number:     newQuantity(10),
+		ParentHash: &parent,
+		Difficulty: newQuantity(0),
+		MixHash:    &mixHash,
+		GasLimit:   newQuantity(30_000_000),
+		Timestamp:  newQuantity(1_700_000_000),
+	}
+	env, err := newEnv(h, Options{})
+	require.NoError(t, err)
+	require.NotNil(t, env.Random)
+	assert.Equal(t, mixHash, *env.Random)
+	assert.Equal(t, map[uint64]types.Hash{9: parent}, env.BlockHashes)
+
+	h.Difficulty = newQuantity(1)
+	env, err = newEnv(h, Options{})
+	require.NoError(t, err)
+	assert.Nil(t, env.Random)
+}
+
+func TestNewEnv_DerivesBlobBaseFee(t *testing.T) {
+	h := &header{
+		Number:        newQuantity(10),
+		GasLimit:      newQuantity(30_000_000),
+		Timestamp:     newQuantity(1_700_000_000),
+		ExcessBlobGas: newQuantity(0),
+	}
+	_, err := newEnv(h, Options{})
+	assert.ErrorContains(t, err, "blob base fee of block 10 is unknown")
+
+	env, err := newEnv(h, Options{BlobBaseFeeUpdateFraction: CancunBlobBaseFeeUpdateFraction})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), env.BlobBaseFee)
+
+	// mainnet blocks after Cancun use its fraction
+	h.Number = newQuantity(19_426_587)
+	h.Timestamp = newQuantity(1_710_338_135)
+	h.ExcessBlobGas = newQuantity(10 * CancunBlobBaseFeeUpdateFraction)
+	env, err = newEnv(h, Options{ChainID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(22_026), env.BlobBaseFee)
+
+	// later forks raise the fraction
+	for timestamp, fraction := range map[uint64]uint64{
+		1_746_612_311: PragueBlobBaseFeeUpdateFraction,
+		1_764_798_551: PragueBlobBaseFeeUpdateFraction,
+		1_765_290_071: BPO1BlobBaseFeeUpdateFraction,
+		1_767_747_671: BPO2BlobBaseFeeUpdateFraction,
+	} {
+		h.Timestamp = newQuantity(timestamp)
+		h.ExcessBlobGas = newQuantity(10 * fraction)
+		env, err = newEnv(h, Options{ChainID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(22_026), env.BlobBaseFee, "timestamp %v", timestamp)
+	}
+
+	h.BlobBaseFee = newQuantity(7)
+	env, err = newEnv(h, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(7), env.BlobBaseFee)
+}
+
+func TestValidate_ReportsMissingAccountsAndFeeCap(t *testing.T) {
+	ss := substate.NewSubstate(
+		substate.NewWorldState(),
+		substate.NewWorldState(),
+		substate.NewEnv(types.Address{}, nil, 0, 0, 0, big.NewInt(10), nil, nil, nil),
+		substate.NewMessage(0, true, big.NewInt(5), 0, types.Address{1}, &types.Address{2}, big.NewInt(0),
+			nil, nil, nil, nil, big.NewInt(5), big.NewInt(5), nil, nil, nil),
+		substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 0),
+		0, 0,
+	)
+	record := new(Record)
+	validate(ss, record)
+	assert.Len(t, record.Problems, 3)
+
+	ss.InputSubstate.Add(types.Address{1}, 0, uint256.NewInt(0), nil).Add(types.Address{2}, 0, uint256.NewInt(0), nil)
+	ss.Env.BaseFee = big.NewInt(5)
+	record = new(Record)
+	validate(ss, record)
+	assert.Empty(t, record.Problems)
+}
+
+func TestWrite_SkipsIncompleteRecords(t *testing.T) {
+	sdb, err := db.NewDefaultSubstateDB(t.TempDir())
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	complete := &Record{Substate: getTestSubstate(2, 0), Block: 2}
+	incomplete := &Record{Substate: getTestSubstate(1, 0), Block: 1, Problems: []string{"problem"}}
+	missing := &Record{Block: 1, Tx: 1, Problems: []string{"missing"}}
+
+	report, err := Write(sdb, []*Record{complete, missing, incomplete}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	require.Len(t, report.Incomplete, 2)
+	assert.False(t, report.Incomplete[0].Written)
+	assert.Equal(t, 1, report.Incomplete[1].Transaction)
+
+	has, err := sdb.HasSubstate(1, 0)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	report, err = Write(sdb, []*Record{incomplete}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.True(t, report.Incomplete[0].Written)
+	has, err = sdb.HasSubstate(1, 0)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func newQuantity(v uint64) *quantity {
+	return (*quantity)(new(big.Int).SetUint64(v))
+}
+
+func getTestSubstate(block uint64, tx int) *substate.Substate {
+	return substate.NewSubstate(
+		substate.NewWorldState().Add(types.Address{1}, 0, uint256.NewInt(1), nil),
+		substate.NewWorldState(),
+		substate.NewEnv(types.Address{}, big.NewInt(0), 0, block, 0, nil, nil, nil, nil),
+		substate.NewMessage(0, true, big.NewInt(0), 0, types.Address{1}, nil, big.NewInt(0),
+			nil, nil, nil, nil, big.NewInt(0), big.NewInt(0), nil, nil, nil),
+		substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 0),
+		block, tx,
+	)
+}
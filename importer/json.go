@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+)
+
+// quantity is a number encoded as JSON number, as hex string or as decimal string
+// as produced by geth tracers and by evm t8n.
+type quantity big.Int
+
+// UnmarshalJSON decodes all forms of quantities
+func (q *quantity) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		return fmt.Errorf("invalid quantity %s", data)
+	}
+	return q.UnmarshalText([]byte(text))
+}
+
+// UnmarshalText decodes quantities used as keys of JSON objects
+func (q *quantity) UnmarshalText(text []byte) error {
+	s, base := string(text), 10
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s, base = s[2:], 16
+	}
+	i, ok := new(big.Int).SetString(s, base)
+	if !ok || i.Sign() < 0 {
+		return fmt.Errorf("invalid quantity %q", text)
+	}
+	*q = quantity(*i)
+	return nil
+}
+
+func (q *quantity) big() *big.Int {
+	if q == nil {
+		return nil
+	}
+	return new(big.Int).Set((*big.Int)(q))
+}
+
+func (q *quantity) uint64() (uint64, error) {
+	i := (*big.Int)(q)
+	if !i.IsUint64() {
+		return 0, fmt.Errorf("quantity %v does not fit 64 bits", i)
+	}
+	return i.Uint64(), nil
+}
+
+func (q *quantity) uint256() (*uint256.Int, error) {
+	u, overflow := uint256.FromBig((*big.Int)(q))
+	if overflow {
+		return nil, fmt.Errorf("quantity %v does not fit 256 bits", (*big.Int)(q))
+	}
+	return u, nil
+}
+
+// account is an account of prestateTracer results and of t8n allocs. Missing
+// fields are zero in full states and unchanged in post states of diff mode.
+type account struct {
+	Balance *quantity                 `json:"balance"`
+	Nonce   *quantity                 `json:"nonce"`
+	Code    *types.HexBytes           `json:"code"`
+	Storage map[types.Hash]types.Hash `json:"storage"`
+}
+
+// alloc maps addresses to accounts.
+type alloc map[types.Address]*account
+
+// worldState converts a full state into a world state.
+func (a alloc) worldState() (substate.WorldState, error) {
+	ws := substate.NewWorldState()
+	for addr, acc := range a {
+		if acc == nil {
+			return nil, fmt.Errorf("account %v is null", addr)
+		}
+		converted, err := acc.apply(substate.NewAccount(0, uint256.NewInt(0), nil))
+		if err != nil {
+			return nil, fmt.Errorf("invalid account %v; %w", addr, err)
+		}
+		ws[addr] = converted
+	}
+	return ws, nil
+}
+
+// apply returns a copy of base with fields present in acc replaced.
+func (acc *account) apply(base *substate.Account) (*substate.Account, error) {
+	result := base.Copy()
+	if acc.Balance != nil {
+		balance, err := acc.Balance.uint256()
+		if err != nil {
+			return nil, err
+		}
+		result.Balance = balance
+	}
+	if acc.Nonce != nil {
+		nonce, err := acc.Nonce.uint64()
+		if err != nil {
+			return nil, err
+		}
+		result.Nonce = nonce
+	}
+	if acc.Code != nil {
+		result.Code = *acc.Code
+	}
+	for key, value := range acc.Storage {
+		result.Storage[key] = value
+	}
+	return result, nil
+}
+
+// transaction is a transaction of eth_getBlockByNumber results and of t8n inputs.
+type transaction struct {
+	Hash                 types.Hash                   `json:"hash"`
+	Type                 *quantity                    `json:"type"`
+	From                 *types.Address               `json:"from"`
+	Sender               *types.Address               `json:"sender"`
+	To                   *types.Address               `json:"to"`
+	Nonce                *quantity                    `json:"nonce"`
+	Gas                  *quantity                    `json:"gas"`
+	GasPrice             *quantity                    `json:"gasPrice"`
+	MaxFeePerGas         *quantity                    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *quantity                    `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     *quantity                    `json:"maxFeePerBlobGas"`
+	Value                *quantity                    `json:"value"`
+	Input                *types.HexBytes              `json:"input"`
+	AccessList           *types.AccessList            `json:"accessList"`
+	BlobVersionedHashes  []types.Hash                 `json:"blobVersionedHashes"`
+	AuthorizationList    []types.SetCodeAuthorization `json:"authorizationList"`
+}
+
+// receipt is a receipt of eth_getBlockReceipts results and of t8n results.
+type receipt struct {
+	TransactionHash   types.Hash     `json:"transactionHash"`
+	Status            *quantity      `json:"status"`
+	GasUsed           *quantity      `json:"gasUsed"`
+	LogsBloom         types.HexBytes `json:"logsBloom"`
+	Logs              []*log         `json:"logs"`
+	ContractAddress   *types.Address `json:"contractAddress"`
+	EffectiveGasPrice *quantity      `json:"effectiveGasPrice"`
+	BlobGasPrice      *quantity      `json:"blobGasPrice"`
+}
+
+type log struct {
+	Address types.Address  `json:"address"`
+	Topics  []types.Hash   `json:"topics"`
+	Data    types.HexBytes `json:"data"`
+}
+
+// decodeList decodes a JSON array or a single JSON value of in into a list.
+func decodeList[T any](in io.Reader) ([]T, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var list []T
+		if err = json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	var single T
+	if err = json.Unmarshal(data, &single); err != nil {
+		return nil, err
+	}
+	return []T{single}, nil
+}
+
+// decode decodes a JSON value of in into v, name describes the value in errors.
+func decode(in io.Reader, v any, name string) error {
+	if err := json.NewDecoder(in).Decode(v); err != nil {
+		return fmt.Errorf("cannot decode %v; %w", name, err)
+	}
+	return nil
+}
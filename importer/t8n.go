@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+)
+
+// t8nEnv is the env input of evm t8n.
+type t8nEnv struct {
+	Coinbase      types.Address         `json:"currentCoinbase"`
+	Difficulty    *quantity             `json:"currentDifficulty"`
+	Random        *quantity             `json:"currentRandom"`
+	GasLimit      *quantity             `json:"currentGasLimit"`
+	Number        *quantity             `json:"currentNumber"`
+	Timestamp     *quantity             `json:"currentTimestamp"`
+	BaseFee       *quantity             `json:"currentBaseFee"`
+	ExcessBlobGas *quantity             `json:"currentExcessBlobGas"`
+	BlockHashes   map[string]types.Hash `json:"blockHashes"`
+}
+
+// t8nResult is the result output of evm t8n.
+type t8nResult struct {
+	Receipts []*receipt `json:"receipts"`
+	Rejected []struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	} `json:"rejected"`
+}
+
+// ImportT8n builds records of the transactions of a run of evm t8n from its input alloc, env
+// and txs and from its output result and alloc. The transactions must carry their sender
+// in a from or sender field as signatures are not recovered. Rejected transactions are
+// reported as incomplete.
+//
+// Note: the allocs describe the state before and after all transactions, hence only runs
+// with a single accepted transaction are imported. Transactions of other runs are reported.
+func ImportT8n(preAlloc, env, txs, result, postAlloc io.Reader, options Options) ([]*Record, error) {
+	var (
+		pre, post alloc
+		e         t8nEnv
+		res       t8nResult
+	)
+	if err := decode(preAlloc, &pre, "input alloc"); err != nil {
+		return nil, err
+	}
+	if err := decode(env, &e, "env"); err != nil {
+		return nil, err
+	}
+	txList, err := decodeList[*transaction](txs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode txs; %w", err)
+	}
+	if err = decode(result, &res, "result"); err != nil {
+		return nil, err
+	}
+	if err = decode(postAlloc, &post, "output alloc"); err != nil {
+		return nil, err
+	}
+
+	h := e.header()
+	for _, r := range res.Receipts {
+		if r != nil && r.BlobGasPrice != nil {
+			h.BlobBaseFee = r.BlobGasPrice
+			break
+		}
+	}
+	blockEnv, err := newEnv(h, options)
+	if err != nil {
+		return nil, err
+	}
+	if blockEnv.BlockHashes, err = e.blockHashes(); err != nil {
+		return nil, err
+	}
+	input, err := pre.worldState()
+	if err != nil {
+		return nil, fmt.Errorf("invalid input alloc; %w", err)
+	}
+	outputAlloc, err := post.worldState()
+	if err != nil {
+		return nil, fmt.Errorf("invalid output alloc; %w", err)
+	}
+
+	rejected := make(map[int]string, len(res.Rejected))
+	for _, r := range res.Rejected {
+		rejected[r.Index] = r.Error
+	}
+	accepted := len(txList) - len(rejected)
+	if accepted != len(res.Receipts) {
+		return nil, fmt.Errorf("result has %v receipts for %v accepted transactions", len(res.Receipts), accepted)
+	}
+
+	var records []*Record
+	index := 0
+	for i, tx := range txList {
+		if tx == nil {
+			return nil, fmt.Errorf("transaction %v is null", i)
+		}
+		if reason, found := rejected[i]; found {
+			record := &Record{Block: blockEnv.Number, Tx: i, TxHash: tx.Hash}
+			record.addProblem("transaction %v was rejected: %v", i, reason)
+			records = append(records, record)
+			continue
+		}
+		r := res.Receipts[index]
+		record := &Record{Block: blockEnv.Number, Tx: index, TxHash: r.TransactionHash}
+		index++
+		records = append(records, record)
+		if accepted > 1 {
+			record.addProblem("substates of runs with %v accepted transactions are unknown", accepted)
+			continue
+		}
+
+		msg := newMessage(tx, r.EffectiveGasPrice, record)
+		result := newResult(r, record)
+		if msg == nil || result == nil {
+			continue
+		}
+		record.Substate = substate.NewSubstate(input, stateChanges(input, outputAlloc), blockEnv, msg, result, record.Block, record.Tx)
+		validate(record.Substate, record)
+	}
+	return records, nil
+}
+
+// header converts e into the header fields an environment is made of.
+func (e *t8nEnv) header() *header {
+	h := &header{
+		Number:        e.Number,
+		Miner:         e.Coinbase,
+		Difficulty:    e.Difficulty,
+		GasLimit:      e.GasLimit,
+		Timestamp:     e.Timestamp,
+		BaseFee:       e.BaseFee,
+		ExcessBlobGas: e.ExcessBlobGas,
+	}
+	if e.Random != nil {
+		random := types.BigToHash((*big.Int)(e.Random))
+		h.MixHash = &random
+		if h.Difficulty == nil {
+			h.Difficulty = new(quantity)
+		}
+	}
+	return h
+}
+
+// blockHashes returns the block hashes of e keyed by block number.
+func (e *t8nEnv) blockHashes() (map[uint64]types.Hash, error) {
+	if len(e.BlockHashes) == 0 {
+		return nil, nil
+	}
+	hashes := make(map[uint64]types.Hash, len(e.BlockHashes))
+	for key, hash := range e.BlockHashes {
+		var number quantity
+		if err := number.UnmarshalText([]byte(key)); err != nil {
+			return nil, fmt.Errorf("invalid block hash number; %w", err)
+		}
+		n, err := number.uint64()
+		if err != nil {
+			return nil, err
+		}
+		hashes[n] = hash
+	}
+	return hashes, nil
+}
+
+// stateChanges returns accounts of post which differ from pre. Storage slots of pre missing in post are cleared.
+func stateChanges(pre, post substate.WorldState) substate.WorldState {
+	changes := post.Diff(pre)
+	for addr, acc := range post {
+		preAcc, found := pre[addr]
+		if !found {
+			continue
+		}
+		for key, value := range preAcc.Storage {
+			if _, found := acc.Storage[key]; found || value == (types.Hash{}) {
+				continue
+			}
+			if _, found := changes[addr]; !found {
+				changes[addr] = substate.NewAccount(acc.Nonce, acc.Balance, acc.Code)
+			}
+			changes[addr].Storage[key] = types.Hash{}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPreAlloc = `{
+	"` + testSender + `": {"balance": "0x100000", "nonce": "0x1"},
+	"` + testContract + `": {"balance": "0x0", "code": "0x6000", "storage": {"0x01": "0x02"}}
+}`
+
+const testPostAlloc = `{
+	"` + testSender + `": {"balance": "0xfffff", "nonce": "0x2"},
+	"` + testContract + `": {"balance": "0x5", "code": "0x6000"}
+}`
+
+const testEnv = `{
+	"currentCoinbase": "0x0300000000000000000000000000000000000000",
+	"currentRandom": "0x07",
+	"currentGasLimit": "30000000",
+	"currentNumber": "100",
+	"currentTimestamp": "1700000000",
+	"currentBaseFee": "7",
+	"blockHashes": {"98": "0x0800000000000000000000000000000000000000000000000000000000000000", "99": "0x0900000000000000000000000000000000000000000000000000000000000000"}
+}`
+
+const testTxs = `[{
+	"type": "0x0",
+	"sender": "` + testSender + `",
+	"to": "` + testContract + `",
+	"nonce": "0x1",
+	"gas": "0x5208",
+	"gasPrice": "0x9",
+	"value": "0x5",
+	"input": "0x"
+}]`
+
+const testResult = `{
+	"receipts": [{
+		"transactionHash": "` + testTxHash + `",
+		"status": "0x1",
+		"gasUsed": "0x5208",
+		"logsBloom": ` + testBloom + `,
+		"logs": null
+	}],
+	"rejected": []
+}`
+
+func importTestT8n(env, txs, result string) ([]*Record, error) {
+	return ImportT8n(strings.NewReader(testPreAlloc), strings.NewReader(env), strings.NewReader(txs),
+		strings.NewReader(result), strings.NewReader(testPostAlloc), Options{})
+}
+
+func TestImportT8n_BuildsSubstate(t *testing.T) {
+	records, err := importTestT8n(testEnv, testTxs, testResult)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Empty(t, record.Problems)
+	require.NotNil(t, record.Substate)
+
+	ss := record.Substate
+	assert.Equal(t, uint64(100), ss.Block)
+	assert.Equal(t, types.Hash{31: 7}, *ss.Env.Random)
+	assert.Len(t, ss.Env.BlockHashes, 2)
+	assert.Equal(t, int64(9), ss.Message.GasFeeCap.Int64())
+	assert.Len(t, ss.InputSubstate, 2)
+
+	sender, contract := types.Address{1}, types.Address{2}
+	require.Len(t, ss.OutputSubstate, 2)
+	assert.Equal(t, uint64(2), ss.OutputSubstate[sender].Nonce)
+	assert.Equal(t, uint256.NewInt(5), ss.OutputSubstate[contract].Balance)
+	// the slot missing in the output alloc has been cleared
+	assert.Equal(t, types.Hash{}, ss.OutputSubstate[contract].Storage[types.Hash{31: 1}])
+	assert.Contains(t, ss.OutputSubstate[contract].Storage, types.Hash{31: 1})
+}
+
+func TestImportT8n_ReportsRejectedTransactions(t *testing.T) {
+	txs := "[" + testTxs[1:len(testTxs)-1] + "," + testTxs[1:]
+	result := strings.Replace(testResult, `"rejected": []`, `"rejected": [{"index": 1, "error": "nonce too low"}]`, 1)
+
+	records, err := importTestT8n(testEnv, txs, result)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Empty(t, records[0].Problems)
+	assert.Nil(t, records[1].Substate)
+	assert.Equal(t, []string{"transaction 1 was rejected: nonce too low"}, records[1].Problems)
+}
+
+func TestImportT8n_ReportsRunsWithMultipleTransactions(t *testing.T) {
+	txs := "[" + testTxs[1:len(testTxs)-1] + "," + testTxs[1:]
+	receipts := testResult[strings.Index(testResult, "{\n\t\t"):strings.Index(testResult, "],\n")]
+	result := strings.Replace(testResult, receipts, receipts+","+receipts, 1)
+
+	records, err := importTestT8n(testEnv, txs, result)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	for i, record := range records {
+		assert.Nil(t, record.Substate)
+		assert.Equal(t, i, record.Tx)
+		assert.Equal(t, []string{"substates of runs with 2 accepted transactions are unknown"}, record.Problems)
+	}
+}
+
+func TestImportT8n_RejectsInvalidInput(t *testing.T) {
+	_, err := importTestT8n(testEnv, testTxs, `{"receipts": []}`)
+	assert.ErrorContains(t, err, "result has 0 receipts for 1 accepted transactions")
+
+	_, err = importTestT8n(strings.Replace(testEnv, `"currentNumber": "100",`, "", 1), testTxs, testResult)
+	assert.ErrorContains(t, err, "block misses number")
+
+	_, err = importTestT8n(strings.Replace(testEnv, `"98"`, `"x"`, 1), testTxs, testResult)
+	assert.ErrorContains(t, err, "invalid block hash number")
+}
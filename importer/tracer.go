@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+)
+
+// traceResult is a result of debug_traceBlockByNumber with the prestateTracer.
+type traceResult struct {
+	TxHash types.Hash      `json:"txHash"`
+	Result json.RawMessage `json:"result"`
+}
+
+// diffResult is a result of the prestateTracer in diff mode.
+type diffResult struct {
+	Pre  alloc `json:"pre"`
+	Post alloc `json:"post"`
+}
+
+// ImportTracer builds records of all transactions of blocks. The blocks are results of
+// eth_getBlockByNumber with full transactions, receipts are results of eth_getBlockReceipts
+// and traces results of debug_traceBlockByNumber with the prestateTracer. All of them
+// are either a single value or an array, receipts and traces are matched by transaction hash.
+//
+// Traces in diff mode only carry accounts changed by transactions, hence transactions
+// reading unchanged accounts are reported as incomplete. Traces without diff mode carry
+// all accounts read but no output substate, they must be followed by a diff-mode trace
+// of the same transaction.
+func ImportTracer(blocks, receipts, traces io.Reader, options Options) ([]*Record, error) {
+	headers, err := decodeList[*header](blocks)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode blocks; %w", err)
+	}
+	receiptList, err := decodeList[*receipt](receipts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode receipts; %w", err)
+	}
+	traceList, err := decodeList[*traceResult](traces)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode traces; %w", err)
+	}
+
+	receiptsByHash := make(map[types.Hash]*receipt, len(receiptList))
+	for _, r := range receiptList {
+		if r != nil {
+			receiptsByHash[r.TransactionHash] = r
+		}
+	}
+	prestates := make(map[types.Hash]alloc)
+	diffs := make(map[types.Hash]*diffResult)
+	for _, trace := range traceList {
+		if trace == nil {
+			continue
+		}
+		if err = decodeTrace(trace, prestates, diffs); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []*Record
+	for i, h := range headers {
+		if h == nil {
+			return nil, fmt.Errorf("block %v is null", i)
+		}
+		for _, tx := range h.Transactions {
+			if tx == nil {
+				return nil, fmt.Errorf("block %v has no transaction objects", i)
+			}
+			if r := receiptsByHash[tx.Hash]; r != nil && r.BlobGasPrice != nil {
+				h.BlobBaseFee = r.BlobGasPrice
+				break
+			}
+		}
+		env, err := newEnv(h, options)
+		if err != nil {
+			return nil, err
+		}
+		for index, tx := range h.Transactions {
+			records = append(records, newTracerRecord(env, index, tx, receiptsByHash[tx.Hash], prestates[tx.Hash], diffs[tx.Hash]))
+		}
+	}
+	return records, nil
+}
+
+// decodeTrace adds trace to prestates or to diffs depending on its mode.
+func decodeTrace(trace *traceResult, prestates map[types.Hash]alloc, diffs map[types.Hash]*diffResult) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trace.Result, &fields); err != nil {
+		return fmt.Errorf("cannot decode trace of %v; %w", trace.TxHash, err)
+	}
+	_, hasPre := fields["pre"]
+	_, hasPost := fields["post"]
+	if hasPre && hasPost && len(fields) == 2 {
+		diff := new(diffResult)
+		if err := decode(bytes.NewReader(trace.Result), diff, "trace of "+trace.TxHash.String()); err != nil {
+			return err
+		}
+		diffs[trace.TxHash] = diff
+		return nil
+	}
+	var prestate alloc
+	if err := decode(bytes.NewReader(trace.Result), &prestate, "trace of "+trace.TxHash.String()); err != nil {
+		return err
+	}
+	prestates[trace.TxHash] = prestate
+	return nil
+}
+
+// newTracerRecord builds the record of tx which is the transaction of index within its block.
+func newTracerRecord(env *substate.Env, index int, tx *transaction, r *receipt, prestate alloc, diff *diffResult) *Record {
+	record := &Record{Block: env.Number, Tx: index, TxHash: tx.Hash}
+	if r == nil {
+		record.addProblem("receipt is missing")
+		return record
+	}
+	if diff == nil {
+		record.addProblem("diff-mode trace is missing")
+		return record
+	}
+
+	msg := newMessage(tx, r.EffectiveGasPrice, record)
+	result := newResult(r, record)
+	input, output, err := diff.worldStates(prestate)
+	if err != nil {
+		record.addProblem("invalid trace; %v", err)
+		return record
+	}
+	if msg == nil || result == nil {
+		return record
+	}
+	record.Substate = substate.NewSubstate(input, output, env.Clone(), msg, result, env.Number, index)
+	validate(record.Substate, record)
+	return record
+}
+
+// worldStates returns the input and output substates of a diff. The accounts of prestate are
+// added to the input substate if given. Accounts of pre which are missing in post have
+// been destroyed and storage slots of pre missing in post have been cleared.
+func (d *diffResult) worldStates(prestate alloc) (substate.WorldState, substate.WorldState, error) {
+	input, err := prestate.worldState()
+	if err != nil {
+		return nil, nil, err
+	}
+	pre, err := d.Pre.worldState()
+	if err != nil {
+		return nil, nil, err
+	}
+	for addr, acc := range pre {
+		if _, found := input[addr]; !found {
+			input[addr] = acc
+		}
+	}
+
+	output := substate.NewWorldState()
+	for addr, acc := range d.Post {
+		if acc == nil {
+			return nil, nil, fmt.Errorf("account %v is null", addr)
+		}
+		base, found := pre[addr]
+		if !found {
+			base = substate.NewAccount(0, uint256.NewInt(0), nil)
+		}
+		changed, err := acc.apply(base)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid account %v; %w", addr, err)
+		}
+		for key := range base.Storage {
+			if _, found := acc.Storage[key]; !found {
+				changed.Storage[key] = types.Hash{}
+			}
+		}
+		output[addr] = changed
+	}
+	return input, output, nil
+}
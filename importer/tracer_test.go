@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTxHash   = "0x1100000000000000000000000000000000000000000000000000000000000000"
+	testSender   = "0x0100000000000000000000000000000000000000"
+	testContract = "0x0200000000000000000000000000000000000000"
+	testBloom    = `"0x` + "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" + `"`
+)
+
+const testBlocks = `[{
+	"number": "0x64",
+	"parentHash": "0x0900000000000000000000000000000000000000000000000000000000000000",
+	"miner": "0x0300000000000000000000000000000000000000",
+	"difficulty": "0x0",
+	"mixHash": "0x0700000000000000000000000000000000000000000000000000000000000000",
+	"gasLimit": "0x1c9c380",
+	"timestamp": "0x6553f100",
+	"baseFeePerGas": "0x7",
+	"transactions": [{
+		"hash": "` + testTxHash + `",
+		"type": "0x2",
+		"from": "` + testSender + `",
+		"to": "` + testContract + `",
+		"nonce": "0x1",
+		"gas": "0x5208",
+		"gasPrice": "0x9",
+		"maxFeePerGas": "0x14",
+		"maxPriorityFeePerGas": "0x2",
+		"value": "0x5",
+		"input": "0x",
+		"accessList": []
+	}]
+}]`
+
+const testReceipts = `[{
+	"transactionHash": "` + testTxHash + `",
+	"status": "0x1",
+	"gasUsed": "0x5208",
+	"logsBloom": ` + testBloom + `,
+	"logs": [{"address": "` + testContract + `", "topics": [], "data": "0x01"}],
+	"contractAddress": null,
+	"effectiveGasPrice": "0x9"
+}]`
+
+const testTraces = `[{
+	"txHash": "` + testTxHash + `",
+	"result": {
+		"pre": {
+			"` + testSender + `": {"balance": "0x100000", "nonce": 1},
+			"` + testContract + `": {"balance": "0x0", "code": "0x6000", "storage": {
+				"0x0100000000000000000000000000000000000000000000000000000000000000": "0x0200000000000000000000000000000000000000000000000000000000000000"
+			}}
+		},
+		"post": {
+			"` + testSender + `": {"balance": "0xfffff", "nonce": 2},
+			"` + testContract + `": {"balance": "0x5"}
+		}
+	}
+}]`
+
+func TestImportTracer_BuildsSubstatesFromDiffMode(t *testing.T) {
+	records, err := ImportTracer(strings.NewReader(testBlocks), strings.NewReader(testReceipts), strings.NewReader(testTraces), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Empty(t, record.Problems)
+	require.NotNil(t, record.Substate)
+
+	ss := record.Substate
+	assert.Equal(t, uint64(100), ss.Block)
+	assert.Equal(t, 0, ss.Transaction)
+	assert.Equal(t, types.Hash{7}, *ss.Env.Random)
+	assert.Equal(t, types.Hash{9}, ss.Env.BlockHashes[99])
+	assert.Equal(t, int64(9), ss.Message.GasPrice.Int64())
+	assert.Equal(t, int64(20), ss.Message.GasFeeCap.Int64())
+	assert.NotNil(t, ss.Message.AccessList)
+	require.Len(t, ss.Result.Logs, 1)
+	assert.Equal(t, []byte{1}, ss.Result.Logs[0].Data)
+
+	sender, contract := types.Address{1}, types.Address{2}
+	require.Contains(t, ss.InputSubstate, contract)
+	assert.Equal(t, types.Hash{2}, ss.InputSubstate[contract].Storage[types.Hash{1}])
+	assert.Equal(t, uint64(2), ss.OutputSubstate[sender].Nonce)
+	assert.Equal(t, uint256.NewInt(5), ss.OutputSubstate[contract].Balance)
+	assert.Equal(t, []byte{0x60, 0x00}, ss.OutputSubstate[contract].Code)
+	// slots missing in post have been cleared
+	assert.Equal(t, types.Hash{}, ss.OutputSubstate[contract].Storage[types.Hash{1}])
+}
+
+func TestImportTracer_ReportsMissingReceiptAndTrace(t *testing.T) {
+	records, err := ImportTracer(strings.NewReader(testBlocks), strings.NewReader("[]"), strings.NewReader(testTraces), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Nil(t, records[0].Substate)
+	assert.Equal(t, []string{"receipt is missing"}, records[0].Problems)
+
+	records, err = ImportTracer(strings.NewReader(testBlocks), strings.NewReader(testReceipts), strings.NewReader("[]"), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Nil(t, records[0].Substate)
+	assert.Equal(t, []string{"diff-mode trace is missing"}, records[0].Problems)
+}
+
+func TestImportTracer_ReportsAccountsMissingFromDiff(t *testing.T) {
+	// the recipient is unchanged hence missing from the diff
+	diff := `{"txHash": "` + testTxHash + `", "result": {
+		"pre": {"` + testSender + `": {"balance": "0x100000", "nonce": 1}},
+		"post": {"` + testSender + `": {"balance": "0xfffff", "nonce": 2}}
+	}}`
+	traces := "[" + diff + "]"
+	records, err := ImportTracer(strings.NewReader(testBlocks), strings.NewReader(testReceipts), strings.NewReader(traces), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.NotNil(t, records[0].Substate)
+	assert.Equal(t, []string{"recipient " + types.Address{2}.String() + " is missing from input substate"}, records[0].Problems)
+
+	// a prestate trace completes the input substate
+	prestate := `{"txHash": "` + testTxHash + `", "result": {"` + testContract + `": {"balance": "0x0"}}}`
+	records, err = ImportTracer(strings.NewReader(testBlocks), strings.NewReader(testReceipts), strings.NewReader("["+prestate+","+diff+"]"), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Empty(t, records[0].Problems)
+}
+
+func TestImportTracer_RejectsInvalidInput(t *testing.T) {
+	_, err := ImportTracer(strings.NewReader("{"), strings.NewReader(testReceipts), strings.NewReader(testTraces), Options{})
+	assert.ErrorContains(t, err, "cannot decode blocks")
+
+	blocks := strings.Replace(testBlocks, `"gasLimit": "0x1c9c380",`, "", 1)
+	_, err = ImportTracer(strings.NewReader(blocks), strings.NewReader(testReceipts), strings.NewReader(testTraces), Options{})
+	assert.ErrorContains(t, err, "block misses number, gas limit or timestamp")
+}
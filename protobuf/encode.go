@@ -29,6 +29,15 @@ func Encode(ss *substate.Substate, block uint64, tx int) ([]byte, error) {
 	return bytes, nil
 }
 
+// NewSubstate converts aida-substate into protobuf Substate message referencing all code by its hash
+func NewSubstate(ss *substate.Substate) (*Substate, error) {
+	pSubstate, err := toProtobufSubstate(ss)
+	if err != nil {
+		return nil, err
+	}
+	return pSubstate.HashedCopy()
+}
+
 func toProtobufSubstate(ss *substate.Substate) (*Substate, error) {
 	input, err := toProtobufAlloc(ss.InputSubstate)
 	if err != nil {
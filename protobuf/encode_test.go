@@ -120,6 +120,35 @@ func TestEncode_EncodeWithError(t *testing.T) {
 	assert.Nil(t, encoded)
 }
 
+func TestEncode_NewSubstateReferencesCodeByHash(t *testing.T) {
+	ss := &substate.Substate{
+		InputSubstate: substate.WorldState{
+			types.Address{0x01}: substate.NewAccount(1, uint256.NewInt(1), []byte{0x03}),
+		},
+		OutputSubstate: make(substate.WorldState),
+		Env:            &substate.Env{Difficulty: big.NewInt(1)},
+		Message: &substate.Message{
+			GasPrice: big.NewInt(2),
+			Value:    big.NewInt(2),
+			Data:     []byte{0x04},
+		},
+		Result: &substate.Result{},
+	}
+
+	msg, err := NewSubstate(ss)
+	assert.NoError(t, err)
+	codeHash, err := CodeHash([]byte{0x04})
+	assert.NoError(t, err)
+	assert.Equal(t, codeHash.Bytes(), msg.GetTxMessage().GetInitCodeHash())
+	assert.Nil(t, msg.GetTxMessage().GetData())
+
+	data, err := Encode(ss, 1, 0)
+	assert.NoError(t, err)
+	encoded, err := proto.Marshal(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, data, encoded)
+}
+
 func TestEncode_WorldState(t *testing.T) {
 	ws := substate.WorldState{
 		types.Address{1}: {
@@ -7,6 +7,15 @@ import (
 
 // EncodeExceptionBlock converts ExceptionBlock struct into protobuf-encoded ExceptionBlock
 func EncodeExceptionBlock(s *substate.ExceptionBlock) ([]byte, error) {
+	block, err := NewExceptionBlock(s)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(block)
+}
+
+// NewExceptionBlock converts ExceptionBlock struct into protobuf ExceptionBlock message
+func NewExceptionBlock(s *substate.ExceptionBlock) (*ExceptionBlock, error) {
 	data := make(map[int32]*ExceptionTx, len(s.Transactions))
 	for key, value := range s.Transactions {
 		encodedTx, err := EncodeExceptionTx(&value)
@@ -33,11 +42,11 @@ func EncodeExceptionBlock(s *substate.ExceptionBlock) ([]byte, error) {
 		}
 	}
 
-	return proto.Marshal(&ExceptionBlock{
+	return &ExceptionBlock{
 		Transactions: data,
 		PreBlock:     pre,
 		PostBlock:    post,
-	})
+	}, nil
 }
 
 // EncodeExceptionTx converts ExceptionTx struct into protobuf-encoded ExceptionTx
@@ -45,3 +45,20 @@ func TestEncodeDecodeExceptionBlock(t *testing.T) {
 		t.Errorf("VmException mismatch: got %v, want %v", decoded.Transactions[1].VmException, exceptionBlock.Transactions[1].VmException)
 	}
 }
+
+func TestNewExceptionBlock(t *testing.T) {
+	exceptionBlock := &substate.ExceptionBlock{
+		Transactions: map[int]substate.ExceptionTx{2: {VmException: true}},
+	}
+
+	msg, err := NewExceptionBlock(exceptionBlock)
+	if err != nil {
+		t.Fatalf("NewExceptionBlock failed: %v", err)
+	}
+	if msg.GetPreBlock() != nil || msg.GetPostBlock() != nil {
+		t.Errorf("unexpected pre/post block: %v, %v", msg.GetPreBlock(), msg.GetPostBlock())
+	}
+	if !msg.GetTransactions()[2].GetVmException() {
+		t.Errorf("VmException of tx 2 not set")
+	}
+}
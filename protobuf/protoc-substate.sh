@@ -1,6 +1,8 @@
 #!/bin/bash
 
 #go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.8
+#go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
 
 protoc --go_out=. substate.proto
 protoc --go_out=. misc.proto
+protoc --go_out=. --go-grpc_out=. service.proto
@@ -0,0 +1,159 @@
+// Package recorder records the substate of a transaction while it is executed on a StateDB.
+package recorder
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+)
+
+// Recorder wraps the StateReader a transaction is executed on. Accounts and storage slots
+// are added to the input substate when they are read for the first time, the output substate
+// holds their values after the execution. A Recorder records a single transaction.
+//
+// Note: slots must be read through the Recorder before they are written, as done by
+// SSTORE, otherwise their values are missing from both substates.
+type Recorder struct {
+	state StateReader
+
+	// input holds accounts existing when read for the first time, accessed lists all accounts read.
+	input    substate.WorldState
+	accessed map[types.Address]map[types.Hash]struct{}
+
+	// destroyed maps accounts self-destructed or created by the transaction to
+	// true or false respectively, the last event of an account wins.
+	destroyed map[types.Address]bool
+}
+
+// NewRecorder returns a Recorder reading from state.
+func NewRecorder(state StateReader) *Recorder {
+	return &Recorder{
+		state:     state,
+		input:     substate.NewWorldState(),
+		accessed:  make(map[types.Address]map[types.Hash]struct{}),
+		destroyed: make(map[types.Address]bool),
+	}
+}
+
+// Exist reports whether the account exists and records it.
+func (r *Recorder) Exist(addr types.Address) bool {
+	r.access(addr)
+	return r.state.Exist(addr)
+}
+
+// GetNonce returns the nonce of the account and records it.
+func (r *Recorder) GetNonce(addr types.Address) uint64 {
+	r.access(addr)
+	return r.state.GetNonce(addr)
+}
+
+// GetBalance returns the balance of the account and records it.
+func (r *Recorder) GetBalance(addr types.Address) *uint256.Int {
+	r.access(addr)
+	return r.state.GetBalance(addr)
+}
+
+// GetCode returns the code of the account and records it.
+func (r *Recorder) GetCode(addr types.Address) []byte {
+	r.access(addr)
+	return r.state.GetCode(addr)
+}
+
+// GetState returns the value of the storage slot and records it.
+func (r *Recorder) GetState(addr types.Address, key types.Hash) types.Hash {
+	r.access(addr)
+	value := r.state.GetState(addr, key)
+	if _, found := r.accessed[addr][key]; found {
+		return value
+	}
+	r.accessed[addr][key] = struct{}{}
+	if acc, found := r.input[addr]; found {
+		acc.Storage[key] = value
+	}
+	return value
+}
+
+// access adds the account to the input substate when it is read for the first time.
+func (r *Recorder) access(addr types.Address) {
+	if _, found := r.accessed[addr]; found {
+		return
+	}
+	r.accessed[addr] = make(map[types.Hash]struct{})
+	if !r.state.Exist(addr) {
+		return
+	}
+	// the balance may be changed in place by the execution, hence it is copied
+	balance := new(uint256.Int).Set(r.state.GetBalance(addr))
+	r.input[addr] = substate.NewAccount(r.state.GetNonce(addr), balance, bytes.Clone(r.state.GetCode(addr)))
+}
+
+// SelfDestruct records that the transaction self-destructed the account.
+// It must be called before the account is cleared.
+func (r *Recorder) SelfDestruct(addr types.Address) {
+	r.access(addr)
+	r.destroyed[addr] = true
+}
+
+// CreateAccount records that the transaction created the account.
+// It must be called before the account is created.
+func (r *Recorder) CreateAccount(addr types.Address) {
+	r.access(addr)
+	r.destroyed[addr] = false
+}
+
+// InputSubstate returns the accounts and slots read by the transaction before it changed them.
+func (r *Recorder) InputSubstate() substate.WorldState {
+	input := substate.NewWorldState()
+	for addr, acc := range r.input {
+		input[addr] = acc.Copy()
+	}
+	return input
+}
+
+// OutputSubstate returns the values of accounts and slots read by the transaction after its
+// execution. Accounts not existing after the execution are omitted.
+func (r *Recorder) OutputSubstate() substate.WorldState {
+	output := substate.NewWorldState()
+	for addr, keys := range r.accessed {
+		if !r.state.Exist(addr) {
+			continue
+		}
+		balance := new(uint256.Int).Set(r.state.GetBalance(addr))
+		acc := substate.NewAccount(r.state.GetNonce(addr), balance, bytes.Clone(r.state.GetCode(addr)))
+		for key := range keys {
+			acc.Storage[key] = r.state.GetState(addr, key)
+		}
+		output[addr] = acc
+	}
+	return output
+}
+
+// DestroyedAccounts returns the accounts destroyed and resurrected by the transaction as
+// expected by DestroyedAccountDB.SetDestroyedAccounts. All accounts created by the transaction
+// are resurrected as they may have been destroyed by earlier transactions.
+func (r *Recorder) DestroyedAccounts() (destroyed []types.Address, resurrected []types.Address) {
+	for addr, isDestroyed := range r.destroyed {
+		if isDestroyed {
+			destroyed = append(destroyed, addr)
+		} else {
+			resurrected = append(resurrected, addr)
+		}
+	}
+	sortAddresses(destroyed)
+	sortAddresses(resurrected)
+	return destroyed, resurrected
+}
+
+// Substate returns the substate of the executed transaction ready for SubstateDB.PutSubstate.
+func (r *Recorder) Substate(env *substate.Env, msg *substate.Message, result *substate.Result, block uint64, tx int) *substate.Substate {
+	return substate.NewSubstate(r.InputSubstate(), r.OutputSubstate(), env, msg, result, block, tx)
+}
+
+func sortAddresses(addrs []types.Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+}
@@ -0,0 +1,162 @@
+package recorder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newMockState returns a mock reading from ws, changes of ws are visible to later reads.
+func newMockState(ctrl *gomock.Controller, ws substate.WorldState) *MockStateReader {
+	state := NewMockStateReader(ctrl)
+	state.EXPECT().Exist(gomock.Any()).DoAndReturn(func(addr types.Address) bool {
+		_, found := ws[addr]
+		return found
+	}).AnyTimes()
+	state.EXPECT().GetNonce(gomock.Any()).DoAndReturn(func(addr types.Address) uint64 {
+		if acc, found := ws[addr]; found {
+			return acc.Nonce
+		}
+		return 0
+	}).AnyTimes()
+	state.EXPECT().GetBalance(gomock.Any()).DoAndReturn(func(addr types.Address) *uint256.Int {
+		if acc, found := ws[addr]; found {
+			return acc.Balance
+		}
+		return uint256.NewInt(0)
+	}).AnyTimes()
+	state.EXPECT().GetCode(gomock.Any()).DoAndReturn(func(addr types.Address) []byte {
+		if acc, found := ws[addr]; found {
+			return acc.Code
+		}
+		return nil
+	}).AnyTimes()
+	state.EXPECT().GetState(gomock.Any(), gomock.Any()).DoAndReturn(func(addr types.Address, key types.Hash) types.Hash {
+		if acc, found := ws[addr]; found {
+			return acc.Storage[key]
+		}
+		return types.Hash{}
+	}).AnyTimes()
+	return state
+}
+
+func TestRecorder_RecordsFirstReadsAndFinalValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender, contract, unused := types.Address{1}, types.Address{2}, types.Address{3}
+	ws := substate.NewWorldState().
+		Add(sender, 1, uint256.NewInt(100), nil).
+		Add(contract, 0, uint256.NewInt(0), []byte{0x60}).
+		Add(unused, 0, uint256.NewInt(1), nil)
+	ws[contract].Storage[types.Hash{1}] = types.Hash{1}
+	ws[contract].Storage[types.Hash{2}] = types.Hash{2}
+
+	r := NewRecorder(newMockState(ctrl, ws))
+	assert.Equal(t, uint64(1), r.GetNonce(sender))
+	assert.Equal(t, []byte{0x60}, r.GetCode(contract))
+	assert.Equal(t, types.Hash{1}, r.GetState(contract, types.Hash{1}))
+
+	// the execution changes the state, later reads are not recorded
+	ws[sender] = substate.NewAccount(2, uint256.NewInt(90), nil)
+	ws[contract].Storage[types.Hash{1}] = types.Hash{}
+	assert.Equal(t, uint64(2), r.GetNonce(sender))
+	assert.Equal(t, types.Hash{}, r.GetState(contract, types.Hash{1}))
+
+	input := substate.NewWorldState().
+		Add(sender, 1, uint256.NewInt(100), nil).
+		Add(contract, 0, uint256.NewInt(0), []byte{0x60})
+	input[contract].Storage[types.Hash{1}] = types.Hash{1}
+	assert.True(t, input.Equal(r.InputSubstate()))
+
+	output := substate.NewWorldState().
+		Add(sender, 2, uint256.NewInt(90), nil).
+		Add(contract, 0, uint256.NewInt(0), []byte{0x60})
+	output[contract].Storage[types.Hash{1}] = types.Hash{}
+	assert.True(t, output.Equal(r.OutputSubstate()))
+}
+
+func TestRecorder_CopiesBalances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := types.Address{1}
+	ws := substate.NewWorldState().Add(sender, 1, uint256.NewInt(100), nil)
+
+	r := NewRecorder(newMockState(ctrl, ws))
+	r.GetBalance(sender)
+
+	// the execution changes the balance in place
+	ws[sender].Balance.SetUint64(90)
+	output := r.OutputSubstate()
+	ws[sender].Balance.SetUint64(80)
+
+	assert.Equal(t, uint256.NewInt(100), r.InputSubstate()[sender].Balance)
+	assert.Equal(t, uint256.NewInt(90), output[sender].Balance)
+}
+
+func TestRecorder_RecordsCreatedAndDestroyedAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender, created, destroyed := types.Address{1}, types.Address{2}, types.Address{3}
+	ws := substate.NewWorldState().
+		Add(sender, 1, uint256.NewInt(100), nil).
+		Add(destroyed, 1, uint256.NewInt(5), []byte{0xff})
+
+	r := NewRecorder(newMockState(ctrl, ws))
+	r.CreateAccount(created)
+	ws.Add(created, 1, uint256.NewInt(0), []byte{0x60})
+	assert.Equal(t, types.Hash{}, r.GetState(created, types.Hash{1}))
+	ws[created].Storage[types.Hash{1}] = types.Hash{1}
+
+	r.SelfDestruct(destroyed)
+	delete(ws, destroyed)
+
+	input := r.InputSubstate()
+	assert.NotContains(t, input, created)
+	assert.Contains(t, input, destroyed)
+	output := r.OutputSubstate()
+	assert.NotContains(t, output, destroyed)
+	require.Contains(t, output, created)
+	assert.Equal(t, types.Hash{1}, output[created].Storage[types.Hash{1}])
+
+	des, res := r.DestroyedAccounts()
+	assert.Equal(t, []types.Address{destroyed}, des)
+	assert.Equal(t, []types.Address{created}, res)
+
+	// an account created and destroyed by the same transaction is destroyed
+	r.SelfDestruct(created)
+	des, res = r.DestroyedAccounts()
+	assert.Equal(t, []types.Address{created, destroyed}, des)
+	assert.Empty(t, res)
+}
+
+func TestRecorder_SubstateCanBeStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := types.Address{1}
+	ws := substate.NewWorldState().Add(sender, 1, uint256.NewInt(100), nil)
+
+	r := NewRecorder(newMockState(ctrl, ws))
+	r.GetBalance(sender)
+	ws[sender] = substate.NewAccount(2, uint256.NewInt(79), nil)
+
+	ss := r.Substate(
+		substate.NewEnv(types.Address{}, big.NewInt(0), 30_000_000, 10, 0, nil, nil, nil, nil),
+		substate.NewMessage(1, true, big.NewInt(1), 21_000, sender, &sender, big.NewInt(0), nil, nil, nil,
+			nil, big.NewInt(1), big.NewInt(1), nil, nil, nil),
+		substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000),
+		10, 0,
+	)
+	sdb, err := db.NewDefaultSubstateDB(t.TempDir())
+	require.NoError(t, err)
+	defer sdb.Close()
+	require.NoError(t, sdb.PutSubstate(ss))
+
+	got, err := sdb.GetSubstate(10, 0)
+	require.NoError(t, err)
+	assert.NoError(t, ss.Equal(got))
+	assert.Equal(t, uint64(1), got.InputSubstate[sender].Nonce)
+	assert.Equal(t, uint64(2), got.OutputSubstate[sender].Nonce)
+}
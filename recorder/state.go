@@ -0,0 +1,26 @@
+package recorder
+
+import (
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+)
+
+// StateReader is the read interface of a StateDB transactions are executed on.
+//
+//go:generate mockgen -source=state.go -destination=./state_mock.go -package=recorder
+type StateReader interface {
+	// Exist reports whether the account exists.
+	Exist(addr types.Address) bool
+
+	// GetNonce returns the nonce of the account.
+	GetNonce(addr types.Address) uint64
+
+	// GetBalance returns the balance of the account.
+	GetBalance(addr types.Address) *uint256.Int
+
+	// GetCode returns the code of the account.
+	GetCode(addr types.Address) []byte
+
+	// GetState returns the value of the storage slot of the account.
+	GetState(addr types.Address, key types.Hash) types.Hash
+}
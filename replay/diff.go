@@ -0,0 +1,179 @@
+package replay
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/diff"
+	"github.com/0xsoniclabs/substate/substate"
+)
+
+// DiffOptions configures Diff.
+type DiffOptions struct {
+	// Workers is the number of blocks executed in parallel.
+	Workers int
+
+	// Exceptions receives an exception for every block with divergent transactions if set.
+	// The exception of a transaction holds its input substate and the reference output.
+	Exceptions db.ExceptionDB
+}
+
+// Divergence describes a transaction on which the executors disagree.
+type Divergence struct {
+	Block          uint64           `json:"block"`
+	Transaction    int              `json:"transaction"`
+	Differences    diff.Differences `json:"differences,omitempty"`
+	ReferenceError string           `json:"referenceError,omitempty"`
+	CandidateError string           `json:"candidateError,omitempty"`
+
+	input     substate.WorldState
+	reference *Outcome
+}
+
+// DiffReport describes the outcome of Diff.
+type DiffReport struct {
+	First        uint64       `json:"first"`
+	Last         uint64       `json:"last"`
+	Transactions int          `json:"transactions"`
+	Divergences  []Divergence `json:"divergences,omitempty"`
+}
+
+// Diff executes every substate of blocks first to last with the reference and the candidate
+// executor and compares their outcomes field by field. Divergences are reported ordered by
+// block and transaction, only failures to read or write the databases are returned as error.
+func Diff(sdb db.SubstateDB, first, last uint64, reference, candidate Executor, options DiffOptions) (*DiffReport, error) {
+	report := &DiffReport{First: first, Last: last}
+	var mu sync.Mutex
+
+	pool := &db.SubstateTaskPool{
+		Name: "substate-diff",
+		TaskFunc: func(block uint64, tx int, ss *substate.Substate, _ *db.SubstateTaskPool) error {
+			divergence := diffTransaction(ss, reference, candidate)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Transactions++
+			if divergence != nil {
+				report.Divergences = append(report.Divergences, *divergence)
+			}
+			return nil
+		},
+		First:   first,
+		Last:    last,
+		Workers: max(options.Workers, 1),
+		DB:      sdb,
+	}
+	if err := pool.Execute(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Divergences, func(i, j int) bool {
+		a, b := report.Divergences[i], report.Divergences[j]
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+		return a.Transaction < b.Transaction
+	})
+	if options.Exceptions != nil {
+		if err := putExceptions(options.Exceptions, report.Divergences); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// diffTransaction executes ss with both executors, it returns nil if their outcomes are equal.
+func diffTransaction(ss *substate.Substate, reference, candidate Executor) *Divergence {
+	want, wantErr := reference.Execute(ss)
+	got, gotErr := candidate.Execute(ss)
+	divergence := &Divergence{Block: ss.Block, Transaction: ss.Transaction, input: ss.InputSubstate, reference: want}
+	switch {
+	case wantErr != nil || gotErr != nil:
+		if wantErr != nil && gotErr != nil && wantErr.Error() == gotErr.Error() {
+			return nil
+		}
+		if wantErr != nil {
+			divergence.ReferenceError = wantErr.Error()
+		}
+		if gotErr != nil {
+			divergence.CandidateError = gotErr.Error()
+		}
+	default:
+		divergence.Differences = CompareOutcomes(want, got)
+		if len(divergence.Differences) == 0 {
+			return nil
+		}
+	}
+	return divergence
+}
+
+// putExceptions stores an exception for every block of divergences with outcomes of the reference.
+// Transactions are added to exceptions already stored for the block.
+func putExceptions(edb db.ExceptionDB, divergences []Divergence) error {
+	codeDB := db.MakeDefaultCodeDBFromBaseDB(edb)
+	exceptions := make(map[uint64]*substate.Exception)
+	var blocks []uint64
+	for _, d := range divergences {
+		if d.reference == nil {
+			continue
+		}
+		ex, found := exceptions[d.Block]
+		if !found {
+			existing, err := edb.GetException(d.Block)
+			if err != nil {
+				return err
+			}
+			ex = &substate.Exception{Block: d.Block, Data: substate.ExceptionBlock{Transactions: make(map[int]substate.ExceptionTx)}}
+			if existing != nil {
+				ex = existing
+				if ex.Data.Transactions == nil {
+					ex.Data.Transactions = make(map[int]substate.ExceptionTx)
+				}
+			}
+			exceptions[d.Block] = ex
+			blocks = append(blocks, d.Block)
+		}
+		pre, post := d.input, d.reference.OutputSubstate
+		for _, ws := range []substate.WorldState{pre, post} {
+			for _, acc := range ws {
+				if err := codeDB.PutCode(acc.Code); err != nil {
+					return fmt.Errorf("cannot put code of exception block: %v; %w", d.Block, err)
+				}
+			}
+		}
+		ex.Data.Transactions[d.Transaction] = substate.ExceptionTx{
+			PreTransaction:  &pre,
+			PostTransaction: &post,
+			VmException:     d.reference.Result != nil && d.reference.Result.Status == 0,
+		}
+	}
+	for _, block := range blocks {
+		if err := edb.PutException(exceptions[block]); err != nil {
+			return fmt.Errorf("cannot put exception block: %v; %w", block, err)
+		}
+	}
+	return nil
+}
+
+// CompareOutcomes returns the differences of got from want with paths rooted at OutputSubstate
+// and Result. Storage slots holding zero are equal to missing slots.
+func CompareOutcomes(want, got *Outcome) diff.Differences {
+	if want == nil || got == nil {
+		if want == got {
+			return nil
+		}
+		return diff.Differences{{Path: "Outcome", Got: presence(got != nil), Want: presence(want != nil)}}
+	}
+	return diff.Comparer{ZeroSlotsAreMissing: true}.Substate(
+		&substate.Substate{OutputSubstate: want.OutputSubstate, Result: want.Result},
+		&substate.Substate{OutputSubstate: got.OutputSubstate, Result: got.Result},
+	)
+}
+
+func presence(present bool) string {
+	if present {
+		return diff.Present
+	}
+	return diff.Missing
+}
@@ -0,0 +1,146 @@
+package replay
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/db"
+	"github.com/0xsoniclabs/substate/diff"
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestSubstate(block uint64, tx int) *substate.Substate {
+	output := substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(block), []byte{0x60})
+	output[types.Address{1}].Storage[types.Hash{1}] = types.Hash{1}
+	return substate.NewSubstate(
+		substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(block), []byte{0x60}),
+		output,
+		substate.NewEnv(types.Address{}, big.NewInt(0), 0, block, 0, nil, nil, nil, nil),
+		substate.NewMessage(1, true, big.NewInt(0), 0, types.Address{1}, &types.Address{1}, big.NewInt(0),
+			nil, nil, nil, nil, big.NewInt(0), big.NewInt(0), nil, nil, nil),
+		substate.NewResult(1, types.Bloom{}, []*types.Log{{Address: types.Address{1}, Topics: []types.Hash{{1}}, Data: []byte{1}}}, types.Address{}, 21_000),
+		block, tx,
+	)
+}
+
+func createTestDB(t *testing.T) db.SubstateDB {
+	sdb, err := db.NewDefaultSubstateDB(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { sdb.Close() })
+	for block := uint64(1); block <= 4; block++ {
+		for tx := 0; tx < 2; tx++ {
+			require.NoError(t, sdb.PutSubstate(getTestSubstate(block, tx)))
+		}
+	}
+	return sdb
+}
+
+// stub returns an executor changing recorded outcomes of transactions selected by diverge.
+func stub(diverge func(ss *substate.Substate) bool) Executor {
+	return ExecutorFunc(func(ss *substate.Substate) (*Outcome, error) {
+		outcome := &Outcome{OutputSubstate: ss.OutputSubstate.Diff(substate.NewWorldState()), Result: ss.Result}
+		if diverge(ss) {
+			outcome.OutputSubstate[types.Address{1}].Storage[types.Hash{1}] = types.Hash{2}
+			outcome.Result = substate.NewResult(0, types.Bloom{}, nil, types.Address{}, 21_000)
+		}
+		return outcome, nil
+	})
+}
+
+func TestDiff_ReportsNoDivergenceForEqualExecutors(t *testing.T) {
+	sdb := createTestDB(t)
+	report, err := Diff(sdb, 1, 4, Recorded, stub(func(*substate.Substate) bool { return false }), DiffOptions{Workers: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 8, report.Transactions)
+	assert.Empty(t, report.Divergences)
+}
+
+func TestDiff_ReportsDivergencesInOrder(t *testing.T) {
+	sdb := createTestDB(t)
+	candidate := stub(func(ss *substate.Substate) bool { return ss.Block >= 3 && ss.Transaction == 1 })
+	report, err := Diff(sdb, 2, 4, Recorded, candidate, DiffOptions{Workers: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Transactions)
+	require.Len(t, report.Divergences, 2)
+	assert.Equal(t, uint64(3), report.Divergences[0].Block)
+	assert.Equal(t, uint64(4), report.Divergences[1].Block)
+	assert.Equal(t, []string{
+		"OutputSubstate[" + types.Address{1}.String() + "].Storage[" + types.Hash{1}.String() + "]",
+		"Result.Status",
+		"Result.Logs.Length",
+	}, report.Divergences[0].Differences.Paths())
+}
+
+func TestDiff_ReportsExecutorErrors(t *testing.T) {
+	sdb := createTestDB(t)
+	failing := ExecutorFunc(func(ss *substate.Substate) (*Outcome, error) {
+		return nil, errors.New("unsupported")
+	})
+
+	report, err := Diff(sdb, 1, 1, Recorded, failing, DiffOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Divergences, 2)
+	assert.Equal(t, "unsupported", report.Divergences[0].CandidateError)
+	assert.Empty(t, report.Divergences[0].ReferenceError)
+
+	// equal errors are no divergence
+	report, err = Diff(sdb, 1, 1, failing, failing, DiffOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Divergences)
+}
+
+func TestDiff_StoresDivergencesAsExceptions(t *testing.T) {
+	sdb := createTestDB(t)
+	edb := db.MakeDefaultExceptionDBFromBaseDB(sdb)
+	candidate := stub(func(ss *substate.Substate) bool { return ss.Block == 2 })
+
+	_, err := Diff(sdb, 1, 3, Recorded, candidate, DiffOptions{Exceptions: edb})
+	require.NoError(t, err)
+
+	ex, err := edb.GetException(2)
+	require.NoError(t, err)
+	require.NotNil(t, ex)
+	require.Len(t, ex.Data.Transactions, 2)
+	want := getTestSubstate(2, 0)
+	assert.True(t, ex.Data.Transactions[0].PreTransaction.Equal(want.InputSubstate))
+	assert.True(t, ex.Data.Transactions[1].PostTransaction.Equal(want.OutputSubstate))
+	assert.False(t, ex.Data.Transactions[0].VmException)
+
+	ex, err = edb.GetException(1)
+	require.NoError(t, err)
+	assert.Nil(t, ex)
+}
+
+func TestCompareOutcomes_TreatsZeroSlotsAsMissing(t *testing.T) {
+	want := &Outcome{OutputSubstate: substate.NewWorldState().Add(types.Address{1}, 0, uint256.NewInt(0), nil)}
+	got := &Outcome{OutputSubstate: substate.NewWorldState().Add(types.Address{1}, 0, uint256.NewInt(0), nil)}
+	got.OutputSubstate[types.Address{1}].Storage[types.Hash{1}] = types.Hash{}
+	assert.Empty(t, CompareOutcomes(want, got))
+
+	got.OutputSubstate.Add(types.Address{2}, 0, uint256.NewInt(0), nil)
+	want.OutputSubstate.Add(types.Address{3}, 0, uint256.NewInt(0), nil)
+	assert.Equal(t, diff.Differences{
+		{Path: "OutputSubstate[" + types.Address{2}.String() + "]", Got: diff.Present, Want: diff.Missing},
+		{Path: "OutputSubstate[" + types.Address{3}.String() + "]", Got: diff.Missing, Want: diff.Present},
+	}, CompareOutcomes(want, got))
+	assert.Equal(t, diff.Differences{{Path: "Outcome", Got: diff.Missing, Want: diff.Present}}, CompareOutcomes(want, nil))
+}
+
+func TestRegister_MakesExecutorsAvailable(t *testing.T) {
+	executor := stub(func(*substate.Substate) bool { return false })
+	Register("test-stub", executor)
+	assert.Panics(t, func() { Register("test-stub", executor) })
+
+	got, err := GetExecutor("test-stub")
+	require.NoError(t, err)
+	assert.NotNil(t, got)
+	assert.Contains(t, Executors(), "recorded")
+
+	_, err = GetExecutor("unknown")
+	assert.ErrorContains(t, err, "executor unknown is not registered")
+}
@@ -0,0 +1,78 @@
+// Package replay executes recorded transactions with pluggable executors and compares outcomes.
+package replay
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/0xsoniclabs/substate/substate"
+)
+
+// Outcome is the effect of executing the transaction of a substate.
+type Outcome struct {
+	OutputSubstate substate.WorldState
+	Result         *substate.Result
+}
+
+// Executor executes the transaction of a substate on its input substate and environment.
+// Implementations must be safe for concurrent use and must not modify the substate.
+type Executor interface {
+	Execute(ss *substate.Substate) (*Outcome, error)
+}
+
+// ExecutorFunc adapts a function to an Executor.
+type ExecutorFunc func(ss *substate.Substate) (*Outcome, error)
+
+// Execute calls f(ss).
+func (f ExecutorFunc) Execute(ss *substate.Substate) (*Outcome, error) {
+	return f(ss)
+}
+
+// Recorded is an Executor returning the recorded outcome of substates.
+var Recorded = ExecutorFunc(func(ss *substate.Substate) (*Outcome, error) {
+	return &Outcome{OutputSubstate: ss.OutputSubstate, Result: ss.Result}, nil
+})
+
+var (
+	executorsMu sync.RWMutex
+	executors   = map[string]Executor{"recorded": Recorded}
+)
+
+// Register makes an executor available by name to commands, it panics if the name is taken.
+// Executors are usually registered by init functions of the packages implementing them.
+func Register(name string, executor Executor) {
+	executorsMu.Lock()
+	defer executorsMu.Unlock()
+	if _, found := executors[name]; found {
+		panic(fmt.Sprintf("executor %v is already registered", name))
+	}
+	executors[name] = executor
+}
+
+// GetExecutor returns the executor registered by name.
+func GetExecutor(name string) (Executor, error) {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+	executor, found := executors[name]
+	if !found {
+		return nil, fmt.Errorf("executor %v is not registered, available executors: %v", name, executorNames())
+	}
+	return executor, nil
+}
+
+// Executors returns the sorted names of all registered executors.
+func Executors() []string {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+	return executorNames()
+}
+
+func executorNames() []string {
+	names := make([]string, 0, len(executors))
+	for name := range executors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
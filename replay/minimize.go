@@ -0,0 +1,154 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+)
+
+// Predicate reports whether a substate still shows the behaviour it is minimised for.
+// Predicates must not modify the substate.
+type Predicate func(ss *substate.Substate) (bool, error)
+
+// Diverges returns a predicate holding for substates whose outcomes of reference and candidate
+// differ the way they differ for ss, i.e. at the same paths and with the same errors. Substates
+// diverging otherwise, e.g. as an account needed by the transaction is removed, are rejected,
+// hence minimised substates keep the original divergence.
+func Diverges(reference, candidate Executor, ss *substate.Substate) Predicate {
+	original := diffTransaction(ss, reference, candidate)
+	return func(ss *substate.Substate) (bool, error) {
+		return original.sameAs(diffTransaction(ss, reference, candidate)), nil
+	}
+}
+
+// sameAs reports whether d and other are divergences at the same paths with the same errors.
+func (d *Divergence) sameAs(other *Divergence) bool {
+	if d == nil || other == nil {
+		return false
+	}
+	return d.ReferenceError == other.ReferenceError && d.CandidateError == other.CandidateError &&
+		slices.Equal(d.Differences.Paths(), other.Differences.Paths())
+}
+
+// slot is a storage slot of an account of the input substate.
+type slot struct {
+	address types.Address
+	key     types.Hash
+}
+
+// Minimize removes accounts and storage slots from the input substate and block hashes
+// from the environment of ss as long as holds still accepts the reduced substate. Accounts
+// are removed first, then storage slots of the remaining accounts and then block hashes,
+// each by delta debugging. The output substate, message and result are kept, ss is not modified.
+func Minimize(ss *substate.Substate, holds Predicate) (*substate.Substate, error) {
+	if ok, err := holds(ss.Clone()); err != nil || !ok {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("predicate does not hold for substate block: %v, tx: %v", ss.Block, ss.Transaction)
+	}
+
+	accounts := make([]types.Address, 0, len(ss.InputSubstate))
+	for addr := range ss.InputSubstate {
+		accounts = append(accounts, addr)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i][:], accounts[j][:]) < 0 })
+	var blocks []uint64
+	if ss.Env != nil {
+		for number := range ss.Env.BlockHashes {
+			blocks = append(blocks, number)
+		}
+		sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+	}
+
+	var err error
+	accounts, err = ddmin(accounts, func(accounts []types.Address) (bool, error) {
+		return holds(reduce(ss, accounts, allSlots(ss, accounts), blocks))
+	})
+	if err != nil {
+		return nil, err
+	}
+	slots, err := ddmin(allSlots(ss, accounts), func(slots []slot) (bool, error) {
+		return holds(reduce(ss, accounts, slots, blocks))
+	})
+	if err != nil {
+		return nil, err
+	}
+	blocks, err = ddmin(blocks, func(blocks []uint64) (bool, error) {
+		return holds(reduce(ss, accounts, slots, blocks))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reduce(ss, accounts, slots, blocks), nil
+}
+
+// allSlots returns the sorted storage slots of accounts in the input substate of ss.
+func allSlots(ss *substate.Substate, accounts []types.Address) []slot {
+	var slots []slot
+	for _, addr := range accounts {
+		start := len(slots)
+		for key := range ss.InputSubstate[addr].Storage {
+			slots = append(slots, slot{address: addr, key: key})
+		}
+		added := slots[start:]
+		sort.Slice(added, func(i, j int) bool { return bytes.Compare(added[i].key[:], added[j].key[:]) < 0 })
+	}
+	return slots
+}
+
+// reduce returns a copy of ss whose input substate only holds accounts with slots
+// and whose environment only holds hashes of blocks.
+func reduce(ss *substate.Substate, accounts []types.Address, slots []slot, blocks []uint64) *substate.Substate {
+	reduced := ss.Clone()
+	reduced.InputSubstate = substate.NewWorldState()
+	for _, addr := range accounts {
+		acc := ss.InputSubstate[addr].Copy()
+		acc.Storage = make(map[types.Hash]types.Hash)
+		reduced.InputSubstate[addr] = acc
+	}
+	for _, s := range slots {
+		reduced.InputSubstate[s.address].Storage[s.key] = ss.InputSubstate[s.address].Storage[s.key]
+	}
+	if reduced.Env != nil && reduced.Env.BlockHashes != nil {
+		reduced.Env.BlockHashes = make(map[uint64]types.Hash, len(blocks))
+		for _, number := range blocks {
+			reduced.Env.BlockHashes[number] = ss.Env.BlockHashes[number]
+		}
+	}
+	return reduced
+}
+
+// ddmin returns a subset of items from which no chunk can be removed without holds
+// rejecting the rest. Chunks are halved whenever none of them can be removed.
+func ddmin[T any](items []T, holds func([]T) (bool, error)) ([]T, error) {
+	granularity := 2
+	for len(items) > 0 {
+		granularity = min(granularity, len(items))
+		size := (len(items) + granularity - 1) / granularity
+		reduced := false
+		for start := 0; start < len(items) && !reduced; start += size {
+			end := min(start+size, len(items))
+			rest := append(append(make([]T, 0, len(items)-(end-start)), items[:start]...), items[end:]...)
+			ok, err := holds(rest)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				items, reduced = rest, true
+				granularity = max(granularity-1, 2)
+			}
+		}
+		if !reduced {
+			if granularity == len(items) {
+				break
+			}
+			granularity = min(2*granularity, len(items))
+		}
+	}
+	return items, nil
+}
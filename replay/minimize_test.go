@@ -0,0 +1,138 @@
+package replay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getLargeTestSubstate() *substate.Substate {
+	ss := getTestSubstate(10, 0)
+	ss.Env.BlockHashes = make(map[uint64]types.Hash)
+	for i := byte(1); i <= 20; i++ {
+		acc := substate.NewAccount(uint64(i), uint256.NewInt(uint64(i)), []byte{i})
+		for j := byte(1); j <= 10; j++ {
+			acc.Storage[types.Hash{j}] = types.Hash{i, j}
+		}
+		ss.InputSubstate[types.Address{i}] = acc
+		ss.Env.BlockHashes[uint64(i)] = types.Hash{i}
+	}
+	return ss
+}
+
+func TestMinimize_KeepsOnlyElementsNeededByPredicate(t *testing.T) {
+	ss := getLargeTestSubstate()
+	calls := 0
+	holds := func(ss *substate.Substate) (bool, error) {
+		calls++
+		acc, found := ss.InputSubstate[types.Address{7}]
+		_, hash := ss.Env.BlockHashes[3]
+		return found && acc.Storage[types.Hash{4}] == types.Hash{7, 4} && ss.InputSubstate[types.Address{12}] != nil && hash, nil
+	}
+
+	got, err := Minimize(ss, holds)
+	require.NoError(t, err)
+
+	want := substate.NewWorldState().
+		Add(types.Address{7}, 7, uint256.NewInt(7), []byte{7}).
+		Add(types.Address{12}, 12, uint256.NewInt(12), []byte{12})
+	want[types.Address{7}].Storage[types.Hash{4}] = types.Hash{7, 4}
+	assert.True(t, want.Equal(got.InputSubstate), "got %v", got.InputSubstate)
+	assert.Equal(t, map[uint64]types.Hash{3: {3}}, got.Env.BlockHashes)
+	assert.True(t, ss.OutputSubstate.Equal(got.OutputSubstate))
+	assert.Equal(t, ss.Message, got.Message)
+	assert.Less(t, calls, 200)
+
+	assert.Len(t, ss.InputSubstate, 20, "original substate must not be modified")
+	assert.Len(t, ss.InputSubstate[types.Address{7}].Storage, 10, "original substate must not be modified")
+	assert.Len(t, ss.Env.BlockHashes, 20, "original substate must not be modified")
+}
+
+func TestMinimize_RemovesEverythingIfPredicateAlwaysHolds(t *testing.T) {
+	got, err := Minimize(getLargeTestSubstate(), func(*substate.Substate) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.Empty(t, got.InputSubstate)
+	assert.NotNil(t, got.Env.BlockHashes)
+	assert.Empty(t, got.Env.BlockHashes)
+}
+
+func TestMinimize_FailsIfPredicateDoesNotHold(t *testing.T) {
+	_, err := Minimize(getLargeTestSubstate(), func(*substate.Substate) (bool, error) { return false, nil })
+	assert.ErrorContains(t, err, "predicate does not hold for substate block: 10, tx: 0")
+
+	injected := errors.New("injected")
+	calls := 0
+	_, err = Minimize(getLargeTestSubstate(), func(*substate.Substate) (bool, error) {
+		calls++
+		if calls > 3 {
+			return false, injected
+		}
+		return true, nil
+	})
+	assert.ErrorIs(t, err, injected)
+}
+
+func TestMinimize_DivergesKeepsAccountCausingDivergence(t *testing.T) {
+	ss := getLargeTestSubstate()
+	candidate := stub(func(ss *substate.Substate) bool {
+		acc, found := ss.InputSubstate[types.Address{5}]
+		return found && acc.Storage[types.Hash{9}] != types.Hash{}
+	})
+
+	got, err := Minimize(ss, Diverges(Recorded, candidate, ss))
+	require.NoError(t, err)
+	require.Len(t, got.InputSubstate, 1)
+	assert.Equal(t, map[types.Hash]types.Hash{{9}: {5, 9}}, got.InputSubstate[types.Address{5}].Storage)
+	assert.Empty(t, got.Env.BlockHashes)
+
+	_, err = Minimize(ss, Diverges(Recorded, Recorded, ss))
+	assert.Error(t, err)
+}
+
+func TestMinimize_DivergesKeepsOriginalDivergence(t *testing.T) {
+	ss := getLargeTestSubstate()
+	diverging := stub(func(ss *substate.Substate) bool {
+		acc, found := ss.InputSubstate[types.Address{5}]
+		return found && acc.Storage[types.Hash{9}] != types.Hash{}
+	})
+	// the transaction fails without account 7, its outcome diverges at other paths then
+	candidate := ExecutorFunc(func(ss *substate.Substate) (*Outcome, error) {
+		if _, found := ss.InputSubstate[types.Address{7}]; !found {
+			return &Outcome{OutputSubstate: ss.OutputSubstate, Result: substate.NewResult(0, types.Bloom{}, nil, types.Address{}, 21_000)}, nil
+		}
+		return diverging.Execute(ss)
+	})
+
+	got, err := Minimize(ss, Diverges(Recorded, candidate, ss))
+	require.NoError(t, err)
+	require.Len(t, got.InputSubstate, 2)
+	assert.Equal(t, map[types.Hash]types.Hash{{9}: {5, 9}}, got.InputSubstate[types.Address{5}].Storage)
+	assert.Empty(t, got.InputSubstate[types.Address{7}].Storage)
+}
+
+func TestDdmin_FindsMinimalSubset(t *testing.T) {
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	got, err := ddmin(items, func(kept []int) (bool, error) {
+		found := 0
+		for _, i := range kept {
+			if i == 13 || i == 42 || i == 99 {
+				found++
+			}
+		}
+		return found == 3, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{13, 42, 99}, got)
+
+	got, err = ddmin([]int(nil), func([]int) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
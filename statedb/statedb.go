@@ -0,0 +1,456 @@
+// Package statedb provides an in-memory StateDB transactions of substates can be replayed on.
+package statedb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/holiman/uint256"
+)
+
+// emptyCodeHash is the code hash of existing accounts without code.
+var emptyCodeHash = hash.Keccak256Hash(nil)
+
+// StateDB is an in-memory state initialised with the input substate of a transaction.
+// Changes are journaled and can be reverted to snapshots. Accounts not present in the
+// input substate do not exist.
+type StateDB struct {
+	accounts substate.WorldState
+	original substate.WorldState // state at the beginning of the transaction
+	accessed map[types.Address]map[types.Hash]struct{}
+
+	transient      map[types.Address]map[types.Hash]types.Hash
+	accessList     map[types.Address]map[types.Hash]struct{}
+	selfDestructed map[types.Address]struct{}
+	created        map[types.Address]struct{}
+	touched        map[types.Address]struct{}
+	logs           []*types.Log
+	refund         uint64
+
+	// journal holds functions undoing changes, revisions the journal lengths of snapshots.
+	journal   []func()
+	revisions []int
+}
+
+// NewStateDB returns a StateDB holding a copy of ws.
+func NewStateDB(ws substate.WorldState) *StateDB {
+	s := &StateDB{
+		accounts: substate.NewWorldState(),
+		accessed: make(map[types.Address]map[types.Hash]struct{}),
+	}
+	for addr, acc := range ws {
+		s.accounts[addr] = copyAccount(acc)
+	}
+	s.resetTransaction()
+	return s
+}
+
+// resetTransaction clears all state of the current transaction.
+func (s *StateDB) resetTransaction() {
+	s.original = substate.NewWorldState()
+	for addr, acc := range s.accounts {
+		s.original[addr] = copyAccount(acc)
+	}
+	s.transient = make(map[types.Address]map[types.Hash]types.Hash)
+	s.accessList = make(map[types.Address]map[types.Hash]struct{})
+	s.selfDestructed = make(map[types.Address]struct{})
+	s.created = make(map[types.Address]struct{})
+	s.touched = make(map[types.Address]struct{})
+	s.refund = 0
+	s.journal = nil
+	s.revisions = nil
+}
+
+// copyAccount returns a copy of acc which does not share the balance.
+func copyAccount(acc *substate.Account) *substate.Account {
+	c := acc.Copy()
+	c.Balance = new(uint256.Int).Set(acc.Balance)
+	return c
+}
+
+func (s *StateDB) access(addr types.Address) {
+	if _, found := s.accessed[addr]; !found {
+		s.accessed[addr] = make(map[types.Hash]struct{})
+	}
+}
+
+// getOrCreate returns the account of addr, it is created if it does not exist.
+func (s *StateDB) getOrCreate(addr types.Address) *substate.Account {
+	s.access(addr)
+	if acc, found := s.accounts[addr]; found {
+		return acc
+	}
+	acc := substate.NewAccount(0, uint256.NewInt(0), nil)
+	s.accounts[addr] = acc
+	s.journal = append(s.journal, func() { delete(s.accounts, addr) })
+	return acc
+}
+
+// CreateAccount creates a new account keeping the balance of a previous account at addr.
+func (s *StateDB) CreateAccount(addr types.Address) {
+	s.access(addr)
+	prev, found := s.accounts[addr]
+	balance := uint256.NewInt(0)
+	if found {
+		balance = new(uint256.Int).Set(prev.Balance)
+	}
+	s.accounts[addr] = substate.NewAccount(0, balance, nil)
+	_, wasCreated := s.created[addr]
+	s.created[addr] = struct{}{}
+	s.journal = append(s.journal, func() {
+		if found {
+			s.accounts[addr] = prev
+		} else {
+			delete(s.accounts, addr)
+		}
+		if !wasCreated {
+			delete(s.created, addr)
+		}
+	})
+}
+
+// Exist reports whether the account exists, self-destructed accounts exist until Finalise.
+func (s *StateDB) Exist(addr types.Address) bool {
+	s.access(addr)
+	_, found := s.accounts[addr]
+	return found
+}
+
+// Empty reports whether the account does not exist or has no nonce, balance and code as defined by EIP-161.
+func (s *StateDB) Empty(addr types.Address) bool {
+	s.access(addr)
+	acc, found := s.accounts[addr]
+	return !found || (acc.Nonce == 0 && acc.Balance.IsZero() && len(acc.Code) == 0)
+}
+
+// GetBalance returns the balance of the account.
+func (s *StateDB) GetBalance(addr types.Address) *uint256.Int {
+	s.access(addr)
+	if acc, found := s.accounts[addr]; found {
+		return new(uint256.Int).Set(acc.Balance)
+	}
+	return uint256.NewInt(0)
+}
+
+// AddBalance adds amount to the balance of the account and touches it.
+func (s *StateDB) AddBalance(addr types.Address, amount *uint256.Int) {
+	s.touch(addr)
+	s.setBalance(addr, new(uint256.Int).Add(s.GetBalance(addr), amount))
+}
+
+// SubBalance subtracts amount from the balance of the account and touches it.
+func (s *StateDB) SubBalance(addr types.Address, amount *uint256.Int) {
+	s.touch(addr)
+	s.setBalance(addr, new(uint256.Int).Sub(s.GetBalance(addr), amount))
+}
+
+func (s *StateDB) setBalance(addr types.Address, balance *uint256.Int) {
+	acc := s.getOrCreate(addr)
+	prev := acc.Balance
+	acc.Balance = balance
+	s.journal = append(s.journal, func() { acc.Balance = prev })
+}
+
+// touch marks the account as touched for the deletion of empty accounts.
+func (s *StateDB) touch(addr types.Address) {
+	if _, found := s.touched[addr]; found {
+		return
+	}
+	s.touched[addr] = struct{}{}
+	s.journal = append(s.journal, func() { delete(s.touched, addr) })
+}
+
+// GetNonce returns the nonce of the account.
+func (s *StateDB) GetNonce(addr types.Address) uint64 {
+	s.access(addr)
+	if acc, found := s.accounts[addr]; found {
+		return acc.Nonce
+	}
+	return 0
+}
+
+// SetNonce sets the nonce of the account.
+func (s *StateDB) SetNonce(addr types.Address, nonce uint64) {
+	acc := s.getOrCreate(addr)
+	prev := acc.Nonce
+	acc.Nonce = nonce
+	s.journal = append(s.journal, func() { acc.Nonce = prev })
+}
+
+// GetCode returns the code of the account.
+func (s *StateDB) GetCode(addr types.Address) []byte {
+	s.access(addr)
+	if acc, found := s.accounts[addr]; found {
+		return acc.Code
+	}
+	return nil
+}
+
+// GetCodeSize returns the size of the code of the account.
+func (s *StateDB) GetCodeSize(addr types.Address) int {
+	return len(s.GetCode(addr))
+}
+
+// GetCodeHash returns the hash of the code of the account, it is zero for accounts not existing.
+func (s *StateDB) GetCodeHash(addr types.Address) types.Hash {
+	s.access(addr)
+	acc, found := s.accounts[addr]
+	if !found {
+		return types.Hash{}
+	}
+	if len(acc.Code) == 0 {
+		return emptyCodeHash
+	}
+	return hash.Keccak256Hash(acc.Code)
+}
+
+// SetCode sets the code of the account.
+func (s *StateDB) SetCode(addr types.Address, code []byte) {
+	acc := s.getOrCreate(addr)
+	prev := acc.Code
+	acc.Code = bytes.Clone(code)
+	s.journal = append(s.journal, func() { acc.Code = prev })
+}
+
+// GetState returns the current value of the storage slot.
+func (s *StateDB) GetState(addr types.Address, key types.Hash) types.Hash {
+	s.access(addr)
+	s.accessed[addr][key] = struct{}{}
+	if acc, found := s.accounts[addr]; found {
+		return acc.Storage[key]
+	}
+	return types.Hash{}
+}
+
+// GetCommittedState returns the value of the storage slot at the beginning of the transaction.
+func (s *StateDB) GetCommittedState(addr types.Address, key types.Hash) types.Hash {
+	s.access(addr)
+	s.accessed[addr][key] = struct{}{}
+	if acc, found := s.original[addr]; found {
+		if _, created := s.created[addr]; !created {
+			return acc.Storage[key]
+		}
+	}
+	return types.Hash{}
+}
+
+// SetState sets the value of the storage slot.
+func (s *StateDB) SetState(addr types.Address, key, value types.Hash) {
+	acc := s.getOrCreate(addr)
+	s.accessed[addr][key] = struct{}{}
+	prev, found := acc.Storage[key]
+	acc.Storage[key] = value
+	s.journal = append(s.journal, func() {
+		if found {
+			acc.Storage[key] = prev
+		} else {
+			delete(acc.Storage, key)
+		}
+	})
+}
+
+// GetTransientState returns the value of the transient storage slot as defined by EIP-1153.
+func (s *StateDB) GetTransientState(addr types.Address, key types.Hash) types.Hash {
+	return s.transient[addr][key]
+}
+
+// SetTransientState sets the value of the transient storage slot.
+func (s *StateDB) SetTransientState(addr types.Address, key, value types.Hash) {
+	if _, found := s.transient[addr]; !found {
+		s.transient[addr] = make(map[types.Hash]types.Hash)
+	}
+	prev := s.transient[addr][key]
+	s.transient[addr][key] = value
+	s.journal = append(s.journal, func() { s.transient[addr][key] = prev })
+}
+
+// SelfDestruct marks the account as self-destructed and clears its balance.
+// The account is deleted by Finalise.
+func (s *StateDB) SelfDestruct(addr types.Address) {
+	s.access(addr)
+	acc, found := s.accounts[addr]
+	if !found {
+		return
+	}
+	prev := acc.Balance
+	acc.Balance = uint256.NewInt(0)
+	_, wasDestructed := s.selfDestructed[addr]
+	s.selfDestructed[addr] = struct{}{}
+	s.journal = append(s.journal, func() {
+		acc.Balance = prev
+		if !wasDestructed {
+			delete(s.selfDestructed, addr)
+		}
+	})
+}
+
+// SelfDestruct6780 self-destructs the account only if it has been created by the
+// current transaction as defined by EIP-6780.
+func (s *StateDB) SelfDestruct6780(addr types.Address) {
+	if _, found := s.created[addr]; found {
+		s.SelfDestruct(addr)
+	}
+}
+
+// HasSelfDestructed reports whether the account has been self-destructed by the current transaction.
+func (s *StateDB) HasSelfDestructed(addr types.Address) bool {
+	_, found := s.selfDestructed[addr]
+	return found
+}
+
+// AddAddressToAccessList adds the address to the access list as defined by EIP-2929.
+func (s *StateDB) AddAddressToAccessList(addr types.Address) {
+	if _, found := s.accessList[addr]; found {
+		return
+	}
+	s.accessList[addr] = make(map[types.Hash]struct{})
+	s.journal = append(s.journal, func() { delete(s.accessList, addr) })
+}
+
+// AddSlotToAccessList adds the address and the slot to the access list.
+func (s *StateDB) AddSlotToAccessList(addr types.Address, key types.Hash) {
+	s.AddAddressToAccessList(addr)
+	if _, found := s.accessList[addr][key]; found {
+		return
+	}
+	s.accessList[addr][key] = struct{}{}
+	s.journal = append(s.journal, func() { delete(s.accessList[addr], key) })
+}
+
+// AddressInAccessList reports whether the address is in the access list.
+func (s *StateDB) AddressInAccessList(addr types.Address) bool {
+	_, found := s.accessList[addr]
+	return found
+}
+
+// SlotInAccessList reports whether the address and the slot are in the access list.
+func (s *StateDB) SlotInAccessList(addr types.Address, key types.Hash) (addressOk bool, slotOk bool) {
+	keys, addressOk := s.accessList[addr]
+	_, slotOk = keys[key]
+	return addressOk, slotOk
+}
+
+// AddLog appends a log of the current transaction.
+func (s *StateDB) AddLog(log *types.Log) {
+	s.logs = append(s.logs, log)
+	s.journal = append(s.journal, func() { s.logs = s.logs[:len(s.logs)-1] })
+}
+
+// Logs returns the logs of the current transaction holding their consensus fields only.
+func (s *StateDB) Logs() []*types.Log {
+	logs := make([]*types.Log, len(s.logs))
+	for i, l := range s.logs {
+		logs[i] = &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data}
+	}
+	return logs
+}
+
+// AddRefund adds gas to the refund counter.
+func (s *StateDB) AddRefund(gas uint64) {
+	prev := s.refund
+	s.refund += gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+// SubRefund removes gas from the refund counter, it panics if the counter would go below zero.
+func (s *StateDB) SubRefund(gas uint64) {
+	if gas > s.refund {
+		panic(fmt.Sprintf("refund counter below zero (gas: %d > refund: %d)", gas, s.refund))
+	}
+	prev := s.refund
+	s.refund -= gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+// GetRefund returns the refund counter.
+func (s *StateDB) GetRefund() uint64 {
+	return s.refund
+}
+
+// Snapshot returns the identifier of the current revision of the state.
+func (s *StateDB) Snapshot() int {
+	s.revisions = append(s.revisions, len(s.journal))
+	return len(s.revisions) - 1
+}
+
+// RevertToSnapshot reverts all changes made since the snapshot, it panics for invalid snapshots.
+func (s *StateDB) RevertToSnapshot(id int) {
+	if id < 0 || id >= len(s.revisions) {
+		panic(fmt.Sprintf("revision id %v cannot be reverted", id))
+	}
+	length := s.revisions[id]
+	for i := len(s.journal) - 1; i >= length; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:length]
+	s.revisions = s.revisions[:id]
+}
+
+// Finalise ends the current transaction. Self-destructed accounts are deleted and so are
+// touched empty accounts if deleteEmptyAccounts is set as defined by EIP-161. Transient
+// storage, the access list and the refund counter are cleared, logs are kept.
+func (s *StateDB) Finalise(deleteEmptyAccounts bool) {
+	for addr := range s.selfDestructed {
+		delete(s.accounts, addr)
+	}
+	if deleteEmptyAccounts {
+		for addr := range s.touched {
+			if s.Empty(addr) {
+				delete(s.accounts, addr)
+			}
+		}
+	}
+	s.resetTransaction()
+}
+
+// OutputSubstate returns the accounts accessed by the transaction which exist in the current
+// state, in the shape of recorded output substates. Slots are included if they have been
+// accessed, slots holding zero are omitted as WorldState.Diff considers them equal to
+// missing slots.
+func (s *StateDB) OutputSubstate() substate.WorldState {
+	output := substate.NewWorldState()
+	for addr, keys := range s.accessed {
+		acc, found := s.accounts[addr]
+		if !found {
+			continue
+		}
+		out := substate.NewAccount(acc.Nonce, new(uint256.Int).Set(acc.Balance), acc.Code)
+		for key := range keys {
+			if value := acc.Storage[key]; value != (types.Hash{}) {
+				out.Storage[key] = value
+			}
+		}
+		output[addr] = out
+	}
+	return output
+}
+
+// Result returns the result of the current transaction with its logs and their bloom.
+func (s *StateDB) Result(status uint64, contractAddress types.Address, gasUsed uint64) *substate.Result {
+	logs := s.Logs()
+	return substate.NewResult(status, LogsBloom(logs), logs, contractAddress, gasUsed)
+}
+
+// LogsBloom returns the bloom filter of logs.
+func LogsBloom(logs []*types.Log) types.Bloom {
+	var bloom types.Bloom
+	for _, l := range logs {
+		addToBloom(&bloom, l.Address[:])
+		for _, topic := range l.Topics {
+			addToBloom(&bloom, topic[:])
+		}
+	}
+	return bloom
+}
+
+// addToBloom sets the three bits of data in bloom.
+func addToBloom(bloom *types.Bloom, data []byte) {
+	h := hash.Keccak256Hash(data)
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(h[i+1]) + uint(h[i])<<8) & 2047
+		bloom[types.BloomByteLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
@@ -0,0 +1,162 @@
+package statedb
+
+import (
+	"math/bits"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/hash"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestWorldState() substate.WorldState {
+	ws := substate.NewWorldState().
+		Add(types.Address{1}, 1, uint256.NewInt(100), nil).
+		Add(types.Address{2}, 0, uint256.NewInt(0), []byte{0x60, 0x00})
+	ws[types.Address{2}].Storage[types.Hash{1}] = types.Hash{1}
+	return ws
+}
+
+func TestStateDB_ReadsInputSubstate(t *testing.T) {
+	ws := getTestWorldState()
+	s := NewStateDB(ws)
+
+	assert.True(t, s.Exist(types.Address{1}))
+	assert.False(t, s.Exist(types.Address{3}))
+	assert.Equal(t, uint64(1), s.GetNonce(types.Address{1}))
+	assert.Equal(t, uint256.NewInt(100), s.GetBalance(types.Address{1}))
+	assert.Equal(t, []byte{0x60, 0x00}, s.GetCode(types.Address{2}))
+	assert.Equal(t, 2, s.GetCodeSize(types.Address{2}))
+	assert.Equal(t, hash.Keccak256Hash([]byte{0x60, 0x00}), s.GetCodeHash(types.Address{2}))
+	assert.Equal(t, hash.Keccak256Hash(nil), s.GetCodeHash(types.Address{1}))
+	assert.Equal(t, types.Hash{}, s.GetCodeHash(types.Address{3}))
+	assert.Equal(t, types.Hash{1}, s.GetState(types.Address{2}, types.Hash{1}))
+	assert.True(t, s.Empty(types.Address{3}))
+	assert.False(t, s.Empty(types.Address{2}))
+
+	// the input substate is not modified
+	s.AddBalance(types.Address{1}, uint256.NewInt(1))
+	s.SetState(types.Address{2}, types.Hash{1}, types.Hash{2})
+	assert.Equal(t, uint256.NewInt(100), ws[types.Address{1}].Balance)
+	assert.Equal(t, types.Hash{1}, ws[types.Address{2}].Storage[types.Hash{1}])
+	assert.Equal(t, types.Hash{1}, s.GetCommittedState(types.Address{2}, types.Hash{1}))
+}
+
+func TestStateDB_RevertsToSnapshots(t *testing.T) {
+	s := NewStateDB(getTestWorldState())
+	sender, contract, created := types.Address{1}, types.Address{2}, types.Address{3}
+
+	s.SubBalance(sender, uint256.NewInt(10))
+	s.SetNonce(sender, 2)
+	first := s.Snapshot()
+	s.CreateAccount(created)
+	s.SetCode(created, []byte{0x01})
+	s.SetState(contract, types.Hash{1}, types.Hash{})
+	s.SetState(contract, types.Hash{2}, types.Hash{2})
+	s.SetTransientState(contract, types.Hash{1}, types.Hash{3})
+	s.AddSlotToAccessList(contract, types.Hash{1})
+	s.AddLog(&types.Log{Address: contract})
+	s.AddRefund(10)
+	second := s.Snapshot()
+	s.SubRefund(5)
+	s.SelfDestruct(contract)
+	assert.True(t, s.HasSelfDestructed(contract))
+	assert.True(t, s.GetBalance(contract).IsZero())
+
+	s.RevertToSnapshot(second)
+	assert.False(t, s.HasSelfDestructed(contract))
+	assert.Equal(t, uint64(10), s.GetRefund())
+	assert.Equal(t, types.Hash{3}, s.GetTransientState(contract, types.Hash{1}))
+	addressOk, slotOk := s.SlotInAccessList(contract, types.Hash{1})
+	assert.True(t, addressOk && slotOk)
+
+	s.RevertToSnapshot(first)
+	assert.False(t, s.Exist(created))
+	assert.Equal(t, types.Hash{1}, s.GetState(contract, types.Hash{1}))
+	assert.Equal(t, types.Hash{}, s.GetState(contract, types.Hash{2}))
+	assert.Equal(t, types.Hash{}, s.GetTransientState(contract, types.Hash{1}))
+	assert.False(t, s.AddressInAccessList(contract))
+	assert.Empty(t, s.Logs())
+	assert.Zero(t, s.GetRefund())
+	assert.Equal(t, uint64(2), s.GetNonce(sender))
+	assert.Equal(t, uint256.NewInt(90), s.GetBalance(sender))
+
+	assert.Panics(t, func() { s.RevertToSnapshot(second) })
+	assert.Panics(t, func() { s.SubRefund(1) })
+}
+
+func TestStateDB_FinaliseDeletesAccounts(t *testing.T) {
+	s := NewStateDB(getTestWorldState())
+	contract, created, empty := types.Address{2}, types.Address{3}, types.Address{4}
+
+	s.CreateAccount(created)
+	s.SetState(created, types.Hash{1}, types.Hash{1})
+	assert.Equal(t, types.Hash{}, s.GetCommittedState(created, types.Hash{1}))
+	s.SelfDestruct6780(contract)
+	assert.False(t, s.HasSelfDestructed(contract))
+	s.SelfDestruct6780(created)
+	assert.True(t, s.HasSelfDestructed(created))
+	s.AddBalance(empty, uint256.NewInt(0))
+	s.SetTransientState(contract, types.Hash{1}, types.Hash{1})
+	assert.True(t, s.Exist(empty))
+
+	s.Finalise(true)
+	assert.False(t, s.Exist(created))
+	assert.False(t, s.Exist(empty))
+	assert.True(t, s.Exist(contract))
+	assert.Equal(t, types.Hash{}, s.GetTransientState(contract, types.Hash{1}))
+}
+
+func TestStateDB_OutputSubstateMatchesRecordingShape(t *testing.T) {
+	s := NewStateDB(getTestWorldState().Add(types.Address{5}, 0, uint256.NewInt(1), nil))
+	sender, contract := types.Address{1}, types.Address{2}
+
+	s.SubBalance(sender, uint256.NewInt(10))
+	s.SetNonce(sender, 2)
+	s.GetCode(contract)
+	s.SetState(contract, types.Hash{1}, types.Hash{})
+	s.SetState(contract, types.Hash{2}, types.Hash{2})
+	s.GetState(contract, types.Hash{3})
+	s.Finalise(true)
+
+	recorded := substate.NewWorldState().
+		Add(sender, 2, uint256.NewInt(90), nil).
+		Add(contract, 0, uint256.NewInt(0), []byte{0x60, 0x00})
+	recorded[contract].Storage[types.Hash{1}] = types.Hash{}
+	recorded[contract].Storage[types.Hash{2}] = types.Hash{2}
+
+	output := s.OutputSubstate()
+	assert.NotContains(t, output, types.Address{5})
+	assert.Empty(t, output.Diff(recorded))
+	assert.Empty(t, recorded.Diff(output))
+}
+
+func TestStateDB_ResultHoldsLogs(t *testing.T) {
+	s := NewStateDB(getTestWorldState())
+	s.AddLog(&types.Log{Address: types.Address{2}, Topics: []types.Hash{{1}}, Data: []byte{1}, BlockNumber: 5, Index: 3})
+
+	result := s.Result(1, types.Address{}, 21_000)
+	recorded := substate.NewResult(1, result.Bloom, []*types.Log{{Address: types.Address{2}, Topics: []types.Hash{{1}}, Data: []byte{1}}},
+		types.Address{}, 21_000)
+	assert.True(t, recorded.Equal(result))
+	require.Len(t, result.Logs, 1)
+	assert.Zero(t, result.Logs[0].BlockNumber)
+}
+
+func TestLogsBloom_SetsThreeBitsPerItem(t *testing.T) {
+	assert.Equal(t, types.Bloom{}, LogsBloom(nil))
+
+	address := LogsBloom([]*types.Log{{Address: types.Address{1}}})
+	topic := LogsBloom([]*types.Log{{Address: types.Address{1}, Topics: []types.Hash{{2}}}})
+	count, countTopic := 0, 0
+	for i := range address {
+		count += bits.OnesCount8(address[i])
+		countTopic += bits.OnesCount8(topic[i])
+		assert.Equal(t, address[i], address[i]&topic[i])
+	}
+	assert.True(t, count > 0 && count <= 3)
+	assert.True(t, countTopic > count && countTopic <= 6)
+}
@@ -0,0 +1,313 @@
+package statetest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/0xsoniclabs/substate/types/rlp"
+	"github.com/0xsoniclabs/substate/utils"
+)
+
+// Fixture is the content of a GeneralStateTests file mapping names of tests to tests.
+type Fixture map[string]*StateTest
+
+// StateTest is a GeneralStateTest of a single recorded transaction. Fields recorded by
+// substates which are not part of the format are stored in Info and in extensions of Env
+// and PostState, they are ignored by test runners.
+//
+// Note: recorded transactions carry their sender instead of a secret key, hence runners
+// must accept the sender field. Post states do not carry the state root.
+type StateTest struct {
+	Info        Info                   `json:"_info"`
+	Env         Env                    `json:"env"`
+	Pre         substate.WorldState    `json:"pre"`
+	Transaction Transaction            `json:"transaction"`
+	Post        map[string][]PostState `json:"post"`
+}
+
+// Info describes the origin of a test.
+type Info struct {
+	Comment     string `json:"comment,omitempty"`
+	ChainID     uint64 `json:"chainId"`
+	Block       uint64 `json:"block"`
+	Transaction int    `json:"transaction"`
+	CheckNonce  bool   `json:"checkNonce"`
+}
+
+// Env is the block environment of a test.
+type Env struct {
+	Coinbase   types.Address   `json:"currentCoinbase"`
+	Difficulty *types.HexBig   `json:"currentDifficulty,omitempty"`
+	Random     *types.Hash     `json:"currentRandom,omitempty"`
+	GasLimit   types.HexUint64 `json:"currentGasLimit"`
+	Number     types.HexUint64 `json:"currentNumber"`
+	Timestamp  types.HexUint64 `json:"currentTimestamp"`
+	BaseFee    *types.HexBig   `json:"currentBaseFee,omitempty"`
+
+	// BlobBaseFee and BlockHashes are extensions, GeneralStateTests derive
+	// the blob base fee from the excess blob gas and have no block hashes.
+	BlobBaseFee *types.HexBig                  `json:"currentBlobBaseFee,omitempty"`
+	BlockHashes map[types.HexUint64]types.Hash `json:"blockHashes,omitempty"`
+}
+
+// Transaction is the transaction of a test. Data, GasLimit and Value
+// hold a single value as a recorded transaction has no variants.
+type Transaction struct {
+	Data                 []types.HexBytes             `json:"data"`
+	GasLimit             []types.HexUint64            `json:"gasLimit"`
+	Value                []*types.HexBig              `json:"value"`
+	Nonce                types.HexUint64              `json:"nonce"`
+	GasPrice             *types.HexBig                `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *types.HexBig                `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *types.HexBig                `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     *types.HexBig                `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes  []types.Hash                 `json:"blobVersionedHashes,omitempty"`
+	AccessLists          []*types.AccessList          `json:"accessLists,omitempty"`
+	AuthorizationList    []types.SetCodeAuthorization `json:"authorizationList,omitempty"`
+	Sender               types.Address                `json:"sender"`
+
+	// To is empty for contract creations.
+	To string `json:"to"`
+}
+
+// PostState is the expected outcome of a transaction.
+type PostState struct {
+	Logs    types.Hash `json:"logs"`
+	Indexes Indexes    `json:"indexes"`
+
+	// State and Receipt are extensions holding the recorded accounts
+	// changed by the transaction and the recorded receipt.
+	State   substate.WorldState `json:"state"`
+	Receipt *substate.Result    `json:"receipt"`
+}
+
+// Indexes select the variant of the transaction of a post state.
+type Indexes struct {
+	Data  int `json:"data"`
+	Gas   int `json:"gas"`
+	Value int `json:"value"`
+}
+
+// Name returns the name of the test of a transaction within a fixture.
+func Name(block uint64, tx int) string {
+	return fmt.Sprintf("substate_%v_%v", block, tx)
+}
+
+// NewStateTest converts ss recorded on the chain of chainID into a test of fork. The fork
+// is derived by Fork if it is empty.
+func NewStateTest(ss *substate.Substate, chainID uint64, fork string) (*StateTest, error) {
+	if ss.Env == nil || ss.Message == nil || ss.Result == nil {
+		return nil, fmt.Errorf("substate block: %v, tx: %v is incomplete", ss.Block, ss.Transaction)
+	}
+	if fork == "" {
+		fork = Fork(chainID, ss.Env, ss.Message)
+	} else if !IsFork(fork) {
+		return nil, fmt.Errorf("unknown fork %v", fork)
+	}
+	logsHash, err := LogsHash(ss.Result.Logs)
+	if err != nil {
+		return nil, err
+	}
+
+	env := ss.Env
+	test := &StateTest{
+		Info: Info{
+			Comment:     "recorded substate",
+			ChainID:     chainID,
+			Block:       ss.Block,
+			Transaction: ss.Transaction,
+			CheckNonce:  ss.Message.CheckNonce,
+		},
+		Env: Env{
+			Coinbase:    env.Coinbase,
+			Difficulty:  (*types.HexBig)(env.Difficulty),
+			Random:      env.Random,
+			GasLimit:    types.HexUint64(env.GasLimit),
+			Number:      types.HexUint64(env.Number),
+			Timestamp:   types.HexUint64(env.Timestamp),
+			BaseFee:     (*types.HexBig)(env.BaseFee),
+			BlobBaseFee: (*types.HexBig)(env.BlobBaseFee),
+		},
+		Pre:         ss.InputSubstate,
+		Transaction: newTransaction(ss.Message),
+		Post: map[string][]PostState{
+			fork: {{
+				Logs:    logsHash,
+				State:   ss.OutputSubstate,
+				Receipt: ss.Result,
+			}},
+		},
+	}
+	if env.BlockHashes != nil {
+		test.Env.BlockHashes = make(map[types.HexUint64]types.Hash, len(env.BlockHashes))
+		for number, hash := range env.BlockHashes {
+			test.Env.BlockHashes[types.HexUint64(number)] = hash
+		}
+	}
+	return test, nil
+}
+
+// newTransaction converts msg into a transaction of a test, fee caps are only
+// included for transaction types introducing them.
+func newTransaction(msg *substate.Message) Transaction {
+	tx := Transaction{
+		Data:                []types.HexBytes{msg.Data},
+		GasLimit:            []types.HexUint64{types.HexUint64(msg.Gas)},
+		Value:               []*types.HexBig{(*types.HexBig)(msg.Value)},
+		Nonce:               types.HexUint64(msg.Nonce),
+		GasPrice:            (*types.HexBig)(msg.GasPrice),
+		MaxFeePerBlobGas:    (*types.HexBig)(msg.BlobGasFeeCap),
+		BlobVersionedHashes: msg.BlobHashes,
+		AuthorizationList:   msg.SetCodeAuthorizations,
+		Sender:              msg.From,
+	}
+	if msg.TxType() >= substate.DynamicFeeTxType {
+		tx.MaxFeePerGas = (*types.HexBig)(msg.GasFeeCap)
+		tx.MaxPriorityFeePerGas = (*types.HexBig)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		tx.AccessLists = []*types.AccessList{&msg.AccessList}
+	}
+	if msg.To != nil {
+		tx.To = msg.To.String()
+	}
+	return tx
+}
+
+// LogsHash returns the hash of the RLP encoding of logs which is expected by post states.
+func LogsHash(logs []*types.Log) (types.Hash, error) {
+	if logs == nil {
+		logs = []*types.Log{}
+	}
+	encoded, err := rlp.EncodeToBytes(logs)
+	if err != nil {
+		return types.Hash{}, fmt.Errorf("cannot encode logs; %w", err)
+	}
+	return utils.Keccak256Hash(encoded)
+}
+
+// ToSubstate converts a test created by NewStateTest back into a substate.
+func (t *StateTest) ToSubstate() (*substate.Substate, error) {
+	if len(t.Post) != 1 {
+		return nil, fmt.Errorf("test has %v forks instead of 1", len(t.Post))
+	}
+	var post PostState
+	for _, states := range t.Post {
+		if len(states) != 1 {
+			return nil, fmt.Errorf("test has %v post states instead of 1", len(states))
+		}
+		post = states[0]
+	}
+	if post.Receipt == nil {
+		return nil, errors.New("post state has no receipt")
+	}
+	logsHash, err := LogsHash(post.Receipt.Logs)
+	if err != nil {
+		return nil, err
+	}
+	if logsHash != post.Logs {
+		return nil, fmt.Errorf("logs hash %v does not match receipt logs hash %v", post.Logs, logsHash)
+	}
+	msg, err := t.Transaction.toMessage(post.Indexes, t.Info.CheckNonce, t.Env.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockHashes map[uint64]types.Hash
+	if t.Env.BlockHashes != nil {
+		blockHashes = make(map[uint64]types.Hash, len(t.Env.BlockHashes))
+		for number, hash := range t.Env.BlockHashes {
+			blockHashes[uint64(number)] = hash
+		}
+	}
+	env := substate.NewEnv(
+		t.Env.Coinbase,
+		(*big.Int)(t.Env.Difficulty),
+		uint64(t.Env.GasLimit),
+		uint64(t.Env.Number),
+		uint64(t.Env.Timestamp),
+		(*big.Int)(t.Env.BaseFee),
+		(*big.Int)(t.Env.BlobBaseFee),
+		blockHashes,
+		t.Env.Random,
+	)
+
+	pre, postState := t.Pre, post.State
+	if pre == nil {
+		pre = substate.NewWorldState()
+	}
+	if postState == nil {
+		postState = substate.NewWorldState()
+	}
+	return substate.NewSubstate(pre, postState, env, msg, post.Receipt, t.Info.Block, t.Info.Transaction), nil
+}
+
+// toMessage converts the variant of tx selected by indexes into a message. Fee caps missing
+// in the test are the gas price, a missing gas price is the effective price with baseFee.
+func (tx *Transaction) toMessage(indexes Indexes, checkNonce bool, baseFee *types.HexBig) (*substate.Message, error) {
+	if indexes.Data < 0 || indexes.Data >= len(tx.Data) ||
+		indexes.Gas < 0 || indexes.Gas >= len(tx.GasLimit) ||
+		indexes.Value < 0 || indexes.Value >= len(tx.Value) {
+		return nil, fmt.Errorf("indexes %+v out of range", indexes)
+	}
+	var to *types.Address
+	if tx.To != "" {
+		var addr types.HexBytes
+		if err := addr.UnmarshalText([]byte(tx.To)); err != nil {
+			return nil, fmt.Errorf("invalid to address; %w", err)
+		}
+		if len(addr) != types.AddressLength {
+			return nil, fmt.Errorf("invalid to address %v", tx.To)
+		}
+		a := types.BytesToAddress(addr)
+		to = &a
+	}
+	var accessList types.AccessList
+	if len(tx.AccessLists) > 0 {
+		if indexes.Data >= len(tx.AccessLists) {
+			return nil, fmt.Errorf("access list of data index %v is missing", indexes.Data)
+		}
+		accessList = types.AccessList{}
+		if tx.AccessLists[indexes.Data] != nil {
+			accessList = *tx.AccessLists[indexes.Data]
+		}
+	}
+
+	gasPrice := (*big.Int)(tx.GasPrice)
+	gasFeeCap, gasTipCap := (*big.Int)(tx.MaxFeePerGas), (*big.Int)(tx.MaxPriorityFeePerGas)
+	switch {
+	case gasPrice == nil && (gasFeeCap == nil || gasTipCap == nil):
+		return nil, errors.New("transaction has neither gas price nor fee caps")
+	case gasPrice == nil:
+		gasPrice = new(big.Int).Set(gasFeeCap)
+		if baseFee != nil {
+			if tip := new(big.Int).Add(gasTipCap, (*big.Int)(baseFee)); tip.Cmp(gasPrice) < 0 {
+				gasPrice = tip
+			}
+		}
+	case gasFeeCap == nil:
+		gasFeeCap, gasTipCap = gasPrice, gasPrice
+	}
+
+	return substate.NewMessage(
+		uint64(tx.Nonce),
+		checkNonce,
+		gasPrice,
+		uint64(tx.GasLimit[indexes.Gas]),
+		tx.Sender,
+		to,
+		(*big.Int)(tx.Value[indexes.Value]),
+		tx.Data[indexes.Data],
+		nil,
+		nil,
+		accessList,
+		gasFeeCap,
+		gasTipCap,
+		(*big.Int)(tx.MaxFeePerBlobGas),
+		tx.BlobVersionedHashes,
+		tx.AuthorizationList,
+	), nil
+}
@@ -0,0 +1,141 @@
+package statetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestSubstate() *substate.Substate {
+	random := types.Hash{7}
+	input := substate.NewWorldState().
+		Add(types.Address{1}, 1, uint256.NewInt(1_000_000), nil).
+		Add(types.Address{2}, 0, uint256.NewInt(0), []byte{0x60, 0x00})
+	input[types.Address{2}].Storage[types.Hash{1}] = types.Hash{2}
+	output := substate.NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(900_000), nil)
+
+	return substate.NewSubstate(
+		input,
+		output,
+		substate.NewEnv(types.Address{3}, nil, 30_000_000, 19_500_000, 1_711_000_000, big.NewInt(7), big.NewInt(1),
+			map[uint64]types.Hash{19_499_999: {9}}, &random),
+		substate.NewMessage(1, true, big.NewInt(9), 50_000, types.Address{1}, &types.Address{2}, big.NewInt(5),
+			[]byte{1, 2, 3}, nil, nil,
+			types.AccessList{{Address: types.Address{2}, StorageKeys: []types.Hash{{1}}}},
+			big.NewInt(20), big.NewInt(2), nil, nil, nil),
+		substate.NewResult(1, types.BytesToBloom([]byte{1}),
+			[]*types.Log{{Address: types.Address{2}, Topics: []types.Hash{{6}}, Data: []byte{7}}}, types.Address{}, 21_000),
+		19_500_000,
+		3,
+	)
+}
+
+func TestStateTest_ReimportsToEqualSubstate(t *testing.T) {
+	ss := getTestSubstate()
+	test, err := NewStateTest(ss, MainnetChainID, "")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(Fixture{Name(ss.Block, ss.Transaction): test})
+	require.NoError(t, err)
+	var fixture Fixture
+	require.NoError(t, json.Unmarshal(data, &fixture))
+	require.Contains(t, fixture, "substate_19500000_3")
+
+	got, err := fixture["substate_19500000_3"].ToSubstate()
+	require.NoError(t, err)
+	assert.NoError(t, ss.Equal(got))
+	assert.Equal(t, ss.Block, got.Block)
+	assert.Equal(t, ss.Transaction, got.Transaction)
+	assert.Equal(t, ss.Env.BlockHashes, got.Env.BlockHashes)
+}
+
+func TestStateTest_MapsSubstateToFixtureFields(t *testing.T) {
+	test, err := NewStateTest(getTestSubstate(), MainnetChainID, "")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(test)
+	require.NoError(t, err)
+	for _, want := range []string{
+		`"currentNumber":"0x1298be0"`,
+		`"currentRandom":"0x07`,
+		`"gasLimit":["0xc350"]`,
+		`"data":["0x010203"]`,
+		`"to":"0x0200000000000000000000000000000000000000"`,
+		`"sender":"0x0100000000000000000000000000000000000000"`,
+		`"maxFeePerGas":"0x14"`,
+		`"accessLists":[[{"address":"0x02`,
+		`"post":{"Cancun":[{`,
+		`"indexes":{"data":0,"gas":0,"value":0}`,
+	} {
+		assert.Contains(t, string(data), want)
+	}
+}
+
+func TestStateTest_LegacyTransactionHasNoFeeCaps(t *testing.T) {
+	ss := getTestSubstate()
+	ss.Message.GasFeeCap, ss.Message.GasTipCap = ss.Message.GasPrice, ss.Message.GasPrice
+	ss.Message.AccessList = nil
+	ss.Message.To = nil
+
+	test, err := NewStateTest(ss, MainnetChainID, "")
+	require.NoError(t, err)
+	assert.Nil(t, test.Transaction.MaxFeePerGas)
+	assert.Nil(t, test.Transaction.AccessLists)
+	assert.Empty(t, test.Transaction.To)
+
+	got, err := test.ToSubstate()
+	require.NoError(t, err)
+	assert.NoError(t, ss.Equal(got))
+}
+
+func TestStateTest_DerivesEffectiveGasPrice(t *testing.T) {
+	ss := getTestSubstate()
+	test, err := NewStateTest(ss, MainnetChainID, "")
+	require.NoError(t, err)
+	test.Transaction.GasPrice = nil
+
+	got, err := test.ToSubstate()
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(9), got.Message.GasPrice)
+}
+
+func TestStateTest_EmptyLogsHash(t *testing.T) {
+	hash, err := LogsHash(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", hash.String())
+}
+
+func TestStateTest_Errors(t *testing.T) {
+	_, err := NewStateTest(&substate.Substate{}, MainnetChainID, "")
+	assert.ErrorContains(t, err, "incomplete")
+	_, err = NewStateTest(getTestSubstate(), MainnetChainID, "Amsterdam")
+	assert.ErrorContains(t, err, "unknown fork Amsterdam")
+
+	valid, err := NewStateTest(getTestSubstate(), MainnetChainID, "")
+	require.NoError(t, err)
+
+	tests := map[string]func(test *StateTest){
+		"no fork":      func(test *StateTest) { test.Post = nil },
+		"logs hash":    func(test *StateTest) { test.Post["Cancun"][0].Logs = types.Hash{} },
+		"no receipt":   func(test *StateTest) { test.Post["Cancun"][0].Receipt = nil },
+		"indexes":      func(test *StateTest) { test.Post["Cancun"][0].Indexes.Data = 1 },
+		"to":           func(test *StateTest) { test.Transaction.To = "0x1" },
+		"no gas price": func(test *StateTest) { test.Transaction.GasPrice, test.Transaction.MaxFeePerGas = nil, nil },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			test := *valid
+			test.Transaction = valid.Transaction
+			test.Post = map[string][]PostState{"Cancun": {valid.Post["Cancun"][0]}}
+			modify(&test)
+			_, err := test.ToSubstate()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package statetest
+
+import "github.com/0xsoniclabs/substate/substate"
+
+// MainnetChainID is the chain id of the Ethereum mainnet.
+const MainnetChainID = 1
+
+// fork is a fork activated either at a block or at a timestamp.
+type fork struct {
+	name      string
+	block     uint64
+	timestamp uint64
+}
+
+// mainnetForks are the Ethereum mainnet forks named as by GeneralStateTests,
+// forks which did not change the execution rules are omitted.
+var mainnetForks = []fork{
+	{name: "Frontier"},
+	{name: "Homestead", block: 1_150_000},
+	{name: "EIP150", block: 2_463_000},
+	{name: "EIP158", block: 2_675_000},
+	{name: "Byzantium", block: 4_370_000},
+	{name: "ConstantinopleFix", block: 7_280_000},
+	{name: "Istanbul", block: 9_069_000},
+	{name: "Berlin", block: 12_244_000},
+	{name: "London", block: 12_965_000},
+	{name: "Paris", block: 15_537_394},
+	{name: "Shanghai", block: 15_537_394, timestamp: 1_681_338_455},
+	{name: "Cancun", block: 15_537_394, timestamp: 1_710_338_135},
+	{name: "Prague", block: 15_537_394, timestamp: 1_746_612_311},
+	{name: "Osaka", block: 15_537_394, timestamp: 1_764_798_551},
+}
+
+// Fork returns the name of the fork env belongs to as used by GeneralStateTests. Blocks of
+// the Ethereum mainnet are looked up in its fork schedule. The schedules of other chains are
+// unknown, their fork is the latest fork whose features are present in env or in msg, hence
+// Osaka, which added no such feature, is never returned for them.
+// Note: msg is nillable.
+func Fork(chainID uint64, env *substate.Env, msg *substate.Message) string {
+	if chainID == MainnetChainID {
+		name := mainnetForks[0].name
+		for _, f := range mainnetForks[1:] {
+			if env.Number < f.block || env.Timestamp < f.timestamp {
+				break
+			}
+			name = f.name
+		}
+		return name
+	}
+	switch {
+	case msg != nil && msg.TxType() == substate.SetCodeTxType:
+		return "Prague"
+	case env.BlobBaseFee != nil:
+		return "Cancun"
+	case env.Random != nil:
+		return "Shanghai"
+	case env.BaseFee != nil:
+		return "London"
+	default:
+		return "Berlin"
+	}
+}
+
+// IsFork reports whether name is the name of a fork returned by Fork.
+func IsFork(name string) bool {
+	for _, f := range mainnetForks {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
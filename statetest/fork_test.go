@@ -0,0 +1,49 @@
+package statetest
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/substate"
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFork_FollowsMainnetSchedule(t *testing.T) {
+	tests := []struct {
+		number, timestamp uint64
+		want              string
+	}{
+		{0, 0, "Frontier"},
+		{1_150_000, 0, "Homestead"},
+		{4_369_999, 0, "EIP158"},
+		{7_280_000, 0, "ConstantinopleFix"},
+		{12_965_000, 0, "London"},
+		{15_537_394, 1_663_224_162, "Paris"},
+		{17_034_870, 1_681_338_455, "Shanghai"},
+		{19_426_587, 1_710_338_135, "Cancun"},
+		{22_431_084, 1_746_612_311, "Prague"},
+		{23_935_694, 1_764_798_551, "Osaka"},
+	}
+	for _, test := range tests {
+		env := &substate.Env{Number: test.number, Timestamp: test.timestamp}
+		assert.Equal(t, test.want, Fork(MainnetChainID, env, nil), "block %v", test.number)
+	}
+}
+
+func TestFork_OtherChainsUseEnvFeatures(t *testing.T) {
+	random := types.Hash{1}
+	assert.Equal(t, "Berlin", Fork(146, &substate.Env{}, nil))
+	assert.Equal(t, "London", Fork(146, &substate.Env{BaseFee: big.NewInt(1)}, nil))
+	assert.Equal(t, "Shanghai", Fork(146, &substate.Env{BaseFee: big.NewInt(1), Random: &random}, nil))
+	cancun := &substate.Env{BaseFee: big.NewInt(1), BlobBaseFee: big.NewInt(1), Random: &random}
+	assert.Equal(t, "Cancun", Fork(146, cancun, &substate.Message{}))
+	assert.Equal(t, "Prague", Fork(146, cancun, &substate.Message{SetCodeAuthorizations: []types.SetCodeAuthorization{{}}}))
+}
+
+func TestIsFork_AcceptsForkNames(t *testing.T) {
+	assert.True(t, IsFork("Osaka"))
+	assert.True(t, IsFork("Frontier"))
+	assert.False(t, IsFork("osaka"))
+	assert.False(t, IsFork(""))
+}
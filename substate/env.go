@@ -62,14 +62,14 @@ func (e *Env) Equal(y *Env) bool {
 	}
 
 	equal := e.Coinbase == y.Coinbase &&
-		e.Difficulty.Cmp(y.Difficulty) == 0 &&
+		bigIntEqual(e.Difficulty, y.Difficulty) &&
 		e.GasLimit == y.GasLimit &&
 		e.Number == y.Number &&
 		e.Timestamp == y.Timestamp &&
 		len(e.BlockHashes) == len(y.BlockHashes) &&
-		e.BaseFee.Cmp(y.BaseFee) == 0 &&
-		e.BlobBaseFee.Cmp(y.BlobBaseFee) == 0 &&
-		((e.Random == nil && y.Random == nil) || (e.Random.String() == y.Random.String()))
+		bigIntEqual(e.BaseFee, y.BaseFee) &&
+		bigIntEqual(e.BlobBaseFee, y.BlobBaseFee) &&
+		(e.Random == y.Random || (e.Random != nil && y.Random != nil && *e.Random == *y.Random))
 	if !equal {
 		return false
 	}
@@ -84,6 +84,14 @@ func (e *Env) Equal(y *Env) bool {
 	return true
 }
 
+// bigIntEqual returns true if x and y are both nil or hold the same value.
+func bigIntEqual(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Cmp(y) == 0
+}
+
 func (e *Env) String() string {
 	var builder strings.Builder
 
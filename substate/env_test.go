@@ -225,6 +225,18 @@ func TestEnv_EqualNil(t *testing.T) {
 	assert.False(t, env.Equal(nil))
 }
 
+func TestEnv_EqualNilFields(t *testing.T) {
+	env := &Env{
+		BaseFee:     new(big.Int).SetUint64(1),
+		BlobBaseFee: new(big.Int).SetUint64(1),
+		Random:      &types.Hash{1},
+	}
+
+	assert.False(t, env.Equal(&Env{}))
+	assert.False(t, (&Env{}).Equal(env))
+	assert.True(t, (&Env{}).Equal(&Env{}))
+}
+
 func TestEnv_String(t *testing.T) {
 	env := &Env{
 		Coinbase:    types.Address{0},
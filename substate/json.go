@@ -0,0 +1,382 @@
+package substate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+)
+
+// JSON encodings of substates follow geth conventions: quantities and byte strings are
+// hex strings with 0x prefix, addresses and hashes are 0x-prefixed hex strings and
+// optional fields are omitted if they are nil. Code is encoded by value.
+
+type substateJSON struct {
+	Block          types.HexUint64 `json:"block"`
+	Transaction    types.HexUint64 `json:"transaction"`
+	InputSubstate  WorldState      `json:"inputSubstate"`
+	OutputSubstate WorldState      `json:"outputSubstate"`
+	Env            *Env            `json:"env"`
+	Message        *Message        `json:"message"`
+	Result         *Result         `json:"result"`
+}
+
+// MarshalJSON implements json.Marshaler interface for Substate
+func (s *Substate) MarshalJSON() ([]byte, error) {
+	if s.Transaction < 0 {
+		return nil, fmt.Errorf("negative transaction %v", s.Transaction)
+	}
+	return json.Marshal(substateJSON{
+		Block:          types.HexUint64(s.Block),
+		Transaction:    types.HexUint64(s.Transaction),
+		InputSubstate:  s.InputSubstate,
+		OutputSubstate: s.OutputSubstate,
+		Env:            s.Env,
+		Message:        s.Message,
+		Result:         s.Result,
+	})
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (s *Substate) UnmarshalJSON(data []byte) error {
+	var dec substateJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	if uint64(dec.Transaction) > math.MaxInt {
+		return fmt.Errorf("transaction %v out of range", uint64(dec.Transaction))
+	}
+	*s = Substate{
+		InputSubstate:  dec.InputSubstate,
+		OutputSubstate: dec.OutputSubstate,
+		Env:            dec.Env,
+		Message:        dec.Message,
+		Result:         dec.Result,
+		Block:          uint64(dec.Block),
+		Transaction:    int(dec.Transaction),
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface for WorldState
+func (ws WorldState) MarshalJSON() ([]byte, error) {
+	if ws == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(map[types.Address]*Account(ws))
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (ws *WorldState) UnmarshalJSON(data []byte) error {
+	var dec map[types.Address]*Account
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	for addr, acc := range dec {
+		if acc == nil {
+			return fmt.Errorf("account %v is null", addr)
+		}
+	}
+	*ws = dec
+	return nil
+}
+
+type accountJSON struct {
+	Nonce   types.HexUint64           `json:"nonce"`
+	Balance *types.HexUint256         `json:"balance,omitempty"`
+	Code    types.HexBytes            `json:"code"`
+	Storage map[types.Hash]types.Hash `json:"storage"`
+}
+
+// MarshalJSON implements json.Marshaler interface for Account
+func (a *Account) MarshalJSON() ([]byte, error) {
+	storage := a.Storage
+	if storage == nil {
+		storage = make(map[types.Hash]types.Hash)
+	}
+	return json.Marshal(accountJSON{
+		Nonce:   types.HexUint64(a.Nonce),
+		Balance: (*types.HexUint256)(a.Balance),
+		Code:    a.Code,
+		Storage: storage,
+	})
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (a *Account) UnmarshalJSON(data []byte) error {
+	var dec accountJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	*a = *NewAccount(uint64(dec.Nonce), (*uint256.Int)(dec.Balance), dec.Code)
+	for key, value := range dec.Storage {
+		a.Storage[key] = value
+	}
+	return nil
+}
+
+type envJSON struct {
+	Coinbase    types.Address                  `json:"coinbase"`
+	Difficulty  *types.HexBig                  `json:"difficulty,omitempty"`
+	GasLimit    types.HexUint64                `json:"gasLimit"`
+	Number      types.HexUint64                `json:"number"`
+	Timestamp   types.HexUint64                `json:"timestamp"`
+	BaseFee     *types.HexBig                  `json:"baseFee,omitempty"`
+	BlobBaseFee *types.HexBig                  `json:"blobBaseFee,omitempty"`
+	Random      *types.Hash                    `json:"random,omitempty"`
+	BlockHashes map[types.HexUint64]types.Hash `json:"blockHashes,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface for Env
+func (e *Env) MarshalJSON() ([]byte, error) {
+	var blockHashes map[types.HexUint64]types.Hash
+	if e.BlockHashes != nil {
+		blockHashes = make(map[types.HexUint64]types.Hash, len(e.BlockHashes))
+		for number, hash := range e.BlockHashes {
+			blockHashes[types.HexUint64(number)] = hash
+		}
+	}
+	return json.Marshal(envJSON{
+		Coinbase:    e.Coinbase,
+		Difficulty:  (*types.HexBig)(e.Difficulty),
+		GasLimit:    types.HexUint64(e.GasLimit),
+		Number:      types.HexUint64(e.Number),
+		Timestamp:   types.HexUint64(e.Timestamp),
+		BaseFee:     (*types.HexBig)(e.BaseFee),
+		BlobBaseFee: (*types.HexBig)(e.BlobBaseFee),
+		Random:      e.Random,
+		BlockHashes: blockHashes,
+	})
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (e *Env) UnmarshalJSON(data []byte) error {
+	var dec envJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	var blockHashes map[uint64]types.Hash
+	if dec.BlockHashes != nil {
+		blockHashes = make(map[uint64]types.Hash, len(dec.BlockHashes))
+		for number, hash := range dec.BlockHashes {
+			blockHashes[uint64(number)] = hash
+		}
+	}
+	*e = *NewEnv(
+		dec.Coinbase,
+		(*big.Int)(dec.Difficulty),
+		uint64(dec.GasLimit),
+		uint64(dec.Number),
+		uint64(dec.Timestamp),
+		(*big.Int)(dec.BaseFee),
+		(*big.Int)(dec.BlobBaseFee),
+		blockHashes,
+		dec.Random,
+	)
+	return nil
+}
+
+type messageJSON struct {
+	Type                  *types.HexUint64             `json:"type,omitempty"`
+	Nonce                 types.HexUint64              `json:"nonce"`
+	CheckNonce            bool                         `json:"checkNonce"`
+	GasPrice              *types.HexBig                `json:"gasPrice,omitempty"`
+	Gas                   types.HexUint64              `json:"gas"`
+	From                  types.Address                `json:"from"`
+	To                    *types.Address               `json:"to,omitempty"`
+	Value                 *types.HexBig                `json:"value,omitempty"`
+	Data                  types.HexBytes               `json:"input"`
+	AccessList            *types.AccessList            `json:"accessList,omitempty"`
+	GasFeeCap             *types.HexBig                `json:"maxFeePerGas,omitempty"`
+	GasTipCap             *types.HexBig                `json:"maxPriorityFeePerGas,omitempty"`
+	BlobGasFeeCap         *types.HexBig                `json:"maxFeePerBlobGas,omitempty"`
+	BlobHashes            []types.Hash                 `json:"blobVersionedHashes,omitempty"`
+	SetCodeAuthorizations []types.SetCodeAuthorization `json:"authorizationList,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface for Message. The type
+// of the transaction is only included if it is recorded by the message.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	enc := messageJSON{
+		Nonce:                 types.HexUint64(m.Nonce),
+		CheckNonce:            m.CheckNonce,
+		GasPrice:              (*types.HexBig)(m.GasPrice),
+		Gas:                   types.HexUint64(m.Gas),
+		From:                  m.From,
+		To:                    m.To,
+		Value:                 (*types.HexBig)(m.Value),
+		Data:                  m.Data,
+		GasFeeCap:             (*types.HexBig)(m.GasFeeCap),
+		GasTipCap:             (*types.HexBig)(m.GasTipCap),
+		BlobGasFeeCap:         (*types.HexBig)(m.BlobGasFeeCap),
+		BlobHashes:            m.BlobHashes,
+		SetCodeAuthorizations: m.SetCodeAuthorizations,
+	}
+	if m.ProtobufTxType != nil {
+		if *m.ProtobufTxType < 0 {
+			return nil, fmt.Errorf("negative transaction type %v", *m.ProtobufTxType)
+		}
+		txType := types.HexUint64(*m.ProtobufTxType)
+		enc.Type = &txType
+	}
+	if m.AccessList != nil {
+		enc.AccessList = &m.AccessList
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var dec messageJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	var txType *int32
+	if dec.Type != nil {
+		if *dec.Type > 0x7f {
+			return fmt.Errorf("invalid transaction type %v", uint64(*dec.Type))
+		}
+		t := int32(*dec.Type)
+		txType = &t
+	}
+	var accessList types.AccessList
+	if dec.AccessList != nil {
+		accessList = *dec.AccessList
+		if accessList == nil {
+			accessList = types.AccessList{}
+		}
+	}
+	*m = *NewMessage(
+		uint64(dec.Nonce),
+		dec.CheckNonce,
+		(*big.Int)(dec.GasPrice),
+		uint64(dec.Gas),
+		dec.From,
+		dec.To,
+		(*big.Int)(dec.Value),
+		dec.Data,
+		nil,
+		txType,
+		accessList,
+		(*big.Int)(dec.GasFeeCap),
+		(*big.Int)(dec.GasTipCap),
+		(*big.Int)(dec.BlobGasFeeCap),
+		dec.BlobHashes,
+		dec.SetCodeAuthorizations,
+	)
+	return nil
+}
+
+type resultJSON struct {
+	Status          types.HexUint64 `json:"status"`
+	Bloom           types.HexBytes  `json:"logsBloom"`
+	Logs            []*logJSON      `json:"logs"`
+	ContractAddress types.Address   `json:"contractAddress"`
+	GasUsed         types.HexUint64 `json:"gasUsed"`
+}
+
+// logJSON holds the consensus fields of a log which are recorded by substates.
+type logJSON struct {
+	Address types.Address  `json:"address"`
+	Topics  []types.Hash   `json:"topics"`
+	Data    types.HexBytes `json:"data"`
+}
+
+// MarshalJSON implements json.Marshaler interface for Result. Only consensus
+// fields of logs are included as other fields are not recorded.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	enc := resultJSON{
+		Status:          types.HexUint64(r.Status),
+		Bloom:           r.Bloom.Bytes(),
+		ContractAddress: r.ContractAddress,
+		GasUsed:         types.HexUint64(r.GasUsed),
+	}
+	if r.Logs != nil {
+		enc.Logs = make([]*logJSON, len(r.Logs))
+		for i, log := range r.Logs {
+			topics := log.Topics
+			if topics == nil {
+				topics = []types.Hash{}
+			}
+			enc.Logs[i] = &logJSON{Address: log.Address, Topics: topics, Data: log.Data}
+		}
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var dec resultJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	if len(dec.Bloom) != types.BloomByteLength {
+		return fmt.Errorf("logsBloom has %v bytes instead of %v", len(dec.Bloom), types.BloomByteLength)
+	}
+	var logs []*types.Log
+	if dec.Logs != nil {
+		logs = make([]*types.Log, len(dec.Logs))
+		for i, log := range dec.Logs {
+			if log == nil {
+				return fmt.Errorf("log %v is null", i)
+			}
+			logs[i] = &types.Log{Address: log.Address, Topics: log.Topics, Data: log.Data}
+		}
+	}
+	*r = *NewResult(uint64(dec.Status), types.BytesToBloom(dec.Bloom), logs, dec.ContractAddress, uint64(dec.GasUsed))
+	return nil
+}
+
+type exceptionJSON struct {
+	Block        types.HexUint64                     `json:"block"`
+	Transactions map[types.HexUint64]exceptionTxJSON `json:"transactions"`
+	PreBlock     *WorldState                         `json:"preBlock,omitempty"`
+	PostBlock    *WorldState                         `json:"postBlock,omitempty"`
+}
+
+type exceptionTxJSON struct {
+	PreTransaction  *WorldState `json:"preTransaction,omitempty"`
+	PostTransaction *WorldState `json:"postTransaction,omitempty"`
+	VmException     bool        `json:"vmException"`
+}
+
+// MarshalJSON implements json.Marshaler interface for Exception
+func (ex *Exception) MarshalJSON() ([]byte, error) {
+	enc := exceptionJSON{
+		Block:     types.HexUint64(ex.Block),
+		PreBlock:  ex.Data.PreBlock,
+		PostBlock: ex.Data.PostBlock,
+	}
+	if ex.Data.Transactions != nil {
+		enc.Transactions = make(map[types.HexUint64]exceptionTxJSON, len(ex.Data.Transactions))
+		for tx, data := range ex.Data.Transactions {
+			if tx < 0 {
+				return nil, fmt.Errorf("negative transaction %v", tx)
+			}
+			enc.Transactions[types.HexUint64(tx)] = exceptionTxJSON(data)
+		}
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (ex *Exception) UnmarshalJSON(data []byte) error {
+	var dec exceptionJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	*ex = Exception{
+		Block: uint64(dec.Block),
+		Data:  ExceptionBlock{PreBlock: dec.PreBlock, PostBlock: dec.PostBlock},
+	}
+	if dec.Transactions != nil {
+		ex.Data.Transactions = make(map[int]ExceptionTx, len(dec.Transactions))
+		for tx, data := range dec.Transactions {
+			ex.Data.Transactions[int(tx)] = ExceptionTx(data)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package substate
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsoniclabs/substate/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJSONTestSubstate() *Substate {
+	txType := int32(SetCodeTxType)
+	random := types.Hash{7}
+	input := NewWorldState().
+		Add(types.Address{1}, 1, uint256.NewInt(100), []byte{0x60, 0x00}).
+		Add(types.Address{2}, 0, new(uint256.Int).Lsh(uint256.NewInt(1), 200), nil)
+	input[types.Address{1}].Storage[types.Hash{1}] = types.Hash{2}
+	output := NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(90), []byte{0x60, 0x00})
+
+	return NewSubstate(
+		input,
+		output,
+		NewEnv(types.Address{3}, nil, 30_000_000, 100, 1_700_000_000, big.NewInt(7), big.NewInt(1),
+			map[uint64]types.Hash{99: {9}}, &random),
+		NewMessage(1, true, big.NewInt(10), 21_000, types.Address{1}, &types.Address{2}, big.NewInt(5),
+			[]byte{1, 2, 3}, nil, &txType,
+			types.AccessList{{Address: types.Address{2}, StorageKeys: []types.Hash{{1}}}},
+			big.NewInt(20), big.NewInt(2), big.NewInt(3), []types.Hash{{4}},
+			[]types.SetCodeAuthorization{{ChainID: *uint256.NewInt(146), Address: types.Address{5}, Nonce: 3, V: 1,
+				R: *uint256.NewInt(11), S: *uint256.NewInt(12)}}),
+		NewResult(1, types.BytesToBloom([]byte{1}),
+			[]*types.Log{{Address: types.Address{2}, Topics: []types.Hash{{6}}, Data: []byte{7}}}, types.Address{}, 21_000),
+		100,
+		2,
+	)
+}
+
+func TestSubstate_JSONRoundTrip(t *testing.T) {
+	ss := getJSONTestSubstate()
+
+	data, err := json.Marshal(ss)
+	require.NoError(t, err)
+	got := new(Substate)
+	require.NoError(t, json.Unmarshal(data, got))
+
+	assert.NoError(t, ss.Equal(got))
+	assert.Equal(t, ss.Block, got.Block)
+	assert.Equal(t, ss.Transaction, got.Transaction)
+	assert.Equal(t, ss.Message.ProtobufTxType, got.Message.ProtobufTxType)
+	assert.Equal(t, ss.Env.Random, got.Env.Random)
+	assert.Equal(t, ss.Message.SetCodeAuthorizations, got.Message.SetCodeAuthorizations)
+}
+
+func TestSubstate_JSONUsesHexConventions(t *testing.T) {
+	data, err := json.Marshal(getJSONTestSubstate())
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		`"block":"0x64"`,
+		`"transaction":"0x2"`,
+		`"code":"0x6000"`,
+		`"balance":"0x64"`,
+		`"gasLimit":"0x1c9c380"`,
+		`"blockHashes":{"0x63":"0x09`,
+		`"input":"0x010203"`,
+		`"type":"0x4"`,
+		`"chainId":"0x92"`,
+	} {
+		assert.Contains(t, string(data), want)
+	}
+	assert.NotContains(t, string(data), `"difficulty"`)
+}
+
+func TestSubstate_JSONKeepsNilAndEmptyFields(t *testing.T) {
+	msg := NewMessage(0, false, big.NewInt(1), 0, types.Address{}, nil, big.NewInt(0), nil, nil, nil,
+		types.AccessList{}, nil, nil, nil, nil, nil)
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	got := new(Message)
+	require.NoError(t, json.Unmarshal(data, got))
+	assert.Nil(t, got.To)
+	assert.Nil(t, got.GasFeeCap)
+	assert.Nil(t, got.ProtobufTxType)
+	assert.NotNil(t, got.AccessList)
+	assert.Empty(t, got.Data)
+
+	msg.AccessList = nil
+	data, err = json.Marshal(msg)
+	require.NoError(t, err)
+	got = new(Message)
+	require.NoError(t, json.Unmarshal(data, got))
+	assert.Nil(t, got.AccessList)
+}
+
+func TestSubstate_JSONRejectsInvalidInput(t *testing.T) {
+	ss := getJSONTestSubstate()
+	data, err := json.Marshal(ss)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		old, new string
+	}{
+		"decimal quantity": {`"nonce":"0x1"`, `"nonce":"1"`},
+		"short bloom":      {`"logsBloom":"0x`, `"logsBloom":"0x00`},
+		"type":             {`"type":"0x4"`, `"type":"0x100"`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			invalid := strings.Replace(string(data), test.old, test.new, 1)
+			require.NotEqual(t, string(data), invalid)
+			assert.Error(t, json.Unmarshal([]byte(invalid), new(Substate)))
+		})
+	}
+
+	var ws WorldState
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"0x01":null}`), &ws), "is null")
+}
+
+func TestException_JSONRoundTrip(t *testing.T) {
+	pre := NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(1), []byte{1})
+	post := NewWorldState().Add(types.Address{1}, 2, uint256.NewInt(0), nil)
+	ex := &Exception{
+		Block: 5,
+		Data: ExceptionBlock{
+			Transactions: map[int]ExceptionTx{
+				3: {PreTransaction: &pre, PostTransaction: &post, VmException: true},
+			},
+			PreBlock:  &pre,
+			PostBlock: &post,
+		},
+	}
+
+	data, err := json.Marshal(ex)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"transactions":{"0x3":`)
+	got := new(Exception)
+	require.NoError(t, json.Unmarshal(data, got))
+	assert.True(t, ex.Equal(*got))
+}
@@ -109,9 +109,9 @@ func (m *Message) Equal(y *Message) bool {
 		m.Value.Cmp(y.Value) == 0 &&
 		bytes.Equal(m.Data, y.Data) &&
 		len(m.AccessList) == len(y.AccessList) &&
-		m.GasFeeCap.Cmp(y.GasFeeCap) == 0 &&
-		m.GasTipCap.Cmp(y.GasTipCap) == 0 &&
-		m.BlobGasFeeCap.Cmp(y.BlobGasFeeCap) == 0 &&
+		bigIntEqual(m.GasFeeCap, y.GasFeeCap) &&
+		bigIntEqual(m.GasTipCap, y.GasTipCap) &&
+		bigIntEqual(m.BlobGasFeeCap, y.BlobGasFeeCap) &&
 		len(m.SetCodeAuthorizations) == len(y.SetCodeAuthorizations)
 	if !equal {
 		return false
@@ -168,6 +168,28 @@ func (m *Message) DataHash() (types.Hash, error) {
 	return *m.dataHash, nil
 }
 
+// TxType returns the type of the transaction of the message. Messages decoded from protobuf
+// carry their type, the type of other messages is derived from the fields their type introduced.
+// Access-list transactions with an empty access list are hence reported as legacy transactions.
+func (m *Message) TxType() int {
+	if m.ProtobufTxType != nil {
+		return int(*m.ProtobufTxType)
+	}
+	switch {
+	case len(m.SetCodeAuthorizations) > 0:
+		return SetCodeTxType
+	case len(m.BlobHashes) > 0:
+		return BlobTxType
+	case m.GasFeeCap != nil && !bigIntEqual(m.GasFeeCap, m.GasPrice),
+		m.GasTipCap != nil && !bigIntEqual(m.GasTipCap, m.GasPrice):
+		return DynamicFeeTxType
+	case len(m.AccessList) > 0:
+		return AccessListTxType
+	default:
+		return LegacyTxType
+	}
+}
+
 func (m *Message) String() string {
 	if m == nil {
 		return "<nil>"
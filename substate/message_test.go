@@ -319,3 +319,26 @@ func TestMessage_String(t *testing.T) {
 	expected := "Nonce: 1\nCheckNonce: true\nFrom: 0x0100000000000000000000000000000000000000\nTo: 0x0100000000000000000000000000000000000000\nValue: 1\nData: \x01\nData Hash: 0x0000000000000000000000000000000000000000000000000000000000000000\nGas Fee Cap: 1\nGas Tip Cap: 1\nAddress: 0x0100000000000000000000000000000000000000Storage Key 0: 0x0000000000000000000000000000000000000000000000000000000000000001SetCodeAuthorization:\nChainID: 1\nAddress: 0x0100000000000000000000000000000000000000\nNonce: 1\nV: 1\nR: 1\nS: 1\n"
 	assert.Equal(t, expected, msg.String())
 }
+
+func TestMessage_TxType(t *testing.T) {
+	blobTxType := int32(BlobTxType)
+	tests := map[string]struct {
+		msg  *Message
+		want int
+	}{
+		"protobuf": {&Message{ProtobufTxType: &blobTxType}, BlobTxType},
+		"legacy":   {&Message{GasPrice: big.NewInt(2), GasFeeCap: big.NewInt(2), GasTipCap: big.NewInt(2)}, LegacyTxType},
+		"accessList": {&Message{
+			GasPrice:   big.NewInt(2),
+			AccessList: types.AccessList{{Address: types.Address{1}}},
+		}, AccessListTxType},
+		"dynamicFee": {&Message{GasPrice: big.NewInt(2), GasFeeCap: big.NewInt(3), GasTipCap: big.NewInt(1)}, DynamicFeeTxType},
+		"blob":       {&Message{BlobHashes: []types.Hash{{1}}}, BlobTxType},
+		"setCode":    {&Message{SetCodeAuthorizations: []types.SetCodeAuthorization{{Nonce: 1}}}, SetCodeTxType},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.msg.TxType())
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/holiman/uint256"
+)
+
+// HexBytes marshals as a JSON string with 0x prefix, empty bytes marshal as "0x".
+type HexBytes []byte
+
+// MarshalText implements TextMarshaler interface for HexBytes
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(b)), nil
+}
+
+// UnmarshalText decodes the form generated by MarshalText
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	digits, err := hexDigits(text)
+	if err != nil {
+		return err
+	}
+	if len(digits)%2 == 1 {
+		return fmt.Errorf("hex string %q has odd length", text)
+	}
+	dec, err := hex.DecodeString(digits)
+	if err != nil {
+		return fmt.Errorf("invalid hex string %q", text)
+	}
+	*b = dec
+	return nil
+}
+
+// HexUint64 marshals as a JSON string with 0x prefix and without leading zeros.
+type HexUint64 uint64
+
+// MarshalText implements TextMarshaler interface for HexUint64
+func (n HexUint64) MarshalText() ([]byte, error) {
+	return []byte("0x" + strconv.FormatUint(uint64(n), 16)), nil
+}
+
+// UnmarshalText decodes the form generated by MarshalText
+func (n *HexUint64) UnmarshalText(text []byte) error {
+	digits, err := hexQuantityDigits(text)
+	if err != nil {
+		return err
+	}
+	dec, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid hex quantity %q", text)
+	}
+	*n = HexUint64(dec)
+	return nil
+}
+
+// HexBig marshals as a JSON string with 0x prefix and without leading zeros,
+// negative values are prefixed with "-".
+type HexBig big.Int
+
+// MarshalText implements TextMarshaler interface for HexBig
+func (b *HexBig) MarshalText() ([]byte, error) {
+	i := (*big.Int)(b)
+	if i.Sign() < 0 {
+		return []byte("-0x" + new(big.Int).Neg(i).Text(16)), nil
+	}
+	return []byte("0x" + i.Text(16)), nil
+}
+
+// UnmarshalText decodes the form generated by MarshalText
+func (b *HexBig) UnmarshalText(text []byte) error {
+	negative := len(text) > 0 && text[0] == '-'
+	if negative {
+		text = text[1:]
+	}
+	digits, err := hexQuantityDigits(text)
+	if err != nil {
+		return err
+	}
+	i, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return fmt.Errorf("invalid hex quantity %q", text)
+	}
+	if negative {
+		i.Neg(i)
+	}
+	*b = HexBig(*i)
+	return nil
+}
+
+// HexUint256 marshals as a JSON string with 0x prefix and without leading zeros.
+type HexUint256 uint256.Int
+
+// MarshalText implements TextMarshaler interface for HexUint256
+func (u *HexUint256) MarshalText() ([]byte, error) {
+	return []byte((*uint256.Int)(u).Hex()), nil
+}
+
+// UnmarshalText decodes the form generated by MarshalText
+func (u *HexUint256) UnmarshalText(text []byte) error {
+	if _, err := hexQuantityDigits(text); err != nil {
+		return err
+	}
+	if err := (*uint256.Int)(u).SetFromHex(string(text)); err != nil {
+		return fmt.Errorf("invalid hex quantity %q; %w", text, err)
+	}
+	return nil
+}
+
+// hexDigits returns text without its mandatory 0x prefix.
+func hexDigits(text []byte) (string, error) {
+	if !has0xPrefix(string(text)) {
+		return "", fmt.Errorf("hex string %q without 0x prefix", text)
+	}
+	return string(text[2:]), nil
+}
+
+// hexQuantityDigits returns digits of a hex quantity which must not be empty nor have leading zeros.
+func hexQuantityDigits(text []byte) (string, error) {
+	digits, err := hexDigits(text)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) == 0 {
+		return "", fmt.Errorf("hex quantity %q without digits", text)
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return "", fmt.Errorf("hex quantity %q with leading zero digits", text)
+	}
+	return digits, nil
+}
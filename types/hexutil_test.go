@@ -0,0 +1,75 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHexBytes_RoundTrip(t *testing.T) {
+	for _, b := range []HexBytes{{}, {0}, {1, 2, 0xff}} {
+		data, err := json.Marshal(b)
+		require.NoError(t, err)
+		var got HexBytes
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, b, got)
+	}
+	data, err := json.Marshal(HexBytes{0x0a, 0xbc})
+	require.NoError(t, err)
+	assert.Equal(t, `"0x0abc"`, string(data))
+}
+
+func TestHexBytes_RejectsInvalidInput(t *testing.T) {
+	var b HexBytes
+	for _, input := range []string{`"01"`, `"0x1"`, `"0xzz"`} {
+		assert.Error(t, json.Unmarshal([]byte(input), &b), input)
+	}
+}
+
+func TestHexUint64_RoundTrip(t *testing.T) {
+	for _, n := range []HexUint64{0, 1, 0x1234, 1<<64 - 1} {
+		data, err := json.Marshal(n)
+		require.NoError(t, err)
+		var got HexUint64
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, n, got)
+	}
+	data, err := json.Marshal(HexUint64(255))
+	require.NoError(t, err)
+	assert.Equal(t, `"0xff"`, string(data))
+}
+
+func TestHexUint64_RejectsInvalidInput(t *testing.T) {
+	var n HexUint64
+	for _, input := range []string{`"10"`, `"0x"`, `"0x01"`, `"0x10000000000000000"`} {
+		assert.Error(t, json.Unmarshal([]byte(input), &n), input)
+	}
+}
+
+func TestHexBig_RoundTrip(t *testing.T) {
+	for _, i := range []*big.Int{big.NewInt(0), big.NewInt(-5), new(big.Int).Lsh(big.NewInt(1), 300)} {
+		data, err := json.Marshal((*HexBig)(i))
+		require.NoError(t, err)
+		got := new(HexBig)
+		require.NoError(t, json.Unmarshal(data, got))
+		assert.Zero(t, i.Cmp((*big.Int)(got)))
+	}
+	data, err := json.Marshal((*HexBig)(big.NewInt(-16)))
+	require.NoError(t, err)
+	assert.Equal(t, `"-0x10"`, string(data))
+	assert.Error(t, json.Unmarshal([]byte(`"0xg"`), new(HexBig)))
+}
+
+func TestHexUint256_RoundTrip(t *testing.T) {
+	u := new(uint256.Int).Lsh(uint256.NewInt(1), 255)
+	data, err := json.Marshal((*HexUint256)(u))
+	require.NoError(t, err)
+	got := new(HexUint256)
+	require.NoError(t, json.Unmarshal(data, got))
+	assert.Equal(t, u, (*uint256.Int)(got))
+	assert.Error(t, json.Unmarshal([]byte(`"12"`), new(HexUint256)))
+}
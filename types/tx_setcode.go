@@ -1,6 +1,9 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/holiman/uint256"
 )
 
@@ -44,3 +47,47 @@ func (s *SetCodeAuthorization) Equal(to SetCodeAuthorization) bool {
 	}
 	return true
 }
+
+type setCodeAuthorizationJSON struct {
+	ChainID *HexUint256 `json:"chainId"`
+	Address Address     `json:"address"`
+	Nonce   HexUint64   `json:"nonce"`
+	V       HexUint64   `json:"yParity"`
+	R       *HexUint256 `json:"r"`
+	S       *HexUint256 `json:"s"`
+}
+
+// MarshalJSON implements json.Marshaler interface for SetCodeAuthorization
+func (s SetCodeAuthorization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(setCodeAuthorizationJSON{
+		ChainID: (*HexUint256)(&s.ChainID),
+		Address: s.Address,
+		Nonce:   HexUint64(s.Nonce),
+		V:       HexUint64(s.V),
+		R:       (*HexUint256)(&s.R),
+		S:       (*HexUint256)(&s.S),
+	})
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (s *SetCodeAuthorization) UnmarshalJSON(data []byte) error {
+	var dec setCodeAuthorizationJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	if dec.ChainID == nil || dec.R == nil || dec.S == nil {
+		return fmt.Errorf("authorization of %v is incomplete", dec.Address)
+	}
+	if dec.V > 0xff {
+		return fmt.Errorf("authorization of %v has invalid yParity %v", dec.Address, uint64(dec.V))
+	}
+	*s = SetCodeAuthorization{
+		ChainID: uint256.Int(*dec.ChainID),
+		Address: dec.Address,
+		Nonce:   uint64(dec.Nonce),
+		V:       uint8(dec.V),
+		R:       uint256.Int(*dec.R),
+		S:       uint256.Int(*dec.S),
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_EqualSetCodeAuthorization(t *testing.T) {
@@ -44,3 +47,18 @@ func Test_EqualSetCodeAuthorization(t *testing.T) {
 		t.Fatal("messages setCodeAuthorizations are same but equal returned false")
 	}
 }
+
+func TestSetCodeAuthorization_JSONRoundTrip(t *testing.T) {
+	auth := SetCodeAuthorization{ChainID: *uint256.NewInt(146), Address: Address{1}, Nonce: 2, V: 1,
+		R: *uint256.NewInt(3), S: *uint256.NewInt(4)}
+	data, err := json.Marshal(auth)
+	require.NoError(t, err)
+	assert.Equal(t, `{"chainId":"0x92","address":"0x0100000000000000000000000000000000000000","nonce":"0x2","yParity":"0x1","r":"0x3","s":"0x4"}`, string(data))
+
+	var got SetCodeAuthorization
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.True(t, auth.Equal(got))
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"address":"0x01"}`), &got), "incomplete")
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"chainId":"0x1","yParity":"0x100","r":"0x1","s":"0x1"}`), &got), "invalid yParity")
+}
@@ -1,6 +1,8 @@
 package updateset
 
 import (
+	"encoding/json"
+
 	"github.com/0xsoniclabs/substate/substate"
 	"github.com/0xsoniclabs/substate/types"
 )
@@ -38,3 +40,32 @@ func (s *UpdateSet) Equal(y *UpdateSet) bool {
 	}
 	return true
 }
+
+type updateSetJSON struct {
+	Block           types.HexUint64     `json:"block"`
+	WorldState      substate.WorldState `json:"worldState"`
+	DeletedAccounts []types.Address     `json:"deletedAccounts,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface for UpdateSet
+func (s *UpdateSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(updateSetJSON{
+		Block:           types.HexUint64(s.Block),
+		WorldState:      s.WorldState,
+		DeletedAccounts: s.DeletedAccounts,
+	})
+}
+
+// UnmarshalJSON decodes the form generated by MarshalJSON
+func (s *UpdateSet) UnmarshalJSON(data []byte) error {
+	var dec updateSetJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	*s = UpdateSet{
+		WorldState:      dec.WorldState,
+		Block:           uint64(dec.Block),
+		DeletedAccounts: dec.DeletedAccounts,
+	}
+	return nil
+}
@@ -1,12 +1,14 @@
 package updateset
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/0xsoniclabs/substate/substate"
 	"github.com/0xsoniclabs/substate/types"
 	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUpdateSet_Equal(t *testing.T) {
@@ -56,3 +58,18 @@ func TestUpdateSet_Equal(t *testing.T) {
 	updateSet5.DeletedAccounts = []types.Address{{4}}
 	assert.False(t, updateSet1.Equal(updateSet5))
 }
+
+func TestUpdateSet_JSONRoundTrip(t *testing.T) {
+	ws := substate.NewWorldState().Add(types.Address{1}, 1, uint256.NewInt(100), []byte{1, 2, 3})
+	ws[types.Address{1}].Storage[types.Hash{1}] = types.Hash{2}
+	us := &UpdateSet{WorldState: ws, Block: 10, DeletedAccounts: []types.Address{{3}}}
+
+	data, err := json.Marshal(us)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"block":"0xa"`)
+
+	got := new(UpdateSet)
+	require.NoError(t, json.Unmarshal(data, got))
+	assert.True(t, us.Equal(got))
+	assert.Equal(t, us.DeletedAccounts, got.DeletedAccounts)
+}
@@ -36,6 +36,57 @@ var (
 		Name:  "skip-create-txs",
 		Usage: "Skip executing CREATE transactions",
 	}
+	DbBackendFlag = cli.StringFlag{
+		Name:  "db-backend",
+		Usage: "Key-value store backend of the databases (leveldb, pebble)",
+		Value: "leveldb",
+	}
+	LayerPrecedenceFlag = cli.StringFlag{
+		Name:  "layer-precedence",
+		Usage: "Which database wins when a union of databases contains the same key (first, last)",
+		Value: "first",
+	}
+	DbFlag = cli.PathFlag{
+		Name:     "db",
+		Usage:    "Aida DB",
+		Required: true,
+	}
+	ListenAddressFlag = cli.StringFlag{
+		Name:  "listen",
+		Usage: "Address the server listens on",
+		Value: "localhost:5151",
+	}
+	DryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only report what would be changed, the database is not modified",
+	}
+	BatchSizeFlag = cli.IntFlag{
+		Name:  "batch-size",
+		Usage: "Number of database changes written by a single batch",
+		Value: 1000,
+	}
+	EncodingFlag = cli.StringFlag{
+		Name:     "encoding",
+		Usage:    "Encoding the records are converted to (rlp, protobuf)",
+		Required: true,
+	}
+	EncodingTagFlag = cli.BoolFlag{
+		Name:  "encoding-tag",
+		Usage: "Tag written substates and update sets with their encoding",
+	}
+	RepairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "Delete corrupted entries found in the database",
+	}
+	ReportFlag = cli.PathFlag{
+		Name:  "report",
+		Usage: "File the JSON report is written to, standard output is used if not set",
+	}
+	ConflictPolicyFlag = cli.StringFlag{
+		Name:  "conflict-policy",
+		Usage: "How records differing between databases are handled (fail, keep-first, keep-last, report)",
+		Value: "fail",
+	}
 	BlockSegmentFlag = cli.StringFlag{
 		Name:     "block-segment",
 		Usage:    "Single block segment (e.g. 1001, 1_001, 1_001-2_000, 1-2k, 1-2M)",
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -100,3 +102,23 @@ func Must[T any](v T, err error) T {
 	}
 	return v
 }
+
+// WriteJSONReport writes report as indented JSON into the file of path,
+// the standard output is used if path is empty.
+func WriteJSONReport(path string, report any) error {
+	out := os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("cannot create report; %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("cannot write report; %w", err)
+	}
+	return nil
+}
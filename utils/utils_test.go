@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseBlockSegment(t *testing.T) {
@@ -167,3 +169,13 @@ func TestMust(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteJSONReport(t *testing.T) {
+	path := t.TempDir() + "/report.json"
+	require.NoError(t, WriteJSONReport(path, map[string]int{"a": 1}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a": 1}`, string(data))
+
+	assert.Error(t, WriteJSONReport(t.TempDir()+"/missing/report.json", nil))
+}